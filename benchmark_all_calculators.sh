@@ -36,7 +36,7 @@ benchmark() {
 # C++
 benchmark "cpp" "g++ -std=c++11 -o bsm_greeks bsm_greeks.cpp" "./bsm_greeks" "bsm_greeks" "Compiled"
 # Go
-benchmark "go" "" "go run bsm_greeks.go" "" "Interpreted/Go run"
+benchmark "go" "" "go run ./cmd/bsm" "" "Interpreted/Go run"
 # Python
 if [ -d "$ROOT_DIR/python/.venv" ]; then
   benchmark "python" "" 'source .venv/bin/activate && python bsm_greeks.py && deactivate' "" "Python venv"
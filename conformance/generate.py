@@ -0,0 +1,87 @@
+#!/usr/bin/env python3
+"""Regenerates golden.json: BSM prices and first-order Greeks computed in
+80-digit decimal arithmetic, independently of every port's floating-point
+code, so the file is a reference rather than a snapshot of one port.
+
+    python3 conformance/generate.py > conformance/golden.json
+"""
+import itertools
+import json
+from decimal import Decimal as D, getcontext
+
+getcontext().prec = 80
+
+PI = D("3.14159265358979323846264338327950288419716939937510582097494459230781640628620899")
+THETA_BASIS = 365
+
+
+def norm_pdf(x):
+    return (-x * x / 2).exp() / (2 * PI).sqrt()
+
+
+def norm_cdf(x):
+    # 1/2 + phi(x) sum x^(2n+1) / (1*3*...*(2n+1)), exact to the working
+    # precision for the |x| < 12 used here.
+    term, total, n = x, x, 0
+    while abs(term) > D(10) ** -70:
+        n += 1
+        term = term * x * x / (2 * n + 1)
+        total += term
+    return D("0.5") + norm_pdf(x) * total
+
+
+def greeks(S0, K, T, sigma, r, q, call):
+    sqrtT = T.sqrt()
+    sd = sigma * sqrtT
+    d1 = ((S0 / K).ln() + (r - q + sigma * sigma / 2) * T) / sd
+    d2 = d1 - sd
+    phi = D(1) if call else D(-1)
+    eq, er = (-q * T).exp(), (-r * T).exp()
+    Nd1, Nd2, n1 = norm_cdf(phi * d1), norm_cdf(phi * d2), norm_pdf(d1)
+    price = phi * (S0 * eq * Nd1 - K * er * Nd2)
+    vega = S0 * eq * n1 * sqrtT
+    theta = -S0 * eq * n1 * sigma / (2 * sqrtT) + phi * (q * S0 * eq * Nd1 - r * K * er * Nd2)
+    rho = phi * K * T * er * Nd2
+    eps = -phi * T * S0 * eq * Nd1
+    return {
+        "Price": price,
+        "Delta": phi * eq * Nd1,
+        "Gamma": eq * n1 / (S0 * sd),
+        "VegaPerVol": vega,
+        "VegaPerVolPt": vega / 100,
+        "ThetaPerYear": theta,
+        "ThetaPerDay": theta / THETA_BASIS,
+        "RhoPer1": rho,
+        "RhoPerBp": rho / 10000,
+        "PhiPer1": eps,
+        "PhiPerBp": eps / 10000,
+    }
+
+
+def main():
+    cases = []
+    grid = itertools.product(
+        ["call", "put"], ["50", "80", "100", "120", "200"], ["0.01", "0.5", "2"],
+        ["0.05", "0.2", "0.6"], ["0.03", "-0.01"], ["0", "0.02"])
+    for opt, K, T, sigma, r, q in grid:
+        inputs = {"S0": 100, "K": float(K), "T": float(T), "Sigma": float(sigma),
+                  "R": float(r), "Q": float(q), "OptType": opt}
+        # Evaluate at the binary values the ports will see.
+        exact = greeks(*(D(inputs[k]) for k in ("S0", "K", "T", "Sigma", "R", "Q")), opt == "call")
+        cases.append({
+            "name": f"{opt} K={K} T={T} sigma={sigma} r={r} q={q}",
+            "inputs": inputs,
+            "expected": {k: float(v) for k, v in exact.items()},
+        })
+    doc = {
+        "description": "BSM European prices and first-order Greeks; see conformance/README.md",
+        "thetaBasis": THETA_BASIS,
+        # A value passes if |got - want| <= abs + rel * |want|.
+        "tolerance": {"abs": 1e-9, "rel": 1e-8},
+        "cases": cases,
+    }
+    print(json.dumps(doc, indent=1))
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,90 @@
+#!/usr/bin/env python3
+"""Checks an implementation against golden.json.
+
+    python3 conformance/run.py go
+    python3 conformance/run.py python
+    python3 conformance/run.py -- <command...>
+
+A command is sent the cases' inputs as a JSON array on stdin and must print
+one JSON object per case, in order: either the Go CLI's records, with the
+values under "greeks", or the values directly. Field names are the Go
+Outputs names (Price, Delta, ..., PhiPerBp); the golden theta basis is in
+golden.json and the BSM_THETA_BASIS environment variable.
+"""
+import argparse
+import json
+import math
+import os
+import subprocess
+import sys
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+ROOT = os.path.dirname(HERE)
+
+
+def run_command(cmd, cwd, inputs, theta_basis):
+    env = dict(os.environ, BSM_THETA_BASIS=str(theta_basis))
+    res = subprocess.run(cmd, cwd=cwd, input=json.dumps(inputs), capture_output=True,
+                         text=True, env=env)
+    if res.returncode != 0:
+        sys.exit(f"{' '.join(cmd)} failed:\n{res.stderr}")
+    rows = [json.loads(line) for line in res.stdout.splitlines() if line.strip()]
+    return [r.get("greeks", r) for r in rows]
+
+
+def run_python(inputs, theta_basis):
+    sys.path.insert(0, os.path.join(ROOT, "python"))
+    from bsm_greeks import BSMInputs, price_and_greeks_bsm
+    names = {"price": "Price", "delta": "Delta", "gamma": "Gamma",
+             "vega_per_vol": "VegaPerVol", "vega_per_volpt": "VegaPerVolPt",
+             "theta_per_year": "ThetaPerYear", "theta_per_day": "ThetaPerDay",
+             "rho_per_1": "RhoPer1", "rho_per_bp": "RhoPerBp",
+             "phi_per_1": "PhiPer1", "phi_per_bp": "PhiPerBp"}
+    out = []
+    for x in inputs:
+        res = price_and_greeks_bsm(BSMInputs(x["S0"], x["K"], x["T"], x["Sigma"], x["R"],
+                                             x["Q"], x["OptType"]), theta_basis)
+        out.append({names[k]: v for k, v in vars(res).items() if k in names})
+    return out
+
+
+def main():
+    p = argparse.ArgumentParser(description=__doc__.splitlines()[0])
+    p.add_argument("target", nargs="?", choices=["go", "python"])
+    p.add_argument("command", nargs="*", help="after --, a command speaking the protocol above")
+    p.add_argument("--golden", default=os.path.join(HERE, "golden.json"))
+    args = p.parse_args()
+    if bool(args.target) == bool(args.command):
+        p.error("give a target or a command after --")
+
+    with open(args.golden) as f:
+        golden = json.load(f)
+    cases, basis = golden["cases"], golden["thetaBasis"]
+    tol_abs, tol_rel = golden["tolerance"]["abs"], golden["tolerance"]["rel"]
+    inputs = [c["inputs"] for c in cases]
+    if args.target == "go":
+        got = run_command(["go", "run", "./cmd/bsm", "-json", "-theta-basis", str(basis)],
+                          os.path.join(ROOT, "go"), inputs, basis)
+    elif args.target == "python":
+        got = run_python(inputs, basis)
+    else:
+        got = run_command(args.command, None, inputs, basis)
+    if len(got) != len(cases):
+        sys.exit(f"got {len(got)} results for {len(cases)} cases")
+
+    failures = 0
+    for case, values in zip(cases, got):
+        for field, want in case["expected"].items():
+            v = values.get(field)
+            if v is None:
+                print(f"{case['name']}: missing {field}")
+                failures += 1
+            elif not abs(v - want) <= tol_abs + tol_rel * abs(want):
+                print(f"{case['name']}: {field} = {v!r}, want {want!r}")
+                failures += 1
+    print(f"{len(cases)} cases, {failures} mismatches")
+    sys.exit(1 if failures else 0)
+
+
+if __name__ == "__main__":
+    main()
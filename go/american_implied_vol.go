@@ -0,0 +1,110 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// americanPricer returns the American price as a function of volatility,
+// by the method Price would use under o.
+func americanPricer(in Inputs, o priceOptions) (func(sigma float64) (float64, error), error) {
+	if in.Model != "" && in.Model != BSMModel {
+		return nil, fmt.Errorf("American implied vol needs the BSM model, got %q", in.Model)
+	}
+	switch o.american {
+	case "", AmericanTree:
+		opt, err := o.tree.resolve()
+		if err != nil {
+			return nil, err
+		}
+		return func(sigma float64) (float64, error) {
+			x := in
+			x.Sigma = sigma
+			return treePrice(x, opt)
+		}, nil
+	case AmericanBS02:
+		if err := noDividends(in, "Bjerksund-Stensland"); err != nil {
+			return nil, err
+		}
+		return func(sigma float64) (float64, error) {
+			x := in
+			x.Sigma = sigma
+			if p := bs02Price(x); !math.IsNaN(p) {
+				return p, nil
+			}
+			return 0, fmt.Errorf("Bjerksund-Stensland failed at vol %g", sigma)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown American method %q", o.american)
+}
+
+// americanImpliedVol inverts the American pricer by Brent's method. The
+// American price is at least the European one, so the European implied
+// vol bounds the answer from above when it exists. Low vols are bounded by
+// the tree, whose probabilities need the vol to outweigh the drift, and
+// by early exercise: deep in the money the option is worth its intrinsic
+// value over a range of vols, and a price there implies no unique vol.
+func americanImpliedVol(price float64, in Inputs, o priceOptions) (float64, error) {
+	in.Sigma = 0.2 // Any valid vol, to validate the rest
+	if err := in.Validate(); err != nil {
+		return 0, err
+	}
+	value, err := americanPricer(in, o)
+	if err != nil {
+		return 0, err
+	}
+	intrinsic := math.Max(in.S0-in.K, 0)
+	if in.OptType == Put {
+		intrinsic = math.Max(in.K-in.S0, 0)
+	}
+	if price < intrinsic {
+		return 0, fmt.Errorf("%w: price %.6g is below exercise value %.6g", ErrNoImpliedVol, price, intrinsic)
+	}
+
+	lo, hi := 1e-3, 1.0
+	vLo, err := value(lo)
+	for err != nil && lo < 0.5 {
+		lo *= 2
+		vLo, err = value(lo)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if price <= vLo {
+		return 0, fmt.Errorf("%w: price %.6g is at or below the American value %.6g at vol %g",
+			ErrNoImpliedVol, price, vLo, lo)
+	}
+	euro := in
+	euro.Exercise = European
+	if s, err := ImpliedVol(price, euro); err == nil && s > lo {
+		hi = s
+	}
+	for {
+		vHi, err := value(hi)
+		if err != nil {
+			return 0, err
+		}
+		if vHi >= price {
+			break
+		}
+		if lo, hi = hi, 2*hi; hi > 20 {
+			return 0, fmt.Errorf("%w: price %.6g is above the American value at vol 20", ErrNoImpliedVol, price)
+		}
+	}
+	var valueErr error
+	res, err := numerics.Brent(func(sigma float64) float64 {
+		v, err := value(sigma)
+		if err != nil {
+			valueErr = err
+			return math.NaN()
+		}
+		return v - price
+	}, lo, hi, numerics.Options{XTol: 1e-10, FTol: 1e-12 * math.Max(price, 1e-300)})
+	if err != nil {
+		return 0, fmt.Errorf("American implied vol: %w", errors.Join(err, valueErr))
+	}
+	return res.X, nil
+}
@@ -0,0 +1,61 @@
+package bsm
+
+import "math"
+
+// setAnalytics fills the contract analytics from the European terminal
+// distribution at Sigma: lognormal about the forward for BSM (on the
+// escrowed spot with discrete dividends) and Black-76, normal for
+// Bachelier. For an American option they describe holding to expiry,
+// while BreakEven carries the American premium. It also sets DualDelta,
+// by homogeneity where the model allows.
+func (o *Outputs) setAnalytics(in Inputs, opts priceOptions) {
+	T, sigma := math.Max(in.T, 1e-6), math.Max(in.Sigma, 1e-8) // The kernel's limits
+	sd := sigma * math.Sqrt(T)
+	F := in.S0
+	if in.Model == "" || in.Model == BSMModel {
+		F = (in.S0 - dividendPV(in, 0)) * math.Exp((in.R-in.Q)*T)
+	}
+	phi := 1.0
+	if in.OptType == Put {
+		phi = -1
+	}
+
+	switch {
+	case in.Model == BachelierModel:
+		// The price depends on F - K only.
+		o.DualDelta = -o.Delta
+	case len(in.Dividends) > 0 && in.Exercise != American && opts.dividends == HaugHaugDividends:
+		// The adjusted vol depends on the spot, so homogeneity fails: bump.
+		h := 1e-4 * in.K
+		up, down := in, in
+		up.K, down.K = in.K+h, in.K-h
+		o.DualDelta = (priceHaugHaug(up, 365).Price - priceHaugHaug(down, 365).Price) / (2 * h)
+	default:
+		// The price is homogeneous of degree one in the (escrowed) spot
+		// and strike, so Price = Delta S + DualDelta K.
+		o.DualDelta = (o.Price - o.Delta*(in.S0-dividendPV(in, 0))) / in.K
+	}
+	if o.Price > 0 {
+		o.Lambda = o.Delta * in.S0 / o.Price
+	}
+
+	o.BreakEven = in.K + phi*o.Price*math.Exp(in.R*T)
+	if in.Model != BachelierModel && in.Exercise != American && len(in.Dividends) == 0 {
+		return // The closed-form kernel filled the rest from its own terms
+	}
+	o.LogMoneyness = math.Log(in.K / F)
+	if in.Model == BachelierModel {
+		d := (F - in.K) / sd
+		o.StdMoneyness = -d
+		o.ProbITM = normCDF(phi * d)
+		o.ExpectedPayoff = phi*(F-in.K)*o.ProbITM + sd*normPDF(d)
+		o.ProbITMStock = (F*o.ProbITM + phi*sd*normPDF(d)) / F
+		return
+	}
+	d1 := -o.LogMoneyness/sd + 0.5*sd
+	d2 := d1 - sd
+	o.StdMoneyness = o.LogMoneyness / sd
+	o.ProbITM = normCDF(phi * d2)
+	o.ProbITMStock = normCDF(phi * d1)
+	o.ExpectedPayoff = phi * (F*normCDF(phi*d1) - in.K*normCDF(phi*d2))
+}
@@ -0,0 +1,94 @@
+package bsm
+
+import (
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// Undiscounted Bachelier (normal model) formula for a forward F, strike K and
+// total normal standard deviation stdDev = sigmaN*sqrt(T). Valid for negative
+// forwards and strikes.
+func bachelierFormula(F, K, stdDev float64, isCall bool) float64 {
+	if stdDev <= 0 {
+		if isCall {
+			return math.Max(F-K, 0)
+		}
+		return math.Max(K-F, 0)
+	}
+	d := (F - K) / stdDev
+	if isCall {
+		return (F-K)*normCDF(d) + stdDev*normPDF(d)
+	}
+	return (K-F)*normCDF(-d) + stdDev*normPDF(d)
+}
+
+// Bachelier prices an option on a forward inputs.S0 in the normal model,
+// with inputs.Sigma a normal (absolute) vol, discounting at inputs.R;
+// inputs.Q must be zero and the forward and strike may be negative. It
+// takes the same inputs and options as Price with the model set to
+// BachelierModel, so Delta is dPrice/dF, vol Greeks are per 1.00 of normal
+// vol, and RhoPer1 holds F fixed.
+func Bachelier(inputs Inputs, opts ...Option) (Outputs, error) {
+	inputs.Model = BachelierModel
+	return Price(inputs, opts...)
+}
+
+// priceAndGreeksBachelier is the normal-model counterpart of
+// priceAndGreeks.
+func priceAndGreeksBachelier(in Inputs, thetaBasis int) Outputs {
+	F, K, T, sigma, r := in.S0, in.K, math.Max(in.T, 1e-6), math.Max(in.Sigma, 1e-12), in.R
+	sqrtT := math.Sqrt(T)
+	sd := sigma * sqrtT
+	d := (F - K) / sd
+	df := math.Exp(-r * T)
+	n := normPDF(d)
+	isCall := in.OptType == Call
+
+	price := df * bachelierFormula(F, K, sd, isCall)
+	var delta, charm float64
+	if isCall {
+		delta = df * normCDF(d)
+		charm = r*df*normCDF(d) + df*n*d/(2*T)
+	} else {
+		delta = -df * normCDF(-d)
+		charm = -r*df*normCDF(-d) + df*n*d/(2*T)
+	}
+	gamma := df * n / sd
+	vega := df * sqrtT * n
+	theta := r*price - df*sigma*n/(2*sqrtT)
+	rho := -T * price
+	return Outputs{
+		Price:        price,
+		Delta:        delta,
+		Gamma:        gamma,
+		VegaPerVol:   vega,
+		VegaPerVolPt: vega * 0.01,
+		ThetaPerYear: theta,
+		ThetaPerDay:  theta / float64(thetaBasis),
+		RhoPer1:      rho,
+		RhoPerBp:     rho / 10000.0,
+		Vanna:        -df * n * d / sigma,
+		Volga:        vega * d * d / sigma,
+		CharmPerYear: charm,
+		CharmPerDay:  charm / float64(thetaBasis),
+		Speed:        -gamma * d / sd,
+		Zomma:        gamma * (d*d - 1) / sigma,
+		ColorPerYear: gamma * (r + (1-d*d)/(2*T)),
+		ColorPerDay:  gamma * (r + (1-d*d)/(2*T)) / float64(thetaBasis),
+	}
+}
+
+// bachelierImpliedVol returns the normal vol that reproduces an undiscounted
+// price by Brent's method. Prices at or below intrinsic give zero.
+func bachelierImpliedVol(price, F, K, T float64, isCall bool) float64 {
+	if T <= 0 || price <= bachelierFormula(F, K, 0, isCall) {
+		return 0
+	}
+	sqrtT := math.Sqrt(T)
+	hi := math.Max(price, math.Abs(F-K))/sqrtT*10 + 1e-4 // Prices far above any quote
+	res, _ := numerics.Brent(func(v float64) float64 {
+		return bachelierFormula(F, K, v*sqrtT, isCall) - price
+	}, 0, hi, numerics.Options{XTol: 1e-14})
+	return res.X
+}
@@ -0,0 +1,126 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// batchChunk is how many contracts a worker claims at a time: large enough
+// to keep the shared counter cold, small enough to balance American options
+// (priced on trees) against cheap closed forms.
+const batchChunk = 256
+
+// PriceBatch prices many contracts with Price across GOMAXPROCS workers.
+// Outputs are in input order. Contracts that fail leave a zero Outputs and
+// contribute a "contract i: ..." error to the joined error returned; the
+// rest are still priced.
+func PriceBatch(inputs []Inputs, opts ...Option) ([]Outputs, error) {
+	out := make([]Outputs, len(inputs))
+	return out, PriceBatchInto(out, inputs, opts...)
+}
+
+// PriceBatchInto is PriceBatch writing into out, which must be as long as
+// inputs, so that repeated runs reuse one buffer.
+func PriceBatchInto(out []Outputs, inputs []Inputs, opts ...Option) error {
+	return PriceBatchIntoContext(context.Background(), out, inputs, opts...)
+}
+
+// PriceBatchContext is PriceBatch with a context: when ctx is done the
+// workers stop claiming contracts and it returns ctx's error, joined with
+// any contract errors, and the outputs priced so far; the rest are left
+// zero.
+func PriceBatchContext(ctx context.Context, inputs []Inputs, opts ...Option) ([]Outputs, error) {
+	out := make([]Outputs, len(inputs))
+	return out, PriceBatchIntoContext(ctx, out, inputs, opts...)
+}
+
+// PriceBatchIntoContext is PriceBatchInto with a context, as
+// PriceBatchContext. Each call is a "price_batch" span recording the
+// contract count and how many failed; the span itself fails only if the
+// batch could not run or was stopped.
+func PriceBatchIntoContext(ctx context.Context, out []Outputs, inputs []Inputs, opts ...Option) error {
+	ctx, sp := StartSpan(ctx, "price_batch", slog.Int("contracts", len(inputs)))
+	failed, stopped, err := priceBatch(ctx, out, inputs, opts)
+	if failed > 0 {
+		sp.End(stopped, slog.Int("contracts", len(inputs)), slog.Int("errors", failed))
+	} else {
+		sp.End(err, slog.Int("contracts", len(inputs)), slog.Int("errors", 0))
+	}
+	return err
+}
+
+// priceBatch does PriceBatchIntoContext's work, also returning the number
+// of contracts that failed and ctx's error if it stopped the batch.
+func priceBatch(ctx context.Context, out []Outputs, inputs []Inputs, opts []Option) (int, error, error) {
+	if len(out) != len(inputs) {
+		return 0, nil, fmt.Errorf("output buffer has %d slots for %d contracts", len(out), len(inputs))
+	}
+	o := resolveOptions(opts)
+	if o.thetaBasis <= 0 {
+		return 0, nil, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if n := (len(inputs) + batchChunk - 1) / batchChunk; n < workers {
+		workers = n
+	}
+	type indexedErr struct {
+		i   int
+		err error
+	}
+	failed := make([][]indexedErr, workers)
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				start := int(next.Add(batchChunk)) - batchChunk
+				if start >= len(inputs) {
+					return
+				}
+				end := min(start+batchChunk, len(inputs))
+				for i := start; i < end; i++ {
+					res, err := priceWith(inputs[i], o)
+					if err != nil {
+						failed[w] = append(failed[w], indexedErr{i, err})
+						res = Outputs{}
+					}
+					out[i] = res
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var all []indexedErr
+	for _, f := range failed {
+		all = append(all, f...)
+	}
+	stopped := ctx.Err()
+	if next.Load() >= int64(len(inputs)) {
+		stopped = nil // Every contract was claimed and priced
+	}
+	if len(all) == 0 {
+		return 0, stopped, stopped
+	}
+	sort.Slice(all, func(a, b int) bool { return all[a].i < all[b].i })
+	errs := make([]error, len(all), len(all)+1)
+	for k, e := range all {
+		errs[k] = fmt.Errorf("contract %d: %w", e.i, e.err)
+	}
+	if stopped != nil {
+		errs = append(errs, stopped)
+	}
+	return len(all), stopped, errors.Join(errs...)
+}
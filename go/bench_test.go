@@ -0,0 +1,90 @@
+package bsm
+
+import (
+	"testing"
+)
+
+var benchInputs = Inputs{S0: 100, K: 105, T: 0.5, Sigma: 0.22, R: 0.04, Q: 0.01, OptType: Call}
+
+// benchSink keeps the compiler from discarding benchmarked results.
+var benchSink Outputs
+
+func BenchmarkPrice(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		benchSink, _ = Price(benchInputs)
+	}
+}
+
+func BenchmarkPriceWithThetaBasis(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		benchSink, _ = Price(benchInputs, WithThetaBasis(252))
+	}
+}
+
+func BenchmarkPriceBatch(b *testing.B) {
+	inputs := make([]Inputs, 10000)
+	for i := range inputs {
+		inputs[i] = benchInputs
+		inputs[i].K = 80 + float64(i%40)
+	}
+	out := make([]Outputs, len(inputs))
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := PriceBatchInto(out, inputs); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*len(inputs)), "ns/contract")
+}
+
+// TestPriceAllocations guards the hot path: a closed-form Price call with
+// no options must not touch the heap.
+func TestPriceAllocations(t *testing.T) {
+	for _, in := range []Inputs{
+		benchInputs,
+		{S0: 100, K: 95, T: 1, Sigma: 0.3, R: 0.02, OptType: Put, Model: Black76Model},
+		{S0: 0.01, K: 0.012, T: 2, Sigma: 0.008, R: 0.02, OptType: Call, Model: BachelierModel},
+	} {
+		if n := testing.AllocsPerRun(100, func() { benchSink, _ = Price(in) }); n != 0 {
+			t.Errorf("%s Price: %g allocations per call, want 0", in.Model, n)
+		}
+	}
+}
+
+// benchChain is one expiry of 200 strikes with a skew, the dominant
+// workload: BenchmarkChainPrice prices it with Price per strike and
+// BenchmarkChainExpiryPricer with the shared terms.
+var benchStrikes, benchVols = func() ([]float64, []float64) {
+	strikes, vols := make([]float64, 200), make([]float64, 200)
+	for i := range strikes {
+		strikes[i] = 50 + 0.5*float64(i)
+		vols[i] = 0.22 - 0.1*(strikes[i]/100-1)
+	}
+	return strikes, vols
+}()
+
+func BenchmarkChainPrice(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		in := benchInputs
+		for i, K := range benchStrikes {
+			in.K, in.Sigma = K, benchVols[i]
+			benchSink, _ = Price(in)
+		}
+	}
+}
+
+func BenchmarkChainExpiryPricer(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		p, err := NewExpiryPricer(benchInputs)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i, K := range benchStrikes {
+			benchSink, _ = p.Price(K, benchVols[i], Call)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package bsm
+
+// priceAndGreeksBlack76 prices an option on a forward F with Black (1976).
+// It is BSM with the dividend yield set equal to the rate, so the outputs
+// follow the BSM conventions: Delta is dPrice/dF and RhoPer1 is the
+// sensitivity to r with F held fixed (Phi is folded into it).
+func priceAndGreeksBlack76(F, K, T, sigma, r float64, optType OptionType, thetaBasis int) Outputs {
+	out := priceAndGreeks(Inputs{S0: F, K: K, T: T, Sigma: sigma, R: r, Q: r, OptType: optType}, thetaBasis)
+	out.RhoPer1 += out.PhiPer1
+	out.RhoPerBp += out.PhiPerBp
+	out.PhiPer1, out.PhiPerBp = 0, 0
+	return out
+}
+
+// Black76 prices an option on a futures or forward price inputs.S0 with
+// Black (1976), discounting at inputs.R; inputs.Q must be zero. It takes the
+// same inputs and options as Price with the model set to Black76Model, and
+// its outputs follow priceAndGreeksBlack76's conventions.
+func Black76(inputs Inputs, opts ...Option) (Outputs, error) {
+	inputs.Model = Black76Model
+	return Price(inputs, opts...)
+}
+
+// priceBlack76 prices validated Black-76 inputs as BSM with Q = R, for
+// either exercise style.
+func priceBlack76(inputs Inputs, o priceOptions) (Outputs, error) {
+	inputs.Model, inputs.Q = BSMModel, inputs.R
+	out, err := priceBSM(inputs, o)
+	if err != nil {
+		return Outputs{}, err
+	}
+	out.RhoPer1 += out.PhiPer1
+	out.RhoPerBp += out.PhiPerBp
+	out.PhiPer1, out.PhiPerBp = 0, 0
+	return out, nil
+}
@@ -0,0 +1,163 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// Bond is a fixed-coupon bullet bond with a face value of 100, described
+// from the option's expiry: Maturity is the time from expiry to the final
+// cash flow. Prices are dirty.
+type Bond struct {
+	Coupon    float64 // Annual coupon rate (decimal)
+	Frequency int     // Coupons per year; 2 if zero
+	Maturity  float64 // Years from option expiry to maturity
+}
+
+func (b Bond) freq() float64 {
+	if b.Frequency <= 0 {
+		return 2
+	}
+	return float64(b.Frequency)
+}
+
+// cashFlows calls fn with the time and amount of each remaining cash flow.
+func (b Bond) cashFlows(fn func(t, cf float64)) {
+	f := b.freq()
+	n := int(math.Ceil(b.Maturity*f - 1e-9))
+	for i := 1; i <= n; i++ {
+		t := b.Maturity - float64(n-i)/f
+		cf := 100 * b.Coupon / f
+		if i == n {
+			cf += 100
+		}
+		fn(t, cf)
+	}
+}
+
+// Price returns the dirty price at yield y (compounded at the coupon frequency).
+func (b Bond) Price(y float64) float64 {
+	f := b.freq()
+	p := 0.0
+	b.cashFlows(func(t, cf float64) {
+		p += cf * math.Pow(1+y/f, -f*t)
+	})
+	return p
+}
+
+// ModifiedDuration returns -(1/P) dP/dy at yield y.
+func (b Bond) ModifiedDuration(y float64) float64 {
+	f := b.freq()
+	p, dp := 0.0, 0.0
+	b.cashFlows(func(t, cf float64) {
+		p += cf * math.Pow(1+y/f, -f*t)
+		dp -= t * cf * math.Pow(1+y/f, -f*t-1)
+	})
+	return -dp / p
+}
+
+// Yield solves Price(y) = price by Newton's method, falling back to bisection.
+func (b Bond) Yield(price float64) (float64, error) {
+	if price <= 0 {
+		return 0, fmt.Errorf("bond price %g must be positive", price)
+	}
+	res, err := numerics.Newton(func(y float64) (float64, float64) {
+		p := b.Price(y)
+		return p - price, -p * b.ModifiedDuration(y)
+	}, b.Coupon, -0.99*b.freq(), 2.0, numerics.Options{MaxIterations: 100, FTol: 1e-12 * price})
+	if err != nil {
+		return 0, fmt.Errorf("bond yield for price %g: %w", price, err)
+	}
+	return res.X, nil
+}
+
+// PriceVolFromYieldVol converts a lognormal yield vol to a price vol using
+// the first-order relation dP/P = -D y dy/y.
+func PriceVolFromYieldVol(yieldVol, y, modDuration float64) float64 {
+	return modDuration * math.Abs(y) * yieldVol
+}
+
+// YieldVolFromPriceVol is the inverse of PriceVolFromYieldVol.
+func YieldVolFromPriceVol(priceVol, y, modDuration float64) float64 {
+	return priceVol / (modDuration * math.Abs(y))
+}
+
+// BondOptionInputs describes a European option on a bond's forward price.
+// Give either ForwardPrice or ForwardYield, and either PriceVol or YieldVol.
+type BondOptionInputs struct {
+	Bond         Bond
+	ForwardPrice float64    // Forward dirty price at expiry
+	ForwardYield float64    // Used when ForwardPrice is zero
+	K            float64    // Strike price
+	T            float64    // Time to expiry (years)
+	R            float64    // Discount rate to expiry (cont. comp.)
+	PriceVol     float64    // Lognormal forward price vol
+	YieldVol     float64    // Lognormal forward yield vol; used when PriceVol is zero
+	OptType      OptionType // Call or Put on the bond price
+}
+
+// BondOptionOutputs are the Black-76 outputs on the forward price (Delta is
+// per unit of forward price) plus the yield-space view.
+type BondOptionOutputs struct {
+	Outputs
+	ForwardPrice     float64
+	ForwardYield     float64
+	ModifiedDuration float64
+	PriceVol         float64
+	YieldVol         float64
+	DeltaPerBp       float64 // Price change for a 1bp rise in the forward yield
+}
+
+// PriceBondOption prices a bond option with Black-76 on the forward price.
+// The contract on the forward is validated as Price validates Inputs, so
+// errors wrap the same sentinels, such as ErrNegativeStrike or ErrExpired.
+func PriceBondOption(in BondOptionInputs, thetaBasis int) (BondOptionOutputs, error) {
+	if in.Bond.Maturity <= 0 {
+		return BondOptionOutputs{}, errors.New("bond maturity must be after option expiry")
+	}
+	if thetaBasis <= 0 {
+		return BondOptionOutputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	if in.PriceVol < 0 || in.YieldVol < 0 {
+		return BondOptionOutputs{}, fmt.Errorf("%w, got price vol %g and yield vol %g", ErrNegativeVol, in.PriceVol, in.YieldVol)
+	}
+	fwdPrice, fwdYield := in.ForwardPrice, in.ForwardYield
+	if fwdPrice > 0 {
+		y, err := in.Bond.Yield(fwdPrice)
+		if err != nil {
+			return BondOptionOutputs{}, err
+		}
+		fwdYield = y
+	} else {
+		fwdPrice = in.Bond.Price(fwdYield)
+	}
+	dur := in.Bond.ModifiedDuration(fwdYield)
+
+	priceVol, yieldVol := in.PriceVol, in.YieldVol
+	switch {
+	case priceVol > 0:
+		yieldVol = YieldVolFromPriceVol(priceVol, fwdYield, dur)
+	case yieldVol > 0:
+		priceVol = PriceVolFromYieldVol(yieldVol, fwdYield, dur)
+	default:
+		return BondOptionOutputs{}, errors.New("bond option needs a price vol or a yield vol")
+	}
+
+	fwd := Inputs{S0: fwdPrice, K: in.K, T: in.T, Sigma: priceVol, R: in.R, OptType: in.OptType, Model: Black76Model}
+	if err := fwd.Validate(); err != nil {
+		return BondOptionOutputs{}, err
+	}
+	out := priceAndGreeksBlack76(fwdPrice, in.K, in.T, priceVol, in.R, in.OptType, thetaBasis)
+	return BondOptionOutputs{
+		Outputs:          out,
+		ForwardPrice:     fwdPrice,
+		ForwardYield:     fwdYield,
+		ModifiedDuration: dur,
+		PriceVol:         priceVol,
+		YieldVol:         yieldVol,
+		DeltaPerBp:       -out.Delta * dur * fwdPrice / 10000.0,
+	}, nil
+}
@@ -0,0 +1,31 @@
+package bsm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPriceBondOptionValidates(t *testing.T) {
+	good := BondOptionInputs{Bond: Bond{Coupon: 0.04, Maturity: 5}, ForwardYield: 0.04, K: 100, T: 0.5, R: 0.03,
+		YieldVol: 0.2, OptType: Call}
+	if _, err := PriceBondOption(good, 365); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name  string
+		edit  func(*BondOptionInputs)
+		basis int
+		want  error
+	}{
+		{"negative strike", func(in *BondOptionInputs) { in.K = -1 }, 365, ErrNegativeStrike},
+		{"expired", func(in *BondOptionInputs) { in.T = -1 }, 365, ErrExpired},
+		{"negative vol", func(in *BondOptionInputs) { in.PriceVol = -0.05 }, 365, ErrNegativeVol},
+		{"zero theta basis", func(*BondOptionInputs) {}, 0, ErrThetaBasis},
+	} {
+		in := good
+		c.edit(&in)
+		if _, err := PriceBondOption(in, c.basis); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// WienerConstruction maps a vector of independent standard normals z to a
+// Brownian path w[i] = W(grid[i]). With quasi-random z the construction
+// decides which dimensions carry the most variance: the bridge and PCA put
+// it in the first few, where Sobol points are best distributed.
+type WienerConstruction interface {
+	Build(z, w []float64)
+}
+
+// IncrementalConstruction is the plain forward construction, one increment
+// per step.
+type IncrementalConstruction struct {
+	sqrtDt []float64
+}
+
+func NewIncrementalConstruction(grid TimeGrid) (*IncrementalConstruction, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	c := &IncrementalConstruction{sqrtDt: make([]float64, len(grid))}
+	prev := 0.0
+	for i, t := range grid {
+		c.sqrtDt[i] = math.Sqrt(t - prev)
+		prev = t
+	}
+	return c, nil
+}
+
+func (c *IncrementalConstruction) Build(z, w []float64) {
+	sum := 0.0
+	for i, s := range c.sqrtDt {
+		sum += s * z[i]
+		w[i] = sum
+	}
+}
+
+// BrownianBridge builds the terminal value first, then fills midpoints
+// conditionally on their already-built neighbours.
+type BrownianBridge struct {
+	bridge, left, right []int
+	leftW, rightW, sd   []float64
+}
+
+func NewBrownianBridge(grid TimeGrid) (*BrownianBridge, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	n := len(grid)
+	b := &BrownianBridge{
+		bridge: make([]int, n), left: make([]int, n), right: make([]int, n),
+		leftW: make([]float64, n), rightW: make([]float64, n), sd: make([]float64, n),
+	}
+	t := grid
+	filled := make([]bool, n)
+	filled[n-1] = true
+	b.bridge[0] = n - 1
+	b.sd[0] = math.Sqrt(t[n-1])
+
+	j := 0
+	for i := 1; i < n; i++ {
+		for filled[j] {
+			j++
+		}
+		k := j
+		for !filled[k] {
+			k++
+		}
+		// Points j..k-1 are empty and k is built; fill the middle one, l,
+		// between W(t[j-1]) (or W(0) = 0) and W(t[k]).
+		l := j + (k-1-j)/2
+		filled[l] = true
+		b.bridge[i], b.left[i], b.right[i] = l, j, k
+		tl := 0.0
+		if j > 0 {
+			tl = t[j-1]
+		}
+		span := t[k] - tl
+		b.leftW[i] = (t[k] - t[l]) / span
+		b.rightW[i] = (t[l] - tl) / span
+		b.sd[i] = math.Sqrt((t[l] - tl) * (t[k] - t[l]) / span)
+		if j = k + 1; j >= n {
+			j = 0
+		}
+	}
+	return b, nil
+}
+
+func (b *BrownianBridge) Build(z, w []float64) {
+	n := len(b.bridge)
+	w[n-1] = b.sd[0] * z[0]
+	for i := 1; i < n; i++ {
+		j, k, l := b.left[i], b.right[i], b.bridge[i]
+		w[l] = b.rightW[i]*w[k] + b.sd[i]*z[i]
+		if j > 0 {
+			w[l] += b.leftW[i] * w[j-1]
+		}
+	}
+}
+
+// PCAConstruction uses the eigenvectors of the covariance min(t_i, t_j),
+// largest eigenvalue first.
+type PCAConstruction struct {
+	factors  [][]float64 // factors[k][i] = sqrt(lambda_k) * v_k[i]
+	explains []float64   // Cumulative fraction of variance
+}
+
+func NewPCAConstruction(grid TimeGrid) (*PCAConstruction, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	n := len(grid)
+	cov := make([][]float64, n)
+	for i := range cov {
+		cov[i] = make([]float64, n)
+		for j := range cov[i] {
+			cov[i][j] = math.Min(grid[i], grid[j])
+		}
+	}
+	vals, vecs := symmetricEigen(cov)
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool { return vals[order[a]] > vals[order[b]] })
+
+	p := &PCAConstruction{factors: make([][]float64, n), explains: make([]float64, n)}
+	total, cum := 0.0, 0.0
+	for _, v := range vals {
+		total += math.Max(v, 0)
+	}
+	for k, idx := range order {
+		lambda := math.Max(vals[idx], 0)
+		f := make([]float64, n)
+		for i := range f {
+			f[i] = math.Sqrt(lambda) * vecs[i][idx]
+		}
+		p.factors[k] = f
+		cum += lambda
+		p.explains[k] = cum / total
+	}
+	return p, nil
+}
+
+// ExplainedVariance returns the fraction of total variance carried by the
+// first k factors.
+func (p *PCAConstruction) ExplainedVariance(k int) float64 {
+	if k <= 0 {
+		return 0
+	}
+	if k > len(p.explains) {
+		k = len(p.explains)
+	}
+	return p.explains[k-1]
+}
+
+func (p *PCAConstruction) Build(z, w []float64) {
+	for i := range w {
+		w[i] = 0
+	}
+	for k, f := range p.factors {
+		zk := z[k]
+		for i, fi := range f {
+			w[i] += fi * zk
+		}
+	}
+}
+
+// symmetricEigen diagonalises a symmetric matrix with cyclic Jacobi
+// rotations. It returns eigenvalues and eigenvectors as columns of vecs.
+func symmetricEigen(m [][]float64) (vals []float64, vecs [][]float64) {
+	n := len(m)
+	a := make([][]float64, n)
+	vecs = make([][]float64, n)
+	for i := range a {
+		a[i] = append([]float64(nil), m[i]...)
+		vecs[i] = make([]float64, n)
+		vecs[i][i] = 1
+	}
+	for sweep := 0; sweep < 100; sweep++ {
+		off := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += a[i][j] * a[i][j]
+			}
+		}
+		if off < 1e-22 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(a[p][q]) < 1e-300 {
+					continue
+				}
+				theta := (a[q][q] - a[p][p]) / (2 * a[p][q])
+				t := math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					akp, akq := a[k][p], a[k][q]
+					a[k][p], a[k][q] = c*akp-s*akq, s*akp+c*akq
+				}
+				for k := 0; k < n; k++ {
+					apk, aqk := a[p][k], a[q][k]
+					a[p][k], a[q][k] = c*apk-s*aqk, s*apk+c*aqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := vecs[k][p], vecs[k][q]
+					vecs[k][p], vecs[k][q] = c*vkp-s*vkq, s*vkp+c*vkq
+				}
+			}
+		}
+	}
+	vals = make([]float64, n)
+	for i := range vals {
+		vals[i] = a[i][i]
+	}
+	return vals, vecs
+}
+
+// SobolNormals draws vectors of standard normals from a Sobol sequence via
+// the inverse normal CDF. Dimensions past MaxSobolDim are padded from a
+// pseudo-random RNG, which costs little once a bridge or PCA construction
+// has moved most of the variance into the leading dimensions.
+type SobolNormals struct {
+	sobol *Sobol
+	u     []float64
+	pad   RNG
+	dim   int
+}
+
+func NewSobolNormals(dim int, pad RNG) (*SobolNormals, error) {
+	if dim < 1 {
+		return nil, errors.New("dimension must be positive")
+	}
+	qd := dim
+	if qd > MaxSobolDim {
+		if pad == nil {
+			return nil, errors.New("dimension exceeds the Sobol table and no padding RNG given")
+		}
+		qd = MaxSobolDim
+	}
+	sobol, err := NewSobol(qd)
+	if err != nil {
+		return nil, err
+	}
+	return &SobolNormals{sobol: sobol, u: make([]float64, qd), pad: pad, dim: dim}, nil
+}
+
+// Next writes the next normal vector to z (len(z) must be the dimension).
+func (s *SobolNormals) Next(z []float64) {
+	s.sobol.Next(s.u)
+	for i, u := range s.u {
+		z[i] = normInv(u)
+	}
+	for i := len(s.u); i < s.dim; i++ {
+		z[i] = s.pad.NormFloat64()
+	}
+}
+
+// PathFromWiener fills out (len(grid)+1, out[0] = S0) from a Brownian path w
+// on grid.
+func (g GBM) PathFromWiener(grid TimeGrid, w, out []float64) {
+	out[0] = g.S0
+	drift := g.R - g.Q - 0.5*g.Sigma*g.Sigma
+	for i, t := range grid {
+		out[i+1] = g.S0 * math.Exp(drift*t+g.Sigma*w[i])
+	}
+}
+
+// GenerateQMCPaths simulates n GBM paths driven by Sobol normals through the
+// given construction; pad supplies dimensions beyond the Sobol table.
+func GenerateQMCPaths(g GBM, grid TimeGrid, n int, c WienerConstruction, pad RNG) ([][]float64, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, errors.New("number of paths must be positive")
+	}
+	normals, err := NewSobolNormals(len(grid), pad)
+	if err != nil {
+		return nil, err
+	}
+	z := make([]float64, len(grid))
+	w := make([]float64, len(grid))
+	width := len(grid) + 1
+	buf := make([]float64, n*width)
+	paths := make([][]float64, n)
+	for i := range paths {
+		paths[i] = buf[i*width : (i+1)*width : (i+1)*width]
+		normals.Next(z)
+		c.Build(z, w)
+		g.PathFromWiener(grid, w, paths[i])
+	}
+	return paths, nil
+}
@@ -1,13 +1,16 @@
-package main
+package bsm
 
 import (
+	"errors"
 	"fmt"
 	"math"
 )
 
-// Standard normal cumulative distribution function
+// Standard normal cumulative distribution function, from erfc so the lower
+// tail keeps full relative accuracy: 1 + erf(x) cancels to nothing below
+// about x = -8, zeroing deep out-of-the-money prices and probabilities
 func normCDF(x float64) float64 {
-	return 0.5 * (1.0 + math.Erf(x/math.Sqrt2))
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
 }
 
 // Standard normal probability density function
@@ -15,23 +18,87 @@ func normPDF(x float64) float64 {
 	return math.Exp(-0.5*x*x) / math.Sqrt(2*math.Pi)
 }
 
-// OptionType is either Call or Put
-const (
-	Call = "call"
-	Put  = "put"
-)
+// Inverse standard normal CDF: Acklam's rational approximation refined with
+// one Halley step, accurate to about 1e-15
+func normInv(p float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	a := [6]float64{-3.969683028665376e+01, 2.209460984245205e+02, -2.759285104469687e+02, 1.383577518672690e+02, -3.066479806614716e+01, 2.506628277459239e+00}
+	b := [5]float64{-5.447609879822406e+01, 1.615858368580409e+02, -1.556989798598866e+02, 6.680131188771972e+01, -1.328068155288572e+01}
+	c := [6]float64{-7.784894002430293e-03, -3.223964580411365e-01, -2.400758277161838e+00, -2.549732539343734e+00, 4.374664141464968e+00, 2.938163982698783e+00}
+	d := [4]float64{7.784695709041462e-03, 3.224671290700398e-01, 2.445134137142996e+00, 3.754408661907416e+00}
+	const pLow = 0.02425
+
+	var x float64
+	switch {
+	case p < pLow:
+		q := math.Sqrt(-2 * math.Log(p))
+		x = (((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	case p <= 1-pLow:
+		q := p - 0.5
+		r := q * q
+		x = (((((a[0]*r+a[1])*r+a[2])*r+a[3])*r+a[4])*r + a[5]) * q / (((((b[0]*r+b[1])*r+b[2])*r+b[3])*r+b[4])*r + 1)
+	default:
+		q := math.Sqrt(-2 * math.Log(1-p))
+		x = -(((((c[0]*q+c[1])*q+c[2])*q+c[3])*q+c[4])*q + c[5]) / ((((d[0]*q+d[1])*q+d[2])*q+d[3])*q + 1)
+	}
+
+	e := normCDF(x) - p
+	u := e * math.Sqrt(2*math.Pi) * math.Exp(0.5*x*x)
+	return x - u/(1+0.5*x*u)
+}
 
-type BSMInputs struct {
-	S0      float64 // Spot price
-	K       float64 // Strike
-	T       float64 // Time to expiry (years)
-	Sigma   float64 // Volatility (per annum, decimal)
-	R       float64 // Risk-free rate (cont. comp.)
-	Q       float64 // Dividend yield (cont. comp.)
-	OptType string  // "call" or "put"
+// Undiscounted Black formula for a forward F, strike K and total standard
+// deviation stdDev = sigma*sqrt(T)
+func blackFormula(F, K, stdDev float64, isCall bool) float64 {
+	if stdDev <= 0 || F <= 0 || K <= 0 {
+		if isCall {
+			return math.Max(F-K, 0)
+		}
+		return math.Max(K-F, 0)
+	}
+	d1 := (math.Log(F/K) + 0.5*stdDev*stdDev) / stdDev
+	d2 := d1 - stdDev
+	if isCall {
+		return F*normCDF(d1) - K*normCDF(d2)
+	}
+	return K*normCDF(-d2) - F*normCDF(-d1)
+}
+
+// Inputs describes a European option on a dividend-paying asset.
+type Inputs struct {
+	S0      float64    // Spot price
+	K       float64    // Strike
+	T       float64    // Time to expiry (years)
+	Sigma   float64    // Volatility (per annum, decimal)
+	R       float64    // Risk-free rate (cont. comp.)
+	Q       float64    // Dividend yield (cont. comp.)
+	OptType OptionType // Call or Put
+
+	Exercise ExerciseStyle // European if empty
+	Model    PricingModel  // BSMModel if empty
+
+	// Dividends are discrete cash dividends, paid on top of the yield Q;
+	// those going ex after expiry are ignored. Price and PriceTree
+	// support them under BSM; the exotic pricers reject them.
+	Dividends []CashDividend `json:",omitempty"`
 }
 
-type BSMOutputs struct {
+// PricingModel selects the dynamics Price assumes.
+type PricingModel string
+
+const (
+	BSMModel       PricingModel = "bsm"       // Lognormal spot with dividend yield Q
+	Black76Model   PricingModel = "black76"   // Lognormal futures: S0 is the futures price, Q must be zero
+	BachelierModel PricingModel = "bachelier" // Normal forward: S0 is the forward, Sigma a normal vol, Q must be zero
+)
+
+// Outputs is an option's price and Greeks.
+type Outputs struct {
 	Price        float64
 	Delta        float64
 	Gamma        float64
@@ -41,96 +108,277 @@ type BSMOutputs struct {
 	ThetaPerDay  float64
 	RhoPer1      float64
 	RhoPerBp     float64
-	PhiPer1      float64
+	PhiPer1      float64 // dPrice/dq, also called epsilon
 	PhiPerBp     float64
+	DualDelta    float64 // dPrice/dK
+
+	// Second-order Greeks. Vols are per 1.00; charm and color are the
+	// changes in delta and gamma as time passes.
+	Vanna        float64 // dDelta/dSigma
+	Volga        float64 // dVega/dSigma (vomma)
+	CharmPerYear float64
+	CharmPerDay  float64
+	Speed        float64 // dGamma/dS
+	Zomma        float64 // dGamma/dSigma
+	ColorPerYear float64
+	ColorPerDay  float64
+
+	// Contract analytics from the terminal distribution (see analytics.go).
+	// They are not additive, so aggregates (scale, add) leave them zero.
+	Lambda         float64 // Elasticity: Delta S / Price, zero for a worthless option
+	ProbITM        float64 // Risk-neutral probability of expiring in the money
+	ProbITMStock   float64 // The same under the stock (share) measure
+	ExpectedPayoff float64 // Risk-neutral expected payoff at expiry
+	BreakEven      float64 // Spot at expiry where the payoff repays the premium with interest
+	LogMoneyness   float64 // ln(K/F)
+	StdMoneyness   float64 // ln(K/F) / (sigma sqrt(T)); (K - F) / (sigma sqrt(T)) under Bachelier
 }
 
-func priceAndGreeksBSM(inputs BSMInputs, thetaBasis int) BSMOutputs {
-	S0, K, T, sigma, r, q := inputs.S0, inputs.K, inputs.T, inputs.Sigma, inputs.R, inputs.Q
-	optType := inputs.OptType
+// priceAndGreeks is the closed-form BSM kernel behind Price. It expects
+// validated inputs; the floors on T and sigma below are the limits at
+// expiry and at zero vol, not a repair of bad inputs.
+func priceAndGreeks(inputs Inputs, thetaBasis int) Outputs {
+	e := newExpiryTerms(inputs.S0, inputs.T, inputs.R, inputs.Q)
+	return e.priceAndGreeks(inputs.K, inputs.Sigma, inputs.OptType, thetaBasis)
+}
+
+// expiryTerms are the kernel's terms shared by every strike of one spot,
+// expiry and carry, so a chain computes them once (see ExpiryPricer).
+type expiryTerms struct {
+	S0, T, r, q  float64
+	sqrtT, drift float64 // drift is (r - q) T
+	expQT, expRT float64
+	sFwd         float64 // Discounted forward
+}
 
-	// Guards for edge cases
+func newExpiryTerms(S0, T, r, q float64) expiryTerms {
+	// Expiry limit
 	if T < 1e-6 {
 		T = 1e-6
 	}
+	e := expiryTerms{S0: S0, T: T, r: r, q: q, sqrtT: math.Sqrt(T), drift: (r - q) * T}
+	e.expQT = math.Exp(-q * T)
+	e.expRT = math.Exp(-r * T)
+	e.sFwd = S0 * e.expQT
+	return e
+}
+
+// priceAndGreeks is the kernel for one strike and vol on e's expiry.
+func (e *expiryTerms) priceAndGreeks(K, sigma float64, optType OptionType, thetaBasis int) Outputs {
+	S0, T, r, q := e.S0, e.T, e.r, e.q
+	sqrtT, expQT, expRT := e.sqrtT, e.expQT, e.expRT
+
+	// Zero-vol limit
 	if sigma < 1e-8 {
 		sigma = 1e-8
 	}
 
-	// d1, d2
-	d1 := (math.Log(S0/K) + (r-q+0.5*sigma*sigma)*T) / (sigma * math.Sqrt(T))
-	d2 := d1 - sigma*math.Sqrt(T)
+	// d1, d2 and the shared terms, each computed once
+	sd := sigma * sqrtT
+	logFK := math.Log(S0/K) + e.drift // ln(F/K)
+	d1 := logFK/sd + 0.5*sd
+	d2 := d1 - sd
 
-	expQT := math.Exp(-q * T)
-	expRT := math.Exp(-r * T)
-
-	N_d1 := normCDF(d1)
-	N_d2 := normCDF(d2)
-	N_md1 := normCDF(-d1)
-	N_md2 := normCDF(-d2)
+	// phi folds calls and puts together; N(phi d) keeps the tail accuracy
+	// that 1 - N(d) would lose.
+	phi := 1.0
+	if optType != Call {
+		phi = -1
+	}
+	Nd1 := normCDF(phi * d1)
+	Nd2 := normCDF(phi * d2)
 	n_d1 := normPDF(d1)
+	sFwd := e.sFwd
+	kPV := K * expRT
 
-	var price, delta, gamma, vega, theta, rho, phi float64
-
-	if optType == Call {
-		price = S0*expQT*N_d1 - K*expRT*N_d2
-		delta = expQT * N_d1
-		theta = -S0*expQT*n_d1*sigma/(2*math.Sqrt(T)) + q*S0*expQT*N_d1 - r*K*expRT*N_d2
-		rho = K * T * expRT * N_d2
-		phi = -T * S0 * expQT * N_d1
-	} else {
-		price = K*expRT*N_md2 - S0*expQT*N_md1
-		delta = expQT*N_d1 - expQT
-		theta = -S0*expQT*n_d1*sigma/(2*math.Sqrt(T)) - q*S0*expQT*N_md1 + r*K*expRT*N_md2
-		rho = -K * T * expRT * N_md2
-		phi = T * S0 * expQT * N_md1
-	}
-
-	gamma = expQT * n_d1 / (S0 * sigma * math.Sqrt(T))
-	vega = S0 * expQT * n_d1 * math.Sqrt(T)
-	vegaPerVolPt := vega * 0.01
-	thetaPerDay := theta / float64(thetaBasis)
-	rhoPerBp := rho / 10000.0
-	phiPerBp := phi / 10000.0
-
-	return BSMOutputs{
+	price := phi * (sFwd*Nd1 - kPV*Nd2)
+	delta := phi * expQT * Nd1
+	theta := -sFwd*n_d1*sigma/(2*sqrtT) + phi*(q*sFwd*Nd1-r*kPV*Nd2)
+	rho := phi * T * kPV * Nd2
+	epsilon := -phi * T * sFwd * Nd1
+	gamma := expQT * n_d1 / (S0 * sd)
+	vega := sFwd * n_d1 * sqrtT
+
+	// Second order
+	vanna := -expQT * n_d1 * d2 / sigma
+	volga := vega * d1 * d2 / sigma
+	charm := phi*q*expQT*Nd1 - expQT*n_d1*(2*(r-q)*T-d2*sd)/(2*T*sd)
+	speed := -gamma / S0 * (d1/sd + 1)
+	zomma := gamma * (d1*d2 - 1) / sigma
+	color := expQT * n_d1 / (2 * S0 * T * sd) * (2*q*T + 1 + d1*(2*(r-q)*T-d2*sd)/sd)
+
+	return Outputs{
 		Price:        price,
 		Delta:        delta,
 		Gamma:        gamma,
 		VegaPerVol:   vega,
-		VegaPerVolPt: vegaPerVolPt,
+		VegaPerVolPt: vega * 0.01,
 		ThetaPerYear: theta,
-		ThetaPerDay:  thetaPerDay,
+		ThetaPerDay:  theta / float64(thetaBasis),
 		RhoPer1:      rho,
-		RhoPerBp:     rhoPerBp,
-		PhiPer1:      phi,
-		PhiPerBp:     phiPerBp,
-	}
-}
-
-func main() {
-	// Example from the guide
-	inputs := BSMInputs{
-		S0:      100.0,
-		K:       100.0,
-		T:       0.5,
-		Sigma:   0.20,
-		R:       0.03,
-		Q:       0.01,
-		OptType: Call, // or Put
-	}
-	// Use 365 for calendar-day theta, 252 for trading-day theta
-	outputs := priceAndGreeksBSM(inputs, 365)
-
-	fmt.Printf("Price: %.6f\n", outputs.Price)
-	fmt.Printf("Delta: %.6f\n", outputs.Delta)
-	fmt.Printf("Gamma: %.6f\n", outputs.Gamma)
-	fmt.Printf("Vega (per 1.00 vol): %.6f\n", outputs.VegaPerVol)
-	fmt.Printf("Vega (per vol-pt): %.6f\n", outputs.VegaPerVolPt)
-	fmt.Printf("Theta (per year): %.6f\n", outputs.ThetaPerYear)
-	fmt.Printf("Theta (per day): %.6f\n", outputs.ThetaPerDay)
-	fmt.Printf("Rho (per 1.00): %.6f\n", outputs.RhoPer1)
-	fmt.Printf("Rho (per bp): %.6f\n", outputs.RhoPerBp)
-	fmt.Printf("Phi (per 1.00): %.6f\n", outputs.PhiPer1)
-	fmt.Printf("Phi (per bp): %.6f\n", outputs.PhiPerBp)
+		RhoPerBp:     rho / 10000.0,
+		PhiPer1:      epsilon,
+		PhiPerBp:     epsilon / 10000.0,
+		Vanna:        vanna,
+		Volga:        volga,
+		CharmPerYear: charm,
+		CharmPerDay:  charm / float64(thetaBasis),
+		Speed:        speed,
+		Zomma:        zomma,
+		ColorPerYear: color,
+		ColorPerDay:  color / float64(thetaBasis),
+
+		// The analytics from the same terms, saving setAnalytics the work
+		ProbITM:        Nd2,
+		ProbITMStock:   Nd1,
+		ExpectedPayoff: price / expRT,
+		LogMoneyness:   -logFK,
+		StdMoneyness:   -logFK / sd,
+	}
+}
+
+// Option configures Price.
+type Option func(*priceOptions)
+
+type priceOptions struct {
+	thetaBasis int
+	tree       TreeOptions
+	american   AmericanMethod
+	dividends  DividendMethod
+}
+
+// WithThetaBasis sets the days per year for ThetaPerDay: 365 for calendar
+// days (default), 252 for trading days.
+func WithThetaBasis(days int) Option {
+	return func(o *priceOptions) { o.thetaBasis = days }
+}
+
+// WithTree sets the tree used for American options (see PriceTree).
+func WithTree(opt TreeOptions) Option {
+	return func(o *priceOptions) { o.tree = opt }
+}
+
+// Price returns the price and Greeks of an option under inputs.Model
+// (Black-Scholes-Merton by default), or an error if the inputs are out of
+// range. European options are priced in closed form, American ones on a tree or with the Bjerksund-Stensland
+// approximation (see WithAmericanMethod).
+func Price(inputs Inputs, opts ...Option) (Outputs, error) {
+	o := resolveOptions(opts)
+	if o.thetaBasis <= 0 {
+		return Outputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+	return priceWith(inputs, o)
+}
+
+// resolveOptions applies opts to the defaults. The options write through
+// a pointer the compiler cannot follow, so the struct they fill escapes;
+// it is only allocated when there are options, keeping a bare Price call
+// free of heap allocations.
+func resolveOptions(opts []Option) priceOptions {
+	if len(opts) == 0 {
+		return priceOptions{thetaBasis: 365}
+	}
+	o := &priceOptions{thetaBasis: 365}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return *o
+}
+
+// priceWith validates inputs, prices them by model and adds the contract
+// analytics.
+func priceWith(inputs Inputs, o priceOptions) (Outputs, error) {
+	if err := inputs.Validate(); err != nil {
+		return Outputs{}, err
+	}
+	var out Outputs
+	var err error
+	switch inputs.Model {
+	case Black76Model:
+		out, err = priceBlack76(inputs, o)
+	case BachelierModel:
+		if inputs.Exercise == American {
+			return Outputs{}, errors.New("the Bachelier model prices European options only")
+		}
+		out = priceAndGreeksBachelier(inputs, o.thetaBasis)
+	default:
+		out, err = priceBSM(inputs, o)
+	}
+	if err != nil {
+		return Outputs{}, err
+	}
+	out.setAnalytics(inputs, o)
+	return out, nil
+}
+
+// priceBSM prices validated inputs under BSM, by exercise style.
+func priceBSM(inputs Inputs, o priceOptions) (Outputs, error) {
+	if inputs.Exercise == American {
+		switch o.american {
+		case "", AmericanTree:
+			return PriceTree(inputs, o.tree, o.thetaBasis)
+		case AmericanBS02:
+			return PriceBjerksundStensland(inputs, o.thetaBasis)
+		}
+		return Outputs{}, fmt.Errorf("unknown American method %q", o.american)
+	}
+	if len(inputs.Dividends) > 0 {
+		switch o.dividends {
+		case "", EscrowedDividends:
+			return priceEscrowed(inputs, o.thetaBasis), nil
+		case HaugHaugDividends:
+			return priceHaugHaug(inputs, o.thetaBasis), nil
+		}
+		return Outputs{}, fmt.Errorf("unknown dividend method %q", o.dividends)
+	}
+	return priceAndGreeks(inputs, o.thetaBasis), nil
+}
+
+// Errors returned by Validate, and so by Price and the other pricers,
+// wrapped with the offending value; test for them with errors.Is.
+var (
+	ErrNonFinite         = errors.New("inputs must be finite")
+	ErrNegativeSpot      = errors.New("spot must be positive")
+	ErrNegativeStrike    = errors.New("strike must be positive")
+	ErrExpired           = errors.New("time to expiry must be non-negative")
+	ErrNegativeVol       = errors.New("volatility must be non-negative")
+	ErrUnknownOptionType = errors.New("unknown option type")
+	ErrUnknownExercise   = errors.New("unknown exercise style")
+	ErrUnknownModel      = errors.New("unknown pricing model")
+	ErrYieldNotAllowed   = errors.New("model takes no dividend yield")
+	ErrThetaBasis        = errors.New("theta basis must be positive")
+)
+
+// Validate checks that inputs can be priced: positive spot and strike
+// (any sign under the Bachelier model), non-negative expiry and vol, finite
+// rates and a call or put. Zero expiry and zero vol are valid; the pricers
+// treat them as the limits at expiry and of a deterministic spot.
+func (in Inputs) Validate() error {
+	for _, v := range []float64{in.S0, in.K, in.T, in.Sigma, in.R, in.Q} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%w: %+v", ErrNonFinite, in)
+		}
+	}
+	normal := in.Model == BachelierModel
+	switch {
+	case in.S0 <= 0 && !normal:
+		return fmt.Errorf("%w, got %g", ErrNegativeSpot, in.S0)
+	case in.K <= 0 && !normal:
+		return fmt.Errorf("%w, got %g", ErrNegativeStrike, in.K)
+	case in.T < 0:
+		return fmt.Errorf("%w, got %g", ErrExpired, in.T)
+	case in.Sigma < 0:
+		return fmt.Errorf("%w, got %g", ErrNegativeVol, in.Sigma)
+	case in.OptType == 0:
+		return fmt.Errorf("%w: not set, want %v or %v", ErrUnknownOptionType, Call, Put)
+	case !in.OptType.Valid():
+		return fmt.Errorf("%w %v, want %v or %v", ErrUnknownOptionType, in.OptType, Call, Put)
+	case in.Exercise != "" && in.Exercise != European && in.Exercise != American:
+		return fmt.Errorf("%w %q", ErrUnknownExercise, in.Exercise)
+	case in.Model != "" && in.Model != BSMModel && in.Model != Black76Model && !normal:
+		return fmt.Errorf("%w %q", ErrUnknownModel, in.Model)
+	case (in.Model == Black76Model || normal) && in.Q != 0:
+		return fmt.Errorf("the %s %w, got %g", in.Model, ErrYieldNotAllowed, in.Q)
+	}
+	return validateDividends(in)
 }
@@ -0,0 +1,209 @@
+package bsm
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ExpiryPricer prices the strikes of one underlying and expiry, computing
+// what they share once: the discount factors, sqrt(T), the drift and the
+// discounted forward. Its outputs are Price's, bit for bit. European BSM
+// and Black-76 contracts without discrete dividends take the shared
+// terms; anything else falls back to Price per strike.
+type ExpiryPricer struct {
+	in    Inputs // Spot, expiry, rates, exercise, model and dividends; K, Sigma and OptType per call
+	o     priceOptions
+	terms expiryTerms
+	fast  bool
+}
+
+// NewExpiryPricer validates the shared inputs, ignoring K, Sigma and
+// OptType, and precomputes their terms.
+func NewExpiryPricer(in Inputs, opts ...Option) (*ExpiryPricer, error) {
+	o := resolveOptions(opts)
+	if o.thetaBasis <= 0 {
+		return nil, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+	check := in
+	check.K, check.Sigma, check.OptType = 1, 0, Call
+	if err := check.Validate(); err != nil {
+		return nil, err
+	}
+	p := &ExpiryPricer{in: in, o: o}
+	european := in.Exercise != American && len(in.Dividends) == 0
+	switch {
+	case european && (in.Model == "" || in.Model == BSMModel):
+		p.terms, p.fast = newExpiryTerms(in.S0, in.T, in.R, in.Q), true
+	case european && in.Model == Black76Model:
+		p.terms, p.fast = newExpiryTerms(in.S0, in.T, in.R, in.R), true
+	}
+	return p, nil
+}
+
+// Price prices the strike K at vol sigma.
+func (p *ExpiryPricer) Price(K, sigma float64, optType OptionType) (Outputs, error) {
+	in := p.in
+	in.K, in.Sigma, in.OptType = K, sigma, optType
+	if !p.fast {
+		return priceWith(in, p.o)
+	}
+	if err := in.Validate(); err != nil {
+		return Outputs{}, err
+	}
+	out := p.terms.priceAndGreeks(K, sigma, optType, p.o.thetaBasis)
+	if in.Model == Black76Model {
+		// As priceBlack76: rho holds F fixed.
+		out.RhoPer1 += out.PhiPer1
+		out.RhoPerBp += out.PhiPerBp
+		out.PhiPer1, out.PhiPerBp = 0, 0
+	}
+	out.setAnalytics(in, p.o)
+	return out, nil
+}
+
+// PriceStrikes prices strikes[i] at vols[i] into a new slice, stopping at
+// the first strike that fails.
+func (p *ExpiryPricer) PriceStrikes(strikes, vols []float64, optType OptionType) ([]Outputs, error) {
+	if len(strikes) != len(vols) {
+		return nil, fmt.Errorf("%d strikes but %d vols", len(strikes), len(vols))
+	}
+	out := make([]Outputs, len(strikes))
+	for i, K := range strikes {
+		var err error
+		if out[i], err = p.Price(K, vols[i], optType); err != nil {
+			return nil, fmt.Errorf("strike %g: %w", K, err)
+		}
+	}
+	return out, nil
+}
+
+// PriceCache memoizes Price on inputs quantized to a relative tolerance,
+// so contracts repriced with unchanged (or negligibly changed) inputs
+// cost a map lookup. A hit returns the outputs of the first contract
+// priced in its quantum, which differ from the exact ones by about the
+// tolerance times the sensitivities. Contracts with discrete dividends are
+// priced without the cache. It is safe for concurrent use.
+type PriceCache struct {
+	o        priceOptions
+	drop     uint // Mantissa bits the quantization drops
+	capacity int
+
+	mu           sync.Mutex
+	entries      map[cacheKey]*list.Element
+	lru          list.List // Of *cacheEntry, most recently used first
+	hits, misses uint64
+}
+
+type cacheKey struct {
+	S0, K, T, Sigma, R, Q float64
+	OptType               OptionType
+	Exercise              ExerciseStyle
+	Model                 PricingModel
+}
+
+type cacheEntry struct {
+	key cacheKey
+	out Outputs
+}
+
+// CacheStats counts a PriceCache's lookups.
+type CacheStats struct {
+	Hits, Misses uint64
+	Entries      int
+}
+
+// NewPriceCache returns a cache of at most capacity results (unbounded if
+// zero), evicting the least recently used, for inputs equal to within
+// tolerance relative to each input (0 for exact matches only). The
+// options apply to every contract priced through it.
+func NewPriceCache(capacity int, tolerance float64, opts ...Option) (*PriceCache, error) {
+	o := resolveOptions(opts)
+	if o.thetaBasis <= 0 {
+		return nil, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+	if capacity < 0 {
+		return nil, fmt.Errorf("cache capacity must be non-negative, got %d", capacity)
+	}
+	if !(tolerance >= 0 && tolerance < 1) {
+		return nil, errors.New("cache tolerance must be in [0, 1)")
+	}
+	c := &PriceCache{o: o, capacity: capacity, entries: make(map[cacheKey]*list.Element)}
+	if tolerance > 0 {
+		// Keep the leading bits that resolve the tolerance.
+		keep := math.Ceil(-math.Log2(tolerance))
+		c.drop = uint(52 - math.Min(keep, 52))
+	}
+	return c, nil
+}
+
+// quantize rounds x's mantissa to the nearest multiple of 2^drop units in
+// the last place, so inputs within the tolerance mostly share a key.
+func quantize(x float64, drop uint) float64 {
+	if drop == 0 || x == 0 {
+		return x + 0 // -0 keys as 0
+	}
+	b := math.Float64bits(x)
+	b = (b + 1<<(drop-1)) &^ (1<<drop - 1)
+	return math.Float64frombits(b)
+}
+
+// Price returns Price(in) under the cache's options, from the cache when
+// it can.
+func (c *PriceCache) Price(in Inputs) (Outputs, error) {
+	if len(in.Dividends) > 0 {
+		return priceWith(in, c.o)
+	}
+	key := cacheKey{
+		S0: quantize(in.S0, c.drop), K: quantize(in.K, c.drop), T: quantize(in.T, c.drop),
+		Sigma: quantize(in.Sigma, c.drop), R: quantize(in.R, c.drop), Q: quantize(in.Q, c.drop),
+		OptType: in.OptType, Exercise: in.Exercise, Model: in.Model,
+	}
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		c.hits++
+		out := e.Value.(*cacheEntry).out
+		c.mu.Unlock()
+		return out, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// Price outside the lock: a tree can take a while. Errors are not
+	// cached.
+	out, err := priceWith(in, c.o)
+	if err != nil {
+		return Outputs{}, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.entries[key] = c.lru.PushFront(&cacheEntry{key, out})
+		if c.capacity > 0 && c.lru.Len() > c.capacity {
+			oldest := c.lru.Back()
+			c.lru.Remove(oldest)
+			delete(c.entries, oldest.Value.(*cacheEntry).key)
+		}
+	}
+	return out, nil
+}
+
+// Stats returns the hits, misses and entries so far.
+func (c *PriceCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{Hits: c.hits, Misses: c.misses, Entries: len(c.entries)}
+}
+
+// Reset empties the cache and its counts, e.g. after a market data update
+// that moves every contract.
+func (c *PriceCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.lru.Init()
+	c.hits, c.misses = 0, 0
+}
@@ -0,0 +1,140 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// ResidualFunc returns model-minus-market residuals for a parameter vector.
+type ResidualFunc = numerics.ResidualFunc
+
+// JacobianFunc returns jac[i][j] = d residual_i / d param_j.
+type JacobianFunc = numerics.JacobianFunc
+
+// CalibrationProblem is a bounded, weighted least-squares problem. Models
+// plug in by supplying Residuals; Jacobian is optional and bumped by
+// central differences when nil. Lower/Upper and Weights may be nil.
+type CalibrationProblem struct {
+	Names     []string
+	Initial   []float64
+	Lower     []float64
+	Upper     []float64
+	Residuals ResidualFunc
+	Jacobian  JacobianFunc
+	Weights   []float64 // Multiply each residual
+}
+
+// LMOptions tunes the Levenberg-Marquardt iteration; see
+// numerics.LMOptions.
+type LMOptions = numerics.LMOptions
+
+// CalibrationResult is the fitted parameter vector and fit statistics on the
+// weighted residuals.
+type CalibrationResult struct {
+	Names      []string
+	Params     []float64
+	Residuals  []float64 // Unweighted, at Params
+	RMSE       float64   // Weighted root-mean-square residual
+	Cost       float64   // Half the weighted sum of squares
+	AtBound    []bool
+	Iterations int
+	Converged  bool
+	Reason     string
+}
+
+// Param returns the fitted value of the named parameter.
+func (r CalibrationResult) Param(name string) (float64, bool) {
+	for i, n := range r.Names {
+		if n == name {
+			return r.Params[i], true
+		}
+	}
+	return 0, false
+}
+
+// Calibrate minimises half the weighted sum of squared residuals with a
+// projected Levenberg-Marquardt method: steps are clipped to the box and
+// bound-active parameters whose gradient points outward are held fixed.
+func Calibrate(p CalibrationProblem, opt LMOptions) (CalibrationResult, error) {
+	return CalibrateContext(context.Background(), p, opt)
+}
+
+// CalibrateContext is Calibrate with a context: when ctx is done it stops
+// and returns the best parameters so far, not Converged, with ctx's
+// error. It logs each iteration and the outcome with its
+// diagnostics at debug level.
+func CalibrateContext(ctx context.Context, p CalibrationProblem, opt LMOptions) (CalibrationResult, error) {
+	ctx, sp := StartSpan(ctx, "calibrate", slog.Int("params", len(p.Initial)), fingerprintAttr(p.Initial))
+	sp.level = slog.LevelDebug // Fits run many calibrations
+	res, err := calibrate(ctx, p, opt)
+	sp.End(err, slog.Int("iterations", res.Iterations), slog.Bool("converged", res.Converged),
+		slog.String("reason", res.Reason), slog.Float64("rmse", res.RMSE), slog.Float64("cost", res.Cost))
+	return res, err
+}
+
+func calibrate(ctx context.Context, p CalibrationProblem, opt LMOptions) (CalibrationResult, error) {
+	progress := opt.Progress
+	opt.Progress = func(iteration int, cost, lambda float64) {
+		logAttrs(ctx, slog.LevelDebug, "calibrate iteration", slog.Int("iteration", iteration),
+			slog.Float64("cost", cost), slog.Float64("lambda", lambda))
+		if progress != nil {
+			progress(iteration, cost, lambda)
+		}
+	}
+	fit, err := numerics.LevenbergMarquardt(ctx, numerics.LeastSquares{
+		Initial:   p.Initial,
+		Lower:     p.Lower,
+		Upper:     p.Upper,
+		Residuals: p.Residuals,
+		Jacobian:  p.Jacobian,
+		Weights:   p.Weights,
+	}, opt)
+	if fit.X == nil {
+		return CalibrationResult{}, err
+	}
+	return CalibrationResult{
+		Names:      p.Names,
+		Params:     fit.X,
+		Residuals:  fit.Residuals,
+		RMSE:       fit.RMSE,
+		Cost:       fit.Cost,
+		AtBound:    fit.AtBound,
+		Iterations: fit.Iterations,
+		Converged:  fit.Converged,
+		Reason:     fit.Reason,
+	}, err
+}
+
+// VegaWeights turns price residuals into approximate implied-vol residuals
+// by weighting each by 1/vega. Vegas below floor are raised to it so deep
+// wings do not dominate.
+func VegaWeights(vegas []float64, floor float64) []float64 {
+	w := make([]float64, len(vegas))
+	for i, v := range vegas {
+		w[i] = 1 / math.Max(math.Abs(v), floor)
+	}
+	return w
+}
+
+// SpreadWeights weights each quote by the inverse of its bid/ask spread, so
+// tight quotes are fitted closely and wide ones loosely. Spreads below
+// minSpread are raised to it.
+func SpreadWeights(bid, ask []float64, minSpread float64) ([]float64, error) {
+	if len(bid) != len(ask) {
+		return nil, errors.New("bid and ask must have the same length")
+	}
+	w := make([]float64, len(bid))
+	for i := range bid {
+		s := ask[i] - bid[i]
+		if s < 0 {
+			return nil, fmt.Errorf("crossed quote at index %d", i)
+		}
+		w[i] = 1 / math.Max(s, minSpread)
+	}
+	return w, nil
+}
@@ -0,0 +1,138 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// CapFloorType is either Cap or Floor
+const (
+	Cap   = "cap"
+	Floor = "floor"
+)
+
+// CapletPeriod is one accrual period of a cap or floor. The rate fixes at
+// Fixing and pays Accrual*(L-K)+ (caplet) at the payment date whose
+// discount factor is Discount.
+type CapletPeriod struct {
+	Fixing   float64 // Time to fixing (years)
+	Accrual  float64 // Accrual fraction of the period
+	Forward  float64 // Forward rate for the period
+	Discount float64 // Discount factor to the payment date
+	Vol      float64 // Caplet vol; the cap's flat vol is used if zero
+}
+
+// CapletInputs describes a single caplet or floorlet.
+type CapletInputs struct {
+	Period   CapletPeriod
+	K        float64    // Strike rate
+	Notional float64    // 1 if zero
+	Quoting  VolQuoting // LognormalVol if empty
+	Shift    float64    // Lognormal shift for low/negative rates
+	Type     string     // "cap" or "floor"
+}
+
+// PriceCaplet prices a caplet (call on the rate) or floorlet (put).
+func PriceCaplet(in CapletInputs, thetaBasis int) (RateOptionOutputs, error) {
+	if in.Type != Cap && in.Type != Floor {
+		return RateOptionOutputs{}, errors.New(`caplet type must be "cap" or "floor"`)
+	}
+	p := in.Period
+	if p.Accrual <= 0 || p.Discount <= 0 {
+		return RateOptionOutputs{}, errors.New("caplet needs positive accrual and discount factor")
+	}
+	scale := p.Accrual * p.Discount
+	if in.Notional != 0 {
+		scale *= in.Notional
+	}
+	return priceRateOption(p.Forward, in.K, p.Fixing, scale, p.Vol, in.Quoting, in.Shift, in.Type == Cap, thetaBasis)
+}
+
+// CapFloorInputs describes a cap or floor as a strip of caplets/floorlets.
+type CapFloorInputs struct {
+	Periods  []CapletPeriod
+	K        float64
+	Notional float64
+	Vol      float64 // Flat vol for periods without their own
+	Quoting  VolQuoting
+	Shift    float64
+	Type     string // "cap" or "floor"
+}
+
+type CapFloorOutputs struct {
+	Total   RateOptionOutputs
+	Caplets []RateOptionOutputs
+}
+
+// PriceCapFloor prices every period and sums the results. Delta and gamma
+// in the total assume a parallel move of all forwards; NormalVol is left
+// unset since it does not aggregate.
+func PriceCapFloor(in CapFloorInputs, thetaBasis int) (CapFloorOutputs, error) {
+	if len(in.Periods) == 0 {
+		return CapFloorOutputs{}, errors.New("cap/floor has no periods")
+	}
+	res := CapFloorOutputs{Caplets: make([]RateOptionOutputs, len(in.Periods))}
+	for i, p := range in.Periods {
+		if p.Vol == 0 {
+			p.Vol = in.Vol
+		}
+		out, err := PriceCaplet(CapletInputs{
+			Period:   p,
+			K:        in.K,
+			Notional: in.Notional,
+			Quoting:  in.Quoting,
+			Shift:    in.Shift,
+			Type:     in.Type,
+		}, thetaBasis)
+		if err != nil {
+			return CapFloorOutputs{}, fmt.Errorf("period %d: %w", i, err)
+		}
+		res.Caplets[i] = out
+		res.Total = res.Total.add(out)
+	}
+	return res, nil
+}
+
+func (o RateOptionOutputs) add(p RateOptionOutputs) RateOptionOutputs {
+	return RateOptionOutputs{
+		Price:        o.Price + p.Price,
+		Delta:        o.Delta + p.Delta,
+		DeltaPerBp:   o.DeltaPerBp + p.DeltaPerBp,
+		Gamma:        o.Gamma + p.Gamma,
+		GammaPerBp:   o.GammaPerBp + p.GammaPerBp,
+		Vega:         o.Vega + p.Vega,
+		NormalVega:   o.NormalVega + p.NormalVega,
+		ThetaPerYear: o.ThetaPerYear + p.ThetaPerYear,
+		ThetaPerDay:  o.ThetaPerDay + p.ThetaPerDay,
+	}
+}
+
+// CapSchedule builds periods from start to end (years) at frequency
+// payments per year, projecting simple forwards from a single discount
+// curve: F = (DF(t1)/DF(t2) - 1) / accrual. The first period, which has
+// already fixed in a standard cap, is skipped when skipFirst is set.
+func CapSchedule(start, end float64, frequency int, discount func(t float64) float64, skipFirst bool) ([]CapletPeriod, error) {
+	if frequency <= 0 || end <= start {
+		return nil, errors.New("cap schedule needs end after start and a positive frequency")
+	}
+	step := 1 / float64(frequency)
+	n := int(math.Round((end - start) / step))
+	var periods []CapletPeriod
+	for i := 0; i < n; i++ {
+		if skipFirst && i == 0 {
+			continue
+		}
+		t1 := start + float64(i)*step
+		t2 := math.Min(t1+step, end)
+		tau := t2 - t1
+		df1, df2 := discount(t1), discount(t2)
+		periods = append(periods, CapletPeriod{
+			Fixing:   t1,
+			Accrual:  tau,
+			Forward:  (df1/df2 - 1) / tau,
+			Discount: df2,
+		})
+	}
+	return periods, nil
+}
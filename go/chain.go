@@ -0,0 +1,166 @@
+package bsm
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChainQuote is one listed option in a chain snapshot.
+type ChainQuote struct {
+	Expiry       time.Time
+	T            float64 // Years from the snapshot time
+	Strike       float64
+	OptType      OptionType
+	Bid, Ask     float64
+	OpenInterest float64
+	Volume       float64
+	Timestamp    time.Time // Last quote update; zero if unknown
+	Underlying   float64   // Underlying price at quote time; zero if unknown
+}
+
+// Mid returns the bid/ask midpoint.
+func (q ChainQuote) Mid() float64 { return 0.5 * (q.Bid + q.Ask) }
+
+// ChainSnapshot is an option chain observed at AsOf.
+type ChainSnapshot struct {
+	AsOf   time.Time
+	Spot   float64
+	Quotes []ChainQuote
+}
+
+// Date-only expiries are taken at this time of day, UTC (US equity close).
+const chainExpiryCutoff = 20 * time.Hour
+
+// ReadChainCSV reads a chain from CSV with a header row. Columns are
+// matched case-insensitively:
+//
+//	expiry (YYYY-MM-DD or RFC 3339) or t (years), strike, type (c/p/call/put),
+//	bid, ask; optional open_interest, volume, timestamp (RFC 3339), underlying
+//
+// The snapshot spot is the last non-zero underlying, if any.
+func ReadChainCSV(r io.Reader, asOf time.Time) (*ChainSnapshot, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	return readChainRecords(header, cr.Read, "line", 2, asOf)
+}
+
+// readChainRecords builds a snapshot from named columns and records read
+// by next until io.EOF, as ReadChainCSV describes. Errors name the record
+// as where and its number, counting from first.
+func readChainRecords(header []string, next func() ([]string, error), where string, first int, asOf time.Time) (*ChainSnapshot, error) {
+	col := map[string]int{}
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, req := range []string{"strike", "type", "bid", "ask"} {
+		if _, ok := col[req]; !ok {
+			return nil, fmt.Errorf("missing column %q", req)
+		}
+	}
+	_, hasExpiry := col["expiry"]
+	_, hasT := col["t"]
+	if !hasExpiry && !hasT {
+		return nil, errors.New(`need an "expiry" or "t" column`)
+	}
+
+	snap := &ChainSnapshot{AsOf: asOf}
+	for line := first; ; line++ {
+		rec, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %w", where, line, err)
+		}
+		field := func(name string) string {
+			if i, ok := col[name]; ok && i < len(rec) {
+				return strings.TrimSpace(rec[i])
+			}
+			return ""
+		}
+		num := func(name string) (float64, error) {
+			s := field(name)
+			if s == "" {
+				return 0, nil
+			}
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return 0, fmt.Errorf("%s %d: %s: %w", where, line, name, err)
+			}
+			return v, nil
+		}
+
+		var q ChainQuote
+		if q.OptType, err = ParseOptionType(field("type")); err != nil {
+			return nil, fmt.Errorf("%s %d: %w", where, line, err)
+		}
+		for name, dst := range map[string]*float64{
+			"strike": &q.Strike, "bid": &q.Bid, "ask": &q.Ask, "open_interest": &q.OpenInterest,
+			"volume": &q.Volume, "underlying": &q.Underlying, "t": &q.T,
+		} {
+			if *dst, err = num(name); err != nil {
+				return nil, err
+			}
+		}
+		if s := field("expiry"); s != "" {
+			if q.Expiry, err = parseChainTime(s); err != nil {
+				return nil, fmt.Errorf("%s %d: expiry: %w", where, line, err)
+			}
+			if len(s) == len("2006-01-02") {
+				q.Expiry = q.Expiry.Add(chainExpiryCutoff)
+			}
+			if q.T == 0 {
+				q.T = q.Expiry.Sub(asOf).Hours() / 24 / calendarDaysPerYear
+			}
+		}
+		if s := field("timestamp"); s != "" {
+			if q.Timestamp, err = parseChainTime(s); err != nil {
+				return nil, fmt.Errorf("%s %d: timestamp: %w", where, line, err)
+			}
+		}
+		if q.Underlying > 0 {
+			snap.Spot = q.Underlying
+		}
+		snap.Quotes = append(snap.Quotes, q)
+	}
+	return snap, nil
+}
+
+func parseChainTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// LoadChainSnapshot reads a CSV or Parquet chain file, by its extension.
+func LoadChainSnapshot(path string, asOf time.Time) (*ChainSnapshot, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".csv" && ext != ".parquet" {
+		return nil, fmt.Errorf("unknown chain file type %q", filepath.Ext(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if ext == ".csv" {
+		return ReadChainCSV(f, asOf)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return ReadChainParquet(f, fi.Size(), asOf)
+}
@@ -0,0 +1,96 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// QuoteVols is a chain quote with its implied vols.
+type QuoteVols struct {
+	Quote ChainQuote
+	Vols  IVQuote
+}
+
+// ExpiryImplied is what one expiry of a chain implies by put-call parity.
+type ExpiryImplied struct {
+	Expiry  time.Time
+	T       float64
+	Forward float64
+	// DividendYield is the continuous yield q with F = S exp((r - q) T).
+	// It is zero when the forward is not from parity or there is no spot.
+	DividendYield float64
+	FromParity    bool        // False if no call/put pair was quoted and the forward is S exp(r T)
+	Vols          []QuoteVols // Sorted by strike, calls first
+}
+
+// ChainImplied is the outcome of ImplyChain.
+type ChainImplied struct {
+	AsOf     time.Time
+	Spot     float64
+	Expiries []ExpiryImplied // Sorted by expiry
+	Dropped  []DroppedQuote
+}
+
+// ImplyChain implies a forward and dividend yield per expiry from the
+// call/put pairs nearest the money (F = K + (C - P)/DF at discount rate
+// r, median of three), then inverts each quote's bid, mid and ask at that
+// forward. Quotes with no mid or ask vol are dropped with the reason. The
+// spot is the snapshot's, or else the last quote's underlying.
+func ImplyChain(snap *ChainSnapshot, r float64) (*ChainImplied, error) {
+	if snap == nil || len(snap.Quotes) == 0 {
+		return nil, errors.New("empty chain")
+	}
+	res := &ChainImplied{AsOf: snap.AsOf, Spot: snap.Spot}
+	byExpiry := map[float64][]ChainQuote{}
+	for _, q := range snap.Quotes {
+		if res.Spot <= 0 && q.Underlying > 0 {
+			res.Spot = q.Underlying
+		}
+		if q.T <= 0 {
+			res.Dropped = append(res.Dropped, DroppedQuote{q, "expired"})
+			continue
+		}
+		byExpiry[q.T] = append(byExpiry[q.T], q)
+	}
+	expiries := make([]float64, 0, len(byExpiry))
+	for T := range byExpiry {
+		expiries = append(expiries, T)
+	}
+	sort.Float64s(expiries)
+
+	for _, T := range expiries {
+		quotes := byExpiry[T]
+		df := math.Exp(-r * T)
+		e := ExpiryImplied{Expiry: quotes[0].Expiry, T: T}
+		e.Forward, e.FromParity = parityForward(quotes, df)
+		switch {
+		case e.FromParity && res.Spot > 0:
+			e.DividendYield = r - math.Log(e.Forward/res.Spot)/T
+		case !e.FromParity && res.Spot > 0:
+			e.Forward = res.Spot / df
+		default:
+			for _, q := range quotes {
+				res.Dropped = append(res.Dropped, DroppedQuote{q, "no put-call pair or spot to set the forward"})
+			}
+			continue
+		}
+		sort.Slice(quotes, func(a, b int) bool {
+			if quotes[a].Strike != quotes[b].Strike {
+				return quotes[a].Strike < quotes[b].Strike
+			}
+			return quotes[a].OptType < quotes[b].OptType
+		})
+		for _, q := range quotes {
+			vols, err := q.ImpliedVols(e.Forward, df)
+			if err != nil {
+				res.Dropped = append(res.Dropped, DroppedQuote{q, "no implied vol for mid or ask"})
+				continue
+			}
+			e.Vols = append(e.Vols, QuoteVols{q, vols})
+		}
+		res.Expiries = append(res.Expiries, e)
+	}
+	return res, nil
+}
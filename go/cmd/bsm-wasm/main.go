@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+// Command bsm-wasm is the pricer compiled to WebAssembly for browser code.
+// It registers a global function
+//
+//	priceAndGreeks(inputsJSON, thetaBasis?) -> string
+//
+// taking Inputs as JSON, the same as a "bsm -json" batch element, and an
+// optional theta basis (365). It returns JSON, {"greeks": {...}} with the
+// Outputs or {"error": "..."}, rather than throwing. Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o bsm.wasm ./cmd/bsm-wasm
+//
+// and load it with the wasm_exec.js shipped in $(go env GOROOT)/lib/wasm.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"syscall/js"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+func main() {
+	js.Global().Set("priceAndGreeks", js.FuncOf(priceAndGreeks))
+	select {} // Keep the exports alive for the page's lifetime
+}
+
+type result struct {
+	Greeks *bsm.Outputs `json:"greeks,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+func priceAndGreeks(_ js.Value, args []js.Value) any {
+	var res result
+	out, err := price(args)
+	if err != nil {
+		res.Error = err.Error()
+	} else {
+		res.Greeks = &out
+	}
+	b, _ := json.Marshal(res) // Outputs always marshal
+	return string(b)
+}
+
+// price decodes and prices one contract from the JS arguments.
+func price(args []js.Value) (bsm.Outputs, error) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		return bsm.Outputs{}, errors.New("priceAndGreeks takes the inputs as a JSON string")
+	}
+	var in bsm.Inputs
+	dec := json.NewDecoder(bytes.NewReader([]byte(args[0].String())))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&in); err != nil {
+		return bsm.Outputs{}, err
+	}
+	thetaBasis := 365
+	if len(args) > 1 && args[1].Type() == js.TypeNumber {
+		thetaBasis = args[1].Int()
+	}
+	return bsm.Price(in, bsm.WithThetaBasis(thetaBasis))
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// csvFields are the contract fields price-csv reads, with the header names
+// recognised for each, compared case-insensitively. Exercise and model
+// are optional.
+var csvFields = []struct {
+	name     string
+	aliases  []string
+	required bool
+}{
+	{"spot", []string{"spot", "s0", "s", "underlying"}, true},
+	{"strike", []string{"strike", "k"}, true},
+	{"expiry", []string{"expiry", "t", "tte", "maturity"}, true},
+	{"vol", []string{"vol", "sigma", "iv", "volatility"}, true},
+	{"rate", []string{"rate", "r"}, true},
+	{"yield", []string{"yield", "q", "dividend_yield", "div"}, true},
+	{"type", []string{"type", "opttype", "option_type", "cp", "callput"}, true},
+	{"exercise", []string{"exercise", "style"}, false},
+	{"model", []string{"model"}, false},
+}
+
+// runPriceCSVCommand implements "bsm price-csv": it reads contracts from a
+// CSV file with a header row, prices them in parallel and writes every
+// input row back with the Greek columns and an error column appended. Rows
+// that cannot be read or priced keep empty Greeks and say why, and are
+// listed on stderr; the command fails if any did, after writing the rest.
+func runPriceCSVCommand(args []string, stdin io.Reader, w, stderr io.Writer) error {
+	fs := flag.NewFlagSet("price-csv", flag.ContinueOnError)
+	file := fs.String("in", "-", "the CSV file to read, - for stdin")
+	out := fs.String("o", "", "write to this file instead of stdout")
+	thetaBasis := fs.Int("theta-basis", 365, "days per year for theta and charm (365 calendar, 252 trading)")
+	mapping := fs.String("map", "", "header names for fields, e.g. spot=Px,vol=ImpVol (fields: spot, strike, expiry, vol, rate, yield, type, exercise, model)")
+	round := fs.String("round", "", "decimal places for the Greeks, e.g. 8 or 8,Price=4,Delta=6")
+	halfEven := fs.Bool("half-even", false, "with -round, round ties to even (banker's rounding)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	policy, err := parseRounding(*round, *halfEven)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: bsm price-csv [-in file.csv] [-o out.csv] [-map field=Header,...] [-theta-basis days] [-round places] [-half-even]")
+	}
+	r := stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // Reported per row instead of failing the file
+	header, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	cols, err := mapCSVColumns(header, *mapping)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]string
+	var lines []int
+	var contracts []bsm.Inputs
+	var errs []error
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var c bsm.Inputs
+		var line int
+		var pe *csv.ParseError
+		switch {
+		case errors.As(err, &pe):
+			// A quoting error spoils only its own row.
+			rec, line = nil, pe.StartLine
+		case err != nil:
+			return err
+		default:
+			line, _ = cr.FieldPos(0)
+			c, err = parseCSVContract(rec, len(header), cols)
+		}
+		rows, lines = append(rows, rec), append(lines, line)
+		contracts, errs = append(contracts, c), append(errs, err)
+	}
+
+	opts := []bsm.Option{bsm.WithThetaBasis(*thetaBasis)}
+	outs := make([]bsm.Outputs, len(contracts))
+	if err := bsm.PriceBatchInto(outs, contracts, opts...); err != nil {
+		// Price the failures again one by one to attribute the errors.
+		for i := range contracts {
+			if errs[i] != nil {
+				continue
+			}
+			if _, e := bsm.Price(contracts[i], opts...); e != nil {
+				errs[i] = e
+			}
+		}
+	}
+
+	dst := w
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dst = f
+	}
+	bw := bufio.NewWriter(dst)
+	cw := csv.NewWriter(bw)
+	outHeader := append([]string(nil), header...)
+	for _, c := range greekColumns {
+		outHeader = append(outHeader, c.name)
+	}
+	cw.Write(append(outHeader, "error"))
+	failed := 0
+	for i, rec := range rows {
+		row := make([]string, len(header), len(outHeader)+1)
+		copy(row, rec)
+		msg := ""
+		if errs[i] != nil {
+			failed++
+			msg = errs[i].Error()
+			fmt.Fprintf(stderr, "line %d: %v\n", lines[i], errs[i])
+		}
+		for _, c := range greekColumns {
+			if errs[i] != nil {
+				row = append(row, "")
+			} else {
+				row = append(row, formatGreek(policy, c.name, c.get(outs[i])))
+			}
+		}
+		cw.Write(append(row, msg))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rows failed", failed, len(rows))
+	}
+	return nil
+}
+
+// mapCSVColumns returns the column index of each field in csvFields, -1 for
+// an absent optional one. mapping overrides the aliases as field=Header
+// pairs.
+func mapCSVColumns(header []string, mapping string) ([]int, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	explicit := map[string]string{}
+	if mapping != "" {
+		for _, pair := range strings.Split(mapping, ",") {
+			field, col, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("-map entry %q is not field=Header", pair)
+			}
+			explicit[strings.ToLower(strings.TrimSpace(field))] = strings.ToLower(strings.TrimSpace(col))
+		}
+	}
+	cols := make([]int, len(csvFields))
+	for k, f := range csvFields {
+		cols[k] = -1
+		names := f.aliases
+		if col, ok := explicit[f.name]; ok {
+			names = []string{col}
+			delete(explicit, f.name)
+		}
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				cols[k] = i
+				break
+			}
+		}
+		if cols[k] < 0 && f.required {
+			return nil, fmt.Errorf("CSV header has no %s column (looked for %s)", f.name, strings.Join(names, ", "))
+		}
+	}
+	for field := range explicit { // Any one left over is an error
+		return nil, fmt.Errorf("-map names unknown field %q", field)
+	}
+	return cols, nil
+}
+
+// parseCSVContract reads one row, naming the column of any bad value.
+func parseCSVContract(rec []string, width int, cols []int) (bsm.Inputs, error) {
+	if len(rec) != width {
+		return bsm.Inputs{}, fmt.Errorf("row has %d fields, header has %d", len(rec), width)
+	}
+	var in bsm.Inputs
+	nums := []*float64{&in.S0, &in.K, &in.T, &in.Sigma, &in.R, &in.Q}
+	for k, p := range nums {
+		s := strings.TrimSpace(rec[cols[k]])
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return bsm.Inputs{}, fmt.Errorf("%s: %q is not a number", csvFields[k].name, s)
+		}
+		*p = v
+	}
+	var err error
+	if in.OptType, err = bsm.ParseOptionType(strings.TrimSpace(rec[cols[6]])); err != nil {
+		return bsm.Inputs{}, fmt.Errorf("type: %w", err)
+	}
+	if c := cols[7]; c >= 0 {
+		in.Exercise = bsm.ExerciseStyle(strings.ToLower(strings.TrimSpace(rec[c])))
+	}
+	if c := cols[8]; c >= 0 {
+		in.Model = bsm.PricingModel(strings.ToLower(strings.TrimSpace(rec[c])))
+	}
+	return in, nil
+}
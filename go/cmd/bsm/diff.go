@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// runDiffCommand implements "bsm diff run1.json run2.json": it diffs two
+// saved runs and writes the result as JSON to w, or to -o. -v logs to
+// stderr as JSON.
+func runDiffCommand(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	out := fs.String("o", "", "write the diff to this file instead of stdout")
+	verbose := fs.Bool("v", false, "log to stderr")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: bsm diff [-v] [-o out.json] run1.json run2.json")
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if *verbose {
+		log = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		bsm.SetLogger(log)
+	}
+	log = log.With("trace_id", bsm.NewTraceID(), "from", fs.Arg(0), "to", fs.Arg(1))
+	start := time.Now()
+	js, err := diffRunFiles(fs.Arg(0), fs.Arg(1))
+	elapsed := float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		log.Error("run_diff failed", "error", err, "duration_ms", elapsed)
+		return err
+	}
+	log.Info("run_diff done", "bytes", len(js), "duration_ms", elapsed)
+	if *out != "" {
+		return os.WriteFile(*out, js, 0o644)
+	}
+	_, err = w.Write(js)
+	return err
+}
+
+func diffRunFiles(pathA, pathB string) ([]byte, error) {
+	a, err := bsm.LoadPricingRun(pathA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := bsm.LoadPricingRun(pathB)
+	if err != nil {
+		return nil, err
+	}
+	d, err := bsm.DiffRuns(a, b)
+	if err != nil {
+		return nil, err
+	}
+	js, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(js, '\n'), nil
+}
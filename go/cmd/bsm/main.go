@@ -0,0 +1,34 @@
+// Command bsm prices options from flags or JSON batches, with "price-csv"
+// prices a CSV of contracts, with "diff" compares two saved pricing runs,
+// and with "serve" runs the gRPC pricer.
+//
+//	bsm -s0 100 -k 95 -t 0.25 -sigma 0.3 -type put
+//	bsm -json -in contracts.json -format csv
+//	bsm price-csv -in contracts.csv -o priced.csv
+//	echo '[{"S0":100,"K":100,"T":1,"Sigma":0.2,"OptType":"call"}]' | bsm -json
+//
+// Without flags it prints the example from the guide.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func main() {
+	var err error
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "diff":
+		err = runDiffCommand(os.Args[2:], os.Stdout)
+	case len(os.Args) > 1 && os.Args[1] == "price-csv":
+		err = runPriceCSVCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr)
+	case len(os.Args) > 1 && os.Args[1] == "serve":
+		err = runServeCommand(os.Args[2:])
+	default:
+		err = runPriceCommand(os.Args[1:], os.Stdin, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
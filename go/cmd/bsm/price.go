@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// greekColumns are the reported outputs: the field name used in CSV
+// headers and JSON, and the label used in the table.
+var greekColumns = []struct {
+	name, label string
+	get         func(bsm.Outputs) float64
+}{
+	{"Price", "Price", func(o bsm.Outputs) float64 { return o.Price }},
+	{"Delta", "Delta", func(o bsm.Outputs) float64 { return o.Delta }},
+	{"Gamma", "Gamma", func(o bsm.Outputs) float64 { return o.Gamma }},
+	{"VegaPerVol", "Vega (per 1.00 vol)", func(o bsm.Outputs) float64 { return o.VegaPerVol }},
+	{"VegaPerVolPt", "Vega (per vol-pt)", func(o bsm.Outputs) float64 { return o.VegaPerVolPt }},
+	{"ThetaPerYear", "Theta (per year)", func(o bsm.Outputs) float64 { return o.ThetaPerYear }},
+	{"ThetaPerDay", "Theta (per day)", func(o bsm.Outputs) float64 { return o.ThetaPerDay }},
+	{"RhoPer1", "Rho (per 1.00)", func(o bsm.Outputs) float64 { return o.RhoPer1 }},
+	{"RhoPerBp", "Rho (per bp)", func(o bsm.Outputs) float64 { return o.RhoPerBp }},
+	{"PhiPer1", "Phi (per 1.00)", func(o bsm.Outputs) float64 { return o.PhiPer1 }},
+	{"PhiPerBp", "Phi (per bp)", func(o bsm.Outputs) float64 { return o.PhiPerBp }},
+	{"DualDelta", "Dual delta", func(o bsm.Outputs) float64 { return o.DualDelta }},
+	{"Lambda", "Lambda", func(o bsm.Outputs) float64 { return o.Lambda }},
+	{"Vanna", "Vanna", func(o bsm.Outputs) float64 { return o.Vanna }},
+	{"Volga", "Volga", func(o bsm.Outputs) float64 { return o.Volga }},
+	{"CharmPerDay", "Charm (per day)", func(o bsm.Outputs) float64 { return o.CharmPerDay }},
+	{"Speed", "Speed", func(o bsm.Outputs) float64 { return o.Speed }},
+	{"Zomma", "Zomma", func(o bsm.Outputs) float64 { return o.Zomma }},
+	{"ColorPerDay", "Color (per day)", func(o bsm.Outputs) float64 { return o.ColorPerDay }},
+	{"ProbITM", "P(ITM)", func(o bsm.Outputs) float64 { return o.ProbITM }},
+	{"ProbITMStock", "P(ITM, stock measure)", func(o bsm.Outputs) float64 { return o.ProbITMStock }},
+	{"ExpectedPayoff", "Expected payoff", func(o bsm.Outputs) float64 { return o.ExpectedPayoff }},
+	{"BreakEven", "Break-even spot", func(o bsm.Outputs) float64 { return o.BreakEven }},
+	{"LogMoneyness", "Log-moneyness ln(K/F)", func(o bsm.Outputs) float64 { return o.LogMoneyness }},
+	{"StdMoneyness", "Standardized moneyness", func(o bsm.Outputs) float64 { return o.StdMoneyness }},
+}
+
+// priceResult is one line of JSON output.
+type priceResult struct {
+	Index  int        `json:"index"`
+	Inputs bsm.Inputs `json:"inputs"`
+	Greeks any        `json:"greeks,omitempty"` // *bsm.Outputs, or bsm.RoundedOutputs with -round
+	Error  string     `json:"error,omitempty"`
+}
+
+// runPriceCommand prices the contract given by flags, or with -json the
+// contracts read from -in (stdin by default), and writes them to w in the
+// chosen format. It fails if any contract could not be priced, after
+// writing the rest.
+func runPriceCommand(args []string, stdin io.Reader, w io.Writer) error {
+	fs := flag.NewFlagSet("bsm", flag.ContinueOnError)
+	// Defaults are the example from the guide.
+	in := bsm.Inputs{OptType: bsm.Call}
+	fs.Float64Var(&in.S0, "s0", 100, "spot price")
+	fs.Float64Var(&in.K, "k", 100, "strike")
+	fs.Float64Var(&in.T, "t", 0.5, "time to expiry in years")
+	fs.Float64Var(&in.Sigma, "sigma", 0.20, "volatility (decimal)")
+	fs.Float64Var(&in.R, "r", 0.03, "risk-free rate (cont. comp.)")
+	fs.Float64Var(&in.Q, "q", 0.01, "dividend yield (cont. comp.); 0 unless set with -model black76 or bachelier")
+	fs.Func("type", "call or put (default call)", func(s string) (err error) {
+		in.OptType, err = bsm.ParseOptionType(s)
+		return err
+	})
+	exercise := fs.String("exercise", "", "european or american")
+	model := fs.String("model", "", "bsm, black76 or bachelier")
+	thetaBasis := fs.Int("theta-basis", 365, "days per year for theta and charm (365 calendar, 252 trading)")
+	batch := fs.Bool("json", false, "read contracts as a JSON array or stream of objects instead of flags")
+	file := fs.String("in", "-", "with -json, the file to read, - for stdin")
+	format := fs.String("format", "", "table, csv or json (json lines); table by default, json with -json")
+	round := fs.String("round", "", "decimal places for csv and json, e.g. 8 or 8,Price=4,Delta=6")
+	halfEven := fs.Bool("half-even", false, "with -round, round ties to even (banker's rounding)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	policy, err := parseRounding(*round, *halfEven)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments %q; usage: bsm [flags] or bsm -json [-in file]", fs.Args())
+	}
+	in.Exercise = bsm.ExerciseStyle(*exercise)
+	in.Model = bsm.PricingModel(*model)
+	if in.Model == bsm.Black76Model || in.Model == bsm.BachelierModel {
+		// The guide's yield is for its BSM example; these models take none.
+		setQ := false
+		fs.Visit(func(f *flag.Flag) { setQ = setQ || f.Name == "q" })
+		if !setQ {
+			in.Q = 0
+		}
+	}
+
+	contracts := []bsm.Inputs{in}
+	if *batch {
+		r := stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		if contracts, err = readContracts(r); err != nil {
+			return err
+		}
+		if *format == "" {
+			*format = "json"
+		}
+	}
+
+	opts := []bsm.Option{bsm.WithThetaBasis(*thetaBasis)}
+	outs, err := bsm.PriceBatch(contracts, opts...)
+	errs := make([]error, len(contracts))
+	if err != nil {
+		// Price the failures again one by one to attribute the errors.
+		for i := range contracts {
+			if _, e := bsm.Price(contracts[i], opts...); e != nil {
+				errs[i] = e
+			}
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	switch *format {
+	case "", "table":
+		err = writeTable(bw, contracts, outs, errs)
+	case "csv":
+		err = writeCSV(bw, contracts, outs, errs, policy)
+	case "json":
+		err = writeJSONLines(bw, contracts, outs, errs, policy)
+	default:
+		return fmt.Errorf("unknown format %q (table, csv or json)", *format)
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, e := range errs {
+		if e != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d contracts failed", failed, len(contracts))
+	}
+	return nil
+}
+
+// readContracts reads a JSON array of contracts or a stream of contract
+// objects, e.g. JSON lines.
+func readContracts(r io.Reader) ([]bsm.Inputs, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, errors.New("no contracts in input")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var contracts []bsm.Inputs
+	if data[0] == '[' {
+		if err := dec.Decode(&contracts); err != nil {
+			return nil, fmt.Errorf("reading contracts: %w", err)
+		}
+		return contracts, nil
+	}
+	for dec.More() {
+		var c bsm.Inputs
+		if err := dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("reading contract %d: %w", len(contracts), err)
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts, nil
+}
+
+// writeTable lists a single contract's outputs one per line, as the
+// original example did, and several contracts as aligned columns.
+func writeTable(w io.Writer, contracts []bsm.Inputs, outs []bsm.Outputs, errs []error) error {
+	if len(contracts) == 1 {
+		if errs[0] != nil {
+			_, err := fmt.Fprintf(w, "Error: %v\n", errs[0])
+			return err
+		}
+		for _, c := range greekColumns {
+			if _, err := fmt.Fprintf(w, "%s: %.6f\n", c.label, c.get(outs[0])); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "#\tType\tS0\tK\tT\tSigma\t")
+	for _, c := range greekColumns {
+		fmt.Fprintf(tw, "%s\t", c.name)
+	}
+	fmt.Fprintln(tw)
+	for i, in := range contracts {
+		fmt.Fprintf(tw, "%d\t%s\t%g\t%g\t%g\t%g\t", i, in.OptType, in.S0, in.K, in.T, in.Sigma)
+		if errs[i] != nil {
+			fmt.Fprintf(tw, "error: %v\t\n", errs[i])
+			continue
+		}
+		for _, c := range greekColumns {
+			fmt.Fprintf(tw, "%.6f\t", c.get(outs[i]))
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, contracts []bsm.Inputs, outs []bsm.Outputs, errs []error, policy *bsm.RoundingPolicy) error {
+	cw := csv.NewWriter(w)
+	header := []string{"index", "S0", "K", "T", "Sigma", "R", "Q", "OptType", "Exercise", "Model"}
+	for _, c := range greekColumns {
+		header = append(header, c.name)
+	}
+	cw.Write(append(header, "error"))
+	f := func(v float64) string { return strconv.FormatFloat(v, 'g', -1, 64) }
+	for i, in := range contracts {
+		row := []string{strconv.Itoa(i), f(in.S0), f(in.K), f(in.T), f(in.Sigma), f(in.R), f(in.Q),
+			in.OptType.String(), string(in.Exercise), string(in.Model)}
+		for _, c := range greekColumns {
+			if errs[i] != nil {
+				row = append(row, "")
+			} else {
+				row = append(row, formatGreek(policy, c.name, c.get(outs[i])))
+			}
+		}
+		msg := ""
+		if errs[i] != nil {
+			msg = errs[i].Error()
+		}
+		cw.Write(append(row, msg))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeJSONLines(w io.Writer, contracts []bsm.Inputs, outs []bsm.Outputs, errs []error, policy *bsm.RoundingPolicy) error {
+	enc := json.NewEncoder(w)
+	for i, in := range contracts {
+		res := priceResult{Index: i, Inputs: in}
+		if errs[i] != nil {
+			res.Error = errs[i].Error()
+		} else if policy != nil {
+			res.Greeks = bsm.RoundedOutputs{Outputs: outs[i], Policy: *policy}
+		} else {
+			res.Greeks = &outs[i]
+		}
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseRounding reads a -round spec: default decimal places, Field=places
+// overrides, or both, comma separated. An empty spec means no rounding.
+func parseRounding(spec string, halfEven bool) (*bsm.RoundingPolicy, error) {
+	if spec == "" {
+		if halfEven {
+			return nil, errors.New("-half-even needs -round")
+		}
+		return nil, nil
+	}
+	p := &bsm.RoundingPolicy{Fields: map[string]int{}}
+	if halfEven {
+		p.Mode = bsm.RoundHalfEven
+	}
+	for _, part := range strings.Split(spec, ",") {
+		field, places, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			field, places = "", field
+		}
+		n, err := strconv.Atoi(places)
+		if err != nil {
+			return nil, fmt.Errorf("-round entry %q is not places or Field=places", part)
+		}
+		if field == "" {
+			p.Places = n
+		} else {
+			p.Fields[field] = n
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("-round: %w", err)
+	}
+	return p, nil
+}
+
+// formatGreek prints a Greek for CSV, rounded under policy if there is one.
+func formatGreek(policy *bsm.RoundingPolicy, name string, v float64) string {
+	if policy == nil {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return policy.Format(name, v)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/rpc"
+)
+
+// runServeCommand implements "bsm serve": the gRPC Pricer service from
+// proto/bsm/v1/bsm.proto over cleartext HTTP/2, and on the same port the
+// streaming repricer (see rpc.Streamer), Prometheus metrics on /metrics
+// and a liveness check on /healthz. Requests are logged as JSON on stderr.
+func runServeCommand(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:50051", "listen address")
+	throttle := fs.Duration("throttle", 100*time.Millisecond, "default minimum interval between streamed updates per contract")
+	verbose := fs.Bool("v", false, "log at debug level, including the library's operations")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return errors.New("usage: bsm serve [-addr host:port] [-throttle interval] [-v]")
+	}
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	log := slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	bsm.SetLogger(log)
+	metrics := rpc.NewMetrics()
+	bsm.SetSpanHook(metrics)
+
+	mux := http.NewServeMux()
+	streamer := rpc.NewStreamer(*throttle)
+	mux.Handle("/v1/streams", streamer)
+	mux.Handle("/v1/streams/", streamer)
+	mux.Handle("GET /metrics", metrics)
+	mux.HandleFunc("GET /healthz", rpc.Healthz)
+
+	log.Info("serving", slog.String("addr", *addr), slog.String("services", "bsm.v1.Pricer, /v1/streams, /metrics, /healthz"))
+	srv := rpc.NewServer(*addr)
+	srv.Handler = rpc.Instrument(rpc.Mux(mux), metrics, log)
+	srv.Protocols.SetHTTP1(true) // For browsers, curl and scrapers on the HTTP endpoints
+	return srv.ListenAndServe()
+}
@@ -0,0 +1,196 @@
+//go:build cgo
+
+// Command libbsm builds the pricer as a C shared library for FFI callers
+// (Python ctypes, C# P/Invoke, Excel add-ins):
+//
+//	go build -buildmode=c-shared -o libbsm.so ./cmd/libbsm
+//
+// which also writes libbsm.h. The ABI passes plain structs of doubles and
+// ints, appends new fields only at the end and bumps BSM_ABI_VERSION when
+// it does, so callers can check bsm_abi_version at load time.
+package main
+
+/*
+#include <stdlib.h>
+#include <string.h>
+
+#define BSM_ABI_VERSION 1
+
+enum { BSM_CALL = 0, BSM_PUT = 1 };
+enum { BSM_EUROPEAN = 0, BSM_AMERICAN = 1 };
+enum { BSM_MODEL_BSM = 0, BSM_MODEL_BLACK76 = 1, BSM_MODEL_BACHELIER = 2 };
+
+typedef struct {
+	double s0, k, t, sigma, r, q;
+	int opt_type;  // BSM_CALL or BSM_PUT
+	int exercise;  // BSM_EUROPEAN or BSM_AMERICAN
+	int model;     // BSM_MODEL_*
+} bsm_inputs;
+
+typedef struct {
+	double price, delta, gamma;
+	double vega_per_vol, vega_per_volpt;
+	double theta_per_year, theta_per_day;
+	double rho_per_1, rho_per_bp;
+	double phi_per_1, phi_per_bp;
+	double dual_delta;
+	double vanna, volga, charm_per_year, charm_per_day, speed, zomma, color_per_year, color_per_day;
+	double lambda, prob_itm, prob_itm_stock, expected_payoff, break_even, log_moneyness, std_moneyness;
+} bsm_outputs;
+
+static void bsm_set_error(char *buf, int len, const char *msg) {
+	if (buf == NULL || len <= 0) {
+		return;
+	}
+	strncpy(buf, msg, len - 1);
+	buf[len - 1] = '\0';
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+func main() {} // Required for -buildmode=c-shared
+
+//export bsm_abi_version
+func bsm_abi_version() C.int { return C.BSM_ABI_VERSION }
+
+// bsm_price prices one contract into *out. It returns 0, or -1 with the
+// reason in err (truncated to errlen bytes, NUL-terminated) if the
+// contract is invalid.
+//
+//export bsm_price
+func bsm_price(in *C.bsm_inputs, thetaBasis C.int, out *C.bsm_outputs, err *C.char, errlen C.int) C.int {
+	if in == nil || out == nil {
+		setError(err, errlen, "bsm_price: null inputs or outputs")
+		return -1
+	}
+	inputs, e := fromC(in)
+	if e == nil {
+		var o bsm.Outputs
+		if o, e = bsm.Price(inputs, bsm.WithThetaBasis(int(thetaBasis))); e == nil {
+			toC(o, out)
+			return 0
+		}
+	}
+	setError(err, errlen, e.Error())
+	return -1
+}
+
+// bsm_price_batch prices n contracts in parallel. It returns 0, or the
+// 1-based index of the first contract that failed, with its reason in
+// err; the other contracts are priced either way, and failed ones get
+// zero outputs.
+//
+//export bsm_price_batch
+func bsm_price_batch(in *C.bsm_inputs, n C.int, thetaBasis C.int, out *C.bsm_outputs, err *C.char, errlen C.int) C.int {
+	if n <= 0 {
+		return 0
+	}
+	if in == nil || out == nil {
+		setError(err, errlen, "bsm_price_batch: null inputs or outputs")
+		return -1
+	}
+	ins := unsafe.Slice(in, int(n))
+	outs := unsafe.Slice(out, int(n))
+	contracts := make([]bsm.Inputs, n)
+	errs := make([]error, n)
+	for i := range ins {
+		contracts[i], errs[i] = fromC(&ins[i])
+	}
+	results := make([]bsm.Outputs, n)
+	opts := []bsm.Option{bsm.WithThetaBasis(int(thetaBasis))}
+	if e := bsm.PriceBatchInto(results, contracts, opts...); e != nil {
+		// Price one by one to attribute the errors and keep the rest.
+		for i, c := range contracts {
+			if errs[i] == nil {
+				results[i], errs[i] = bsm.Price(c, opts...)
+			}
+		}
+	}
+	failed := C.int(0)
+	for i := range results {
+		toC(results[i], &outs[i])
+		if errs[i] != nil && failed == 0 {
+			failed = C.int(i + 1)
+			setError(err, errlen, fmt.Sprintf("contract %d: %v", i+1, errs[i]))
+		}
+	}
+	return failed
+}
+
+func fromC(in *C.bsm_inputs) (bsm.Inputs, error) {
+	x := bsm.Inputs{S0: float64(in.s0), K: float64(in.k), T: float64(in.t),
+		Sigma: float64(in.sigma), R: float64(in.r), Q: float64(in.q)}
+	switch in.opt_type {
+	case C.BSM_CALL:
+		x.OptType = bsm.Call
+	case C.BSM_PUT:
+		x.OptType = bsm.Put
+	default:
+		return bsm.Inputs{}, fmt.Errorf("%w: opt_type %d", bsm.ErrUnknownOptionType, in.opt_type)
+	}
+	switch in.exercise {
+	case C.BSM_EUROPEAN:
+		x.Exercise = bsm.European
+	case C.BSM_AMERICAN:
+		x.Exercise = bsm.American
+	default:
+		return bsm.Inputs{}, fmt.Errorf("unknown exercise %d", in.exercise)
+	}
+	switch in.model {
+	case C.BSM_MODEL_BSM:
+		x.Model = bsm.BSMModel
+	case C.BSM_MODEL_BLACK76:
+		x.Model = bsm.Black76Model
+	case C.BSM_MODEL_BACHELIER:
+		x.Model = bsm.BachelierModel
+	default:
+		return bsm.Inputs{}, fmt.Errorf("unknown model %d", in.model)
+	}
+	return x, nil
+}
+
+// toC copies field by field, so the C layout does not follow Outputs'.
+func toC(o bsm.Outputs, out *C.bsm_outputs) {
+	*out = C.bsm_outputs{
+		price:           C.double(o.Price),
+		delta:           C.double(o.Delta),
+		gamma:           C.double(o.Gamma),
+		vega_per_vol:    C.double(o.VegaPerVol),
+		vega_per_volpt:  C.double(o.VegaPerVolPt),
+		theta_per_year:  C.double(o.ThetaPerYear),
+		theta_per_day:   C.double(o.ThetaPerDay),
+		rho_per_1:       C.double(o.RhoPer1),
+		rho_per_bp:      C.double(o.RhoPerBp),
+		phi_per_1:       C.double(o.PhiPer1),
+		phi_per_bp:      C.double(o.PhiPerBp),
+		dual_delta:      C.double(o.DualDelta),
+		vanna:           C.double(o.Vanna),
+		volga:           C.double(o.Volga),
+		charm_per_year:  C.double(o.CharmPerYear),
+		charm_per_day:   C.double(o.CharmPerDay),
+		speed:           C.double(o.Speed),
+		zomma:           C.double(o.Zomma),
+		color_per_year:  C.double(o.ColorPerYear),
+		color_per_day:   C.double(o.ColorPerDay),
+		lambda:          C.double(o.Lambda),
+		prob_itm:        C.double(o.ProbITM),
+		prob_itm_stock:  C.double(o.ProbITMStock),
+		expected_payoff: C.double(o.ExpectedPayoff),
+		break_even:      C.double(o.BreakEven),
+		log_moneyness:   C.double(o.LogMoneyness),
+		std_moneyness:   C.double(o.StdMoneyness),
+	}
+}
+
+func setError(buf *C.char, n C.int, msg string) {
+	cs := C.CString(msg)
+	defer C.free(unsafe.Pointer(cs))
+	C.bsm_set_error(buf, n, cs)
+}
@@ -0,0 +1,122 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ComboLeg is one option leg of a multi-leg structure. Quantity is the signed
+// ratio of the leg: +1 long one, -2 short two, and so on.
+type ComboLeg struct {
+	K        float64    // Strike
+	T        float64    // Time to expiry (years)
+	Sigma    float64    // Volatility for this strike/expiry
+	OptType  OptionType // Call or Put
+	Quantity float64
+}
+
+// ComboRequest prices several legs on the same underlier in one call. Spot,
+// rate and dividend yield are shared by every leg.
+type ComboRequest struct {
+	S0         float64 // Spot price
+	R          float64 // Risk-free rate (cont. comp.)
+	Q          float64 // Dividend yield (cont. comp.)
+	Legs       []ComboLeg
+	ThetaBasis int // Days per year for theta per day; 365 if zero
+}
+
+// ComboResult holds per-leg outputs (for one unit of each leg, unsigned) and
+// the net position outputs weighted by each leg's quantity.
+type ComboResult struct {
+	Legs []Outputs
+	Net  Outputs
+}
+
+// PriceCombo prices every leg of req with Price and aggregates them into
+// net outputs. Each leg is validated; the first bad leg fails the combo.
+func PriceCombo(req ComboRequest) (ComboResult, error) {
+	if len(req.Legs) == 0 {
+		return ComboResult{}, errors.New("combo has no legs")
+	}
+	o := priceOptions{thetaBasis: req.ThetaBasis}
+	if o.thetaBasis == 0 {
+		o.thetaBasis = 365
+	}
+	if o.thetaBasis < 0 {
+		return ComboResult{}, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+
+	res := ComboResult{Legs: make([]Outputs, len(req.Legs))}
+	for i, leg := range req.Legs {
+		if leg.Quantity == 0 {
+			return ComboResult{}, fmt.Errorf("combo leg %d has zero quantity", i)
+		}
+		out, err := priceWith(Inputs{
+			S0:      req.S0,
+			K:       leg.K,
+			T:       leg.T,
+			Sigma:   leg.Sigma,
+			R:       req.R,
+			Q:       req.Q,
+			OptType: leg.OptType,
+		}, o)
+		if err != nil {
+			return ComboResult{}, fmt.Errorf("combo leg %d: %w", i, err)
+		}
+		res.Legs[i] = out
+		res.Net = res.Net.add(out.scale(leg.Quantity))
+	}
+	return res, nil
+}
+
+// scale multiplies every output by f, e.g. to apply a position quantity.
+func (o Outputs) scale(f float64) Outputs {
+	return Outputs{
+		Price:        o.Price * f,
+		Delta:        o.Delta * f,
+		Gamma:        o.Gamma * f,
+		VegaPerVol:   o.VegaPerVol * f,
+		VegaPerVolPt: o.VegaPerVolPt * f,
+		ThetaPerYear: o.ThetaPerYear * f,
+		ThetaPerDay:  o.ThetaPerDay * f,
+		RhoPer1:      o.RhoPer1 * f,
+		RhoPerBp:     o.RhoPerBp * f,
+		PhiPer1:      o.PhiPer1 * f,
+		PhiPerBp:     o.PhiPerBp * f,
+		DualDelta:    o.DualDelta * f,
+		Vanna:        o.Vanna * f,
+		Volga:        o.Volga * f,
+		CharmPerYear: o.CharmPerYear * f,
+		CharmPerDay:  o.CharmPerDay * f,
+		Speed:        o.Speed * f,
+		Zomma:        o.Zomma * f,
+		ColorPerYear: o.ColorPerYear * f,
+		ColorPerDay:  o.ColorPerDay * f,
+	}
+}
+
+// add sums two sets of outputs field by field.
+func (o Outputs) add(p Outputs) Outputs {
+	return Outputs{
+		Price:        o.Price + p.Price,
+		Delta:        o.Delta + p.Delta,
+		Gamma:        o.Gamma + p.Gamma,
+		VegaPerVol:   o.VegaPerVol + p.VegaPerVol,
+		VegaPerVolPt: o.VegaPerVolPt + p.VegaPerVolPt,
+		ThetaPerYear: o.ThetaPerYear + p.ThetaPerYear,
+		ThetaPerDay:  o.ThetaPerDay + p.ThetaPerDay,
+		RhoPer1:      o.RhoPer1 + p.RhoPer1,
+		RhoPerBp:     o.RhoPerBp + p.RhoPerBp,
+		PhiPer1:      o.PhiPer1 + p.PhiPer1,
+		PhiPerBp:     o.PhiPerBp + p.PhiPerBp,
+		DualDelta:    o.DualDelta + p.DualDelta,
+		Vanna:        o.Vanna + p.Vanna,
+		Volga:        o.Volga + p.Volga,
+		CharmPerYear: o.CharmPerYear + p.CharmPerYear,
+		CharmPerDay:  o.CharmPerDay + p.CharmPerDay,
+		Speed:        o.Speed + p.Speed,
+		Zomma:        o.Zomma + p.Zomma,
+		ColorPerYear: o.ColorPerYear + p.ColorPerYear,
+		ColorPerDay:  o.ColorPerDay + p.ColorPerDay,
+	}
+}
@@ -0,0 +1,139 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ForwardPoint is a market forward (futures) price for delivery at T years.
+type ForwardPoint struct {
+	T       float64
+	Forward float64
+}
+
+// CommodityCurve describes a commodity forward curve. When Points are given
+// they are the curve (log-linear between points, flat past the last one);
+// otherwise forwards come from the cost-of-carry model
+//
+//	F(T) = Spot * exp((R + StorageCost - ConvenienceYield) T) * season(T)
+//
+// where season(T) is the factor of the calendar month T falls in.
+type CommodityCurve struct {
+	Spot             float64
+	R                float64 // Risk-free rate (cont. comp.)
+	StorageCost      float64 // Storage cost as a yield (cont. comp.)
+	ConvenienceYield float64 // Convenience yield (cont. comp.)
+	Points           []ForwardPoint
+
+	// SeasonalFactors are multiplicative factors by delivery month (index 0
+	// is January); zero entries are treated as 1. ValuationMonth anchors T=0.
+	SeasonalFactors [12]float64
+	ValuationMonth  time.Month
+}
+
+// Forward returns the forward price for delivery at T.
+func (c CommodityCurve) Forward(T float64) (float64, error) {
+	if len(c.Points) > 0 {
+		return c.interpolate(T)
+	}
+	if c.Spot <= 0 {
+		return 0, errors.New("commodity curve needs a spot price or forward points")
+	}
+	carry := c.R + c.StorageCost - c.ConvenienceYield
+	return c.Spot * math.Exp(carry*T) * c.season(T), nil
+}
+
+func (c CommodityCurve) season(T float64) float64 {
+	start := int(c.ValuationMonth) - 1
+	if start < 0 {
+		start = 0
+	}
+	month := (start + int(math.Floor(T*12))) % 12
+	if f := c.SeasonalFactors[month]; f > 0 {
+		return f
+	}
+	return 1
+}
+
+func (c CommodityCurve) interpolate(T float64) (float64, error) {
+	pts := append([]ForwardPoint(nil), c.Points...)
+	sort.Slice(pts, func(i, j int) bool { return pts[i].T < pts[j].T })
+	for _, p := range pts {
+		if p.Forward <= 0 {
+			return 0, fmt.Errorf("non-positive forward %g at T=%g", p.Forward, p.T)
+		}
+	}
+	if c.Spot > 0 && pts[0].T > 0 {
+		pts = append([]ForwardPoint{{T: 0, Forward: c.Spot}}, pts...)
+	}
+	if T <= pts[0].T {
+		return pts[0].Forward, nil
+	}
+	for i := 1; i < len(pts); i++ {
+		if T <= pts[i].T {
+			a, b := pts[i-1], pts[i]
+			w := (T - a.T) / (b.T - a.T)
+			return math.Exp((1-w)*math.Log(a.Forward) + w*math.Log(b.Forward)), nil
+		}
+	}
+	return pts[len(pts)-1].Forward, nil
+}
+
+// ImpliedConvenienceYield backs out the convenience yield that, with the
+// curve's R and StorageCost, reproduces its forward for delivery at T. It
+// is gross of storage, like the ConvenienceYield field; the net yield is
+// this less StorageCost.
+func (c CommodityCurve) ImpliedConvenienceYield(T float64) (float64, error) {
+	if c.Spot <= 0 || T <= 0 {
+		return 0, errors.New("implied convenience yield needs a spot price and T > 0")
+	}
+	F, err := c.Forward(T)
+	if err != nil {
+		return 0, err
+	}
+	return c.R + c.StorageCost - math.Log(F/c.Spot)/T, nil
+}
+
+// CommodityOptionInputs describes an option on the forward for delivery at
+// Delivery, expiring at T (T <= Delivery).
+type CommodityOptionInputs struct {
+	Curve    CommodityCurve
+	Delivery float64    // Delivery time of the underlying forward (years)
+	K        float64    // Strike
+	T        float64    // Option expiry (years)
+	Sigma    float64    // Vol of the delivery forward
+	OptType  OptionType // Call or Put
+}
+
+// CommodityOutputs are Black-76 outputs against the curve point, so Delta is
+// per unit of the delivery forward.
+type CommodityOutputs struct {
+	Outputs
+	Forward          float64
+	ConvenienceYield float64 // Implied net convenience yield to delivery (0 without spot)
+}
+
+// PriceCommodityOption prices an option on a forward curve point.
+func PriceCommodityOption(in CommodityOptionInputs, thetaBasis int) (CommodityOutputs, error) {
+	if in.Delivery < in.T {
+		return CommodityOutputs{}, fmt.Errorf("delivery %g before option expiry %g", in.Delivery, in.T)
+	}
+	F, err := in.Curve.Forward(in.Delivery)
+	if err != nil {
+		return CommodityOutputs{}, err
+	}
+	if err := (Inputs{S0: F, K: in.K, T: in.T, Sigma: in.Sigma, R: in.Curve.R, OptType: in.OptType, Model: Black76Model}).Validate(); err != nil {
+		return CommodityOutputs{}, err
+	}
+	out := CommodityOutputs{
+		Outputs: priceAndGreeksBlack76(F, in.K, in.T, in.Sigma, in.Curve.R, in.OptType, thetaBasis),
+		Forward: F,
+	}
+	if in.Curve.Spot > 0 && in.Delivery > 0 {
+		out.ConvenienceYield, _ = in.Curve.ImpliedConvenienceYield(in.Delivery)
+	}
+	return out, nil
+}
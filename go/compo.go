@@ -0,0 +1,154 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+)
+
+// CompoInputs describes a composite option: a foreign asset whose strike and
+// payoff are in the domestic currency at the prevailing FX rate, paying
+// max(S_T*X_T - K, 0) domestic for a call.
+type CompoInputs struct {
+	S0      float64    // Foreign asset price, in foreign currency
+	FX0     float64    // FX rate, domestic per unit of foreign
+	K       float64    // Strike, in domestic currency
+	T       float64    // Time to expiry (years)
+	SigmaS  float64    // Asset vol
+	SigmaFX float64    // FX vol
+	Rho     float64    // Correlation between asset and FX (domestic per foreign) returns
+	Rd      float64    // Domestic risk-free rate (cont. comp.)
+	Q       float64    // Asset dividend yield (cont. comp.)
+	OptType OptionType // Call or Put
+}
+
+// CompoOutputs are BSM outputs on the domestic-currency asset S*X (so Delta
+// is per unit of S*X) plus sensitivities to each market input.
+type CompoOutputs struct {
+	Outputs
+	CompoVol   float64 // Vol of S*X
+	DeltaAsset float64 // dPrice/dS0
+	DeltaFX    float64 // dPrice/dFX0
+	VegaAsset  float64 // dPrice/dSigmaS, per 1.00
+	VegaFX     float64 // dPrice/dSigmaFX, per 1.00
+	CorrSens   float64 // dPrice/dRho, per 1.00 of correlation
+}
+
+// CompoVol returns the vol of the product of asset and FX.
+func CompoVol(sigmaS, sigmaFX, rho float64) float64 {
+	return math.Sqrt(math.Max(sigmaS*sigmaS+sigmaFX*sigmaFX+2*rho*sigmaS*sigmaFX, 0))
+}
+
+// PriceCompo prices a compo option. In domestic terms S*X is a traded asset
+// growing at Rd - Q, so the option is BSM on it with the compo vol; the
+// foreign rate drops out.
+func PriceCompo(in CompoInputs, thetaBasis int) (CompoOutputs, error) {
+	if in.S0 <= 0 || in.FX0 <= 0 {
+		return CompoOutputs{}, errors.New("compo option needs positive asset price and FX rate")
+	}
+	if math.Abs(in.Rho) > 1 {
+		return CompoOutputs{}, errors.New("correlation must be in [-1, 1]")
+	}
+	vol := CompoVol(in.SigmaS, in.SigmaFX, in.Rho)
+	x := Inputs{
+		S0:      in.S0 * in.FX0,
+		K:       in.K,
+		T:       in.T,
+		Sigma:   vol,
+		R:       in.Rd,
+		Q:       in.Q,
+		OptType: in.OptType,
+	}
+	if err := x.Validate(); err != nil {
+		return CompoOutputs{}, err
+	}
+	out := priceAndGreeks(x, thetaBasis)
+
+	res := CompoOutputs{
+		Outputs:    out,
+		CompoVol:   vol,
+		DeltaAsset: out.Delta * in.FX0,
+		DeltaFX:    out.Delta * in.S0,
+	}
+	if vol > 0 {
+		res.VegaAsset = out.VegaPerVol * (in.SigmaS + in.Rho*in.SigmaFX) / vol
+		res.VegaFX = out.VegaPerVol * (in.SigmaFX + in.Rho*in.SigmaS) / vol
+		res.CorrSens = out.VegaPerVol * in.SigmaS * in.SigmaFX / vol
+	}
+	return res, nil
+}
+
+// QuantoInputs describes a quanto option: a foreign asset's payoff in
+// foreign units, max(S_T - K, 0) for a call, paid in domestic currency at
+// the fixed rate FixedFX.
+type QuantoInputs struct {
+	S0      float64    // Foreign asset price, in foreign currency
+	K       float64    // Strike, in foreign currency
+	T       float64    // Time to expiry (years)
+	SigmaS  float64    // Asset vol
+	SigmaFX float64    // FX vol
+	Rho     float64    // Correlation between asset and FX (domestic per foreign) returns
+	Rd      float64    // Domestic risk-free rate (cont. comp.)
+	Rf      float64    // Foreign risk-free rate (cont. comp.)
+	Q       float64    // Asset dividend yield (cont. comp.)
+	FixedFX float64    // Domestic paid per unit of payoff (1 if zero)
+	OptType OptionType // Call or Put
+}
+
+// QuantoOutputs are the quanto price and Greeks in domestic currency, with
+// Delta per unit of S0. VegaPerVol is to SigmaS including its effect on the
+// drift, RhoPer1 to Rd and PhiPer1 to Q; Vanna and Volga hold the drift
+// fixed.
+type QuantoOutputs struct {
+	Outputs
+	Drift    float64 // Rf - Q - Rho SigmaS SigmaFX: the asset's drift under the domestic measure
+	RhoF     float64 // dPrice/dRf, per 1.00
+	VegaFX   float64 // dPrice/dSigmaFX, per 1.00
+	CorrSens float64 // dPrice/dRho, per 1.00 of correlation
+}
+
+// PriceQuanto prices a quanto option. Under the domestic measure the asset
+// drifts at Rf - Q - Rho SigmaS SigmaFX, so the option is BSM discounted at
+// Rd with the dividend yield Rd - Drift.
+func PriceQuanto(in QuantoInputs, thetaBasis int) (QuantoOutputs, error) {
+	if in.S0 <= 0 || in.K <= 0 {
+		return QuantoOutputs{}, errors.New("quanto option needs positive asset price and strike")
+	}
+	if in.SigmaS < 0 || in.SigmaFX < 0 {
+		return QuantoOutputs{}, errors.New("quanto vols must be non-negative")
+	}
+	if math.Abs(in.Rho) > 1 {
+		return QuantoOutputs{}, errors.New("correlation must be in [-1, 1]")
+	}
+	fx := in.FixedFX
+	if fx == 0 {
+		fx = 1
+	}
+	drift := in.Rf - in.Q - in.Rho*in.SigmaS*in.SigmaFX
+	x := Inputs{
+		S0:      in.S0,
+		K:       in.K,
+		T:       in.T,
+		Sigma:   in.SigmaS,
+		R:       in.Rd,
+		Q:       in.Rd - drift,
+		OptType: in.OptType,
+	}
+	if err := x.Validate(); err != nil {
+		return QuantoOutputs{}, err
+	}
+	out := priceAndGreeks(x, thetaBasis)
+
+	// The effective yield moves one for one with Rd and Q, against Rf and
+	// by Rho SigmaS SigmaFX with the vols and correlation.
+	phi := out.PhiPer1
+	out.RhoPer1 += phi
+	out.VegaPerVol += phi * in.Rho * in.SigmaFX
+	res := QuantoOutputs{
+		Outputs:  out.withUnits(thetaBasis).scale(fx),
+		Drift:    drift,
+		RhoF:     -phi * fx,
+		VegaFX:   phi * in.Rho * in.SigmaS * fx,
+		CorrSens: phi * in.SigmaS * in.SigmaFX * fx,
+	}
+	return res, nil
+}
@@ -0,0 +1,136 @@
+package bsm
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// CompoundInputs describe an option on a European BSM option: at Expiry the
+// holder may buy (CompoundType Call) or sell (Put) the underlying option,
+// given by the embedded Inputs' K, T and OptType, for Strike.
+type CompoundInputs struct {
+	Inputs
+	Strike       float64    // Paid or received for the underlying option
+	Expiry       float64    // Years, before the underlying's T
+	CompoundType OptionType // Call or put on the underlying option
+}
+
+// ChooserInputs describe a simple chooser: at ChooseAt the holder picks a
+// European call or put with the embedded Inputs' K and T. Inputs.OptType
+// is ignored.
+type ChooserInputs struct {
+	Inputs
+	ChooseAt float64 // Years, at or before T
+}
+
+// PriceCompound prices a compound option with Geske's formula in the
+// bivariate normal. Greeks are by finite differences on the spot and the
+// underlying's vol and rates; theta brings both expiries closer.
+func PriceCompound(in CompoundInputs, thetaBasis int) (Outputs, error) {
+	if err := checkTwoDateInputs(in.Inputs, in.Expiry, thetaBasis, "compound"); err != nil {
+		return Outputs{}, err
+	}
+	if !(in.Strike > 0) {
+		return Outputs{}, fmt.Errorf("compound strike must be positive, got %g", in.Strike)
+	}
+	if !in.CompoundType.Valid() {
+		return Outputs{}, fmt.Errorf("%w: compound type %v", ErrUnknownOptionType, in.CompoundType)
+	}
+	if in.OptType == Put {
+		// The underlying put is worth at most K e^(-r(T-T1)), as S -> 0.
+		if limit := in.K * math.Exp(-in.R*(in.T-in.Expiry)); in.Strike >= limit {
+			return Outputs{}, fmt.Errorf("compound strike %g is at or above the underlying put's maximum value %g", in.Strike, limit)
+		}
+	}
+	return bumpedGreeks(in.Inputs, thetaBasis, func(x Inputs) float64 {
+		return compoundPrice(x, in.Strike, math.Max(in.Expiry-(in.T-x.T), 1e-12), in.CompoundType)
+	}, 1e-3*in.S0), nil
+}
+
+// PriceChooser prices a simple chooser with Rubinstein's formula: a call
+// to T plus a put to ChooseAt struck at K e^(-(r-q)(T-ChooseAt)). Greeks
+// are by finite differences; theta brings both dates closer.
+func PriceChooser(in ChooserInputs, thetaBasis int) (Outputs, error) {
+	probe := in.Inputs
+	probe.OptType = Call
+	if err := checkTwoDateInputs(probe, in.ChooseAt, thetaBasis, "chooser"); err != nil {
+		return Outputs{}, err
+	}
+	return bumpedGreeks(probe, thetaBasis, func(x Inputs) float64 {
+		t1 := math.Max(in.ChooseAt-(in.T-x.T), 1e-12)
+		put := x
+		put.OptType, put.T = Put, t1
+		put.K = x.K * math.Exp(-(x.R-x.Q)*(x.T-t1))
+		// By parity at t1 the choice is worth a call plus e^(-q(T-t1)) of these puts.
+		return priceAndGreeks(x, 365).Price + math.Exp(-x.Q*(x.T-t1))*priceAndGreeks(put, 365).Price
+	}, 1e-3*in.S0), nil
+}
+
+// checkTwoDateInputs validates a European BSM contract with an earlier
+// decision date t1.
+func checkTwoDateInputs(in Inputs, t1 float64, thetaBasis int, what string) error {
+	if err := in.Validate(); err != nil {
+		return err
+	}
+	if err := noDividends(in, what+" pricing"); err != nil {
+		return err
+	}
+	if (in.Model != "" && in.Model != BSMModel) || in.Exercise == American {
+		return fmt.Errorf("%s options are priced on a European BSM option only", what)
+	}
+	if in.Sigma == 0 {
+		return fmt.Errorf("%s pricing needs a positive volatility", what)
+	}
+	if !(t1 > 0) || t1 > in.T {
+		return fmt.Errorf("%s decision date %g must be in (0, %g]", what, t1, in.T)
+	}
+	if thetaBasis <= 0 {
+		return fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	return nil
+}
+
+// compoundPrice is Geske's formula for a compound option of type ct,
+// struck at k1 and expiring at t1, on the option x.
+func compoundPrice(x Inputs, k1, t1 float64, ct OptionType) float64 {
+	S, K2, T2, v, r, q := x.S0, x.K, x.T, x.Sigma, x.R, x.Q
+	// sStar is the spot at t1 where the underlying option is worth k1.
+	under := func(s float64) float64 {
+		u := x
+		u.S0, u.T = s, T2-t1
+		return priceAndGreeks(u, 365).Price - k1
+	}
+	lo, hi := 1e-12*K2, K2
+	if x.OptType == Call {
+		for under(hi) < 0 {
+			hi *= 2
+		}
+	} else {
+		for under(hi) > 0 {
+			hi *= 2
+		}
+	}
+	root, _ := numerics.Brent(under, lo, hi, numerics.Options{}) // Bracketed above
+	sStar := root.X
+
+	sd1, sd2 := v*math.Sqrt(t1), v*math.Sqrt(T2)
+	y1 := (math.Log(S/sStar) + (r-q+0.5*v*v)*t1) / sd1
+	y2 := y1 - sd1
+	z1 := (math.Log(S/K2) + (r-q+0.5*v*v)*T2) / sd2
+	z2 := z1 - sd2
+	rho := math.Sqrt(t1 / T2)
+	fs, fk, f1 := S*math.Exp(-q*T2), K2*math.Exp(-r*T2), k1*math.Exp(-r*t1)
+	M := bivariateNormCDF
+	switch {
+	case ct == Call && x.OptType == Call:
+		return fs*M(z1, y1, rho) - fk*M(z2, y2, rho) - f1*normCDF(y2)
+	case ct == Put && x.OptType == Call:
+		return fk*M(z2, -y2, -rho) - fs*M(z1, -y1, -rho) + f1*normCDF(-y2)
+	case ct == Call && x.OptType == Put:
+		return fk*M(-z2, -y2, rho) - fs*M(-z1, -y1, rho) - f1*normCDF(-y2)
+	default:
+		return fs*M(-z1, y1, -rho) - fk*M(-z2, y2, -rho) + f1*normCDF(y2)
+	}
+}
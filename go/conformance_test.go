@@ -0,0 +1,55 @@
+package bsm
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// golden is conformance/golden.json, shared by every port in the repo.
+type golden struct {
+	ThetaBasis int `json:"thetaBasis"`
+	Tolerance  struct {
+		Abs float64 `json:"abs"`
+		Rel float64 `json:"rel"`
+	} `json:"tolerance"`
+	Cases []struct {
+		Name     string             `json:"name"`
+		Inputs   Inputs             `json:"inputs"`
+		Expected map[string]float64 `json:"expected"`
+	} `json:"cases"`
+}
+
+func TestConformance(t *testing.T) {
+	data, err := os.ReadFile("../conformance/golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g golden
+	if err := json.Unmarshal(data, &g); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Cases) == 0 {
+		t.Fatal("golden file has no cases")
+	}
+	for _, c := range g.Cases {
+		out, err := Price(c.Inputs, WithThetaBasis(g.ThetaBasis))
+		if err != nil {
+			t.Errorf("%s: %v", c.Name, err)
+			continue
+		}
+		v := reflect.ValueOf(out)
+		for field, want := range c.Expected {
+			f := v.FieldByName(field)
+			if !f.IsValid() {
+				t.Fatalf("%s: Outputs has no field %s", c.Name, field)
+			}
+			got := f.Float()
+			if math.Abs(got-want) > g.Tolerance.Abs+g.Tolerance.Rel*math.Abs(want) {
+				t.Errorf("%s: %s = %.17g, want %.17g", c.Name, field, got, want)
+			}
+		}
+	}
+}
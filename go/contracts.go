@@ -0,0 +1,146 @@
+package bsm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SettlementStyle is either CashSettled or PhysicallySettled
+type SettlementStyle string
+
+const (
+	CashSettled       SettlementStyle = "cash"
+	PhysicallySettled SettlementStyle = "physical"
+)
+
+// ExerciseStyle is either European or American
+type ExerciseStyle string
+
+const (
+	European ExerciseStyle = "european"
+	American ExerciseStyle = "american"
+)
+
+// ContractSpec describes how a listed option product is quoted and settled.
+// Premiums quoted at or above TickThreshold use LargeTickSize when it is set
+// (e.g. the US penny/nickel tick schedule).
+type ContractSpec struct {
+	Exchange      string
+	Product       string
+	Currency      string  // Premium currency
+	Multiplier    float64 // Units of underlying per contract
+	TickSize      float64
+	TickThreshold float64
+	LargeTickSize float64
+	Settlement    SettlementStyle
+	Exercise      ExerciseStyle
+}
+
+// Tick returns the minimum price increment for a premium of price.
+func (c ContractSpec) Tick(price float64) float64 {
+	if c.LargeTickSize > 0 && price >= c.TickThreshold {
+		return c.LargeTickSize
+	}
+	return c.TickSize
+}
+
+// RoundToTick rounds a per-unit premium to the nearest valid tick.
+func (c ContractSpec) RoundToTick(price float64) float64 {
+	tick := c.Tick(price)
+	if tick <= 0 {
+		return price
+	}
+	return math.Round(price/tick) * tick
+}
+
+// ContractRegistry looks up contract specifications by exchange and product.
+type ContractRegistry struct {
+	specs map[string]ContractSpec
+}
+
+// NewContractRegistry returns an empty registry.
+func NewContractRegistry() *ContractRegistry {
+	return &ContractRegistry{specs: make(map[string]ContractSpec)}
+}
+
+// DefaultContractRegistry returns a registry preloaded with a few common
+// products. Exchanges change their specs; verify before relying on them.
+func DefaultContractRegistry() *ContractRegistry {
+	reg := NewContractRegistry()
+	for _, spec := range []ContractSpec{
+		{Exchange: "CBOE", Product: "SPX", Currency: "USD", Multiplier: 100, TickSize: 0.05, TickThreshold: 3, LargeTickSize: 0.10, Settlement: CashSettled, Exercise: European},
+		{Exchange: "CBOE", Product: "EQUITY", Currency: "USD", Multiplier: 100, TickSize: 0.01, TickThreshold: 3, LargeTickSize: 0.05, Settlement: PhysicallySettled, Exercise: American},
+		{Exchange: "CME", Product: "ES", Currency: "USD", Multiplier: 50, TickSize: 0.25, Settlement: PhysicallySettled, Exercise: American},
+		{Exchange: "EUREX", Product: "OESX", Currency: "EUR", Multiplier: 10, TickSize: 0.1, Settlement: CashSettled, Exercise: European},
+		{Exchange: "EUREX", Product: "ODAX", Currency: "EUR", Multiplier: 5, TickSize: 0.1, Settlement: CashSettled, Exercise: European},
+	} {
+		reg.Register(spec)
+	}
+	return reg
+}
+
+// Register adds or replaces the spec for its exchange/product pair.
+func (r *ContractRegistry) Register(spec ContractSpec) {
+	r.specs[contractKey(spec.Exchange, spec.Product)] = spec
+}
+
+// Lookup returns the spec for exchange/product.
+func (r *ContractRegistry) Lookup(exchange, product string) (ContractSpec, error) {
+	spec, ok := r.specs[contractKey(exchange, product)]
+	if !ok {
+		return ContractSpec{}, fmt.Errorf("no contract spec for %s %s", exchange, product)
+	}
+	return spec, nil
+}
+
+// Products lists the registered exchange/product keys in sorted order.
+func (r *ContractRegistry) Products() []string {
+	keys := make([]string, 0, len(r.specs))
+	for k := range r.specs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contractKey(exchange, product string) string {
+	return exchange + ":" + product
+}
+
+// ContractOutputs holds outputs for one unit of underlying and for one listed
+// contract (PerUnit scaled by the multiplier, in the premium currency).
+type ContractOutputs struct {
+	Spec        ContractSpec
+	PerUnit     Outputs
+	PerContract Outputs
+}
+
+// PriceContract prices inputs under spec with Price, exercising as the spec
+// says: a spec with an exercise style sets inputs.Exercise, and inputs that
+// name a different style are an error. With roundToTick the per-unit price
+// is rounded to the product's tick before scaling; Greeks are never rounded.
+func PriceContract(inputs Inputs, spec ContractSpec, thetaBasis int, roundToTick bool) (ContractOutputs, error) {
+	if spec.Multiplier <= 0 {
+		return ContractOutputs{}, fmt.Errorf("contract %s %s has non-positive multiplier %g", spec.Exchange, spec.Product, spec.Multiplier)
+	}
+	if spec.Exercise != "" {
+		if inputs.Exercise != "" && inputs.Exercise != spec.Exercise {
+			return ContractOutputs{}, fmt.Errorf("contract %s %s is %s exercise, inputs say %s",
+				spec.Exchange, spec.Product, spec.Exercise, inputs.Exercise)
+		}
+		inputs.Exercise = spec.Exercise
+	}
+	perUnit, err := Price(inputs, WithThetaBasis(thetaBasis))
+	if err != nil {
+		return ContractOutputs{}, fmt.Errorf("contract %s %s: %w", spec.Exchange, spec.Product, err)
+	}
+	if roundToTick {
+		perUnit.Price = spec.RoundToTick(perUnit.Price)
+	}
+	return ContractOutputs{
+		Spec:        spec,
+		PerUnit:     perUnit,
+		PerContract: perUnit.scale(spec.Multiplier),
+	}, nil
+}
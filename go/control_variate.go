@@ -0,0 +1,241 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// PathPayoff evaluates an undiscounted payoff on a simulated path, where
+// path[0] is the initial spot and path[i] the spot at grid[i-1].
+type PathPayoff func(path []float64) float64
+
+// ControlVariate is a payoff whose discounted expectation is known
+// analytically, e.g. the geometric Asian for an arithmetic Asian.
+type ControlVariate struct {
+	Name     string
+	Payoff   PathPayoff
+	Expected float64 // Analytic price (discounted)
+}
+
+// ControlledSimulation prices Payoff by Monte Carlo, using any registered
+// controls with regression-estimated optimal coefficients.
+type ControlledSimulation struct {
+	Generator PathGenerator
+	Grid      TimeGrid
+	Paths     int
+	RNG       RNG
+	Discount  float64 // Discount factor applied to every payoff
+	Payoff    PathPayoff
+	Controls  []ControlVariate
+}
+
+// ControlReport describes one control's contribution.
+type ControlReport struct {
+	Name        string
+	Coefficient float64
+	SampleMean  float64 // Simulated discounted mean of the control
+	Expected    float64
+}
+
+// MCEstimate is a Monte Carlo price with and without the controls applied.
+type MCEstimate struct {
+	Price     float64
+	StdErr    float64
+	RawPrice  float64 // Plain Monte Carlo estimate on the same paths
+	RawStdErr float64
+	Paths     int
+	Controls  []ControlReport
+
+	// VarianceReduction is Var(raw)/Var(controlled): the factor by which the
+	// controls cut the number of paths needed for a given standard error.
+	VarianceReduction float64
+}
+
+// Run simulates the paths and returns the control-variate estimate
+//
+//	Y - beta . (X - E[X])
+//
+// with beta = Cov(X)^-1 Cov(X, Y) estimated from the same paths.
+func (s ControlledSimulation) Run() (MCEstimate, error) {
+	if s.Payoff == nil || s.Generator == nil || s.RNG == nil {
+		return MCEstimate{}, errors.New("simulation needs a generator, an RNG and a payoff")
+	}
+	k := len(s.Controls)
+	if s.Paths <= k+1 {
+		return MCEstimate{}, fmt.Errorf("need more than %d paths for %d controls", k+1, k)
+	}
+	if err := s.Grid.Validate(); err != nil {
+		return MCEstimate{}, err
+	}
+	df := s.Discount
+	if df == 0 {
+		df = 1
+	}
+
+	n := s.Paths
+	y := make([]float64, n)
+	x := make([][]float64, k)
+	for j := range x {
+		x[j] = make([]float64, n)
+	}
+	path := make([]float64, len(s.Grid)+1)
+	for i := 0; i < n; i++ {
+		s.Generator.Path(s.Grid, s.RNG, path)
+		y[i] = df * s.Payoff(path)
+		for j, c := range s.Controls {
+			x[j][i] = df * c.Payoff(path)
+		}
+	}
+
+	meanY := mean(y)
+	meanX := make([]float64, k)
+	for j := range x {
+		meanX[j] = mean(x[j])
+	}
+	// Normal equations: Cov(X) beta = Cov(X, Y).
+	covXX := make([][]float64, k)
+	covXY := make([]float64, k)
+	for a := 0; a < k; a++ {
+		covXX[a] = make([]float64, k)
+		for b := 0; b < k; b++ {
+			covXX[a][b] = covariance(x[a], meanX[a], x[b], meanX[b])
+		}
+		covXY[a] = covariance(x[a], meanX[a], y, meanY)
+	}
+	beta, err := numerics.SolveLinear(covXX, covXY)
+	if err != nil {
+		return MCEstimate{}, fmt.Errorf("control variates are collinear: %w", err)
+	}
+
+	resid := make([]float64, n)
+	for i := range resid {
+		r := y[i]
+		for j := range x {
+			r -= beta[j] * (x[j][i] - meanX[j])
+		}
+		resid[i] = r
+	}
+	price := meanY
+	reports := make([]ControlReport, k)
+	for j, c := range s.Controls {
+		price -= beta[j] * (meanX[j] - c.Expected)
+		reports[j] = ControlReport{Name: c.Name, Coefficient: beta[j], SampleMean: meanX[j], Expected: c.Expected}
+	}
+
+	varY := covariance(y, meanY, y, meanY)
+	varR := covariance(resid, meanY, resid, meanY) * float64(n-1) / float64(n-1-k)
+	est := MCEstimate{
+		Price:     price,
+		StdErr:    math.Sqrt(varR / float64(n)),
+		RawPrice:  meanY,
+		RawStdErr: math.Sqrt(varY / float64(n)),
+		Paths:     n,
+		Controls:  reports,
+	}
+	if varR > 0 {
+		est.VarianceReduction = varY / varR
+	}
+	return est, nil
+}
+
+func mean(a []float64) float64 {
+	s := 0.0
+	for _, v := range a {
+		s += v
+	}
+	return s / float64(len(a))
+}
+
+// covariance returns the unbiased sample covariance given the means.
+func covariance(a []float64, ma float64, b []float64, mb float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += (a[i] - ma) * (b[i] - mb)
+	}
+	return s / float64(len(a)-1)
+}
+
+// ArithmeticAsianPayoff averages the spot over every grid point (path[1:]).
+func ArithmeticAsianPayoff(K float64, optType OptionType) PathPayoff {
+	return func(path []float64) float64 {
+		avg := mean(path[1:])
+		if optType == Call {
+			return math.Max(avg-K, 0)
+		}
+		return math.Max(K-avg, 0)
+	}
+}
+
+// GeometricAsianPayoff is the geometric-average counterpart of ArithmeticAsianPayoff.
+func GeometricAsianPayoff(K float64, optType OptionType) PathPayoff {
+	return func(path []float64) float64 {
+		l := 0.0
+		for _, s := range path[1:] {
+			l += math.Log(s)
+		}
+		avg := math.Exp(l / float64(len(path)-1))
+		if optType == Call {
+			return math.Max(avg-K, 0)
+		}
+		return math.Max(K-avg, 0)
+	}
+}
+
+// EuropeanPayoff pays on the final spot of the path.
+func EuropeanPayoff(K float64, optType OptionType) PathPayoff {
+	return func(path []float64) float64 {
+		s := path[len(path)-1]
+		if optType == Call {
+			return math.Max(s-K, 0)
+		}
+		return math.Max(K-s, 0)
+	}
+}
+
+// GeometricAsianControl is the discrete geometric Asian under GBM, averaging
+// over grid, in closed form: ln G is normal with mean
+// ln S0 + (r-q-sigma^2/2) avg(t_i) and variance sigma^2/n^2 sum min(t_i, t_j).
+func GeometricAsianControl(g GBM, grid TimeGrid, K float64, optType OptionType) ControlVariate {
+	n := float64(len(grid))
+	meanT, sumMin := 0.0, 0.0
+	for i, ti := range grid {
+		meanT += ti / n
+		for j, tj := range grid {
+			if j < i {
+				sumMin += 2 * tj
+			} else if j == i {
+				sumMin += ti
+			}
+		}
+	}
+	variance := g.Sigma * g.Sigma * sumMin / (n * n)
+	mu := math.Log(g.S0) + (g.R-g.Q-0.5*g.Sigma*g.Sigma)*meanT
+	T := grid[len(grid)-1]
+	price := math.Exp(-g.R*T) * blackFormula(math.Exp(mu+0.5*variance), K, math.Sqrt(variance), optType == Call)
+	return ControlVariate{Name: "geometric asian", Payoff: GeometricAsianPayoff(K, optType), Expected: price}
+}
+
+// EuropeanControl is the BSM vanilla on the terminal spot. Its expectation is
+// only right when the generator is GBM with the same inputs, e.g. as a
+// control for barrier or lookback payoffs.
+func EuropeanControl(inputs Inputs) ControlVariate {
+	return ControlVariate{
+		Name:     "bsm " + inputs.OptType.String(),
+		Payoff:   EuropeanPayoff(inputs.K, inputs.OptType),
+		Expected: priceAndGreeks(inputs, 365).Price,
+	}
+}
+
+// TerminalSpotControl uses the discounted terminal spot, whose expectation
+// S0*exp(-qT) holds under any risk-neutral generator (Heston, jumps) when the
+// simulation discounts at exp(-rT).
+func TerminalSpotControl(S0, Q, T float64) ControlVariate {
+	return ControlVariate{
+		Name:     "terminal spot",
+		Payoff:   func(path []float64) float64 { return path[len(path)-1] },
+		Expected: S0 * math.Exp(-Q*T),
+	}
+}
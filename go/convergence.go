@@ -0,0 +1,167 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ResolutionPricer prices at resolution n: time steps, grid nodes, tree
+// steps or paths.
+type ResolutionPricer func(n int) (float64, error)
+
+// ConvergencePoint is one run of a convergence study. Order and Limit are
+// estimated from this run and the two before it (NaN for the first two).
+type ConvergencePoint struct {
+	N      int
+	Value  float64
+	Change float64 // Value minus the previous run's value
+	Order  float64
+	Limit  float64 // Richardson-extrapolated value
+}
+
+// ConvergenceReport summarises a study. Order, Limit and Error come from the
+// last three resolutions; Error is the estimated error of the finest run.
+type ConvergenceReport struct {
+	Points []ConvergencePoint
+	Order  float64
+	Limit  float64
+	Error  float64
+}
+
+// AnalyseConvergence prices at each resolution (strictly increasing, at
+// least three) and fits error ~ C n^-p to consecutive triples.
+func AnalyseConvergence(price ResolutionPricer, resolutions []int) (ConvergenceReport, error) {
+	if len(resolutions) < 3 {
+		return ConvergenceReport{}, errors.New("need at least three resolutions")
+	}
+	pts := make([]ConvergencePoint, len(resolutions))
+	for i, n := range resolutions {
+		if n <= 0 || (i > 0 && n <= resolutions[i-1]) {
+			return ConvergenceReport{}, fmt.Errorf("resolutions must be positive and increasing (got %d)", n)
+		}
+		v, err := price(n)
+		if err != nil {
+			return ConvergenceReport{}, fmt.Errorf("resolution %d: %w", n, err)
+		}
+		pts[i] = ConvergencePoint{N: n, Value: v, Change: math.NaN(), Order: math.NaN(), Limit: math.NaN()}
+		if i > 0 {
+			pts[i].Change = v - pts[i-1].Value
+		}
+		if i > 1 {
+			a, b := pts[i-2], pts[i-1]
+			p := convergenceOrder(a.N, b.N, n, a.Value, b.Value, v)
+			pts[i].Order = p
+			pts[i].Limit = Richardson(b.Value, v, float64(n)/float64(b.N), p)
+		}
+	}
+	last := pts[len(pts)-1]
+	return ConvergenceReport{
+		Points: pts,
+		Order:  last.Order,
+		Limit:  last.Limit,
+		Error:  math.Abs(last.Value - last.Limit),
+	}, nil
+}
+
+// Richardson extrapolates values at resolutions n and ratio*n, with error
+// of order p, to the n -> infinity limit.
+func Richardson(coarse, fine, ratio, p float64) float64 {
+	d := math.Pow(ratio, p) - 1
+	if !(d > 0) || math.IsNaN(p) {
+		return math.NaN()
+	}
+	return fine + (fine-coarse)/d
+}
+
+// convergenceOrder solves
+//
+//	(v1 - v0) / (v2 - v1) = (n0^-p - n1^-p) / (n1^-p - n2^-p)
+//
+// for p by bisection. It returns NaN when the changes alternate in sign or
+// do not shrink, i.e. the runs are not yet in the asymptotic regime.
+func convergenceOrder(n0, n1, n2 int, v0, v1, v2 float64) float64 {
+	d1, d2 := v1-v0, v2-v1
+	if d2 == 0 {
+		if d1 == 0 {
+			return math.NaN()
+		}
+		return math.Inf(1)
+	}
+	target := d1 / d2
+	if target <= 0 {
+		return math.NaN()
+	}
+	x0, x1, x2 := float64(n0), float64(n1), float64(n2)
+	ratio := func(p float64) float64 {
+		return (math.Pow(x0, -p) - math.Pow(x1, -p)) / (math.Pow(x1, -p) - math.Pow(x2, -p))
+	}
+	// ratio increases with p; below p -> 0 it tends to log(n1/n0)/log(n2/n1).
+	lo, hi := 1e-6, 20.0
+	if target < ratio(lo) || target > ratio(hi) {
+		return math.NaN()
+	}
+	for i := 0; i < 100; i++ {
+		m := 0.5 * (lo + hi)
+		if ratio(m) < target {
+			lo = m
+		} else {
+			hi = m
+		}
+	}
+	return 0.5 * (lo + hi)
+}
+
+// ResolutionFor estimates the resolution needed for an error of tol,
+// scaling the finest run by (Error/tol)^(1/Order).
+func (r ConvergenceReport) ResolutionFor(tol float64) (int, error) {
+	if !(r.Order > 0) || math.IsInf(r.Order, 0) || tol <= 0 {
+		return 0, errors.New("no usable order estimate; extend the study to finer resolutions")
+	}
+	last := r.Points[len(r.Points)-1]
+	if r.Error <= tol {
+		return last.N, nil
+	}
+	return int(math.Ceil(float64(last.N) * math.Pow(r.Error/tol, 1/r.Order))), nil
+}
+
+// GeometricResolutions returns count resolutions starting at n0, each ratio
+// times the previous.
+func GeometricResolutions(n0 int, ratio float64, count int) []int {
+	out := make([]int, 0, count)
+	x := float64(n0)
+	for i := 0; i < count; i++ {
+		n := int(math.Round(x))
+		if len(out) > 0 && n <= out[len(out)-1] {
+			n = out[len(out)-1] + 1
+		}
+		out = append(out, n)
+		x *= ratio
+	}
+	return out
+}
+
+// PDEResolution refines the PDE in time and space together: resolution n
+// uses n time steps and spaceRatio*n space intervals on a suggested grid.
+func PDEResolution(c PDEContract, s PDESettings, spaceRatio float64) ResolutionPricer {
+	return func(n int) (float64, error) {
+		run := s
+		run.Grid = nil
+		run.TimeSteps = n
+		run.SpaceSteps = int(math.Round(spaceRatio * float64(n)))
+		out, err := PricePDE(c, run)
+		return out.Price, err
+	}
+}
+
+// MCResolution runs the simulation with n paths. Successive runs draw fresh
+// paths from sim.RNG, so the estimated order is statistical (about 0.5) and
+// the extrapolated limit is only as good as the standard errors.
+func MCResolution(sim ControlledSimulation) ResolutionPricer {
+	return func(n int) (float64, error) {
+		run := sim
+		run.Paths = n
+		out, err := run.Run()
+		return out.Price, err
+	}
+}
@@ -0,0 +1,144 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Crypto markets trade 24/7, so time runs continuously on an ACT/365.25 clock
+// and listed options expire at 08:00 UTC.
+const (
+	cryptoDaysPerYear   = 365.25
+	CryptoExpiryHourUTC = 8
+)
+
+// CryptoYearFraction returns the ACT/365.25 year fraction from now to expiry,
+// to the second, or zero if expiry has passed.
+func CryptoYearFraction(now, expiry time.Time) float64 {
+	if !expiry.After(now) {
+		return 0
+	}
+	return expiry.Sub(now).Seconds() / (cryptoDaysPerYear * secondsPerDay)
+}
+
+// CryptoExpiry returns the 08:00 UTC expiry instant on the given date.
+func CryptoExpiry(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, CryptoExpiryHourUTC, 0, 0, 0, time.UTC)
+}
+
+// NextDailyExpiry returns the first 08:00 UTC strictly after now.
+func NextDailyExpiry(now time.Time) time.Time {
+	now = now.UTC()
+	exp := CryptoExpiry(now.Year(), now.Month(), now.Day())
+	if !exp.After(now) {
+		exp = exp.AddDate(0, 0, 1)
+	}
+	return exp
+}
+
+// NextWeeklyExpiry returns the first Friday 08:00 UTC strictly after now.
+func NextWeeklyExpiry(now time.Time) time.Time {
+	exp := NextDailyExpiry(now)
+	for exp.Weekday() != time.Friday {
+		exp = exp.AddDate(0, 0, 1)
+	}
+	return exp
+}
+
+// NextMonthlyExpiry returns the first last-Friday-of-the-month expiry
+// strictly after now.
+func NextMonthlyExpiry(now time.Time) time.Time {
+	now = now.UTC()
+	for m := 0; ; m++ {
+		first := time.Date(now.Year(), now.Month()+time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+		if exp := lastFridayExpiry(first.Year(), first.Month()); exp.After(now) {
+			return exp
+		}
+	}
+}
+
+// NextQuarterlyExpiry returns the first last-Friday expiry of March, June,
+// September or December strictly after now.
+func NextQuarterlyExpiry(now time.Time) time.Time {
+	exp := NextMonthlyExpiry(now)
+	for exp.Month()%3 != 0 {
+		exp = NextMonthlyExpiry(exp)
+	}
+	return exp
+}
+
+func lastFridayExpiry(year int, month time.Month) time.Time {
+	exp := CryptoExpiry(year, month+1, 0) // Last day of month
+	for exp.Weekday() != time.Friday {
+		exp = exp.AddDate(0, 0, -1)
+	}
+	return exp
+}
+
+// CryptoContractRegistry returns Deribit-style option specs. Coin-margined
+// (inverse) options are one coin per contract with premium in the coin;
+// USDC-margined (linear) options have fractional contract sizes.
+func CryptoContractRegistry() *ContractRegistry {
+	reg := NewContractRegistry()
+	for _, spec := range []ContractSpec{
+		{Exchange: "DERIBIT", Product: "BTC", Currency: "BTC", Multiplier: 1, TickSize: 0.0001, TickThreshold: 0.005, LargeTickSize: 0.0005, Settlement: CashSettled, Exercise: European},
+		{Exchange: "DERIBIT", Product: "ETH", Currency: "ETH", Multiplier: 1, TickSize: 0.0001, TickThreshold: 0.005, LargeTickSize: 0.0005, Settlement: CashSettled, Exercise: European},
+		{Exchange: "DERIBIT", Product: "BTC_USDC", Currency: "USDC", Multiplier: 0.01, TickSize: 5, Settlement: CashSettled, Exercise: European},
+		{Exchange: "DERIBIT", Product: "ETH_USDC", Currency: "USDC", Multiplier: 0.1, TickSize: 0.5, Settlement: CashSettled, Exercise: European},
+	} {
+		reg.Register(spec)
+	}
+	return reg
+}
+
+// CryptoInputs describes a crypto option priced off the forward (the
+// exchange's synthetic future for the expiry), as crypto venues do.
+type CryptoInputs struct {
+	Forward float64    // Forward/futures price for the expiry, in USD
+	K       float64    // Strike, in USD
+	Sigma   float64    // Volatility (decimal; 1.5 = 150%)
+	R       float64    // Discount rate (usually 0 on crypto venues)
+	OptType OptionType // Call or Put
+	Now     time.Time  // Valuation instant
+	Expiry  time.Time  // Expiry instant, normally 08:00 UTC
+}
+
+type CryptoOutputs struct {
+	T           float64 // ACT/365.25 year fraction used
+	PerUnit     Outputs // Per coin, in USD; theta, charm and color per day are per 24h
+	PerContract Outputs // PerUnit scaled by the contract size
+}
+
+// PriceCrypto prices a linear (USD-valued) crypto option with Black-76 on
+// the forward using the 24/7 clock. Vols of several hundred percent are
+// fine; only non-positive vols are rejected.
+func PriceCrypto(in CryptoInputs, spec ContractSpec) (CryptoOutputs, error) {
+	if in.Forward <= 0 || in.K <= 0 {
+		return CryptoOutputs{}, errors.New("crypto option needs positive forward and strike")
+	}
+	if in.Sigma <= 0 {
+		return CryptoOutputs{}, fmt.Errorf("crypto option vol %g must be positive", in.Sigma)
+	}
+	T := CryptoYearFraction(in.Now, in.Expiry)
+	if T == 0 {
+		return CryptoOutputs{}, fmt.Errorf("option expired at %s", in.Expiry.Format(time.RFC3339))
+	}
+	if err := (Inputs{S0: in.Forward, K: in.K, T: T, Sigma: in.Sigma, R: in.R, OptType: in.OptType, Model: Black76Model}).Validate(); err != nil {
+		return CryptoOutputs{}, err
+	}
+	size := spec.Multiplier
+	if size == 0 {
+		size = 1
+	}
+	out := priceAndGreeksBlack76(in.Forward, in.K, T, in.Sigma, in.R, in.OptType, 365)
+	// Per-day Greeks on the same 24/7 clock as T.
+	out.ThetaPerDay = out.ThetaPerYear / cryptoDaysPerYear
+	out.CharmPerDay = out.CharmPerYear / cryptoDaysPerYear
+	out.ColorPerDay = out.ColorPerYear / cryptoDaysPerYear
+	return CryptoOutputs{
+		T:           T,
+		PerUnit:     out,
+		PerContract: out.scale(size),
+	}, nil
+}
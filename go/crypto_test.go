@@ -0,0 +1,129 @@
+package bsm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var cryptoNow = time.Date(2026, 10, 15, 13, 30, 0, 0, time.UTC)
+
+func TestCryptoExpiries(t *testing.T) {
+	cases := []struct {
+		name string
+		got  time.Time
+		want time.Time
+	}{
+		{"daily", NextDailyExpiry(cryptoNow), CryptoExpiry(2026, 10, 16)},
+		{"daily at expiry", NextDailyExpiry(CryptoExpiry(2026, 10, 16)), CryptoExpiry(2026, 10, 17)},
+		{"weekly", NextWeeklyExpiry(cryptoNow), CryptoExpiry(2026, 10, 16)},
+		{"monthly", NextMonthlyExpiry(cryptoNow), CryptoExpiry(2026, 10, 30)},
+		{"monthly rolls", NextMonthlyExpiry(CryptoExpiry(2026, 10, 30)), CryptoExpiry(2026, 11, 27)},
+		{"quarterly", NextQuarterlyExpiry(cryptoNow), CryptoExpiry(2026, 12, 25)},
+	}
+	for _, c := range cases {
+		if !c.got.Equal(c.want) {
+			t.Errorf("%s: got %s, want %s", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestCryptoYearFraction(t *testing.T) {
+	start := CryptoExpiry(2026, 10, 16)
+	if got, want := CryptoYearFraction(start, start.AddDate(0, 0, 1)), 1/365.25; math.Abs(got-want) > 1e-15 {
+		t.Errorf("one day: got %g, want %g", got, want)
+	}
+	// Weekends count on a 24/7 clock.
+	if got, want := CryptoYearFraction(start, start.AddDate(0, 0, 7)), 7/365.25; math.Abs(got-want) > 1e-15 {
+		t.Errorf("one week: got %g, want %g", got, want)
+	}
+	if got := CryptoYearFraction(start, start.Add(-time.Hour)); got != 0 {
+		t.Errorf("expired: got %g, want 0", got)
+	}
+}
+
+// Deribit-style quotes: mark IV and underlying (synthetic future) price in,
+// mark price in coin out. Expected values from an independent Black-76
+// implementation with r = 0 on the ACT/365.25 clock.
+func TestPriceCryptoDeribitQuotes(t *testing.T) {
+	reg := CryptoContractRegistry()
+	cases := []struct {
+		instrument string
+		product    string
+		forward    float64
+		strike     float64
+		expiry     time.Time
+		markIV     float64
+		optType    OptionType
+		wantCoin   float64
+		wantDelta  float64
+	}{
+		{"BTC-30OCT26-70000-C", "BTC", 67250, 70000, CryptoExpiry(2026, 10, 30), 0.52, Call, 0.0251893984669763, 0.3703304368695426},
+		{"BTC-25DEC26-60000-P", "BTC", 67250, 60000, CryptoExpiry(2026, 12, 25), 0.55, Put, 0.04712543936856814, -0.2768485825802124},
+		{"ETH-16OCT26-2000-P", "ETH", 2450, 2000, CryptoExpiry(2026, 10, 16), 2.50, Put, 0.001606773301054246, -0.03404159249126493},
+	}
+	for _, c := range cases {
+		spec, err := reg.Lookup("DERIBIT", c.product)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := PriceCrypto(CryptoInputs{
+			Forward: c.forward,
+			K:       c.strike,
+			Sigma:   c.markIV,
+			OptType: c.optType,
+			Now:     cryptoNow,
+			Expiry:  c.expiry,
+		}, spec)
+		if err != nil {
+			t.Fatalf("%s: %v", c.instrument, err)
+		}
+		if got := out.PerUnit.Price / c.forward; math.Abs(got-c.wantCoin) > 1e-12 {
+			t.Errorf("%s: mark price %.10f coin, want %.10f", c.instrument, got, c.wantCoin)
+		}
+		if got := out.PerUnit.Delta; math.Abs(got-c.wantDelta) > 1e-12 {
+			t.Errorf("%s: delta %.10f, want %.10f", c.instrument, got, c.wantDelta)
+		}
+		u := out.PerUnit
+		if math.Abs(u.ThetaPerDay*365.25-u.ThetaPerYear) > 1e-9 || math.Abs(u.CharmPerDay*365.25-u.CharmPerYear) > 1e-12 ||
+			math.Abs(u.ColorPerDay*365.25-u.ColorPerYear) > 1e-12 {
+			t.Errorf("%s: per-day time Greeks not on a 365.25 basis", c.instrument)
+		}
+	}
+}
+
+func TestPriceCryptoContractSize(t *testing.T) {
+	spec, err := CryptoContractRegistry().Lookup("DERIBIT", "BTC_USDC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := PriceCrypto(CryptoInputs{
+		Forward: 67250,
+		K:       70000,
+		Sigma:   0.52,
+		OptType: Call,
+		Now:     cryptoNow,
+		Expiry:  CryptoExpiry(2026, 10, 30),
+	}, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out.PerContract.Price, 0.01*out.PerUnit.Price; math.Abs(got-want) > 1e-9 {
+		t.Errorf("per-contract price %g, want %g", got, want)
+	}
+}
+
+func TestPriceCryptoRejectsBadInputs(t *testing.T) {
+	base := CryptoInputs{Forward: 67250, K: 70000, Sigma: 0.52, OptType: Call, Now: cryptoNow, Expiry: CryptoExpiry(2026, 10, 30)}
+
+	expired := base
+	expired.Expiry = cryptoNow.Add(-time.Minute)
+	if _, err := PriceCrypto(expired, ContractSpec{}); err == nil {
+		t.Error("expected error for expired option")
+	}
+	zeroVol := base
+	zeroVol.Sigma = 0
+	if _, err := PriceCrypto(zeroVol, ContractSpec{}); err == nil {
+		t.Error("expected error for zero vol")
+	}
+}
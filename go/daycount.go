@@ -0,0 +1,128 @@
+package bsm
+
+import (
+	"fmt"
+	"time"
+)
+
+// DayCount turns a valuation date and an expiry date into a year fraction.
+type DayCount string
+
+const (
+	Act365F DayCount = "ACT/365F" // Calendar days / 365
+	Act360  DayCount = "ACT/360"  // Calendar days / 360
+	Bus252  DayCount = "BUS/252"  // Business days / 252, weekends and Calendar holidays excluded
+)
+
+// DaysPerYear is the convention's day basis: the theta basis under which
+// ThetaPerDay is the change over one of its days.
+func (dc DayCount) DaysPerYear() (int, error) {
+	switch dc {
+	case Act365F:
+		return 365, nil
+	case Act360:
+		return 360, nil
+	case Bus252:
+		return 252, nil
+	}
+	return 0, fmt.Errorf("unknown day count %q", dc)
+}
+
+// HolidayCalendar lists non-business dates besides weekends. Only the date
+// part of each holiday is used.
+type HolidayCalendar struct {
+	Holidays []time.Time
+}
+
+// IsBusinessDay reports whether d is a weekday and not a holiday.
+func (c HolidayCalendar) IsBusinessDay(d time.Time) bool {
+	if !isWeekday(d) {
+		return false
+	}
+	day := dateOf(d)
+	for _, h := range c.Holidays {
+		if dateOf(h) == day {
+			return false
+		}
+	}
+	return true
+}
+
+// BusinessDaysBetween counts business days d with from <= d < to, by date.
+func (c HolidayCalendar) BusinessDaysBetween(from, to time.Time) int {
+	holidays := make(map[civilDate]bool, len(c.Holidays))
+	for _, h := range c.Holidays {
+		holidays[dateOf(h)] = true
+	}
+	n := 0
+	for day, end := midnightUTC(from), midnightUTC(to); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if isWeekday(day) && !holidays[dateOf(day)] {
+			n++
+		}
+	}
+	return n
+}
+
+// YearFraction is the time from valuation to expiry under dc, counting
+// whole days between the two dates; the clock time of each is ignored (see
+// TimeToExpiry for intraday time). cal is used by BUS/252 only.
+func YearFraction(valuation, expiry time.Time, dc DayCount, cal HolidayCalendar) (float64, error) {
+	basis, err := dc.DaysPerYear()
+	if err != nil {
+		return 0, err
+	}
+	from, to := midnightUTC(valuation), midnightUTC(expiry)
+	if to.Before(from) {
+		return 0, fmt.Errorf("%w: expiry %s is before valuation %s", ErrExpired,
+			expiry.Format(time.DateOnly), valuation.Format(time.DateOnly))
+	}
+	days := to.Sub(from).Hours() / 24
+	if dc == Bus252 {
+		days = float64(cal.BusinessDaysBetween(from, to))
+	}
+	return days / float64(basis), nil
+}
+
+// midnightUTC is t's date, in t's own location, as midnight UTC, so that
+// date differences are whole days across DST changes.
+func midnightUTC(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// DatedInputs is the date form of Inputs: the time to expiry comes from
+// Valuation and Expiry under DayCount, and Inputs.T is ignored.
+type DatedInputs struct {
+	Inputs
+	Valuation time.Time
+	Expiry    time.Time
+	DayCount  DayCount        // Act365F if empty
+	Calendar  HolidayCalendar // Holidays for Bus252
+}
+
+// Resolve returns the Inputs with T computed and the theta basis of the
+// day count.
+func (d DatedInputs) Resolve() (Inputs, int, error) {
+	if d.DayCount == "" {
+		d.DayCount = Act365F
+	}
+	T, err := YearFraction(d.Valuation, d.Expiry, d.DayCount, d.Calendar)
+	if err != nil {
+		return Inputs{}, 0, err
+	}
+	basis, _ := d.DayCount.DaysPerYear()
+	in := d.Inputs
+	in.T = T
+	return in, basis, nil
+}
+
+// PriceDated prices like Price with T from the dates. The per-day Greeks
+// (theta, charm, color) are per day of the convention: a calendar day under
+// ACT/365F, 1/360 of a year under ACT/360 and a business day under BUS/252,
+// overriding any WithThetaBasis.
+func PriceDated(in DatedInputs, opts ...Option) (Outputs, error) {
+	inputs, basis, err := in.Resolve()
+	if err != nil {
+		return Outputs{}, err
+	}
+	return Price(inputs, append(opts[:len(opts):len(opts)], WithThetaBasis(basis))...)
+}
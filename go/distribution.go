@@ -0,0 +1,136 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// TerminalDistribution is a distribution of the log-return X = ln(S_T/S_0)
+// over the life of an option. Implementations only need to describe the
+// shape: the pricer rescales S_T so the forward is matched exactly.
+type TerminalDistribution interface {
+	CDF(x float64) float64
+	PDF(x float64) float64
+	Quantile(p float64) float64
+}
+
+// NormalLogReturn is the BSM assumption: X ~ N(Mu, StdDev^2).
+type NormalLogReturn struct {
+	Mu     float64
+	StdDev float64
+}
+
+// BSMLogReturn returns the lognormal terminal distribution implied by inputs.
+func BSMLogReturn(inputs Inputs) NormalLogReturn {
+	return NormalLogReturn{
+		Mu:     (inputs.R - inputs.Q - 0.5*inputs.Sigma*inputs.Sigma) * inputs.T,
+		StdDev: inputs.Sigma * math.Sqrt(inputs.T),
+	}
+}
+
+func (d NormalLogReturn) CDF(x float64) float64 { return normCDF((x - d.Mu) / d.StdDev) }
+
+func (d NormalLogReturn) PDF(x float64) float64 { return normPDF((x-d.Mu)/d.StdDev) / d.StdDev }
+
+func (d NormalLogReturn) Quantile(p float64) float64 {
+	return d.Mu + d.StdDev*quantileByBisection(normCDF, p, -40, 40)
+}
+
+// quantileByBisection inverts a monotone CDF on [lo, hi], returning the
+// nearer end when p is beyond the CDF's range there.
+func quantileByBisection(cdf func(float64) float64, p, lo, hi float64) float64 {
+	res, err := numerics.Bisect(func(x float64) float64 { return cdf(x) - p }, lo, hi, numerics.Options{})
+	if errors.Is(err, numerics.ErrNotBracketed) {
+		if cdf(lo) >= p {
+			return lo
+		}
+		return hi
+	}
+	return res.X
+}
+
+const (
+	distTailProb   = 1e-10 // Probability mass ignored in each tail
+	distIntervals  = 2000  // Simpson intervals per integration segment
+	distMaxSupport = 50.0  // Largest |log-return| integrated over
+)
+
+// PriceWithDistribution prices the European call or put in inputs with the
+// terminal log-return drawn from dist. inputs must pass Validate; Sigma is
+// otherwise ignored.
+func PriceWithDistribution(inputs Inputs, dist TerminalDistribution) (float64, error) {
+	if err := inputs.Validate(); err != nil {
+		return 0, err
+	}
+	K := inputs.K
+	var payoff func(float64) float64
+	if inputs.OptType == Call {
+		payoff = func(sT float64) float64 { return math.Max(sT-K, 0) }
+	} else {
+		payoff = func(sT float64) float64 { return math.Max(K-sT, 0) }
+	}
+	return IntegratePayoff(inputs, dist, payoff, K)
+}
+
+// IntegratePayoff returns exp(-rT) E[payoff(S_T)], where S_T = c*S0*exp(X)
+// and c makes E[S_T] equal the forward S0*exp((r-q)T). Any kinks of the
+// payoff (in spot terms) should be passed so the quadrature splits there.
+func IntegratePayoff(inputs Inputs, dist TerminalDistribution, payoff func(sT float64) float64, kinks ...float64) (float64, error) {
+	if inputs.S0 <= 0 {
+		return 0, errors.New("spot must be positive")
+	}
+	lo := math.Max(dist.Quantile(distTailProb), -distMaxSupport)
+	hi := math.Min(dist.Quantile(1-distTailProb), distMaxSupport)
+	if !(hi > lo) {
+		return 0, fmt.Errorf("degenerate distribution support [%g, %g]", lo, hi)
+	}
+
+	mass := simpson(dist.PDF, lo, hi, distIntervals)
+	growth := simpson(func(x float64) float64 { return math.Exp(x) * dist.PDF(x) }, lo, hi, distIntervals)
+	if mass <= 0 || growth <= 0 || math.IsInf(growth, 0) {
+		return 0, errors.New("distribution has no finite exponential moment")
+	}
+	fwd := inputs.S0 * math.Exp((inputs.R-inputs.Q)*inputs.T)
+	scale := fwd / (inputs.S0 * growth / mass)
+
+	breaks := []float64{lo}
+	for _, k := range kinks {
+		if k <= 0 {
+			continue
+		}
+		if x := math.Log(k / (scale * inputs.S0)); x > lo && x < hi {
+			breaks = append(breaks, x)
+		}
+	}
+	breaks = append(breaks, hi)
+
+	integrand := func(x float64) float64 {
+		return payoff(scale*inputs.S0*math.Exp(x)) * dist.PDF(x)
+	}
+	total := 0.0
+	for i := 1; i < len(breaks); i++ {
+		total += simpson(integrand, breaks[i-1], breaks[i], distIntervals)
+	}
+	return math.Exp(-inputs.R*inputs.T) * total / mass, nil
+}
+
+// simpson integrates f over [a, b] with composite Simpson's rule on n
+// (rounded up to even) intervals.
+func simpson(f func(float64) float64, a, b float64, n int) float64 {
+	if n%2 == 1 {
+		n++
+	}
+	h := (b - a) / float64(n)
+	sum := f(a) + f(b)
+	for i := 1; i < n; i++ {
+		w := 2.0
+		if i%2 == 1 {
+			w = 4.0
+		}
+		sum += w * f(a+float64(i)*h)
+	}
+	return sum * h / 3
+}
@@ -0,0 +1,34 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPriceWithDistribution(t *testing.T) {
+	in := Inputs{S0: 100, K: 105, T: 0.5, Sigma: 0.2, R: 0.03, Q: 0.01, OptType: Call}
+	got, err := PriceWithDistribution(in, BSMLogReturn(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := Price(in); math.Abs(got-want.Price) > 1e-6 {
+		t.Errorf("got %g, want the BSM price %g", got, want.Price)
+	}
+
+	for _, c := range []struct {
+		edit func(*Inputs)
+		want error
+	}{
+		{func(in *Inputs) { in.K = 0 }, ErrNegativeStrike},
+		{func(in *Inputs) { in.T = -0.5 }, ErrExpired},
+		{func(in *Inputs) { in.Sigma = -0.2 }, ErrNegativeVol},
+		{func(in *Inputs) { in.OptType = 0 }, ErrUnknownOptionType},
+	} {
+		bad := in
+		c.edit(&bad)
+		if _, err := PriceWithDistribution(bad, BSMLogReturn(in)); !errors.Is(err, c.want) {
+			t.Errorf("%+v: got %v, want %v", bad, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DividendMethod selects how Price values European options with discrete
+// dividends (Inputs.Dividends).
+type DividendMethod string
+
+const (
+	// EscrowedDividends prices on the spot less the present value of the
+	// dividends to expiry, at the quoted vol. It undervalues options when
+	// the vol is quoted on the stock price, as it usually is.
+	EscrowedDividends DividendMethod = "escrowed"
+	// HaugHaugDividends is escrowed with the Haug-Haug (Beneder-Vorst)
+	// vol adjustment: while dividends are outstanding the escrowed spot is
+	// given the vol sigma S/(S - PV), and the total variance is averaged
+	// to expiry.
+	HaugHaugDividends DividendMethod = "haug-haug"
+)
+
+// WithDividendMethod sets how Price values European options with discrete
+// dividends (EscrowedDividends by default). American options are priced
+// on the escrowed tree (see PriceTree).
+func WithDividendMethod(m DividendMethod) Option {
+	return func(o *priceOptions) { o.dividends = m }
+}
+
+// ErrDividends is returned for a dividend schedule that cannot be priced.
+var ErrDividends = errors.New("invalid dividend schedule")
+
+// validateDividends checks the schedule: finite, ex-dates after today,
+// non-negative amounts, and less in present value than the spot.
+func validateDividends(in Inputs) error {
+	if len(in.Dividends) == 0 {
+		return nil
+	}
+	if in.Model != "" && in.Model != BSMModel {
+		return fmt.Errorf("%w: the %s model takes no dividends", ErrDividends, in.Model)
+	}
+	for _, d := range in.Dividends {
+		if math.IsNaN(d.Time) || math.IsInf(d.Time, 0) || math.IsNaN(d.Amount) || math.IsInf(d.Amount, 0) {
+			return fmt.Errorf("%w: %+v is not finite", ErrDividends, d)
+		}
+		if d.Time <= 0 || d.Amount < 0 {
+			return fmt.Errorf("%w: dividend at %g must be after today with a non-negative amount", ErrDividends, d.Time)
+		}
+	}
+	if pv := dividendPV(in, 0); pv >= in.S0 {
+		return fmt.Errorf("%w: dividends worth %g exceed the spot %g", ErrDividends, pv, in.S0)
+	}
+	return nil
+}
+
+// dividendPV is the value at time t of the dividends going ex after t and
+// no later than expiry.
+func dividendPV(in Inputs, t float64) float64 {
+	pv := 0.0
+	for _, d := range in.Dividends {
+		if d.Time > t && d.Time <= in.T {
+			pv += d.Amount * math.Exp(-in.R*(d.Time-t))
+		}
+	}
+	return pv
+}
+
+// priceEscrowed prices a European option on the escrowed spot. The Greeks
+// are those of the escrowed option, with rho, theta, charm and color
+// picking up how the dividends' present value moves with the rate and as
+// time passes.
+func priceEscrowed(in Inputs, thetaBasis int) Outputs {
+	pv, duration := 0.0, 0.0 // duration is -dPV/dr
+	for _, d := range in.Dividends {
+		if d.Time <= in.T {
+			v := d.Amount * math.Exp(-in.R*d.Time)
+			pv += v
+			duration += d.Time * v
+		}
+	}
+	x := in
+	x.S0 -= pv
+	x.Dividends = nil
+	o := priceAndGreeks(x, thetaBasis)
+	// As time passes each dividend draws nearer, so the escrowed spot
+	// falls at rate r PV.
+	drift := -in.R * pv
+	o.RhoPer1 += o.Delta * duration
+	o.ThetaPerYear += o.Delta * drift
+	o.CharmPerYear += o.Gamma * drift
+	o.ColorPerYear += o.Speed * drift
+	return o.withUnits(thetaBasis)
+}
+
+// haugHaugVol is the vol of the escrowed spot that matches the variance of
+// the stock at vol sigma, interval by interval between ex-dates.
+func haugHaugVol(in Inputs) float64 {
+	divs := make([]CashDividend, 0, len(in.Dividends))
+	for _, d := range in.Dividends {
+		if d.Time <= in.T {
+			divs = append(divs, d)
+		}
+	}
+	sort.Slice(divs, func(i, j int) bool { return divs[i].Time < divs[j].Time })
+	variance, prev := 0.0, 0.0
+	for i, d := range divs {
+		pv := 0.0
+		for _, later := range divs[i:] {
+			pv += later.Amount * math.Exp(-in.R*later.Time)
+		}
+		s := in.Sigma * in.S0 / (in.S0 - pv)
+		variance += s * s * (d.Time - prev)
+		prev = d.Time
+	}
+	variance += in.Sigma * in.Sigma * (in.T - prev)
+	return math.Sqrt(variance / in.T)
+}
+
+// priceHaugHaug prices on the escrowed spot at the adjusted vol. The vol
+// depends on the spot and rate, so the Greeks are bumped; theta ages the
+// dividend dates with the option.
+func priceHaugHaug(in Inputs, thetaBasis int) Outputs {
+	if in.T == 0 || in.Sigma == 0 {
+		return priceEscrowed(in, thetaBasis)
+	}
+	return bumpedGreeks(in, thetaBasis, func(x Inputs) float64 {
+		elapsed := in.T - x.T
+		x.Dividends = make([]CashDividend, 0, len(in.Dividends))
+		for _, d := range in.Dividends {
+			if d.Time-elapsed > 0 {
+				x.Dividends = append(x.Dividends, CashDividend{d.Time - elapsed, d.Amount})
+			}
+		}
+		x.Sigma = haugHaugVol(x)
+		x.S0 -= dividendPV(x, 0)
+		x.Dividends = nil
+		return priceAndGreeks(x, thetaBasis).Price
+	}, 1e-3*in.S0)
+}
+
+// noDividends rejects discrete dividends in pricers that do not model them.
+func noDividends(in Inputs, pricer string) error {
+	if len(in.Dividends) > 0 {
+		return fmt.Errorf("%w: %s does not support discrete dividends", ErrDividends, pricer)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package bsm
+
+import (
+	"errors"
+	"testing"
+)
+
+// PriceEdgeworth checks the contract before the moments, so a bad contract
+// is reported even with an invalid density.
+func TestPriceEdgeworthValidates(t *testing.T) {
+	in := Inputs{S0: 100, K: 105, T: 0.5, Sigma: 0.2, R: 0.03, OptType: Put}
+	dist := EdgeworthFromBSM(in, 0, 3)
+	if _, err := PriceEdgeworth(in, dist); err != nil {
+		t.Fatal(err)
+	}
+	in.K = -1
+	if _, err := PriceEdgeworth(in, dist); !errors.Is(err, ErrNegativeStrike) {
+		t.Errorf("negative strike: got %v, want ErrNegativeStrike", err)
+	}
+	in.K, in.T = 105, -1
+	dist.Kurt = 2
+	if _, err := PriceEdgeworth(in, dist); !errors.Is(err, ErrExpired) {
+		t.Errorf("expired with a bad density: got %v, want ErrExpired", err)
+	}
+}
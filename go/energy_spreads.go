@@ -0,0 +1,139 @@
+package bsm
+
+import "fmt"
+
+// EnergyUnit is a unit that energy prices are quoted per.
+type EnergyUnit string
+
+const (
+	MMBtu  EnergyUnit = "MMBtu"
+	Therm  EnergyUnit = "therm"
+	GJ     EnergyUnit = "GJ"
+	MWh    EnergyUnit = "MWh"
+	Barrel EnergyUnit = "bbl"
+	Gallon EnergyUnit = "gal"
+	Tonne  EnergyUnit = "t"
+)
+
+const GallonsPerBarrel = 42.0
+
+// Size of each unit in a base unit of its dimension (MMBtu for energy,
+// barrels for volume, tonnes for mass).
+var energyUnits = map[EnergyUnit]struct {
+	dimension string
+	size      float64
+}{
+	MMBtu:  {"energy", 1},
+	Therm:  {"energy", 0.1},
+	GJ:     {"energy", 1 / 1.055056},
+	MWh:    {"energy", 3.412142},
+	Barrel: {"volume", 1},
+	Gallon: {"volume", 1 / GallonsPerBarrel},
+	Tonne:  {"mass", 1},
+}
+
+// ConvertEnergyPrice converts a price per unit from into a price per unit to.
+// Only conversions within one dimension are supported: converting barrels to
+// MMBtu depends on the product's heat content and must be done by the caller.
+func ConvertEnergyPrice(price float64, from, to EnergyUnit) (float64, error) {
+	f, ok := energyUnits[from]
+	if !ok {
+		return 0, fmt.Errorf("unknown energy unit %q", from)
+	}
+	t, ok := energyUnits[to]
+	if !ok {
+		return 0, fmt.Errorf("unknown energy unit %q", to)
+	}
+	if f.dimension != t.dimension {
+		return 0, fmt.Errorf("cannot convert %s prices to %s prices", from, to)
+	}
+	return price * t.size / f.size, nil
+}
+
+// EnergySpread defines a two-leg margin in spread units:
+//
+//	LongRatio*F1 - ShortRatio*F2
+//
+// with the ratios expressed in LongUnit/ShortUnit and the forwards quoted per
+// LongQuote/ShortQuote.
+type EnergySpread struct {
+	Name       string
+	LongRatio  float64
+	LongUnit   EnergyUnit
+	LongQuote  EnergyUnit
+	ShortRatio float64
+	ShortUnit  EnergyUnit
+	ShortQuote EnergyUnit
+}
+
+// SparkSpread is power ($/MWh) less gas burnt at heatRate MMBtu/MWh, with
+// gas quoted per gasQuote (MMBtu, therm or GJ).
+func SparkSpread(heatRate float64, gasQuote EnergyUnit) EnergySpread {
+	return EnergySpread{
+		Name:       fmt.Sprintf("spark %.2f MMBtu/MWh", heatRate),
+		LongRatio:  1,
+		LongUnit:   MWh,
+		LongQuote:  MWh,
+		ShortRatio: heatRate,
+		ShortUnit:  MMBtu,
+		ShortQuote: gasQuote,
+	}
+}
+
+// CrackSpread is productBarrels of a refined product less crudeBarrels of
+// crude, per barrel of crude, e.g. CrackSpread(1, 1, Gallon) for a gasoline
+// crack with RBOB quoted in $/gal.
+func CrackSpread(productBarrels, crudeBarrels float64, productQuote EnergyUnit) EnergySpread {
+	return EnergySpread{
+		Name:       fmt.Sprintf("crack %g:%g", productBarrels, crudeBarrels),
+		LongRatio:  productBarrels / crudeBarrels,
+		LongUnit:   Barrel,
+		LongQuote:  productQuote,
+		ShortRatio: 1,
+		ShortUnit:  Barrel,
+		ShortQuote: Barrel,
+	}
+}
+
+// legs returns the multipliers taking quoted forwards to spread units. A
+// quantity converts inversely to a price, hence the swapped from/to.
+func (s EnergySpread) legs() (long, short float64, err error) {
+	long, err = ConvertEnergyPrice(s.LongRatio, s.LongQuote, s.LongUnit)
+	if err != nil {
+		return 0, 0, err
+	}
+	short, err = ConvertEnergyPrice(s.ShortRatio, s.ShortQuote, s.ShortUnit)
+	return long, short, err
+}
+
+// SpreadValue returns the intrinsic margin for quoted forwards f1, f2.
+func (s EnergySpread) SpreadValue(f1, f2 float64) (float64, error) {
+	a, b, err := s.legs()
+	if err != nil {
+		return 0, err
+	}
+	return a*f1 - b*f2, nil
+}
+
+// PriceEnergySpread prices an option on the spread with PriceSpread. in.F1
+// and in.F2 are the quoted forwards and in.K is the strike in spread units;
+// deltas and gammas are per unit of quoted forward.
+func PriceEnergySpread(spread EnergySpread, in SpreadInputs) (SpreadOutputs, error) {
+	a, b, err := spread.legs()
+	if err != nil {
+		return SpreadOutputs{}, err
+	}
+	scaled := in
+	scaled.F1 *= a
+	scaled.F2 *= b
+	out, err := PriceSpread(scaled)
+	if err != nil {
+		return SpreadOutputs{}, err
+	}
+	out.Delta1 *= a
+	out.Delta2 *= b
+	out.Gamma1 *= a * a
+	out.Gamma2 *= b * b
+	out.CrossGamma *= a * b
+	return out, nil
+}
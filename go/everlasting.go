@@ -0,0 +1,67 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+)
+
+// EverlastingInputs describes an everlasting option: no expiry, but every
+// FundingPeriod the long pays (mark - payoff) * FundingPeriod/FundingHorizon
+// to the short. Inputs.T is ignored.
+type EverlastingInputs struct {
+	Inputs
+	FundingPeriod  float64 // Years between funding payments
+	FundingHorizon float64 // Funding normalisation period in years; one 24h day if zero
+	Tolerance      float64 // Series truncation on remaining weight; 1e-10 if zero
+}
+
+type EverlastingOutputs struct {
+	Outputs
+	Terms           int     // Fixed-expiry options summed
+	ExpectedLife    float64 // Weighted mean expiry of the strip (years)
+	TruncatedWeight float64 // Weight left out of the series
+}
+
+const everlastingMaxTerms = 1000000
+
+// PriceEverlasting prices an everlasting option as a strip of fixed-expiry
+// BSM options. With a = FundingPeriod/FundingHorizon the option replicates
+//
+//	sum_{i>=1} a/(1+a)^i * V(T = i*FundingPeriod)
+//
+// (for daily funding normalised per day: 1/2 of the 1-day option, 1/4 of the
+// 2-day option, ...). Greeks are the same weighted sums; theta is that of the
+// strip, which a perpetual pays away as funding rather than through ageing.
+func PriceEverlasting(in EverlastingInputs, thetaBasis int) (EverlastingOutputs, error) {
+	if in.FundingPeriod <= 0 {
+		return EverlastingOutputs{}, errors.New("everlasting option needs a positive funding period")
+	}
+	if err := in.Inputs.Validate(); err != nil {
+		return EverlastingOutputs{}, err
+	}
+	horizon := in.FundingHorizon
+	if horizon == 0 {
+		horizon = 1 / cryptoDaysPerYear
+	}
+	tol := in.Tolerance
+	if tol == 0 {
+		tol = 1e-10
+	}
+	a := in.FundingPeriod / horizon
+	ratio := 1 / (1 + a)
+
+	var out EverlastingOutputs
+	remaining := 1.0
+	w := a * ratio
+	leg := in.Inputs
+	for i := 1; remaining > tol && i <= everlastingMaxTerms; i++ {
+		leg.T = float64(i) * in.FundingPeriod
+		out.Outputs = out.Outputs.add(priceAndGreeks(leg, thetaBasis).scale(w))
+		out.ExpectedLife += w * leg.T
+		out.Terms = i
+		remaining -= w
+		w *= ratio
+	}
+	out.TruncatedWeight = math.Max(remaining, 0)
+	return out, nil
+}
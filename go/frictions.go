@@ -0,0 +1,101 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Frictions describe how an option is hedged, for PriceWithFrictions.
+// Zero values take the defaults in parentheses; the fields mean what they
+// do in HedgeOptions, so SimulateDeltaHedge can check the result.
+type Frictions struct {
+	CostRate   float64 // Transaction cost as a fraction of the value of shares traded, each way
+	Rebalances int     // Evenly spaced hedge trades over the option's life (daily, 252 a year)
+	Side       Side    // Option position (Long)
+}
+
+// FrictionOutputs are the price and Greeks under hedging frictions.
+type FrictionOutputs struct {
+	Outputs
+	AdjustedVol float64 // Leland's vol, at which the option is priced
+	Leland      float64 // Leland number sqrt(8/pi) CostRate / (sigma sqrt(dt))
+	// HedgeErrorStdDev is the standard deviation of the P&L from hedging
+	// discretely rather than continuously, without costs: Kamal and
+	// Derman's sqrt(pi/4) vega sigma / sqrt(Rebalances), to first order.
+	HedgeErrorStdDev float64
+}
+
+// PriceWithFrictions prices a European BSM option hedged at Rebalances
+// trades with proportional costs, using Leland's adjusted vol
+//
+//	sigmaHat^2 = sigma^2 (1 +/- Le),  Le = sqrt(8/pi) CostRate / (sigma sqrt(dt)),
+//
+// plus for a short option, which the hedger buys high and sells low, and
+// minus for a long one. Hedging with deltas at AdjustedVol then recovers
+// the rebalancing costs on average, though not the cost of putting the
+// first hedge on. The Greeks are BSM's at the adjusted vol, with
+// vega, vanna, volga and zomma taken through dSigmaHat/dSigma so they
+// stay sensitivities to the market vol.
+func PriceWithFrictions(in Inputs, f Frictions, opts ...Option) (FrictionOutputs, error) {
+	if err := in.Validate(); err != nil {
+		return FrictionOutputs{}, err
+	}
+	if err := noDividends(in, "frictions pricing"); err != nil {
+		return FrictionOutputs{}, err
+	}
+	if (in.Model != "" && in.Model != BSMModel) || in.Exercise == American {
+		return FrictionOutputs{}, errors.New("frictions pricing supports European BSM options only")
+	}
+	if in.T == 0 || in.Sigma == 0 {
+		return FrictionOutputs{}, errors.New("frictions pricing needs positive expiry and volatility")
+	}
+	if f.Rebalances == 0 {
+		f.Rebalances = int(math.Ceil(in.T * 252))
+	}
+	if f.Rebalances < 1 {
+		return FrictionOutputs{}, errors.New("need at least one rebalance")
+	}
+	if f.CostRate < 0 {
+		return FrictionOutputs{}, errors.New("transaction cost rate must be non-negative")
+	}
+	if err := (Position{Side: f.Side}).checkSide(); err != nil {
+		return FrictionOutputs{}, err
+	}
+	sign := 1.0 // On Le: a short hedger pays the costs as extra vol
+	if f.Side != Short {
+		sign = -1
+	}
+
+	dt := in.T / float64(f.Rebalances)
+	le := math.Sqrt(8/math.Pi) * f.CostRate / (in.Sigma * math.Sqrt(dt))
+	if 1+sign*le <= 0 {
+		return FrictionOutputs{}, fmt.Errorf("leland number %g is at least 1: costs outweigh the long option's vol; hedge less often", le)
+	}
+	// sigmaHat^2 = sigma^2 + sign c sigma for c = sqrt(8/pi) CostRate / sqrt(dt).
+	c := le * in.Sigma
+	x := in
+	x.Sigma = math.Sqrt(in.Sigma*in.Sigma + sign*c*in.Sigma)
+	out, err := Price(x, opts...)
+	if err != nil {
+		return FrictionOutputs{}, err
+	}
+	d1 := (2*in.Sigma + sign*c) / (2 * x.Sigma) // dSigmaHat/dSigma
+	d2 := (1 - d1*d1) / x.Sigma                 // d2SigmaHat/dSigma2
+	out.Volga = out.Volga*d1*d1 + out.VegaPerVol*d2
+	out.VegaPerVol *= d1
+	out.VegaPerVolPt *= d1
+	out.Vanna *= d1
+	out.Zomma *= d1
+
+	base, err := Price(in, opts...)
+	if err != nil {
+		return FrictionOutputs{}, err
+	}
+	return FrictionOutputs{
+		Outputs:          out,
+		AdjustedVol:      x.Sigma,
+		Leland:           le,
+		HedgeErrorStdDev: math.Sqrt(math.Pi/4) * base.VegaPerVol * in.Sigma / math.Sqrt(float64(f.Rebalances)),
+	}, nil
+}
@@ -0,0 +1,91 @@
+package bsm
+
+import (
+	"fmt"
+	"math"
+)
+
+// FXOptionInputs describes a Garman-Kohlhagen option on a currency pair
+// FOR/DOM quoted as domestic units per foreign unit (EURUSD: EUR foreign,
+// USD domestic). A call is the right to buy the foreign currency.
+type FXOptionInputs struct {
+	Spot    float64    // Domestic per foreign
+	K       float64    // Strike, domestic per foreign
+	T       float64    // Time to expiry (years)
+	Sigma   float64    // Vol of the pair
+	Rd      float64    // Domestic rate (cont. comp.)
+	Rf      float64    // Foreign rate (cont. comp.)
+	OptType OptionType // Call or Put on the foreign currency
+}
+
+// FXDeltaConvention is how a broker quotes delta.
+type FXDeltaConvention string
+
+const (
+	SpotDelta      FXDeltaConvention = "spot"
+	ForwardDelta   FXDeltaConvention = "forward"
+	SpotDeltaPA    FXDeltaConvention = "spot-pa"    // Premium-adjusted
+	ForwardDeltaPA FXDeltaConvention = "forward-pa" // Premium-adjusted
+)
+
+// FXOptionOutputs are the BSM outputs per unit of foreign notional in
+// domestic currency (Price is the premium in domestic pips), the premium
+// in the other quoting styles, and delta in each market convention.
+// Premium-adjusted deltas apply when the premium is paid in the foreign
+// currency, as for USDJPY with the premium in USD.
+type FXOptionOutputs struct {
+	Outputs
+	Forward float64
+
+	DomesticPips float64 // Domestic per unit of foreign notional (= Price)
+	ForeignPct   float64 // Foreign per unit of foreign notional
+	DomesticPct  float64 // Domestic per unit of domestic notional
+	ForeignPips  float64 // Foreign per unit of domestic notional
+
+	SpotDelta      float64 // Foreign units to trade at spot per unit of foreign notional (= Delta)
+	ForwardDelta   float64 // Foreign units to trade forward
+	SpotDeltaPA    float64 // Spot delta less the foreign-currency premium
+	ForwardDeltaPA float64 // Forward delta less the foreign-currency premium
+}
+
+// PriceFXOption prices an FX option with Garman-Kohlhagen: BSM with the
+// foreign rate as the dividend yield.
+func PriceFXOption(in FXOptionInputs, thetaBasis int) (FXOptionOutputs, error) {
+	bsmIn := Inputs{S0: in.Spot, K: in.K, T: in.T, Sigma: in.Sigma, R: in.Rd, Q: in.Rf, OptType: in.OptType}
+	if err := bsmIn.Validate(); err != nil {
+		return FXOptionOutputs{}, err
+	}
+	if thetaBasis <= 0 {
+		return FXOptionOutputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	out := priceAndGreeks(bsmIn, thetaBasis)
+	growth := math.Exp(in.Rf * in.T) // Spot delta to forward delta
+	pa := out.Price / in.Spot
+	return FXOptionOutputs{
+		Outputs:        out,
+		Forward:        in.Spot * math.Exp((in.Rd-in.Rf)*in.T),
+		DomesticPips:   out.Price,
+		ForeignPct:     out.Price / in.Spot,
+		DomesticPct:    out.Price / in.K,
+		ForeignPips:    out.Price / (in.Spot * in.K),
+		SpotDelta:      out.Delta,
+		ForwardDelta:   out.Delta * growth,
+		SpotDeltaPA:    out.Delta - pa,
+		ForwardDeltaPA: (out.Delta - pa) * growth,
+	}, nil
+}
+
+// QuotedDelta returns the delta in the given convention.
+func (o FXOptionOutputs) QuotedDelta(conv FXDeltaConvention) (float64, error) {
+	switch conv {
+	case SpotDelta:
+		return o.SpotDelta, nil
+	case ForwardDelta:
+		return o.ForwardDelta, nil
+	case SpotDeltaPA:
+		return o.SpotDeltaPA, nil
+	case ForwardDeltaPA:
+		return o.ForwardDeltaPA, nil
+	}
+	return 0, fmt.Errorf("unknown delta convention %q", conv)
+}
@@ -0,0 +1,3 @@
+module github.com/ag-enzo/black-scholes-greeks-multilang/go
+
+go 1.24
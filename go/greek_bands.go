@@ -0,0 +1,148 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// GreekBand is a value at the mid marks with its range over the bid/ask
+// marks.
+type GreekBand struct {
+	Low, Mid, High float64
+}
+
+// Width returns High - Low.
+func (b GreekBand) Width() float64 { return b.High - b.Low }
+
+func (b GreekBand) scaled(k float64) GreekBand {
+	lo, hi := k*b.Low, k*b.High
+	if k < 0 {
+		lo, hi = hi, lo
+	}
+	return GreekBand{lo, k * b.Mid, hi}
+}
+
+func (b GreekBand) add(o GreekBand) GreekBand {
+	return GreekBand{b.Low + o.Low, b.Mid + o.Mid, b.High + o.High}
+}
+
+// GreekBands reports each Greek as a range. Vega is per vol point and
+// theta per day.
+type GreekBands struct {
+	Price, Delta, Gamma, Vega, Theta, Rho GreekBand
+}
+
+// Scaled multiplies the bands by k, e.g. a signed position size; a
+// negative k swaps each band's ends.
+func (g GreekBands) Scaled(k float64) GreekBands {
+	return GreekBands{
+		g.Price.scaled(k), g.Delta.scaled(k), g.Gamma.scaled(k),
+		g.Vega.scaled(k), g.Theta.scaled(k), g.Rho.scaled(k),
+	}
+}
+
+// Add sums two sets of bands. Adding the ends treats every position's
+// marks as able to sit at their extremes together, so the total is
+// conservative.
+func (g GreekBands) Add(o GreekBands) GreekBands {
+	return GreekBands{
+		g.Price.add(o.Price), g.Delta.add(o.Delta), g.Gamma.add(o.Gamma),
+		g.Vega.add(o.Vega), g.Theta.add(o.Theta), g.Rho.add(o.Rho),
+	}
+}
+
+// SpotQuote is a bid/ask on the underlying. A zero value means use the
+// inputs' spot only.
+type SpotQuote struct {
+	Bid, Ask float64
+}
+
+// bandVolPoints is the number of vols sampled across the bid/ask range;
+// interior points catch Greeks that peak inside it, like vega.
+const bandVolPoints = 5
+
+// BSMGreekBands evaluates the Greeks of in, priced as Price does, over
+// vols from vols.Bid to vols.Ask and, if spot is set, spots from its bid
+// to ask. Mid uses vols.Mid and in.S0; Low and High are the extremes over
+// the grid.
+func BSMGreekBands(in Inputs, vols IVQuote, spot SpotQuote, thetaBasis int) (GreekBands, error) {
+	if thetaBasis <= 0 {
+		return GreekBands{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	o := resolveOptions(nil)
+	o.thetaBasis = thetaBasis
+	return greekBands(in, vols, spot, o)
+}
+
+func greekBands(in Inputs, vols IVQuote, spot SpotQuote, o priceOptions) (GreekBands, error) {
+	in.Sigma = vols.Mid
+	mid, err := priceWith(in, o)
+	if err != nil {
+		return GreekBands{}, err
+	}
+	g := GreekBands{
+		Price: GreekBand{mid.Price, mid.Price, mid.Price},
+		Delta: GreekBand{mid.Delta, mid.Delta, mid.Delta},
+		Gamma: GreekBand{mid.Gamma, mid.Gamma, mid.Gamma},
+		Vega:  GreekBand{mid.VegaPerVolPt, mid.VegaPerVolPt, mid.VegaPerVolPt},
+		Theta: GreekBand{mid.ThetaPerDay, mid.ThetaPerDay, mid.ThetaPerDay},
+		Rho:   GreekBand{mid.RhoPer1, mid.RhoPer1, mid.RhoPer1},
+	}
+	spots := []float64{in.S0}
+	if spot.Bid > 0 && spot.Ask >= spot.Bid {
+		spots = append(spots, spot.Bid, spot.Ask)
+	}
+	widen := func(b *GreekBand, v float64) {
+		b.Low, b.High = math.Min(b.Low, v), math.Max(b.High, v)
+	}
+	for _, s := range spots {
+		for i := 0; i < bandVolPoints; i++ {
+			in.S0 = s
+			in.Sigma = vols.Bid + (vols.Ask-vols.Bid)*float64(i)/float64(bandVolPoints-1)
+			out, err := priceWith(in, o)
+			if err != nil {
+				return GreekBands{}, fmt.Errorf("spot %g, vol %g: %w", in.S0, in.Sigma, err)
+			}
+			widen(&g.Price, out.Price)
+			widen(&g.Delta, out.Delta)
+			widen(&g.Gamma, out.Gamma)
+			widen(&g.Vega, out.VegaPerVolPt)
+			widen(&g.Theta, out.ThetaPerDay)
+			widen(&g.Rho, out.RhoPer1)
+		}
+	}
+	return g, nil
+}
+
+// GreekBands returns the Greek bands of an option at (K, T) using the
+// surface's bid/ask vols, on its forward at T as Greeks prices.
+func (v *VolSurface) GreekBands(K, T float64, optType OptionType, spot SpotQuote, thetaBasis int) (GreekBands, error) {
+	in := v.inputs(K, T, optType)
+	return BSMGreekBands(in, v.VolQuote(K, T), spot, thetaBasis)
+}
+
+// GreekBands sums the position bands of a book on one underlying, with
+// vols[i] the bid/mid/ask vol for position i. Amounts are in the
+// positions' premium currency, so they should share one.
+func (p *Portfolio) GreekBands(vols []IVQuote, spot SpotQuote) (GreekBands, error) {
+	if len(vols) != len(p.Positions) {
+		return GreekBands{}, errors.New("need one vol quote per position")
+	}
+	o, err := p.pricing()
+	if err != nil {
+		return GreekBands{}, err
+	}
+	var total GreekBands
+	for i, pos := range p.Positions {
+		if err := pos.checkSide(); err != nil {
+			return GreekBands{}, err
+		}
+		g, err := greekBands(pos.Inputs, vols[i], spot, o)
+		if err != nil {
+			return GreekBands{}, fmt.Errorf("position %s: %w", pos.ID, err)
+		}
+		total = total.Add(g.Scaled(pos.units()))
+	}
+	return total, nil
+}
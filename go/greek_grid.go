@@ -0,0 +1,90 @@
+package bsm
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+)
+
+// GridRange is N evenly spaced points from Min to Max inclusive.
+type GridRange struct {
+	Min, Max float64
+	N        int
+}
+
+// Points returns the range's points; one point is Min.
+func (r GridRange) Points() []float64 {
+	pts := make([]float64, r.N)
+	for i := range pts {
+		pts[i] = r.Min
+		if r.N > 1 {
+			pts[i] += (r.Max - r.Min) * float64(i) / float64(r.N-1)
+		}
+	}
+	return pts
+}
+
+// GreekGrid is one output of Price over spots and times to expiry, for
+// plotting as a surface.
+type GreekGrid struct {
+	Greek  string      `json:"greek"`
+	Spots  []float64   `json:"spots"`
+	Times  []float64   `json:"times"`  // Years to expiry
+	Values [][]float64 `json:"values"` // Values[i][j] at Times[i] and Spots[j]
+}
+
+// Surface evaluates the Outputs field named greek ("Price", "Gamma",
+// "VegaPerVolPt", ...; the CLI's JSON and CSV names) over a grid of spot
+// and time to expiry, with the rest of in held fixed.
+func Surface(in Inputs, spots, times GridRange, greek string, opts ...Option) (*GreekGrid, error) {
+	f, ok := reflect.TypeOf(Outputs{}).FieldByName(greek)
+	if !ok || f.Type.Kind() != reflect.Float64 {
+		return nil, fmt.Errorf("no output named %q", greek)
+	}
+	if spots.N < 1 || times.N < 1 {
+		return nil, fmt.Errorf("grid needs at least one spot and time, got %d by %d", spots.N, times.N)
+	}
+	g := &GreekGrid{Greek: greek, Spots: spots.Points(), Times: times.Points()}
+	g.Values = make([][]float64, len(g.Times))
+	for i, T := range g.Times {
+		g.Values[i] = make([]float64, len(g.Spots))
+		for j, S := range g.Spots {
+			x := in
+			x.S0, x.T = S, T
+			out, err := Price(x, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("at S=%g T=%g: %w", S, T, err)
+			}
+			g.Values[i][j] = reflect.ValueOf(out).FieldByIndex(f.Index).Float()
+		}
+	}
+	return g, nil
+}
+
+// WriteCSV writes the grid in long form, one "T,S,<greek>" row per point,
+// which plotting tools read directly.
+func (g *GreekGrid) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"T", "S", g.Greek})
+	for i, T := range g.Times {
+		for j, S := range g.Spots {
+			cw.Write([]string{
+				strconv.FormatFloat(T, 'g', -1, 64),
+				strconv.FormatFloat(S, 'g', -1, 64),
+				strconv.FormatFloat(g.Values[i][j], 'g', -1, 64),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the grid as indented JSON.
+func (g *GreekGrid) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
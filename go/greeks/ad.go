@@ -0,0 +1,251 @@
+package greeks
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// HyperDual is V + E1 e1 + E2 e2 + E12 e1e2 with e1^2 = e2^2 = 0. Evaluating
+// f at x + e1 + e2 gives f(x) in V, the first derivative in E1 and E2 and
+// the second in E12, exactly and with no bump size to choose; seeding e1 and e2 on different
+// inputs gives a cross derivative.
+type HyperDual struct {
+	V, E1, E2, E12 float64
+}
+
+// Const is a constant: all its derivatives are zero.
+func Const(v float64) HyperDual { return HyperDual{V: v} }
+
+func (x HyperDual) Add(y HyperDual) HyperDual {
+	return HyperDual{x.V + y.V, x.E1 + y.E1, x.E2 + y.E2, x.E12 + y.E12}
+}
+
+func (x HyperDual) Sub(y HyperDual) HyperDual {
+	return HyperDual{x.V - y.V, x.E1 - y.E1, x.E2 - y.E2, x.E12 - y.E12}
+}
+
+func (x HyperDual) Mul(y HyperDual) HyperDual {
+	return HyperDual{x.V * y.V, x.E1*y.V + x.V*y.E1, x.E2*y.V + x.V*y.E2,
+		x.E12*y.V + x.E1*y.E2 + x.E2*y.E1 + x.V*y.E12}
+}
+
+func (x HyperDual) Div(y HyperDual) HyperDual {
+	return x.Mul(chain(y, 1/y.V, -1/(y.V*y.V), 2/(y.V*y.V*y.V)))
+}
+
+// Scale multiplies by a constant.
+func (x HyperDual) Scale(c float64) HyperDual {
+	return HyperDual{x.V * c, x.E1 * c, x.E2 * c, x.E12 * c}
+}
+
+// Neg is -x.
+func (x HyperDual) Neg() HyperDual { return x.Scale(-1) }
+
+// chain applies a scalar function with value f, first derivative f1 and
+// second derivative f2 at x.V.
+func chain(x HyperDual, f, f1, f2 float64) HyperDual {
+	return HyperDual{f, f1 * x.E1, f1 * x.E2, f1*x.E12 + f2*x.E1*x.E2}
+}
+
+func Exp(x HyperDual) HyperDual {
+	e := math.Exp(x.V)
+	return chain(x, e, e, e)
+}
+
+func Log(x HyperDual) HyperDual {
+	return chain(x, math.Log(x.V), 1/x.V, -1/(x.V*x.V))
+}
+
+func Sqrt(x HyperDual) HyperDual {
+	s := math.Sqrt(x.V)
+	return chain(x, s, 0.5/s, -0.25/(s*x.V))
+}
+
+// NormPDF is the standard normal density.
+func NormPDF(x HyperDual) HyperDual {
+	n := math.Exp(-0.5*x.V*x.V) / math.Sqrt(2*math.Pi)
+	return chain(x, n, -x.V*n, (x.V*x.V-1)*n)
+}
+
+// NormCDF is the standard normal distribution function.
+func NormCDF(x HyperDual) HyperDual {
+	n := math.Exp(-0.5*x.V*x.V) / math.Sqrt(2*math.Pi)
+	return chain(x, 0.5*math.Erfc(-x.V/math.Sqrt2), n, -x.V*n)
+}
+
+// Max is the larger of x and y by value, with its derivatives: the
+// derivative of a payoff or an exercise decision away from the kink.
+func Max(x, y HyperDual) HyperDual {
+	if y.V > x.V {
+		return y
+	}
+	return x
+}
+
+// ADInputs are bsm.Inputs with the continuous inputs as hyper-duals.
+type ADInputs struct {
+	S0, K, T, Sigma, R, Q HyperDual
+	OptType               bsm.OptionType
+	Exercise              bsm.ExerciseStyle
+}
+
+// ADPricer is a pricer written in hyper-dual arithmetic. Any model coded
+// this way gets exact first and second derivatives from AD.
+type ADPricer func(ADInputs) (HyperDual, error)
+
+// ADResult holds the Greeks from AD in the units of Result, plus the
+// second-order Greeks: vanna and volga per 1.00 of vol, and charm, the
+// change in delta per year of time passing.
+type ADResult struct {
+	Result
+	Vanna float64
+	Volga float64
+	Charm float64
+}
+
+// AD differentiates pricer at inputs, evaluating it once per derivative
+// pair: six evaluations in all.
+func AD(pricer ADPricer, inputs bsm.Inputs) (ADResult, error) {
+	if err := inputs.Validate(); err != nil {
+		return ADResult{}, err
+	}
+	const (
+		spot = iota
+		vol
+		rate
+		yield
+		expiry
+	)
+	// eval seeds e1 on input i and e2 on input j (-1 for none).
+	eval := func(i, j int) (HyperDual, error) {
+		vals := []float64{inputs.S0, inputs.Sigma, inputs.R, inputs.Q, inputs.T}
+		x := make([]HyperDual, len(vals))
+		for k, v := range vals {
+			x[k] = Const(v)
+			if k == i {
+				x[k].E1 = 1
+			}
+			if k == j {
+				x[k].E2 = 1
+			}
+		}
+		v, err := pricer(ADInputs{S0: x[spot], K: Const(inputs.K), T: x[expiry], Sigma: x[vol], R: x[rate], Q: x[yield],
+			OptType: inputs.OptType, Exercise: inputs.Exercise})
+		if err != nil {
+			return HyperDual{}, err
+		}
+		if math.IsNaN(v.V) || math.IsNaN(v.E1) || math.IsNaN(v.E2) || math.IsNaN(v.E12) {
+			return HyperDual{}, fmt.Errorf("pricer returned NaN derivatives at %+v", inputs)
+		}
+		return v, nil
+	}
+	var res ADResult
+	ss, err := eval(spot, spot)
+	if err != nil {
+		return ADResult{}, err
+	}
+	vv, err := eval(vol, vol)
+	if err != nil {
+		return ADResult{}, err
+	}
+	sv, err := eval(spot, vol)
+	if err != nil {
+		return ADResult{}, err
+	}
+	st, err := eval(spot, expiry)
+	if err != nil {
+		return ADResult{}, err
+	}
+	r, err := eval(rate, -1)
+	if err != nil {
+		return ADResult{}, err
+	}
+	q, err := eval(yield, -1)
+	if err != nil {
+		return ADResult{}, err
+	}
+	res.Price, res.Delta, res.Gamma = ss.V, ss.E1, ss.E12
+	res.Vega, res.Volga, res.Vanna = vv.E1, vv.E12, sv.E12
+	res.Theta, res.Charm = -st.E2, -st.E12 // Time passing shortens expiry
+	res.Rho, res.Phi = r.E1, q.E1
+	return res, nil
+}
+
+// BSMAD is the Black-Scholes-Merton formula for European options as an
+// ADPricer.
+func BSMAD(x ADInputs) (HyperDual, error) {
+	if x.Exercise == bsm.American {
+		return HyperDual{}, errors.New("BSMAD prices European options only")
+	}
+	if x.T.V <= 0 || x.Sigma.V <= 0 {
+		return HyperDual{}, errors.New("BSMAD needs positive expiry and volatility")
+	}
+	sd := x.Sigma.Mul(Sqrt(x.T))
+	fwd := x.S0.Mul(Exp(x.R.Sub(x.Q).Mul(x.T)))
+	d1 := Log(fwd.Div(x.K)).Div(sd).Add(sd.Scale(0.5))
+	d2 := d1.Sub(sd)
+	df := Exp(x.R.Mul(x.T).Neg())
+	phi := 1.0
+	if x.OptType == bsm.Put {
+		phi = -1
+	}
+	// phi DF (F N(phi d1) - K N(phi d2))
+	return df.Mul(fwd.Mul(NormCDF(d1.Scale(phi))).Sub(x.K.Mul(NormCDF(d2.Scale(phi))))).Scale(phi), nil
+}
+
+// TreeAD is a Cox-Ross-Rubinstein binomial tree with the given number of
+// steps as an ADPricer, European or American. Its derivatives are those of
+// the tree's price at fixed steps, with no bumping noise. That price is
+// piecewise linear in the spot, so Gamma is zero and Delta jumps as nodes
+// cross the strike: take those from the tree's own nodes (bsm.PriceTree)
+// and vega, rho, phi and theta from here.
+func TreeAD(steps int) ADPricer {
+	return func(x ADInputs) (HyperDual, error) {
+		if steps < 1 {
+			return HyperDual{}, errors.New("tree needs at least one step")
+		}
+		if x.T.V <= 0 || x.Sigma.V <= 0 {
+			return HyperDual{}, errors.New("TreeAD needs positive expiry and volatility")
+		}
+		dt := x.T.Scale(1 / float64(steps))
+		u := Exp(x.Sigma.Mul(Sqrt(dt)))
+		d := Const(1).Div(u)
+		growth := Exp(x.R.Sub(x.Q).Mul(dt))
+		p := growth.Sub(d).Div(u.Sub(d))
+		disc := Exp(x.R.Mul(dt).Neg())
+		pu, pd := disc.Mul(p), disc.Mul(Const(1).Sub(p))
+		phi := 1.0
+		if x.OptType == bsm.Put {
+			phi = -1
+		}
+		exercise := func(s HyperDual) HyperDual { return Max(s.Sub(x.K).Scale(phi), Const(0)) }
+
+		// spots[j] is S u^j d^(n-j) at the current layer.
+		spots := make([]HyperDual, steps+1)
+		values := make([]HyperDual, steps+1)
+		spots[0] = x.S0
+		for i := 0; i < steps; i++ {
+			spots[0] = spots[0].Mul(d)
+		}
+		ratio := u.Mul(u)
+		for j := range spots {
+			if j > 0 {
+				spots[j] = spots[j-1].Mul(ratio)
+			}
+			values[j] = exercise(spots[j])
+		}
+		for n := steps - 1; n >= 0; n-- {
+			for j := 0; j <= n; j++ {
+				spots[j] = spots[j].Mul(u) // One layer back: S u^j d^(n-j)
+				values[j] = pu.Mul(values[j+1]).Add(pd.Mul(values[j]))
+				if x.Exercise == bsm.American {
+					values[j] = Max(values[j], exercise(spots[j]))
+				}
+			}
+		}
+		return values[0], nil
+	}
+}
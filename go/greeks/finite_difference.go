@@ -0,0 +1,157 @@
+// Package greeks computes option Greeks numerically by bumping the inputs of
+// any pricer and repricing. It cross-checks the analytic Greeks in package
+// bsm and supplies Greeks for engines without closed forms (trees, PDE,
+// Monte Carlo with common random numbers).
+package greeks
+
+import (
+	"fmt"
+	"math"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// Pricer returns the value of an option.
+type Pricer func(bsm.Inputs) (float64, error)
+
+// PriceOf adapts bsm.Price with the given options to a Pricer.
+func PriceOf(opts ...bsm.Option) Pricer {
+	return func(in bsm.Inputs) (float64, error) {
+		out, err := bsm.Price(in, opts...)
+		return out.Price, err
+	}
+}
+
+// Scheme is the finite-difference stencil.
+type Scheme string
+
+const (
+	Central Scheme = "central" // Second-order accurate, two reprices per Greek
+	Forward Scheme = "forward" // First-order accurate, bumps up only
+)
+
+// Bumps sets the bump sizes and scheme. Zero fields get the defaults in
+// parentheses.
+type Bumps struct {
+	Scheme Scheme  // Central (default) or Forward
+	Spot   float64 // Relative spot bump (1e-3, i.e. 0.1% of spot)
+	Vol    float64 // Absolute vol bump (1e-4)
+	Rate   float64 // Absolute rate and dividend-yield bump (1e-4)
+	Time   float64 // Time step in years (1e-4)
+}
+
+// Result holds the numerical Greeks per unit: vega per 1.00 of vol, theta
+// per year of time passing, rho and phi per 1.00 of rate and yield. Phi
+// is zero for models without a dividend yield.
+type Result struct {
+	Price float64
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64
+	Rho   float64
+	Phi   float64
+}
+
+// FiniteDifference bumps inputs one at a time and reprices with pricer.
+// Central differences fall back to forward ones where the down bump would
+// leave the domain: vol below zero, expiry past today. Theta ages the option
+// by the time step, so it is the value lost as time passes.
+func FiniteDifference(pricer Pricer, inputs bsm.Inputs, bumps Bumps) (Result, error) {
+	if bumps.Scheme == "" {
+		bumps.Scheme = Central
+	}
+	if bumps.Scheme != Central && bumps.Scheme != Forward {
+		return Result{}, fmt.Errorf("unknown finite-difference scheme %q", bumps.Scheme)
+	}
+	bumps.Spot = orDefault(bumps.Spot, 1e-3)
+	bumps.Vol = orDefault(bumps.Vol, 1e-4)
+	bumps.Rate = orDefault(bumps.Rate, 1e-4)
+	bumps.Time = orDefault(bumps.Time, 1e-4)
+	if bumps.Spot < 0 || bumps.Vol < 0 || bumps.Rate < 0 || bumps.Time < 0 {
+		return Result{}, fmt.Errorf("bump sizes must be positive: %+v", bumps)
+	}
+
+	price, err := pricer(inputs)
+	if err != nil {
+		return Result{}, err
+	}
+	r := Result{Price: price}
+	reprice := func(name string, set func(*bsm.Inputs, float64), h float64) (float64, error) {
+		in := inputs
+		set(&in, h)
+		v, err := pricer(in)
+		if err != nil {
+			return 0, fmt.Errorf("%s bump: %w", name, err)
+		}
+		return v, nil
+	}
+	// first returns dV/dx, centrally unless central is false.
+	first := func(name string, set func(*bsm.Inputs, float64), h float64, central bool) (float64, error) {
+		up, err := reprice(name, set, h)
+		if err != nil {
+			return 0, err
+		}
+		if !central {
+			return (up - price) / h, nil
+		}
+		down, err := reprice(name, set, -h)
+		if err != nil {
+			return 0, err
+		}
+		return (up - down) / (2 * h), nil
+	}
+
+	spot := func(x *bsm.Inputs, h float64) { x.S0 += h }
+	hS := bumps.Spot * math.Abs(inputs.S0)
+	if hS == 0 {
+		hS = bumps.Spot
+	}
+	up, err := reprice("spot", spot, hS)
+	if err != nil {
+		return Result{}, err
+	}
+	if bumps.Scheme == Central {
+		down, err := reprice("spot", spot, -hS)
+		if err != nil {
+			return Result{}, err
+		}
+		r.Delta = (up - down) / (2 * hS)
+		r.Gamma = (up - 2*price + down) / (hS * hS)
+	} else {
+		up2, err := reprice("spot", spot, 2*hS)
+		if err != nil {
+			return Result{}, err
+		}
+		r.Delta = (up - price) / hS
+		r.Gamma = (up2 - 2*up + price) / (hS * hS)
+	}
+
+	central := bumps.Scheme == Central
+	if r.Vega, err = first("vol", func(x *bsm.Inputs, h float64) { x.Sigma += h }, bumps.Vol, central && inputs.Sigma > bumps.Vol); err != nil {
+		return Result{}, err
+	}
+	if r.Rho, err = first("rate", func(x *bsm.Inputs, h float64) { x.R += h }, bumps.Rate, central); err != nil {
+		return Result{}, err
+	}
+	if inputs.Model != bsm.Black76Model && inputs.Model != bsm.BachelierModel {
+		if r.Phi, err = first("yield", func(x *bsm.Inputs, h float64) { x.Q += h }, bumps.Rate, central); err != nil {
+			return Result{}, err
+		}
+	}
+	// Theta bumps expiry down, so time passing is the positive direction.
+	hT := math.Min(bumps.Time, inputs.T)
+	if hT > 0 {
+		if r.Theta, err = first("time", func(x *bsm.Inputs, h float64) { x.T -= h }, hT, central && inputs.T > hT); err != nil {
+			return Result{}, err
+		}
+	}
+	return r, nil
+}
+
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
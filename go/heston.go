@@ -0,0 +1,356 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// HestonParams are the Heston stochastic-volatility parameters.
+type HestonParams struct {
+	V0    float64 // Initial variance
+	Kappa float64 // Mean-reversion speed
+	Theta float64 // Long-run variance
+	Xi    float64 // Vol of variance
+	Rho   float64 // Spot/variance correlation
+}
+
+// Feller reports whether 2*kappa*theta >= xi^2, i.e. the variance process
+// stays strictly positive.
+func (p HestonParams) Feller() bool {
+	return 2*p.Kappa*p.Theta >= p.Xi*p.Xi
+}
+
+func (p HestonParams) validate() error {
+	if p.V0 < 0 || p.Kappa <= 0 || p.Theta < 0 || p.Xi <= 0 || math.Abs(p.Rho) >= 1 {
+		return fmt.Errorf("invalid Heston parameters %+v", p)
+	}
+	return nil
+}
+
+// cf is the characteristic function of ln(S_T/F) in the "little Heston
+// trap" form of Albrecher et al., which avoids the branch cut of the
+// complex logarithm.
+func (p HestonParams) cf(u complex128, T float64) complex128 {
+	iu := complex(0, 1) * u
+	xi2 := complex(p.Xi*p.Xi, 0)
+	b := complex(p.Kappa, 0) - complex(p.Rho*p.Xi, 0)*iu
+	d := cmplx.Sqrt(b*b + xi2*(iu+u*u))
+	g := (b - d) / (b + d)
+	e := cmplx.Exp(-d * complex(T, 0))
+	C := complex(p.Kappa*p.Theta/(p.Xi*p.Xi), 0) * ((b-d)*complex(T, 0) - 2*cmplx.Log((1-g*e)/(1-g)))
+	D := (b - d) / xi2 * (1 - e) / (1 - g*e)
+	return cmplx.Exp(C + D*complex(p.V0, 0))
+}
+
+// HestonInputs describes a European option under Heston.
+type HestonInputs struct {
+	S0, K, T, R, Q float64
+	OptType        OptionType
+	Params         HestonParams
+}
+
+// PriceHeston prices a European option under Heston with Lewis's formula.
+func PriceHeston(in HestonInputs) (float64, error) {
+	if in.S0 <= 0 || in.K <= 0 || in.T <= 0 {
+		return 0, errors.New("spot, strike and expiry must be positive")
+	}
+	if err := in.Params.validate(); err != nil {
+		return 0, err
+	}
+	if err := (Inputs{S0: in.S0, K: in.K, T: in.T, R: in.R, Q: in.Q, OptType: in.OptType}).Validate(); err != nil {
+		return 0, err
+	}
+	F := in.S0 * math.Exp((in.R-in.Q)*in.T)
+	prices := hestonSlicePrices(in.Params, F, in.T, math.Exp(-in.R*in.T), []float64{in.K}, []bool{in.OptType == Call})
+	return prices[0], nil
+}
+
+// PriceHestonGreeks prices like PriceHeston and adds Greeks by finite
+// differences. Vega is per 1.00 parallel shift of the instantaneous and
+// long-run vols, sqrt(V0) and sqrt(Theta); theta ages the option with the
+// parameters fixed. To fit the parameters to a smile, calibrate a
+// one-slice VolSurface with CalibrateHeston.
+func PriceHestonGreeks(in HestonInputs, thetaBasis int) (Outputs, error) {
+	if _, err := PriceHeston(in); err != nil {
+		return Outputs{}, err
+	}
+	if thetaBasis <= 0 {
+		return Outputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	v0, vTheta := math.Sqrt(in.Params.V0), math.Sqrt(in.Params.Theta)
+	base := Inputs{S0: in.S0, K: in.K, T: in.T, R: in.R, Q: in.Q, OptType: in.OptType}
+	return bumpedGreeks(base, thetaBasis, func(x Inputs) float64 {
+		p := in.Params
+		p.V0, p.Theta = (v0+x.Sigma)*(v0+x.Sigma), (vTheta+x.Sigma)*(vTheta+x.Sigma)
+		F := x.S0 * math.Exp((x.R-x.Q)*x.T)
+		return hestonSlicePrices(p, F, x.T, math.Exp(-x.R*x.T), []float64{x.K}, []bool{x.OptType == Call})[0]
+	}, 1e-3*in.S0), nil
+}
+
+// hestonSlicePrices prices several strikes at one expiry under Heston.
+func hestonSlicePrices(p HestonParams, F, T, df float64, strikes []float64, isCall []bool) []float64 {
+	return lewisPrices(func(u complex128) complex128 { return p.cf(u, T) }, F, df, strikes, isCall)
+}
+
+// lewisPrices prices several strikes at one expiry from the characteristic
+// function cf of ln(S_T/F), sharing the cf evaluations. Lewis (2001):
+//
+//	C = DF [F - sqrt(FK)/pi int_0^inf Re(e^{iuX} phi(u - i/2)) / (u^2 + 1/4) du]
+//
+// with X = ln(F/K); puts follow by parity.
+func lewisPrices(cf func(complex128) complex128, F, df float64, strikes []float64, isCall []bool) []float64 {
+	// Truncate where the integrand is negligible; phi decays roughly like a
+	// Gaussian in u for long expiries and exponentially for short ones.
+	upper := 10.0
+	for upper < 1e4 && cmplx.Abs(cf(complex(upper, -0.5)))/(upper*upper) > 1e-12 {
+		upper *= 1.5
+	}
+	n := int(math.Max(400, upper/0.05))
+	if n%2 == 1 {
+		n++
+	}
+	h := upper / float64(n)
+	nodes := make([]complex128, n+1)
+	weights := make([]float64, n+1)
+	for j := range nodes {
+		u := float64(j) * h
+		nodes[j] = cf(complex(u, -0.5))
+		w := 2.0
+		if j == 0 || j == n {
+			w = 1
+		} else if j%2 == 1 {
+			w = 4
+		}
+		weights[j] = w * h / 3 / (u*u + 0.25)
+	}
+	out := make([]float64, len(strikes))
+	for k, K := range strikes {
+		X := math.Log(F / K)
+		sum := 0.0
+		for j, phi := range nodes {
+			u := float64(j) * h
+			s, c := math.Sincos(u * X)
+			sum += weights[j] * (c*real(phi) - s*imag(phi))
+		}
+		call := df * (F - math.Sqrt(F*K)/math.Pi*sum)
+		if isCall[k] {
+			out[k] = call
+		} else {
+			out[k] = call - df*(F-K)
+		}
+	}
+	return out
+}
+
+// FellerHandling chooses how calibration treats the Feller condition.
+type FellerHandling string
+
+const (
+	FellerIgnore  FellerHandling = "ignore"
+	FellerPenalty FellerHandling = "penalty" // Residual proportional to the violation
+	FellerEnforce FellerHandling = "enforce" // Parametrise xi^2 = s*2*kappa*theta, s <= 1
+)
+
+// HestonCalibrationMode chooses one parameter set for the whole surface or
+// one per expiry.
+type HestonCalibrationMode string
+
+const (
+	HestonGlobal    HestonCalibrationMode = "global"
+	HestonPerExpiry HestonCalibrationMode = "per-expiry"
+)
+
+// HestonCalibrationOptions configures CalibrateHeston. Zero values take
+// sensible defaults: global mode, Feller ignored, a start from the surface's
+// ATM vols, and wide bounds.
+type HestonCalibrationOptions struct {
+	Mode          HestonCalibrationMode
+	Feller        FellerHandling
+	PenaltyWeight float64 // For FellerPenalty (default 10)
+	Initial       HestonParams
+	Lower, Upper  HestonParams
+	MinVega       float64 // Vega floor for the vol-error weights (default 1e-4*spot)
+	LM            LMOptions
+}
+
+var (
+	defaultHestonLower = HestonParams{V0: 1e-4, Kappa: 1e-3, Theta: 1e-4, Xi: 1e-3, Rho: -0.999}
+	defaultHestonUpper = HestonParams{V0: 4, Kappa: 20, Theta: 4, Xi: 5, Rho: 0.999}
+)
+
+// HestonExpiryFit holds the fit diagnostics for one expiry. In per-expiry
+// mode Params are that expiry's own parameters.
+type HestonExpiryFit struct {
+	T           float64
+	Params      HestonParams
+	RMSE        float64
+	MaxAbsError float64
+	Points      []SmileFitPoint
+}
+
+// HestonCalibration is the outcome of CalibrateHeston. Params is the global
+// fit (the longest expiry's in per-expiry mode).
+type HestonCalibration struct {
+	Params          HestonParams
+	Expiries        []HestonExpiryFit
+	RMSE            float64 // Implied-vol RMSE over all quotes
+	MaxAbsError     float64
+	FellerSatisfied bool
+	Results         []CalibrationResult
+}
+
+// CalibrateHeston fits Heston to the quoted points of surf, minimising
+// vega-weighted price errors (approximately implied-vol errors) on
+// out-of-the-money options, then reports exact implied-vol errors.
+func CalibrateHeston(surf *VolSurface, opt HestonCalibrationOptions) (HestonCalibration, error) {
+	return CalibrateHestonContext(context.Background(), surf, opt)
+}
+
+// CalibrateHestonContext is CalibrateHeston with a context. When ctx is
+// done it returns ctx's error with the expiries fitted so far, the last
+// of them at the best parameters found before it stopped.
+func CalibrateHestonContext(ctx context.Context, surf *VolSurface, opt HestonCalibrationOptions) (HestonCalibration, error) {
+	if surf == nil || len(surf.Slices) == 0 {
+		return HestonCalibration{}, errors.New("empty surface")
+	}
+	if opt.Mode == "" {
+		opt.Mode = HestonGlobal
+	}
+	if opt.Feller == "" {
+		opt.Feller = FellerIgnore
+	}
+	if opt.PenaltyWeight <= 0 {
+		opt.PenaltyWeight = 10
+	}
+	if opt.MinVega <= 0 {
+		opt.MinVega = 1e-4 * surf.Spot
+	}
+	if opt.Lower == (HestonParams{}) {
+		opt.Lower = defaultHestonLower
+	}
+	if opt.Upper == (HestonParams{}) {
+		opt.Upper = defaultHestonUpper
+	}
+	if opt.Initial == (HestonParams{}) {
+		first := surf.Slices[0]
+		last := surf.Slices[len(surf.Slices)-1]
+		s0, s1 := first.Vol(surf.Forward(0)), last.Vol(surf.Forward(len(surf.Slices)-1))
+		opt.Initial = HestonParams{V0: s0 * s0, Kappa: 1.5, Theta: s1 * s1, Xi: 0.5, Rho: -0.5}
+	}
+
+	var groups [][]int
+	if opt.Mode == HestonPerExpiry {
+		for i := range surf.Slices {
+			groups = append(groups, []int{i})
+		}
+	} else {
+		all := make([]int, len(surf.Slices))
+		for i := range all {
+			all[i] = i
+		}
+		groups = [][]int{all}
+	}
+
+	out := HestonCalibration{FellerSatisfied: true}
+	sumSq, count := 0.0, 0
+	var stopped error
+	for _, g := range groups {
+		params, res, err := calibrateHestonSlices(ctx, surf, g, opt)
+		if err != nil && (ctx.Err() == nil || res.Params == nil) {
+			return HestonCalibration{}, err
+		}
+		stopped = err
+		out.Results = append(out.Results, res)
+		out.Params = params
+		out.FellerSatisfied = out.FellerSatisfied && params.Feller()
+		for _, i := range g {
+			fit := hestonExpiryFit(surf, i, params)
+			for _, pt := range fit.Points {
+				sumSq += pt.VolError * pt.VolError
+				count++
+			}
+			out.MaxAbsError = math.Max(out.MaxAbsError, fit.MaxAbsError)
+			out.Expiries = append(out.Expiries, fit)
+		}
+		if stopped != nil {
+			break
+		}
+	}
+	if count > 0 {
+		out.RMSE = math.Sqrt(sumSq / float64(count))
+	}
+	return out, stopped
+}
+
+func calibrateHestonSlices(ctx context.Context, surf *VolSurface, slices []int, opt HestonCalibrationOptions) (HestonParams, CalibrationResult, error) {
+	var sets []sliceQuotes
+	var weights []float64
+	for _, i := range slices {
+		q := surf.otmQuotes(i, opt.MinVega)
+		sets = append(sets, q)
+		weights = append(weights, q.weights...)
+	}
+
+	enforce := opt.Feller == FellerEnforce
+	toParams := func(x []float64) HestonParams {
+		p := HestonParams{V0: x[0], Kappa: x[1], Theta: x[2], Xi: x[3], Rho: x[4]}
+		if enforce {
+			p.Xi = math.Sqrt(x[3] * 2 * p.Kappa * p.Theta)
+		}
+		return p
+	}
+	fromParams := func(p HestonParams) []float64 {
+		x := []float64{p.V0, p.Kappa, p.Theta, p.Xi, p.Rho}
+		if enforce {
+			x[3] = math.Min(p.Xi*p.Xi/(2*p.Kappa*p.Theta), 1)
+		}
+		return x
+	}
+	lower, upper := fromParams(opt.Lower), fromParams(opt.Upper)
+	if enforce {
+		lower[3], upper[3] = 1e-6, 1
+	}
+	if opt.Feller == FellerPenalty {
+		weights = append(weights, opt.PenaltyWeight)
+	}
+
+	problem := CalibrationProblem{
+		Names:   []string{"v0", "kappa", "theta", "xi", "rho"},
+		Initial: fromParams(opt.Initial),
+		Lower:   lower,
+		Upper:   upper,
+		Weights: weights,
+		Residuals: func(x []float64) ([]float64, error) {
+			p := toParams(x)
+			var r []float64
+			for _, q := range sets {
+				model := hestonSlicePrices(p, q.F, q.T, q.df, q.strikes, q.isCall)
+				for j := range model {
+					r = append(r, model[j]-q.prices[j])
+				}
+			}
+			if opt.Feller == FellerPenalty {
+				r = append(r, math.Max(p.Xi*p.Xi-2*p.Kappa*p.Theta, 0))
+			}
+			return r, nil
+		},
+	}
+	if enforce {
+		problem.Names[3] = "feller_ratio"
+	}
+	res, err := CalibrateContext(ctx, problem, opt.LM)
+	if res.Params == nil {
+		return HestonParams{}, CalibrationResult{}, err
+	}
+	return toParams(res.Params), res, err // Stopped early if err is set
+}
+
+func hestonExpiryFit(surf *VolSurface, i int, p HestonParams) HestonExpiryFit {
+	q := surf.otmQuotes(i, 0)
+	fit := HestonExpiryFit{T: q.T, Params: p}
+	fit.Points, fit.RMSE, fit.MaxAbsError = q.compare(hestonSlicePrices(p, q.F, q.T, q.df, q.strikes, q.isCall))
+	return fit
+}
@@ -0,0 +1,144 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// ErrNoImpliedVol is returned when a price lies outside the no-arbitrage
+// bounds, so no volatility reproduces it.
+var ErrNoImpliedVol = errors.New("price outside no-arbitrage bounds")
+
+// ImpliedVol returns the lognormal volatility that reproduces marketPrice
+// for the given inputs (inputs.Sigma is ignored) under their model: BSM on
+// the forward S0 e^((R-Q)T), with S0 less the cash dividends' present value
+// as Price escrows it, or Black-76, whose S0 is the forward itself.
+// A price below the discounted intrinsic value or at or above the
+// discounted upper bound (the forward for a call, the strike for a put)
+// gives an error wrapping ErrNoImpliedVol that states the bound.
+//
+// Under the Bachelier model it returns the normal vol instead, in units of
+// the forward S0 per root year; the forward and strike may be negative and
+// the price has no upper bound, only the discounted intrinsic value below.
+//
+// American inputs invert the American pricer Price would use under opts
+// (the tree by default, or Bjerksund-Stensland with WithAmericanMethod)
+// rather than the European formula, which overstates the vol of options
+// worth exercising early, such as in-the-money puts or calls on dividend
+// payers. opts are otherwise ignored.
+func ImpliedVol(marketPrice float64, inputs Inputs, opts ...Option) (float64, error) {
+	normal := inputs.Model == BachelierModel
+	if inputs.T <= 0 || !normal && (inputs.S0 <= 0 || inputs.K <= 0) {
+		return 0, errors.New("spot, strike and expiry must be positive")
+	}
+	if math.IsNaN(marketPrice) || math.IsInf(marketPrice, 0) {
+		return 0, errors.New("price must be finite")
+	}
+	if inputs.Exercise == American {
+		return americanImpliedVol(marketPrice, inputs, resolveOptions(opts))
+	}
+	check := inputs
+	check.Sigma = 0
+	if err := check.Validate(); err != nil {
+		return 0, err
+	}
+	df := math.Exp(-inputs.R * inputs.T)
+	F := inputs.S0
+	if inputs.Model != Black76Model && !normal {
+		F = (F - dividendPV(inputs, 0)) * math.Exp((inputs.R-inputs.Q)*inputs.T)
+	}
+	isCall := inputs.OptType == Call
+	if normal {
+		lower := df * bachelierFormula(F, inputs.K, 0, isCall)
+		if marketPrice < lower-1e-12*(math.Abs(F)+math.Abs(inputs.K)) {
+			return 0, fmt.Errorf("%w: price %.6g is below intrinsic value %.6g", ErrNoImpliedVol, marketPrice, lower)
+		}
+		return bachelierImpliedVol(marketPrice/df, F, inputs.K, inputs.T, isCall), nil
+	}
+	lower, upper := blackBounds(F, inputs.K, isCall)
+	lower, upper = lower*df, upper*df
+	if marketPrice < lower-1e-12*upper {
+		return 0, fmt.Errorf("%w: price %.6g is below intrinsic value %.6g", ErrNoImpliedVol, marketPrice, lower)
+	}
+	if marketPrice >= upper {
+		return 0, fmt.Errorf("%w: price %.6g is at or above the upper bound %.6g", ErrNoImpliedVol, marketPrice, upper)
+	}
+	return blackImpliedVol(marketPrice/df, F, inputs.K, inputs.T, isCall)
+}
+
+// blackBounds returns the undiscounted Black price's limits at zero and
+// infinite volatility.
+func blackBounds(F, K float64, isCall bool) (lower, upper float64) {
+	if isCall {
+		return math.Max(F-K, 0), F
+	}
+	return math.Max(K-F, 0), K
+}
+
+// blackImpliedVol inverts the undiscounted Black formula. It works with
+// the out-of-the-money option, whose price by put-call parity is the time
+// value, so deep in-the-money quotes keep their precision. Newton steps on
+// the log price in total standard deviation, seeded with the
+// Corrado-Miller approximation, converge quickly even far in the wings,
+// and fall back to bisection on the bracket rather than overshoot; if they
+// still stall, Brent's method finishes.
+func blackImpliedVol(price, F, K, T float64, isCall bool) (float64, error) {
+	intrinsic, upper := blackBounds(F, K, isCall)
+	if price < intrinsic-1e-12*upper || price >= upper {
+		return 0, ErrNoImpliedVol
+	}
+	p := price - intrinsic
+	if intrinsic > 0 {
+		isCall = !isCall
+	}
+	if _, otmUpper := blackBounds(F, K, isCall); p <= 1e-16*otmUpper {
+		return 0, nil
+	}
+
+	lo, hi := 0.0, 1.0
+	for blackFormula(F, K, hi, isCall) < p {
+		if lo, hi = hi, 2*hi; hi > 100 {
+			return 0, ErrNoImpliedVol
+		}
+	}
+	s := corradoMillerSeed(p, F, K, isCall)
+	if !(s > lo && s < hi) {
+		s = math.Sqrt(2 * math.Abs(math.Log(F/K))) // Inflection point of the price in s
+		if !(s > lo && s < hi) {
+			s = 0.5 * (lo + hi)
+		}
+	}
+	logP := math.Log(p)
+	fdf := func(s float64) (float64, float64) {
+		b := blackFormula(F, K, s, isCall)
+		return math.Log(b) - logP, F * normPDF(math.Log(F/K)/s+0.5*s) / b
+	}
+	res, err := numerics.Newton(fdf, s, lo, hi, numerics.Options{MaxIterations: 50, XTol: 1e-15, FTol: 1e-14})
+	if err != nil {
+		res, err = numerics.Brent(func(s float64) float64 { return blackFormula(F, K, s, isCall) - p }, lo, hi,
+			numerics.Options{XTol: 1e-15})
+		if err != nil {
+			return 0, fmt.Errorf("implied vol: %w", err)
+		}
+	}
+	return res.X / math.Sqrt(T), nil
+}
+
+// corradoMillerSeed is the Corrado-Miller approximation to the total
+// standard deviation from an undiscounted price; it returns NaN where the
+// approximation breaks down, typically far out of the money.
+func corradoMillerSeed(price, F, K float64, isCall bool) float64 {
+	c := price
+	if !isCall {
+		c += F - K
+	}
+	m := c - 0.5*(F-K)
+	disc := m*m - (F-K)*(F-K)/math.Pi
+	if disc < 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(2*math.Pi) / (F + K) * (m + math.Sqrt(disc))
+}
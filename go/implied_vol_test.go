@@ -0,0 +1,26 @@
+package bsm
+
+import (
+	"math"
+	"testing"
+)
+
+// ImpliedVol inverts Price under the inputs' model.
+func TestImpliedVolModels(t *testing.T) {
+	for _, in := range []Inputs{
+		{S0: 100, K: 105, T: 0.5, Sigma: 0.3, R: 0.04, Q: 0.02, OptType: Call},
+		{S0: 100, K: 100, T: 0.5, Sigma: 0.25, R: 0.05, OptType: Call, Dividends: []CashDividend{{Time: 0.25, Amount: 3}}},
+		{S0: 95, K: 100, T: 0.5, Sigma: 0.3, R: 0.04, OptType: Put, Model: Black76Model},
+		{S0: -0.002, K: 0.001, T: 2, Sigma: 0.006, R: 0.01, OptType: Call, Model: BachelierModel},
+		{S0: 95, K: 100, T: 0.5, Sigma: 18, R: 0.04, OptType: Put, Model: BachelierModel},
+	} {
+		p, err := Price(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := ImpliedVol(p.Price, in)
+		if err != nil || math.Abs(v-in.Sigma) > 1e-10 {
+			t.Errorf("%s: got %g, %v, want %g", in.Model, v, err, in.Sigma)
+		}
+	}
+}
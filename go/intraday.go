@@ -0,0 +1,118 @@
+package bsm
+
+import (
+	"time"
+)
+
+const (
+	calendarDaysPerYear = 365.0
+	tradingDaysPerYear  = 252.0
+	secondsPerDay       = 86400.0
+)
+
+// TradingSession describes the regular trading hours of a venue. Open and
+// Close are local wall-clock times in Location, as offsets from midnight,
+// so a session keeps its hours on the days clocks change.
+type TradingSession struct {
+	Location *time.Location
+	Open     time.Duration
+	Close    time.Duration
+	Holidays []time.Time // Full-day closures, by their own year, month and day
+}
+
+// USEquitySession returns the NYSE/Nasdaq regular session, 09:30-16:00 New York time.
+func USEquitySession() (TradingSession, error) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return TradingSession{}, err
+	}
+	return TradingSession{
+		Location: loc,
+		Open:     9*time.Hour + 30*time.Minute,
+		Close:    16 * time.Hour,
+	}, nil
+}
+
+// ExpiryTime holds time to expiry measured on two clocks.
+type ExpiryTime struct {
+	Calendar         float64 // ACT/365 years, to the second
+	Business         float64 // Trading-session years on a 252-day basis
+	BusinessSessions float64 // Remaining sessions, including fractions of the first and last
+}
+
+// TimeToExpiryFromNow measures time from time.Now() to the expiry instant.
+func TimeToExpiryFromNow(expiry time.Time, session TradingSession) ExpiryTime {
+	return TimeToExpiry(time.Now(), expiry, session)
+}
+
+// TimeToExpiry measures time from now to expiry. The calendar clock runs
+// continuously; the business clock only runs while the session is open, so
+// the remaining part of today's session and the part of the expiry day's
+// session before the expiry instant are both counted as fractions of a day.
+func TimeToExpiry(now, expiry time.Time, session TradingSession) ExpiryTime {
+	if !expiry.After(now) {
+		return ExpiryTime{}
+	}
+	calendar := expiry.Sub(now).Seconds() / (calendarDaysPerYear * secondsPerDay)
+
+	sessions := session.openSessionsBetween(now, expiry)
+	return ExpiryTime{
+		Calendar:         calendar,
+		Business:         sessions / tradingDaysPerYear,
+		BusinessSessions: sessions,
+	}
+}
+
+// openSessionsBetween counts trading sessions in [from, to), weighting partial
+// sessions by the fraction of session length that falls inside the interval.
+func (s TradingSession) openSessionsBetween(from, to time.Time) float64 {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	length := s.Close - s.Open
+	if length <= 0 {
+		return 0
+	}
+	holidays := make(map[civilDate]bool, len(s.Holidays))
+	for _, h := range s.Holidays {
+		holidays[dateOf(h)] = true
+	}
+
+	from, to = from.In(loc), to.In(loc)
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	total := 0.0
+	for !day.After(to) {
+		if isWeekday(day) && !holidays[dateOf(day)] {
+			y, m, d := day.Date()
+			start := time.Date(y, m, d, 0, 0, 0, int(s.Open), loc)
+			end := time.Date(y, m, d, 0, 0, 0, int(s.Close), loc)
+			if from.After(start) {
+				start = from
+			}
+			if to.Before(end) {
+				end = to
+			}
+			if end.After(start) {
+				total += float64(end.Sub(start)) / float64(length)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return total
+}
+
+type civilDate struct {
+	year  int
+	month time.Month
+	day   int
+}
+
+func dateOf(t time.Time) civilDate {
+	return civilDate{t.Year(), t.Month(), t.Day()}
+}
+
+func isWeekday(t time.Time) bool {
+	wd := t.Weekday()
+	return wd != time.Saturday && wd != time.Sunday
+}
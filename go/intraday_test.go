@@ -0,0 +1,43 @@
+package bsm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// A holiday closes its own date, whatever the zone it was given in.
+func TestOpenSessionsHoliday(t *testing.T) {
+	s, err := USEquitySession()
+	if err != nil {
+		t.Skip(err)
+	}
+	from := time.Date(2026, 12, 24, 10, 0, 0, 0, s.Location) // Thursday
+	to := time.Date(2026, 12, 28, 10, 0, 0, 0, s.Location)   // Monday
+	if got, want := s.openSessionsBetween(from, to), 2.0; math.Abs(got-want) > 1e-12 {
+		t.Errorf("no holiday: got %g sessions, want %g", got, want)
+	}
+	s.Holidays = []time.Time{time.Date(2026, 12, 25, 0, 0, 0, 0, time.UTC)}
+	if got, want := s.openSessionsBetween(from, to), 1.0; math.Abs(got-want) > 1e-12 {
+		t.Errorf("Christmas holiday: got %g sessions, want %g", got, want)
+	}
+}
+
+// The session keeps its wall-clock hours on a day the clocks change; Israel
+// moves them forward on a Friday.
+func TestOpenSessionsAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jerusalem")
+	if err != nil {
+		t.Skip(err)
+	}
+	s := TradingSession{Location: loc, Open: 9 * time.Hour, Close: 17 * time.Hour}
+	from := time.Date(2026, 3, 26, 13, 0, 0, 0, loc) // Thursday, before the change
+	to := time.Date(2026, 3, 27, 13, 0, 0, 0, loc)
+	if got, want := s.openSessionsBetween(from, to), 1.0; math.Abs(got-want) > 1e-12 {
+		t.Errorf("got %g sessions, want %g", got, want)
+	}
+	et := TimeToExpiry(from, to, s)
+	if want := 23.0 / 24 / calendarDaysPerYear; math.Abs(et.Calendar-want) > 1e-12 {
+		t.Errorf("calendar time %g, want %g", et.Calendar, want)
+	}
+}
@@ -0,0 +1,60 @@
+package bsm
+
+import "errors"
+
+// InverseOutputs are the outputs of an inverse (coin-settled) option, whose
+// payoff max(S-K, 0)/S for a call is paid in the coin. Values are per
+// contract in coin unless marked USD.
+type InverseOutputs struct {
+	T        float64
+	PriceUSD float64
+	Price    float64 // Premium in coin
+
+	// Delta is dPrice/dF in coin per USD of forward. PremiumAdjustedDelta is
+	// the exposure in coin (the Black delta less the coin premium), which is
+	// the delta inverse venues display.
+	Delta                float64
+	PremiumAdjustedDelta float64
+	Gamma                float64 // d2Price/dF2, coin per USD^2
+	VegaPerVol           float64 // Coin per 1.00 vol
+	VegaPerVolPt         float64 // Coin per vol point
+	ThetaPerYear         float64 // Coin, forward held fixed
+	ThetaPerDay          float64 // Coin per 24h
+}
+
+// PriceInverse prices an inverse option. With V the USD Black-76 value on the
+// forward F, the coin value is V/F (Deribit's convention of converting at the
+// forward), so
+//
+//	dV_c/dF   = (Delta - V/F) / F
+//	d2V_c/dF2 = Gamma/F - 2 (Delta - V/F) / F^2
+//
+// and vega and theta are the USD figures divided by F.
+func PriceInverse(in CryptoInputs, spec ContractSpec) (InverseOutputs, error) {
+	lin, err := PriceCrypto(in, ContractSpec{Multiplier: 1})
+	if err != nil {
+		return InverseOutputs{}, err
+	}
+	if spec.Multiplier < 0 {
+		return InverseOutputs{}, errors.New("contract size must not be negative")
+	}
+	size := spec.Multiplier
+	if size == 0 {
+		size = 1
+	}
+	o, F := lin.PerUnit, in.Forward
+	coin := o.Price / F
+	adj := o.Delta - coin
+	return InverseOutputs{
+		T:                    lin.T,
+		PriceUSD:             o.Price * size,
+		Price:                coin * size,
+		Delta:                adj / F * size,
+		PremiumAdjustedDelta: adj * size,
+		Gamma:                (o.Gamma/F - 2*adj/(F*F)) * size,
+		VegaPerVol:           o.VegaPerVol / F * size,
+		VegaPerVolPt:         o.VegaPerVolPt / F * size,
+		ThetaPerYear:         o.ThetaPerYear / F * size,
+		ThetaPerDay:          o.ThetaPerDay / F * size,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package bsm
+
+import (
+	"math"
+	"sort"
+)
+
+// IVQuote is the implied vol at a quote's bid, mid and ask. Bid is zero
+// when the bid is at or below intrinsic value, so no vol supports it.
+type IVQuote struct {
+	Bid, Mid, Ask float64
+}
+
+// Spread returns Ask - Bid.
+func (q IVQuote) Spread() float64 { return q.Ask - q.Bid }
+
+// ImpliedVols inverts the quote's bid, mid and ask with forward F and
+// discount factor df.
+func (q ChainQuote) ImpliedVols(F, df float64) (IVQuote, error) {
+	isCall := q.OptType == Call
+	mid, err := blackImpliedVol(q.Mid()/df, F, q.Strike, q.T, isCall)
+	if err != nil {
+		return IVQuote{}, err
+	}
+	ask, err := blackImpliedVol(q.Ask/df, F, q.Strike, q.T, isCall)
+	if err != nil {
+		return IVQuote{}, err
+	}
+	bid, err := blackImpliedVol(q.Bid/df, F, q.Strike, q.T, isCall)
+	if err != nil {
+		bid = 0
+	}
+	return IVQuote{Bid: bid, Mid: mid, Ask: ask}, nil
+}
+
+// VolQuote returns the slice's bid, mid and ask vol at K. The mid is Vol(K);
+// the distances to bid and ask are interpolated linearly in strike from the
+// quotes and held flat beyond the wings. Without bid/ask vols all three are
+// the mid.
+func (s VolSlice) VolQuote(K float64) IVQuote {
+	mid := s.Vol(K)
+	if s.BidVols == nil {
+		return IVQuote{mid, mid, mid}
+	}
+	down, up := s.spreadGaps(K)
+	return IVQuote{Bid: math.Max(mid-down, 0), Mid: mid, Ask: mid + up}
+}
+
+func (s VolSlice) spreadGaps(K float64) (down, up float64) {
+	d := make([]float64, len(s.Strikes))
+	u := make([]float64, len(s.Strikes))
+	for i := range s.Strikes {
+		d[i], u[i] = s.Vols[i]-s.BidVols[i], s.AskVols[i]-s.Vols[i]
+	}
+	return VolSlice{Strikes: s.Strikes, Vols: d}.Vol(K), VolSlice{Strikes: s.Strikes, Vols: u}.Vol(K)
+}
+
+// VolQuote returns the bid, mid and ask vol at (K, T). The mid is Vol(K, T);
+// the distances to bid and ask are interpolated linearly in T at fixed
+// forward moneyness, held flat outside the quoted expiries. Slices without
+// bid/ask vols contribute zero spread.
+func (v *VolSurface) VolQuote(K, T float64) IVQuote {
+	mid := v.Vol(K, T)
+	m := K / v.forwardAt(T)
+	gaps := func(i int) (float64, float64) {
+		if v.Slices[i].BidVols == nil {
+			return 0, 0
+		}
+		return v.Slices[i].spreadGaps(m * v.Forward(i))
+	}
+	sl := v.Slices
+	j := sort.Search(len(sl), func(i int) bool { return sl[i].T >= T })
+	var down, up float64
+	switch {
+	case j == 0:
+		down, up = gaps(0)
+	case j == len(sl):
+		down, up = gaps(j - 1)
+	default:
+		w := (T - sl[j-1].T) / (sl[j].T - sl[j-1].T)
+		d0, u0 := gaps(j - 1)
+		d1, u1 := gaps(j)
+		down, up = (1-w)*d0+w*d1, (1-w)*u0+w*u1
+	}
+	return IVQuote{Bid: math.Max(mid-down, 0), Mid: mid, Ask: mid + up}
+}
+
+// SurfaceGreeks are BSM Greeks at the surface's mid vol, with the bid and
+// ask vols and the option prices they imply.
+type SurfaceGreeks struct {
+	Vols               IVQuote
+	Mid                Outputs
+	PriceBid, PriceAsk float64
+}
+
+// inputs returns the option at (K, T) on the surface's spot and rate,
+// with the yield its forward at T implies, so it prices on the same
+// forward as the surface's vols.
+func (v *VolSurface) inputs(K, T float64, optType OptionType) Inputs {
+	q := v.Q
+	if T > 0 {
+		q = v.R - math.Log(v.forwardAt(T)/v.Spot)/T
+	}
+	return Inputs{S0: v.Spot, K: K, T: T, R: v.R, Q: q, OptType: optType}
+}
+
+// Greeks prices an option at (K, T) off the surface, on its forward at T,
+// carrying the vol spread alongside the mid Greeks.
+func (v *VolSurface) Greeks(K, T float64, optType OptionType, thetaBasis int) (SurfaceGreeks, error) {
+	vols := v.VolQuote(K, T)
+	in := v.inputs(K, T, optType)
+	in.Sigma = vols.Mid
+	mid, err := Price(in, WithThetaBasis(thetaBasis))
+	if err != nil {
+		return SurfaceGreeks{}, err
+	}
+	out := SurfaceGreeks{Vols: vols, Mid: mid}
+	in.Sigma = vols.Bid
+	bid, err := Price(in, WithThetaBasis(thetaBasis))
+	if err != nil {
+		return SurfaceGreeks{}, err
+	}
+	in.Sigma = vols.Ask
+	ask, err := Price(in, WithThetaBasis(thetaBasis))
+	if err != nil {
+		return SurfaceGreeks{}, err
+	}
+	out.PriceBid, out.PriceAsk = bid.Price, ask.Price
+	return out, nil
+}
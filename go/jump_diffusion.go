@@ -0,0 +1,301 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// JumpModel selects the jump-size distribution.
+type JumpModel string
+
+const (
+	MertonJumps JumpModel = "merton" // Normal log-jumps
+	KouJumps    JumpModel = "kou"    // Double-exponential log-jumps
+)
+
+// JumpDiffusionParams are GBM plus compound-Poisson log-jumps, compensated
+// so the discounted spot is a martingale.
+type JumpDiffusionParams struct {
+	Model  JumpModel
+	Sigma  float64 // Diffusive vol
+	Lambda float64 // Jump intensity (per annum)
+
+	MuJ, SigmaJ float64 // Merton: mean and std dev of the log-jump
+
+	P    float64 // Kou: probability a jump is upward
+	Eta1 float64 // Kou: rate of upward jumps (> 1 for a finite forward)
+	Eta2 float64 // Kou: rate of downward jumps
+}
+
+func (p JumpDiffusionParams) validate() error {
+	if p.Sigma < 0 || p.Lambda < 0 {
+		return errors.New("sigma and lambda must be non-negative")
+	}
+	switch p.Model {
+	case MertonJumps:
+		if p.SigmaJ < 0 {
+			return errors.New("jump std dev must be non-negative")
+		}
+	case KouJumps:
+		if p.P < 0 || p.P > 1 || p.Eta1 <= 1 || p.Eta2 <= 0 {
+			return errors.New("kou needs 0 <= p <= 1, eta1 > 1 and eta2 > 0")
+		}
+	default:
+		return fmt.Errorf("unknown jump model %q", p.Model)
+	}
+	return nil
+}
+
+// jumpCF is E[exp(iuY)] for one log-jump Y.
+func (p JumpDiffusionParams) jumpCF(u complex128) complex128 {
+	iu := complex(0, 1) * u
+	if p.Model == KouJumps {
+		return complex(p.P*p.Eta1, 0)/(complex(p.Eta1, 0)-iu) + complex((1-p.P)*p.Eta2, 0)/(complex(p.Eta2, 0)+iu)
+	}
+	return cmplx.Exp(iu*complex(p.MuJ, 0) - 0.5*complex(p.SigmaJ*p.SigmaJ, 0)*u*u)
+}
+
+// cf is the characteristic function of ln(S_T/F).
+func (p JumpDiffusionParams) cf(u complex128, T float64) complex128 {
+	iu := complex(0, 1) * u
+	comp := real(p.jumpCF(complex(0, -1))) - 1 // E[e^Y] - 1
+	psi := -0.5*complex(p.Sigma*p.Sigma, 0)*(iu+u*u) + complex(p.Lambda, 0)*(p.jumpCF(u)-1) - iu*complex(p.Lambda*comp, 0)
+	return cmplx.Exp(psi * complex(T, 0))
+}
+
+// JumpDiffusionInputs describes a European option under a jump-diffusion.
+type JumpDiffusionInputs struct {
+	S0, K, T, R, Q float64
+	OptType        OptionType
+	Params         JumpDiffusionParams
+}
+
+// PriceJumpDiffusion prices a European option with Lewis's formula.
+func PriceJumpDiffusion(in JumpDiffusionInputs) (float64, error) {
+	if in.S0 <= 0 || in.K <= 0 || in.T <= 0 {
+		return 0, errors.New("spot, strike and expiry must be positive")
+	}
+	if err := in.Params.validate(); err != nil {
+		return 0, err
+	}
+	if err := (Inputs{S0: in.S0, K: in.K, T: in.T, R: in.R, Q: in.Q, OptType: in.OptType}).Validate(); err != nil {
+		return 0, err
+	}
+	F := in.S0 * math.Exp((in.R-in.Q)*in.T)
+	cf := func(u complex128) complex128 { return in.Params.cf(u, in.T) }
+	return lewisPrices(cf, F, math.Exp(-in.R*in.T), []float64{in.K}, []bool{in.OptType == Call})[0], nil
+}
+
+// PriceMertonSeries prices a European option under Merton jumps by
+// Merton's series: the Poisson-weighted sum over the number of jumps n of
+// BSM prices with vol sqrt(Sigma^2 + n SigmaJ^2 / T) and a drift
+// correcting for the jumps. Terms are added until the Poisson mass left
+// is below tol (1e-12 if zero). Greeks are by finite differences, vega to
+// the diffusive Sigma.
+func PriceMertonSeries(in JumpDiffusionInputs, tol float64, thetaBasis int) (Outputs, error) {
+	if in.S0 <= 0 || in.K <= 0 || in.T <= 0 {
+		return Outputs{}, errors.New("spot, strike and expiry must be positive")
+	}
+	if err := in.Params.validate(); err != nil {
+		return Outputs{}, err
+	}
+	if in.Params.Model != MertonJumps {
+		return Outputs{}, fmt.Errorf("the Merton series needs Merton jumps, got %q", in.Params.Model)
+	}
+	if tol == 0 {
+		tol = 1e-12
+	}
+	if tol < 0 || tol >= 1 {
+		return Outputs{}, fmt.Errorf("truncation tolerance %g outside (0, 1)", tol)
+	}
+	if thetaBasis <= 0 {
+		return Outputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	base := Inputs{S0: in.S0, K: in.K, T: in.T, Sigma: in.Params.Sigma, R: in.R, Q: in.Q, OptType: in.OptType}
+	if err := base.Validate(); err != nil {
+		return Outputs{}, err
+	}
+	return bumpedGreeks(base, thetaBasis, func(x Inputs) float64 {
+		return mertonSeries(x, in.Params, tol)
+	}, 1e-3*in.S0), nil
+}
+
+// mertonSeries sums Merton's series at x, with x.Sigma the diffusive vol.
+func mertonSeries(x Inputs, p JumpDiffusionParams, tol float64) float64 {
+	k := math.Exp(p.MuJ+0.5*p.SigmaJ*p.SigmaJ) - 1 // E[e^Y] - 1
+	lt := p.Lambda * (1 + k) * x.T                 // Poisson mean under the jump-adjusted measure
+	term := x
+	price, mass := 0.0, 0.0
+	for n := 0; 1-mass > tol && n < 10000; n++ {
+		var w float64
+		if lt > 0 {
+			lg, _ := math.Lgamma(float64(n + 1))
+			w = math.Exp(-lt + float64(n)*math.Log(lt) - lg)
+		} else if n == 0 {
+			w = 1
+		}
+		term.Sigma = math.Sqrt(x.Sigma*x.Sigma + float64(n)*p.SigmaJ*p.SigmaJ/x.T)
+		term.R = x.R - p.Lambda*k + float64(n)*math.Log1p(k)/x.T
+		price += w * priceAndGreeks(term, 365).Price
+		mass += w
+	}
+	return price
+}
+
+// JumpCalibrationOptions configures CalibrateJumpDiffusion. With Backbone
+// set each expiry gets its own diffusive vol and only the jump parameters
+// are shared; otherwise one Sigma fits all the expiries.
+type JumpCalibrationOptions struct {
+	Model     JumpModel
+	MaxExpiry float64 // Fit slices with T <= MaxExpiry (default 0.25)
+	Backbone  bool
+	Initial   JumpDiffusionParams // Zero: a start from the ATM vol
+	MinVega   float64             // Vega floor for the weights (default 1e-4*spot)
+	LM        LMOptions
+}
+
+// JumpExpiryFit holds the fit diagnostics for one expiry with the diffusive
+// vol used there.
+type JumpExpiryFit struct {
+	T           float64
+	Sigma       float64
+	RMSE        float64
+	MaxAbsError float64
+	Points      []SmileFitPoint
+}
+
+// JumpCalibration is the outcome of CalibrateJumpDiffusion. Params.Sigma is
+// the shortest expiry's vol in backbone mode.
+type JumpCalibration struct {
+	Params      JumpDiffusionParams
+	Expiries    []JumpExpiryFit
+	RMSE        float64
+	MaxAbsError float64
+	Result      CalibrationResult
+}
+
+// CalibrateJumpDiffusion fits Merton or Kou jumps to the short-dated
+// slices of surf, where jumps rather than diffusion drive the smile.
+func CalibrateJumpDiffusion(surf *VolSurface, opt JumpCalibrationOptions) (JumpCalibration, error) {
+	return CalibrateJumpDiffusionContext(context.Background(), surf, opt)
+}
+
+// CalibrateJumpDiffusionContext is CalibrateJumpDiffusion with a context.
+// When ctx is done it returns ctx's error with the fit at the best
+// parameters found before it stopped.
+func CalibrateJumpDiffusionContext(ctx context.Context, surf *VolSurface, opt JumpCalibrationOptions) (JumpCalibration, error) {
+	if surf == nil || len(surf.Slices) == 0 {
+		return JumpCalibration{}, errors.New("empty surface")
+	}
+	if opt.Model == "" {
+		opt.Model = MertonJumps
+	}
+	if opt.MaxExpiry <= 0 {
+		opt.MaxExpiry = 0.25
+	}
+	if opt.MinVega <= 0 {
+		opt.MinVega = 1e-4 * surf.Spot
+	}
+	var sets []sliceQuotes
+	var weights []float64
+	for i, s := range surf.Slices {
+		if s.T <= opt.MaxExpiry {
+			q := surf.otmQuotes(i, opt.MinVega)
+			sets = append(sets, q)
+			weights = append(weights, q.weights...)
+		}
+	}
+	if len(sets) == 0 {
+		return JumpCalibration{}, fmt.Errorf("no slices with expiry <= %g", opt.MaxExpiry)
+	}
+
+	init := opt.Initial
+	if init == (JumpDiffusionParams{}) {
+		atm := surf.Slices[0].Vol(surf.Forward(0))
+		init = JumpDiffusionParams{Sigma: 0.8 * atm, Lambda: 1, MuJ: -0.1, SigmaJ: 0.15, P: 0.3, Eta1: 10, Eta2: 5}
+	}
+	init.Model = opt.Model
+
+	// Layout: diffusive vol(s), lambda, then the model's jump parameters.
+	nVol := 1
+	if opt.Backbone {
+		nVol = len(sets)
+	}
+	var names []string
+	var x0, lower, upper []float64
+	for k := 0; k < nVol; k++ {
+		names = append(names, fmt.Sprintf("sigma_%d", k))
+		x0 = append(x0, init.Sigma)
+		lower, upper = append(lower, 1e-3), append(upper, 2)
+	}
+	names = append(names, "lambda")
+	x0, lower, upper = append(x0, init.Lambda), append(lower, 0), append(upper, 50)
+	if opt.Model == KouJumps {
+		names = append(names, "p", "eta1", "eta2")
+		x0 = append(x0, init.P, init.Eta1, init.Eta2)
+		lower = append(lower, 0, 1.01, 0.1)
+		upper = append(upper, 1, 200, 200)
+	} else {
+		names = append(names, "mu_j", "sigma_j")
+		x0 = append(x0, init.MuJ, init.SigmaJ)
+		lower = append(lower, -1, 1e-3)
+		upper = append(upper, 1, 1)
+	}
+	toParams := func(x []float64, slice int) JumpDiffusionParams {
+		p := JumpDiffusionParams{Model: opt.Model, Sigma: x[0], Lambda: x[nVol]}
+		if opt.Backbone {
+			p.Sigma = x[slice]
+		}
+		j := x[nVol+1:]
+		if opt.Model == KouJumps {
+			p.P, p.Eta1, p.Eta2 = j[0], j[1], j[2]
+		} else {
+			p.MuJ, p.SigmaJ = j[0], j[1]
+		}
+		return p
+	}
+	prices := func(p JumpDiffusionParams, q sliceQuotes) []float64 {
+		cf := func(u complex128) complex128 { return p.cf(u, q.T) }
+		return lewisPrices(cf, q.F, q.df, q.strikes, q.isCall)
+	}
+
+	res, err := CalibrateContext(ctx, CalibrationProblem{
+		Names:   names,
+		Initial: x0,
+		Lower:   lower,
+		Upper:   upper,
+		Weights: weights,
+		Residuals: func(x []float64) ([]float64, error) {
+			var r []float64
+			for k, q := range sets {
+				model := prices(toParams(x, k), q)
+				for j := range model {
+					r = append(r, model[j]-q.prices[j])
+				}
+			}
+			return r, nil
+		},
+	}, opt.LM)
+	if err != nil && (ctx.Err() == nil || res.Params == nil) {
+		return JumpCalibration{}, err
+	}
+
+	out := JumpCalibration{Params: toParams(res.Params, 0), Result: res}
+	sumSq, count := 0.0, 0
+	for k, q := range sets {
+		p := toParams(res.Params, k)
+		fit := JumpExpiryFit{T: q.T, Sigma: p.Sigma}
+		fit.Points, fit.RMSE, fit.MaxAbsError = q.compare(prices(p, q))
+		for _, pt := range fit.Points {
+			sumSq += pt.VolError * pt.VolError
+			count++
+		}
+		out.MaxAbsError = math.Max(out.MaxAbsError, fit.MaxAbsError)
+		out.Expiries = append(out.Expiries, fit)
+	}
+	out.RMSE = math.Sqrt(sumSq / float64(count))
+	return out, err
+}
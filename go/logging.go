@@ -0,0 +1,144 @@
+package bsm
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// discardLogger is enabled at no level, so disabled logging costs
+	// neither formatting nor trace IDs.
+	discardLogger = slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.Level(math.MaxInt32)}))
+	logger        atomic.Pointer[slog.Logger]
+	spanHook      atomic.Pointer[SpanHook]
+)
+
+func init() { logger.Store(discardLogger) }
+
+// SetLogger routes the library's structured logs to l. Logging is off
+// until it is called; nil turns it off again.
+func SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = discardLogger
+	}
+	logger.Store(l)
+}
+
+// SpanHook lets a tracer such as OpenTelemetry observe the library's
+// operations without the library depending on it. StartSpan is called as
+// an operation begins and may return a context carrying its span; the
+// returned function is called once when the operation ends.
+type SpanHook interface {
+	StartSpan(ctx context.Context, name string, attrs []slog.Attr) (context.Context, func(err error, attrs []slog.Attr))
+}
+
+// SetSpanHook installs h; nil removes it.
+func SetSpanHook(h SpanHook) {
+	if h == nil {
+		spanHook.Store(nil)
+		return
+	}
+	spanHook.Store(&h)
+}
+
+type traceKey struct{}
+
+// NewTraceID returns a random 128-bit trace ID in hex, the W3C and
+// OpenTelemetry format.
+func NewTraceID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// WithTraceID returns ctx carrying a trace ID for the library's logs.
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, traceKey{}, id)
+}
+
+// TraceID returns ctx's trace ID, or "" if it has none.
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceKey{}).(string)
+	return id
+}
+
+// Fingerprint is a short stable hash of v's JSON encoding, to identify
+// inputs in logs without printing them.
+func Fingerprint(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "unhashable"
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprintAttr is a log attribute holding v's fingerprint, computed
+// only if the record is actually logged.
+func fingerprintAttr(v any) slog.Attr {
+	return slog.Any("input", lazyFingerprint{v})
+}
+
+type lazyFingerprint struct{ v any }
+
+func (f lazyFingerprint) LogValue() slog.Value { return slog.StringValue(Fingerprint(f.v)) }
+
+// Span times one operation, logging its start at debug level and its end
+// with the duration, and reports both to the span hook if one is set.
+type Span struct {
+	ctx   context.Context
+	name  string
+	start time.Time
+	level slog.Level // Of the end record on success
+	end   func(error, []slog.Attr)
+}
+
+// StartSpan begins an operation, giving ctx a trace ID if it has none and
+// anything is listening. Packages built on this one, such as the RPC
+// server, use it so their operations share the logger, hook and trace IDs.
+func StartSpan(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	h := spanHook.Load()
+	if TraceID(ctx) == "" && (h != nil || logger.Load().Enabled(ctx, slog.LevelError)) {
+		ctx = WithTraceID(ctx, NewTraceID())
+	}
+	s := &Span{name: name, start: time.Now(), level: slog.LevelInfo}
+	if h != nil {
+		ctx, s.end = (*h).StartSpan(ctx, name, attrs)
+	}
+	s.ctx = ctx
+	logAttrs(ctx, slog.LevelDebug, name+" start", attrs...)
+	return ctx, s
+}
+
+// End finishes the span with its outcome and any result attributes.
+func (s *Span) End(err error, attrs ...slog.Attr) {
+	if s.end != nil {
+		s.end(err, attrs)
+	}
+	attrs = append(attrs, slog.Float64("duration_ms", float64(time.Since(s.start).Microseconds())/1000))
+	if err != nil {
+		logAttrs(s.ctx, slog.LevelError, s.name+" failed", append(attrs, slog.String("error", err.Error()))...)
+		return
+	}
+	logAttrs(s.ctx, s.level, s.name+" done", attrs...)
+}
+
+// logAttrs logs with ctx's trace ID attached.
+func logAttrs(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	l := logger.Load()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	if id := TraceID(ctx); id != "" {
+		attrs = append(attrs, slog.String("trace_id", id))
+	}
+	l.LogAttrs(ctx, level, msg, attrs...)
+}
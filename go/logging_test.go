@@ -0,0 +1,62 @@
+package bsm
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+// recorder is a slog.Handler keeping every record.
+type recorder struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recorder) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recorder) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recorder) WithGroup(string) slog.Handler            { return h }
+func (h *recorder) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+// find returns the attributes of the first record with msg.
+func (h *recorder) find(msg string) map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message == msg {
+			attrs := map[string]slog.Value{}
+			r.Attrs(func(a slog.Attr) bool { attrs[a.Key] = a.Value; return true })
+			return attrs
+		}
+	}
+	return nil
+}
+
+func TestPriceBatchSpan(t *testing.T) {
+	rec := &recorder{}
+	SetLogger(slog.New(rec))
+	t.Cleanup(func() { SetLogger(nil) })
+
+	ok := Inputs{S0: 100, K: 100, T: 1, Sigma: 0.2, R: 0.03, OptType: Call}
+	bad := ok
+	bad.K = -1
+	ctx := WithTraceID(context.Background(), "trace-1")
+	if _, err := PriceBatchContext(ctx, []Inputs{ok, bad, ok}); err == nil {
+		t.Fatal("batch with a bad contract returned no error")
+	}
+	if rec.find("price_batch start") == nil {
+		t.Error("no start record")
+	}
+	done := rec.find("price_batch done")
+	if done == nil {
+		t.Fatal("no done record")
+	}
+	if done["contracts"].Int64() != 3 || done["errors"].Int64() != 1 || done["trace_id"].String() != "trace-1" {
+		t.Errorf("done record %v", done)
+	}
+}
@@ -0,0 +1,235 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// MCOptions configures PriceMC.
+type MCOptions struct {
+	Paths      int      // Samples (100000); with Antithetic, each is a pair of paths
+	Grid       TimeGrid // Observation times ending at T; one step to expiry if nil
+	Antithetic bool     // Pair each normal draw z with -z
+	Sobol      bool     // Sobol normals through a Brownian bridge instead of pseudo-random ones
+	Seed       uint64   // Pseudo-random seed, also used to pad Sobol dimensions
+}
+
+// MCPayoff is a path payoff for PriceMC; path[0] is the spot and path[i] the
+// spot at Grid[i-1]. Gradient, if set, fills grad[i] = dValue/dpath[i] and
+// enables the pathwise Greeks. Leave it nil for discontinuous payoffs
+// (digitals, barriers), whose pathwise Greeks are biased; the
+// likelihood-ratio Greeks need only Value.
+type MCPayoff struct {
+	Value    PathPayoff
+	Gradient func(path, grad []float64)
+}
+
+// MCOutputs are Monte Carlo estimates with their standard errors. Vega is
+// per unit of vol, as Outputs.VegaPerVol. The pathwise Greeks are zero when
+// the payoff has no Gradient. With Sobol the standard errors treat the
+// points as independent and overstate the error.
+type MCOutputs struct {
+	Price, PriceStdErr                 float64
+	DeltaPathwise, DeltaPathwiseStdErr float64
+	VegaPathwise, VegaPathwiseStdErr   float64
+	DeltaLR, DeltaLRStdErr             float64 // Likelihood ratio
+	VegaLR, VegaLRStdErr               float64
+	Paths                              int // Samples; an antithetic pair counts once
+}
+
+// EuropeanMCPayoff is a vanilla payoff on the terminal spot, with gradient.
+func EuropeanMCPayoff(K float64, optType OptionType) MCPayoff {
+	return MCPayoff{
+		Value: EuropeanPayoff(K, optType),
+		Gradient: func(path, grad []float64) {
+			clear(grad)
+			n := len(path) - 1
+			if optType == Call && path[n] > K {
+				grad[n] = 1
+			} else if optType != Call && path[n] < K {
+				grad[n] = -1
+			}
+		},
+	}
+}
+
+// ArithmeticAsianMCPayoff averages the spot over the grid dates (excluding
+// the spot today), with gradient.
+func ArithmeticAsianMCPayoff(K float64, optType OptionType) MCPayoff {
+	value := ArithmeticAsianPayoff(K, optType)
+	return MCPayoff{
+		Value: value,
+		Gradient: func(path, grad []float64) {
+			clear(grad)
+			if value(path) == 0 {
+				return
+			}
+			w := 1 / float64(len(path)-1)
+			if optType != Call {
+				w = -w
+			}
+			for i := 1; i < len(path); i++ {
+				grad[i] = w
+			}
+		},
+	}
+}
+
+// PriceMC prices a European path-dependent payoff under GBM by Monte Carlo.
+// Delta and vega come both pathwise, differentiating each path, and by
+// likelihood ratio, weighting the payoff by the score of the path density:
+// z1/(S0 sigma sqrt(dt1)) for delta and sum (zi^2 - 1)/sigma - zi sqrt(dti)
+// for vega, where zi are the normalised log-increments. The likelihood-ratio
+// estimators work for any payoff but are noisier, especially on fine grids.
+func PriceMC(in Inputs, payoff MCPayoff, opt MCOptions) (MCOutputs, error) {
+	return PriceMCContext(context.Background(), in, payoff, opt)
+}
+
+// mcCheckEvery is how many samples PriceMCContext draws between checks of
+// its context.
+const mcCheckEvery = 1024
+
+// PriceMCContext is PriceMC with a context: when ctx is done it stops and
+// returns ctx's error with the estimates from the samples drawn so far
+// (Paths says how many), if there are at least two.
+func PriceMCContext(ctx context.Context, in Inputs, payoff MCPayoff, opt MCOptions) (MCOutputs, error) {
+	if err := in.Validate(); err != nil {
+		return MCOutputs{}, err
+	}
+	if err := noDividends(in, "Monte Carlo"); err != nil {
+		return MCOutputs{}, err
+	}
+	if in.Model != "" && in.Model != BSMModel {
+		return MCOutputs{}, errors.New("Monte Carlo supports the BSM model only")
+	}
+	if in.Exercise == American {
+		return MCOutputs{}, errors.New("Monte Carlo does not price early exercise")
+	}
+	if in.T == 0 || in.Sigma == 0 {
+		return MCOutputs{}, errors.New("Monte Carlo needs positive expiry and volatility")
+	}
+	if payoff.Value == nil {
+		return MCOutputs{}, errors.New("payoff has no value function")
+	}
+	if opt.Paths == 0 {
+		opt.Paths = 100000
+	}
+	if opt.Paths < 2 {
+		return MCOutputs{}, errors.New("need at least two paths")
+	}
+	grid := opt.Grid
+	if grid == nil {
+		grid = TimeGrid{in.T}
+	}
+	if err := grid.Validate(); err != nil {
+		return MCOutputs{}, err
+	}
+	if math.Abs(grid[len(grid)-1]-in.T) > 1e-12 {
+		return MCOutputs{}, errors.New("time grid must end at expiry")
+	}
+
+	rng := NewDefaultRNG(opt.Seed)
+	var construction WienerConstruction
+	var sobol *SobolNormals
+	var err error
+	if opt.Sobol {
+		if construction, err = NewBrownianBridge(grid); err != nil {
+			return MCOutputs{}, err
+		}
+		if sobol, err = NewSobolNormals(len(grid), rng); err != nil {
+			return MCOutputs{}, err
+		}
+	} else if construction, err = NewIncrementalConstruction(grid); err != nil {
+		return MCOutputs{}, err
+	}
+
+	g := GBM{S0: in.S0, R: in.R, Q: in.Q, Sigma: in.Sigma}
+	df := math.Exp(-in.R * in.T)
+	sqrtDt := make([]float64, len(grid))
+	prev := 0.0
+	for i, t := range grid {
+		sqrtDt[i] = math.Sqrt(t - prev)
+		prev = t
+	}
+	z := make([]float64, len(grid))
+	w := make([]float64, len(grid))
+	path := make([]float64, len(grid)+1)
+	var grad []float64
+	if payoff.Gradient != nil {
+		grad = make([]float64, len(path))
+	}
+
+	// sample prices one path from Brownian path w and returns the
+	// discounted payoff and its four Greek estimators.
+	sample := func() (v, dPW, vPW, dLR, vLR float64) {
+		g.PathFromWiener(grid, w, path)
+		v = df * payoff.Value(path)
+		prevW, score := 0.0, 0.0
+		for i, s := range sqrtDt {
+			zi := (w[i] - prevW) / s
+			score += (zi*zi-1)/in.Sigma - zi*s
+			prevW = w[i]
+		}
+		dLR = v * w[0] / (in.S0 * in.Sigma * sqrtDt[0] * sqrtDt[0])
+		vLR = v * score
+		if grad != nil {
+			payoff.Gradient(path, grad)
+			for i, t := range grid {
+				s := path[i+1]
+				dPW += grad[i+1] * s / in.S0
+				vPW += grad[i+1] * s * (w[i] - in.Sigma*t)
+			}
+			dPW *= df
+			vPW *= df
+		}
+		return
+	}
+
+	var price, deltaPW, vegaPW, deltaLR, vegaLR runningStat
+	paths := opt.Paths
+	var stopped error
+	for n := 0; n < opt.Paths; n++ {
+		if n%mcCheckEvery == 0 {
+			if stopped = ctx.Err(); stopped != nil {
+				if n < 2 {
+					return MCOutputs{}, stopped
+				}
+				paths = n
+				break
+			}
+		}
+		if sobol != nil {
+			sobol.Next(z)
+		} else {
+			for i := range z {
+				z[i] = rng.NormFloat64()
+			}
+		}
+		construction.Build(z, w)
+		v, dPW, vPW, dLR, vLR := sample()
+		if opt.Antithetic {
+			for i := range w {
+				w[i] = -w[i]
+			}
+			v2, dPW2, vPW2, dLR2, vLR2 := sample()
+			v, dPW, vPW, dLR, vLR = (v+v2)/2, (dPW+dPW2)/2, (vPW+vPW2)/2, (dLR+dLR2)/2, (vLR+vLR2)/2
+		}
+		price.add(v)
+		deltaPW.add(dPW)
+		vegaPW.add(vPW)
+		deltaLR.add(dLR)
+		vegaLR.add(vLR)
+	}
+	out := MCOutputs{
+		Price: price.mean(), PriceStdErr: price.stdErr(),
+		DeltaLR: deltaLR.mean(), DeltaLRStdErr: deltaLR.stdErr(),
+		VegaLR: vegaLR.mean(), VegaLRStdErr: vegaLR.stdErr(),
+		Paths: paths,
+	}
+	if grad != nil {
+		out.DeltaPathwise, out.DeltaPathwiseStdErr = deltaPW.mean(), deltaPW.stdErr()
+		out.VegaPathwise, out.VegaPathwiseStdErr = vegaPW.mean(), vegaPW.stdErr()
+	}
+	return out, stopped
+}
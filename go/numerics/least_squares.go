@@ -0,0 +1,337 @@
+package numerics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ResidualFunc returns model-minus-market residuals for a parameter vector.
+type ResidualFunc func(params []float64) ([]float64, error)
+
+// JacobianFunc returns jac[i][j] = d residual_i / d param_j.
+type JacobianFunc func(params []float64) ([][]float64, error)
+
+// LeastSquares is a bounded, weighted least-squares problem. Jacobian is
+// optional and bumped by central differences when nil. Lower/Upper and
+// Weights may be nil.
+type LeastSquares struct {
+	Initial   []float64
+	Lower     []float64
+	Upper     []float64
+	Residuals ResidualFunc
+	Jacobian  JacobianFunc
+	Weights   []float64 // Multiply each residual
+}
+
+// LMOptions tunes the Levenberg-Marquardt iteration. Zero fields take the
+// defaults in parentheses.
+type LMOptions struct {
+	MaxIterations int     // (200)
+	FTol          float64 // Relative cost reduction to stop (1e-12)
+	XTol          float64 // Relative step size to stop (1e-10)
+	GTol          float64 // Max projected gradient to stop (1e-12)
+	Lambda0       float64 // Initial damping (1e-3)
+	Bump          float64 // Relative bump for the numerical Jacobian (1e-6)
+
+	// Progress, if set, is called at the start of each iteration.
+	Progress func(iteration int, cost, lambda float64)
+}
+
+func (o LMOptions) withDefaults() LMOptions {
+	if o.MaxIterations <= 0 {
+		o.MaxIterations = 200
+	}
+	if o.FTol <= 0 {
+		o.FTol = 1e-12
+	}
+	if o.XTol <= 0 {
+		o.XTol = 1e-10
+	}
+	if o.GTol <= 0 {
+		o.GTol = 1e-12
+	}
+	if o.Lambda0 <= 0 {
+		o.Lambda0 = 1e-3
+	}
+	if o.Bump <= 0 {
+		o.Bump = 1e-6
+	}
+	return o
+}
+
+// LMResult is the fitted parameter vector and fit statistics on the
+// weighted residuals.
+type LMResult struct {
+	X          []float64
+	Residuals  []float64 // Unweighted, at X
+	RMSE       float64   // Weighted root-mean-square residual
+	Cost       float64   // Half the weighted sum of squares
+	AtBound    []bool
+	Iterations int
+	Converged  bool
+	Reason     string
+}
+
+// LevenbergMarquardt minimises half the weighted sum of squared residuals
+// with a projected Levenberg-Marquardt method: steps are clipped to the box
+// and bound-active parameters whose gradient points outward are held
+// fixed. When ctx is done it stops and returns the best parameters so far,
+// not Converged, with ctx's error.
+func LevenbergMarquardt(ctx context.Context, p LeastSquares, opt LMOptions) (LMResult, error) {
+	opt = opt.withDefaults()
+	n := len(p.Initial)
+	if n == 0 || p.Residuals == nil {
+		return LMResult{}, errors.New("calibration needs initial parameters and a residual function")
+	}
+	if (p.Lower != nil && len(p.Lower) != n) || (p.Upper != nil && len(p.Upper) != n) {
+		return LMResult{}, errors.New("bounds must match the number of parameters")
+	}
+	lo, hi := make([]float64, n), make([]float64, n)
+	for j := range lo {
+		lo[j], hi[j] = math.Inf(-1), math.Inf(1)
+		if p.Lower != nil {
+			lo[j] = p.Lower[j]
+		}
+		if p.Upper != nil {
+			hi[j] = p.Upper[j]
+		}
+		if lo[j] > hi[j] {
+			return LMResult{}, fmt.Errorf("lower bound above upper bound for parameter %d", j)
+		}
+	}
+	clip := func(x []float64) {
+		for j := range x {
+			x[j] = math.Min(math.Max(x[j], lo[j]), hi[j])
+		}
+	}
+
+	weighted := func(x []float64) ([]float64, float64, error) {
+		r, err := p.Residuals(x)
+		if err != nil {
+			return nil, 0, err
+		}
+		if p.Weights != nil && len(p.Weights) != len(r) {
+			return nil, 0, errors.New("weights must match the number of residuals")
+		}
+		rw := make([]float64, len(r))
+		cost := 0.0
+		for i, v := range r {
+			if p.Weights != nil {
+				v *= p.Weights[i]
+			}
+			rw[i] = v
+			cost += 0.5 * v * v
+		}
+		if math.IsNaN(cost) {
+			return nil, 0, errors.New("residuals contain NaN")
+		}
+		return rw, cost, nil
+	}
+	jacobian := func(x []float64) ([][]float64, error) {
+		var jac [][]float64
+		var err error
+		if p.Jacobian != nil {
+			jac, err = p.Jacobian(x)
+		} else {
+			jac, err = bumpJacobian(p.Residuals, x, lo, hi, opt.Bump)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.Weights != nil {
+			for i := range jac {
+				for j := range jac[i] {
+					jac[i][j] *= p.Weights[i]
+				}
+			}
+		}
+		return jac, nil
+	}
+
+	x := append([]float64(nil), p.Initial...)
+	clip(x)
+	r, cost, err := weighted(x)
+	if err != nil {
+		return LMResult{}, err
+	}
+	lambda := opt.Lambda0
+	res := LMResult{Reason: "maximum iterations reached"}
+	var stopped error
+
+iterate:
+	for res.Iterations = 0; res.Iterations < opt.MaxIterations; res.Iterations++ {
+		if stopped = ctx.Err(); stopped != nil {
+			res.Reason = "stopped: " + stopped.Error()
+			break
+		}
+		if opt.Progress != nil {
+			opt.Progress(res.Iterations, cost, lambda)
+		}
+		jac, err := jacobian(x)
+		if err != nil {
+			return LMResult{}, err
+		}
+		g := make([]float64, n)
+		A := make([][]float64, n)
+		for a := range A {
+			A[a] = make([]float64, n)
+		}
+		for i, row := range jac {
+			for a := 0; a < n; a++ {
+				g[a] += row[a] * r[i]
+				for b := 0; b < n; b++ {
+					A[a][b] += row[a] * row[b]
+				}
+			}
+		}
+		// Free parameters: interior, or on a bound with the descent
+		// direction pointing inside.
+		free := make([]bool, n)
+		gmax := 0.0
+		for j := range free {
+			free[j] = !(x[j] <= lo[j] && g[j] > 0) && !(x[j] >= hi[j] && g[j] < 0)
+			if free[j] {
+				gmax = math.Max(gmax, math.Abs(g[j]))
+			}
+		}
+		if gmax < opt.GTol {
+			res.Converged, res.Reason = true, "gradient below tolerance"
+			break
+		}
+
+		for {
+			M := make([][]float64, n)
+			rhs := make([]float64, n)
+			for a := range M {
+				M[a] = make([]float64, n)
+				if !free[a] {
+					M[a][a] = 1
+					continue
+				}
+				for b := range M[a] {
+					if free[b] {
+						M[a][b] = A[a][b]
+					}
+				}
+				M[a][a] += lambda * math.Max(A[a][a], 1e-12)
+				rhs[a] = -g[a]
+			}
+			step, err := SolveLinear(M, rhs)
+			if err != nil {
+				return LMResult{}, fmt.Errorf("normal equations: %w", err)
+			}
+			xn := make([]float64, n)
+			for j := range xn {
+				xn[j] = x[j] + step[j]
+			}
+			clip(xn)
+			rn, costN, err := weighted(xn)
+			if err == nil && costN < cost {
+				dx, xs := 0.0, 0.0
+				for j := range xn {
+					dx = math.Max(dx, math.Abs(xn[j]-x[j]))
+					xs = math.Max(xs, math.Abs(x[j]))
+				}
+				reduction := (cost - costN) / math.Max(cost, 1e-300)
+				x, r, cost = xn, rn, costN
+				lambda = math.Max(lambda/3, 1e-12)
+				if reduction < opt.FTol {
+					res.Converged, res.Reason = true, "cost reduction below tolerance"
+					break iterate
+				}
+				if dx <= opt.XTol*(xs+opt.XTol) {
+					res.Converged, res.Reason = true, "step below tolerance"
+					break iterate
+				}
+				break
+			}
+			lambda *= 4
+			if lambda > 1e16 {
+				res.Converged, res.Reason = true, "no further decrease possible"
+				break iterate
+			}
+		}
+	}
+
+	raw, err := p.Residuals(x)
+	if err != nil {
+		return LMResult{}, err
+	}
+	res.X, res.Residuals, res.Cost = x, raw, cost
+	res.RMSE = math.Sqrt(2 * cost / float64(len(r)))
+	res.AtBound = make([]bool, n)
+	for j := range x {
+		res.AtBound[j] = x[j] <= lo[j] || x[j] >= hi[j]
+	}
+	return res, stopped
+}
+
+// bumpJacobian differentiates f by central differences, switching to a
+// one-sided difference where a bump would leave the box.
+func bumpJacobian(f ResidualFunc, x, lo, hi []float64, rel float64) ([][]float64, error) {
+	var jac [][]float64
+	xb := append([]float64(nil), x...)
+	for j := range x {
+		h := rel * math.Max(math.Abs(x[j]), 1e-3)
+		up, dn := math.Min(x[j]+h, hi[j]), math.Max(x[j]-h, lo[j])
+		xb[j] = up
+		ru, err := f(xb)
+		if err != nil {
+			return nil, err
+		}
+		xb[j] = dn
+		rd, err := f(xb)
+		if err != nil {
+			return nil, err
+		}
+		xb[j] = x[j]
+		if jac == nil {
+			jac = make([][]float64, len(ru))
+			for i := range jac {
+				jac[i] = make([]float64, len(x))
+			}
+		}
+		for i := range ru {
+			jac[i][j] = (ru[i] - rd[i]) / (up - dn)
+		}
+	}
+	return jac, nil
+}
+
+// SolveLinear solves A x = b by Gaussian elimination with partial pivoting.
+func SolveLinear(A [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = append(append([]float64(nil), A[i]...), b[i])
+	}
+	for c := 0; c < n; c++ {
+		p := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[p][c]) {
+				p = r
+			}
+		}
+		if math.Abs(m[p][c]) < 1e-300 {
+			return nil, errors.New("singular matrix")
+		}
+		m[c], m[p] = m[p], m[c]
+		for r := c + 1; r < n; r++ {
+			f := m[r][c] / m[c][c]
+			for j := c; j <= n; j++ {
+				m[r][j] -= f * m[c][j]
+			}
+		}
+	}
+	x := make([]float64, n)
+	for i := n - 1; i >= 0; i-- {
+		s := m[i][n]
+		for j := i + 1; j < n; j++ {
+			s -= m[i][j] * x[j]
+		}
+		x[i] = s / m[i][i]
+	}
+	return x, nil
+}
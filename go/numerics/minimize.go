@@ -0,0 +1,168 @@
+package numerics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// GoldenSection minimises f on [a, b], where it must be unimodal, by
+// golden-section search. Result.F is the minimum; FTol is unused.
+func GoldenSection(f func(float64) float64, a, b float64, opt Options) (Result, error) {
+	opt = opt.withDefaults()
+	const g = 0.6180339887498949 // (sqrt(5) - 1) / 2
+	x1, x2 := b-g*(b-a), a+g*(b-a)
+	f1, f2 := f(x1), f(x2)
+	res := Result{Evaluations: 2}
+	for res.Iterations = 0; res.Iterations < opt.MaxIterations; res.Iterations++ {
+		if math.Abs(b-a) <= opt.tol(0.5*(a+b)) {
+			break
+		}
+		if f1 < f2 {
+			b, x2, f2 = x2, x1, f1
+			x1 = b - g*(b-a)
+			f1 = f(x1)
+		} else {
+			a, x1, f1 = x1, x2, f2
+			x2 = a + g*(b-a)
+			f2 = f(x2)
+		}
+		res.Evaluations++
+	}
+	res.X = 0.5 * (a + b)
+	res.F = f(res.X)
+	res.Evaluations++
+	if math.Abs(b-a) > opt.tol(res.X) {
+		return res.exhausted()
+	}
+	return res.done("bracket below tolerance")
+}
+
+// NMOptions tunes the Nelder-Mead search. Zero fields take the defaults in
+// parentheses.
+type NMOptions struct {
+	MaxIterations int     // (200 per parameter)
+	Step          float64 // Initial simplex edge, relative to each |x0[j]| (0.05; 0.00025 where x0[j] is zero)
+	FTol          float64 // Spread of f over the simplex to stop (1e-10)
+	XTol          float64 // Simplex size, relative to max(1, |x|), to stop (1e-10)
+}
+
+func (o NMOptions) withDefaults(n int) NMOptions {
+	if o.MaxIterations <= 0 {
+		o.MaxIterations = 200 * n
+	}
+	if o.Step <= 0 {
+		o.Step = 0.05
+	}
+	if o.FTol <= 0 {
+		o.FTol = 1e-10
+	}
+	if o.XTol <= 0 {
+		o.XTol = 1e-10
+	}
+	return o
+}
+
+// MinResult is a multi-dimensional minimiser's answer with its
+// diagnostics.
+type MinResult struct {
+	X           []float64
+	F           float64 // f(X)
+	Iterations  int
+	Evaluations int
+	Converged   bool
+	Reason      string
+}
+
+// NelderMead minimises f from x0 with the Nelder-Mead simplex method. It
+// needs no derivatives, so it suits noisy or kinked objectives that
+// Levenberg-Marquardt handles badly, at the cost of many more evaluations.
+// Constrain parameters by returning +Inf (or a penalty) outside the
+// feasible region.
+func NelderMead(f func(x []float64) float64, x0 []float64, opt NMOptions) (MinResult, error) {
+	n := len(x0)
+	if n == 0 {
+		return MinResult{}, errors.New("nelder-mead needs at least one parameter")
+	}
+	opt = opt.withDefaults(n)
+	type vertex struct {
+		x []float64
+		f float64
+	}
+	res := MinResult{}
+	eval := func(x []float64) vertex {
+		res.Evaluations++
+		v := f(x)
+		if math.IsNaN(v) {
+			v = math.Inf(1)
+		}
+		return vertex{x, v}
+	}
+	simplex := make([]vertex, n+1)
+	simplex[0] = eval(append([]float64(nil), x0...))
+	for j := 0; j < n; j++ {
+		x := append([]float64(nil), x0...)
+		if x[j] != 0 {
+			x[j] *= 1 + opt.Step
+		} else {
+			x[j] = 0.00025
+		}
+		simplex[j+1] = eval(x)
+	}
+	// along returns c + t (w - c).
+	along := func(c, w []float64, t float64) []float64 {
+		x := make([]float64, n)
+		for j := range x {
+			x[j] = c[j] + t*(w[j]-c[j])
+		}
+		return x
+	}
+
+	for res.Iterations = 0; res.Iterations < opt.MaxIterations; res.Iterations++ {
+		sort.SliceStable(simplex, func(a, b int) bool { return simplex[a].f < simplex[b].f })
+		best, worst := simplex[0], simplex[n]
+		size := 0.0
+		for _, v := range simplex[1:] {
+			for j := range v.x {
+				size = math.Max(size, math.Abs(v.x[j]-best.x[j])/math.Max(1, math.Abs(best.x[j])))
+			}
+		}
+		if math.Abs(worst.f-best.f) <= opt.FTol && size <= opt.XTol {
+			res.X, res.F, res.Converged, res.Reason = best.x, best.f, true, "simplex below tolerance"
+			return res, nil
+		}
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			for j := range centroid {
+				centroid[j] += v.x[j] / float64(n)
+			}
+		}
+		r := eval(along(centroid, worst.x, -1))
+		switch {
+		case r.f < best.f:
+			if e := eval(along(centroid, worst.x, -2)); e.f < r.f {
+				simplex[n] = e
+			} else {
+				simplex[n] = r
+			}
+		case r.f < simplex[n-1].f:
+			simplex[n] = r
+		default:
+			t := 0.5 // Inside contraction
+			if r.f < worst.f {
+				t = -0.5 // Outside contraction
+			}
+			if c := eval(along(centroid, worst.x, t)); c.f < math.Min(r.f, worst.f) {
+				simplex[n] = c
+				continue
+			}
+			for k := 1; k <= n; k++ { // Shrink towards the best vertex
+				simplex[k] = eval(along(best.x, simplex[k].x, 0.5))
+			}
+		}
+	}
+	sort.SliceStable(simplex, func(a, b int) bool { return simplex[a].f < simplex[b].f })
+	res.X, res.F, res.Reason = simplex[0].x, simplex[0].f, "maximum iterations reached"
+	return res, fmt.Errorf("%w after %d iterations", ErrNoConvergence, res.Iterations)
+}
@@ -0,0 +1,131 @@
+package numerics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestRootFinders(t *testing.T) {
+	cubic := func(x float64) float64 { return x*x*x - 2*x - 5 } // Root near 2.0945514815
+	const want = 2.0945514815423265
+	solvers := []struct {
+		name  string
+		solve func() (Result, error)
+	}{
+		{"bisect", func() (Result, error) { return Bisect(cubic, 2, 3, Options{}) }},
+		{"brent", func() (Result, error) { return Brent(cubic, 2, 3, Options{}) }},
+		{"newton", func() (Result, error) {
+			return Newton(func(x float64) (float64, float64) { return cubic(x), 3*x*x - 2 }, 2.5, 2, 3, Options{})
+		}},
+	}
+	for _, s := range solvers {
+		res, err := s.solve()
+		if err != nil || !res.Converged {
+			t.Errorf("%s: err %v, result %+v", s.name, err, res)
+			continue
+		}
+		if math.Abs(res.X-want) > 1e-11 {
+			t.Errorf("%s: got %.15g, want %.15g", s.name, res.X, want)
+		}
+	}
+	if r, _ := Brent(cubic, 2, 3, Options{}); r.Evaluations >= 40 {
+		t.Errorf("brent took %d evaluations", r.Evaluations)
+	}
+}
+
+func TestNewtonSafeguard(t *testing.T) {
+	// Plain Newton on atan overshoots from far out; the bracket reins it in.
+	res, err := Newton(func(x float64) (float64, float64) { return math.Atan(x - 1), 1 / (1 + (x-1)*(x-1)) },
+		-9, -10, 20, Options{})
+	if err != nil || math.Abs(res.X-1) > 1e-12 {
+		t.Errorf("got %+v, %v", res, err)
+	}
+}
+
+func TestRootErrors(t *testing.T) {
+	sq := func(x float64) float64 { return x*x + 1 }
+	if _, err := Brent(sq, -1, 1, Options{}); !errors.Is(err, ErrNotBracketed) {
+		t.Errorf("brent: got %v, want ErrNotBracketed", err)
+	}
+	if _, err := Bisect(sq, -1, 1, Options{}); !errors.Is(err, ErrNotBracketed) {
+		t.Errorf("bisect: got %v, want ErrNotBracketed", err)
+	}
+	res, err := Bisect(math.Sin, 3, 4, Options{MaxIterations: 5})
+	if !errors.Is(err, ErrNoConvergence) || res.Converged || math.Abs(res.X-math.Pi) > 1.0/32 {
+		t.Errorf("capped bisect: got %+v, %v", res, err)
+	}
+	if res, err := Brent(math.Sin, 0, 1, Options{}); err != nil || res.X != 0 || res.Reason != "exact root" {
+		t.Errorf("root at an end: got %+v, %v", res, err)
+	}
+}
+
+func TestGoldenSection(t *testing.T) {
+	res, err := GoldenSection(func(x float64) float64 { return (x - 0.3) * (x - 0.3) }, -1, 2, Options{})
+	if err != nil || math.Abs(res.X-0.3) > 1e-8 {
+		t.Errorf("got %+v, %v", res, err)
+	}
+}
+
+func rosenbrock(x []float64) float64 {
+	return 100*(x[1]-x[0]*x[0])*(x[1]-x[0]*x[0]) + (1-x[0])*(1-x[0])
+}
+
+func TestNelderMead(t *testing.T) {
+	res, err := NelderMead(rosenbrock, []float64{-1.2, 1}, NMOptions{MaxIterations: 2000})
+	if err != nil || !res.Converged {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if math.Abs(res.X[0]-1) > 1e-4 || math.Abs(res.X[1]-1) > 1e-4 {
+		t.Errorf("got %v, want [1 1]", res.X)
+	}
+}
+
+func TestLevenbergMarquardt(t *testing.T) {
+	// Fit y = a exp(b t) to exact data, then with b bounded above its true value.
+	ts := []float64{0, 0.5, 1, 1.5, 2, 2.5}
+	model := func(p []float64) ([]float64, error) {
+		r := make([]float64, len(ts))
+		for i, t := range ts {
+			r[i] = p[0]*math.Exp(p[1]*t) - 2*math.Exp(-0.7*t)
+		}
+		return r, nil
+	}
+	res, err := LevenbergMarquardt(context.Background(), LeastSquares{Initial: []float64{1, 0}, Residuals: model}, LMOptions{})
+	if err != nil || !res.Converged || res.RMSE > 1e-9 {
+		t.Fatalf("got %+v, %v", res, err)
+	}
+	if math.Abs(res.X[0]-2) > 1e-7 || math.Abs(res.X[1]+0.7) > 1e-7 {
+		t.Errorf("got %v, want [2 -0.7]", res.X)
+	}
+
+	res, err = LevenbergMarquardt(context.Background(), LeastSquares{
+		Initial: []float64{1, 0}, Lower: []float64{0, -0.5}, Upper: []float64{10, 1}, Residuals: model,
+	}, LMOptions{})
+	if err != nil || !res.AtBound[1] || res.X[1] != -0.5 {
+		t.Errorf("bounded: got %+v, %v", res, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res, err = LevenbergMarquardt(ctx, LeastSquares{Initial: []float64{1, 0}, Residuals: model}, LMOptions{})
+	if !errors.Is(err, context.Canceled) || res.Converged || res.X == nil {
+		t.Errorf("cancelled: got %+v, %v", res, err)
+	}
+}
+
+func TestSolveLinear(t *testing.T) {
+	x, err := SolveLinear([][]float64{{0, 2, 1}, {1, 1, 1}, {2, 1, 0}}, []float64{7, 6, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{1, 2, 3} {
+		if math.Abs(x[i]-want) > 1e-12 {
+			t.Errorf("x[%d] = %g, want %g", i, x[i], want)
+		}
+	}
+	if _, err := SolveLinear([][]float64{{1, 2}, {2, 4}}, []float64{1, 2}); err == nil {
+		t.Error("singular matrix solved")
+	}
+}
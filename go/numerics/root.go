@@ -0,0 +1,219 @@
+// Package numerics is the solver layer shared by the pricers and
+// calibrations in package bsm: bracketed root finders (bisection, Brent,
+// safeguarded Newton-Raphson), one-dimensional and simplex minimisers, and
+// a bounded Levenberg-Marquardt least-squares method. Every solver reports
+// how it stopped, so callers can log or act on non-convergence.
+package numerics
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrNotBracketed is returned when f has the same sign at both ends of
+	// the interval a root finder is given.
+	ErrNotBracketed = errors.New("root not bracketed")
+	// ErrNoConvergence is returned with the best estimate when a solver
+	// runs out of iterations.
+	ErrNoConvergence = errors.New("did not converge")
+)
+
+// Options bounds a one-dimensional solve. Zero fields take the defaults in
+// parentheses.
+type Options struct {
+	MaxIterations int     // (200)
+	XTol          float64 // Bracket width or step, relative to max(1, |x|), to stop (1e-12)
+	FTol          float64 // |f(x)| to stop (0: only on XTol or an exact root)
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxIterations <= 0 {
+		o.MaxIterations = 200
+	}
+	if o.XTol <= 0 {
+		o.XTol = 1e-12
+	}
+	return o
+}
+
+// tol is the absolute x tolerance near x.
+func (o Options) tol(x float64) float64 { return o.XTol * math.Max(1, math.Abs(x)) }
+
+// Result is a one-dimensional solver's answer with its diagnostics.
+type Result struct {
+	X           float64
+	F           float64 // f(X), or the objective at X for minimisers
+	Iterations  int
+	Evaluations int // Calls of f
+	Converged   bool
+	Reason      string
+}
+
+func (r Result) done(reason string) (Result, error) {
+	r.Converged, r.Reason = true, reason
+	return r, nil
+}
+
+func (r Result) exhausted() (Result, error) {
+	r.Reason = "maximum iterations reached"
+	return r, fmt.Errorf("%w after %d iterations", ErrNoConvergence, r.Iterations)
+}
+
+// bracket evaluates f at a and b and checks that they straddle a root.
+// It returns a finished Result if either end is one.
+func bracket(f func(float64) float64, a, b float64) (fa, fb float64, res Result, done bool, err error) {
+	fa, fb = f(a), f(b)
+	res.Evaluations = 2
+	switch {
+	case math.IsNaN(fa) || math.IsNaN(fb):
+		return 0, 0, res, true, fmt.Errorf("%w: f is NaN at an end of [%g, %g]", ErrNotBracketed, a, b)
+	case fa == 0:
+		res.X, res.Converged, res.Reason = a, true, "exact root"
+		return fa, fb, res, true, nil
+	case fb == 0:
+		res.X, res.Converged, res.Reason = b, true, "exact root"
+		return fa, fb, res, true, nil
+	case (fa < 0) == (fb < 0):
+		return 0, 0, res, true, fmt.Errorf("%w: f(%g) = %g and f(%g) = %g", ErrNotBracketed, a, fa, b, fb)
+	}
+	return fa, fb, res, false, nil
+}
+
+// Bisect finds a root of f in [a, b], where f changes sign, by halving the
+// bracket. It is slow but needs nothing of f beyond continuity.
+func Bisect(f func(float64) float64, a, b float64, opt Options) (Result, error) {
+	opt = opt.withDefaults()
+	fa, _, res, done, err := bracket(f, a, b)
+	if done {
+		return res, err
+	}
+	for res.Iterations = 0; res.Iterations < opt.MaxIterations; res.Iterations++ {
+		res.X = 0.5 * (a + b)
+		if math.Abs(b-a) <= opt.tol(res.X) {
+			return res.done("bracket below tolerance")
+		}
+		res.F = f(res.X)
+		res.Evaluations++
+		if res.F == 0 || math.Abs(res.F) <= opt.FTol {
+			return res.done("residual below tolerance")
+		}
+		if (res.F < 0) == (fa < 0) {
+			a, fa = res.X, res.F
+		} else {
+			b = res.X
+		}
+	}
+	res.X = 0.5 * (a + b)
+	return res.exhausted()
+}
+
+// Brent finds a root of f in [a, b], where f changes sign, by Brent's
+// method: inverse quadratic interpolation and secant steps, falling back to
+// bisection whenever they would converge more slowly.
+func Brent(f func(float64) float64, a, b float64, opt Options) (Result, error) {
+	opt = opt.withDefaults()
+	fa, fb, res, done, err := bracket(f, a, b)
+	if done {
+		return res, err
+	}
+	c, fc := a, fa
+	d := b - a
+	e := d
+	for res.Iterations = 0; res.Iterations < opt.MaxIterations; res.Iterations++ {
+		if (fb < 0) == (fc < 0) {
+			c, fc = a, fa
+			d = b - a
+			e = d
+		}
+		if math.Abs(fc) < math.Abs(fb) {
+			a, b, c = b, c, b
+			fa, fb, fc = fb, fc, fb
+		}
+		res.X, res.F = b, fb
+		eps := 2*1e-16*math.Abs(b) + 0.5*opt.tol(b)
+		m := 0.5 * (c - b)
+		if fb == 0 || math.Abs(fb) <= opt.FTol {
+			return res.done("residual below tolerance")
+		}
+		if math.Abs(m) <= eps {
+			return res.done("bracket below tolerance")
+		}
+		if math.Abs(e) >= eps && math.Abs(fa) > math.Abs(fb) {
+			// Inverse quadratic interpolation, or secant with two points.
+			s := fb / fa
+			var p, q float64
+			if a == c {
+				p, q = 2*m*s, 1-s
+			} else {
+				q, r := fa/fc, fb/fc
+				p = s * (2*m*q*(q-r) - (b-a)*(r-1))
+				q = (q - 1) * (r - 1) * (s - 1)
+			}
+			if p > 0 {
+				q = -q
+			} else {
+				p = -p
+			}
+			if 2*p < math.Min(3*m*q-math.Abs(eps*q), math.Abs(e*q)) {
+				e, d = d, p/q
+			} else {
+				d, e = m, m
+			}
+		} else {
+			d, e = m, m
+		}
+		a, fa = b, fb
+		if math.Abs(d) > eps {
+			b += d
+		} else {
+			b += math.Copysign(eps, m)
+		}
+		fb = f(b)
+		res.Evaluations++
+	}
+	res.X, res.F = b, fb
+	return res.exhausted()
+}
+
+// Newton finds a root of f in [lo, hi], where f changes sign, by
+// Newton-Raphson from x0; fdf returns f and its derivative. Steps that
+// would leave the bracket, which shrinks around the root as f is
+// evaluated, are replaced by bisection, so it converges wherever Bisect
+// would, and quadratically near a simple root.
+func Newton(fdf func(x float64) (f, df float64), x0, lo, hi float64, opt Options) (Result, error) {
+	opt = opt.withDefaults()
+	f := func(x float64) float64 { v, _ := fdf(x); return v }
+	flo, _, res, done, err := bracket(f, lo, hi)
+	if done {
+		return res, err
+	}
+	x := x0
+	if !(x > lo && x < hi) {
+		x = 0.5 * (lo + hi)
+	}
+	for res.Iterations = 0; res.Iterations < opt.MaxIterations; res.Iterations++ {
+		fx, dfx := fdf(x)
+		res.X, res.F = x, fx
+		res.Evaluations++
+		if fx == 0 || math.Abs(fx) <= opt.FTol {
+			return res.done("residual below tolerance")
+		}
+		if (fx < 0) == (flo < 0) {
+			lo = x
+		} else {
+			hi = x
+		}
+		next := x - fx/dfx
+		if !(next > lo && next < hi) {
+			next = 0.5 * (lo + hi)
+		}
+		if math.Abs(next-x) <= opt.tol(next) || hi-lo <= opt.tol(next) {
+			res.X = next
+			return res.done("step below tolerance")
+		}
+		x = next
+	}
+	return res.exhausted()
+}
@@ -0,0 +1,73 @@
+package bsm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// OptionType is Call or Put. The zero value is neither, so an option whose
+// type was never set fails validation, and every pricer taking one rejects
+// it, instead of being priced as a put. The Monte Carlo payoff
+// constructors, such as EuropeanPayoff, cannot fail and take anything but
+// Call as a put; the MC pricers validate only their Inputs.
+type OptionType int
+
+const (
+	Call OptionType = iota + 1
+	Put
+)
+
+// ParseOptionType parses "call" or "put", or "c" or "p", in any case.
+func ParseOptionType(s string) (OptionType, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "c", "call":
+		return Call, nil
+	case "p", "put":
+		return Put, nil
+	}
+	return 0, fmt.Errorf("%w %q, want %q or %q", ErrUnknownOptionType, s, "call", "put")
+}
+
+// Valid reports whether t is Call or Put.
+func (t OptionType) Valid() bool { return t == Call || t == Put }
+
+func (t OptionType) String() string {
+	switch t {
+	case Call:
+		return "call"
+	case Put:
+		return "put"
+	}
+	return fmt.Sprintf("OptionType(%d)", int(t))
+}
+
+// MarshalJSON writes "call" or "put", as saved runs and the CLI always
+// have, and "" for the unset zero value. Other values are an error.
+func (t OptionType) MarshalJSON() ([]byte, error) {
+	if t == 0 {
+		return []byte(`""`), nil
+	}
+	if !t.Valid() {
+		return nil, fmt.Errorf("%w %v", ErrUnknownOptionType, t)
+	}
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON accepts whatever ParseOptionType does, and "" as unset.
+func (t *OptionType) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("option type must be a string: %w", err)
+	}
+	if s == "" {
+		*t = 0
+		return nil
+	}
+	v, err := ParseOptionType(s)
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
@@ -0,0 +1,32 @@
+package bsm
+
+import (
+	"errors"
+	"testing"
+)
+
+// Pricers taking an OptionType reject an unset one rather than price a put.
+func TestPricersRejectUnsetOptionType(t *testing.T) {
+	heston := HestonInputs{S0: 100, K: 100, T: 0.5, R: 0.03,
+		Params: HestonParams{V0: 0.04, Kappa: 2, Theta: 0.04, Xi: 0.5, Rho: -0.7}}
+	in := Inputs{S0: 100, K: 100, T: 0.5, Sigma: 0.2, R: 0.03}
+	bond := BondOptionInputs{Bond: Bond{Coupon: 0.04, Maturity: 5}, ForwardYield: 0.04, K: 100, T: 0.5, R: 0.03, YieldVol: 0.2}
+	for name, price := range map[string]func() error{
+		"PriceHeston":           func() error { _, err := PriceHeston(heston); return err },
+		"PriceHestonGreeks":     func() error { _, err := PriceHestonGreeks(heston, 365); return err },
+		"PriceWithDistribution": func() error { _, err := PriceWithDistribution(in, BSMLogReturn(in)); return err },
+		"PriceEdgeworth": func() error {
+			_, err := PriceEdgeworth(in, EdgeworthLogReturn{StdDev: 0.14, Kurt: 3.5})
+			return err
+		},
+		"PriceBondOption": func() error { _, err := PriceBondOption(bond, 365); return err },
+		"PricePDE": func() error {
+			_, err := PricePDE(PDEContract{S0: 100, K: 100, T: 0.5, Sigma: 0.2, R: 0.03}, PDESettings{TimeSteps: 50, SpaceSteps: 100})
+			return err
+		},
+	} {
+		if err := price(); !errors.Is(err, ErrUnknownOptionType) {
+			t.Errorf("%s: got %v, want ErrUnknownOptionType", name, err)
+		}
+	}
+}
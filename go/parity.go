@@ -0,0 +1,94 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrCrossedQuote is returned when a bid is above its ask, so parity gives
+// no range to imply from.
+var ErrCrossedQuote = errors.New("crossed quote")
+
+// ParityPair is a call and a put quoted at the same strike and expiry.
+type ParityPair struct {
+	K, T             float64
+	CallBid, CallAsk float64
+	PutBid, PutAsk   float64
+}
+
+// ParityEstimate is a value implied by put-call parity from the mids, with
+// the range the bid/ask spreads allow: Low from selling the call and
+// buying the put, High from the reverse (swapped where the value falls as
+// C - P rises).
+type ParityEstimate struct {
+	Value, Low, High float64
+}
+
+// synthetic returns the mid, lowest and highest tradable C - P.
+func (p ParityPair) synthetic() (mid, lo, hi float64, err error) {
+	switch {
+	case !(p.K > 0) || !(p.T > 0):
+		return 0, 0, 0, fmt.Errorf("parity pair needs positive strike and expiry, got K=%g T=%g", p.K, p.T)
+	case p.CallBid < 0 || p.PutBid < 0 || !(p.CallAsk > 0) || !(p.PutAsk > 0):
+		return 0, 0, 0, errors.New("parity pair needs non-negative bids and positive asks")
+	case p.CallBid > p.CallAsk:
+		return 0, 0, 0, fmt.Errorf("%w: call bid %g above ask %g", ErrCrossedQuote, p.CallBid, p.CallAsk)
+	case p.PutBid > p.PutAsk:
+		return 0, 0, 0, fmt.Errorf("%w: put bid %g above ask %g", ErrCrossedQuote, p.PutBid, p.PutAsk)
+	}
+	mid = 0.5*(p.CallBid+p.CallAsk) - 0.5*(p.PutBid+p.PutAsk)
+	return mid, p.CallBid - p.PutAsk, p.CallAsk - p.PutBid, nil
+}
+
+// imply maps the synthetic's mid and range through f, which must be
+// monotone in C - P.
+func (p ParityPair) imply(f func(cp float64) float64) (ParityEstimate, error) {
+	mid, lo, hi, err := p.synthetic()
+	if err != nil {
+		return ParityEstimate{}, err
+	}
+	e := ParityEstimate{Value: f(mid), Low: f(lo), High: f(hi)}
+	if e.Low > e.High {
+		e.Low, e.High = e.High, e.Low
+	}
+	if math.IsNaN(e.Value) || math.IsNaN(e.Low) || math.IsNaN(e.High) {
+		return ParityEstimate{}, errors.New("quotes imply no positive discount factor or forward")
+	}
+	return e, nil
+}
+
+// ImpliedForward implies the forward F = K + (C - P) e^(rT) at discount
+// rate r.
+func ImpliedForward(p ParityPair, r float64) (ParityEstimate, error) {
+	return p.imply(func(cp float64) float64 {
+		return p.K + cp*math.Exp(r*p.T)
+	})
+}
+
+// ImpliedRate implies the continuous financing (repo) rate r from
+// C - P = S e^(-qT) - K e^(-rT) at spot S and dividend yield q.
+func ImpliedRate(p ParityPair, S, q float64) (ParityEstimate, error) {
+	return p.imply(func(cp float64) float64 {
+		df := (S*math.Exp(-q*p.T) - cp) / p.K
+		if df <= 0 {
+			return math.NaN()
+		}
+		return -math.Log(df) / p.T
+	})
+}
+
+// ImpliedDividendYield implies the continuous dividend yield q from
+// C - P = S e^(-qT) - K e^(-rT) at spot S and rate r.
+func ImpliedDividendYield(p ParityPair, S, r float64) (ParityEstimate, error) {
+	if !(S > 0) {
+		return ParityEstimate{}, ErrNegativeSpot
+	}
+	return p.imply(func(cp float64) float64 {
+		pv := cp + p.K*math.Exp(-r*p.T) // S e^(-qT)
+		if pv <= 0 {
+			return math.NaN()
+		}
+		return -math.Log(pv/S) / p.T
+	})
+}
@@ -0,0 +1,773 @@
+package bsm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadChainParquet reads a chain from a Parquet file of size bytes, with
+// the columns ReadChainCSV describes. Dates, timestamps and decimals are
+// read from their logical types; columns it does not use, including nested
+// ones, are skipped.
+//
+// The reader covers what pyarrow, Spark and DuckDB write by default: flat
+// required or optional columns, PLAIN and dictionary encodings, data pages
+// v1 and v2, and uncompressed, Snappy or gzip pages. Other codecs and
+// encodings are reported as unsupported.
+func ReadChainParquet(r io.ReaderAt, size int64, asOf time.Time) (*ChainSnapshot, error) {
+	pf, err := openParquet(r, size)
+	if err != nil {
+		return nil, err
+	}
+	var header []string
+	var cols [][]string
+	for _, c := range pf.columns {
+		if !chainColumns[strings.ToLower(c.name)] {
+			continue
+		}
+		vals, err := pf.read(c)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", c.name, err)
+		}
+		if int64(len(vals)) != pf.rows {
+			return nil, fmt.Errorf("column %s: %d values for %d rows", c.name, len(vals), pf.rows)
+		}
+		header = append(header, c.name)
+		cols = append(cols, vals)
+	}
+	row := 0
+	next := func() ([]string, error) {
+		if int64(row) == pf.rows {
+			return nil, io.EOF
+		}
+		rec := make([]string, len(cols))
+		for j, c := range cols {
+			rec[j] = c[row]
+		}
+		row++
+		return rec, nil
+	}
+	return readChainRecords(header, next, "row", 1, asOf)
+}
+
+// chainColumns are the columns readChainRecords uses.
+var chainColumns = map[string]bool{
+	"expiry": true, "t": true, "strike": true, "type": true, "bid": true, "ask": true,
+	"open_interest": true, "volume": true, "timestamp": true, "underlying": true,
+}
+
+// Parquet physical types, codecs, page types and encodings, as numbered in
+// the format's Thrift definitions.
+const (
+	pqBoolean = iota
+	pqInt32
+	pqInt64
+	pqInt96
+	pqFloat
+	pqDouble
+	pqByteArray
+	pqFixedLenByteArray
+)
+
+const (
+	pqUncompressed = 0
+	pqSnappy       = 1
+	pqGzip         = 2
+)
+
+const (
+	pqDataPage       = 0
+	pqDictionaryPage = 2
+	pqDataPageV2     = 3
+)
+
+const (
+	pqPlain           = 0
+	pqPlainDictionary = 2
+	pqRLEDictionary   = 8
+)
+
+var pqCodecNames = []string{"UNCOMPRESSED", "SNAPPY", "GZIP", "LZO", "BROTLI", "LZ4", "ZSTD", "LZ4_RAW"}
+
+// parquetFile is a Parquet file's footer: its top-level primitive columns
+// and row groups.
+type parquetFile struct {
+	r         io.ReaderAt
+	size      int64
+	rows      int64
+	columns   []parquetColumn
+	rowGroups []thriftStruct
+}
+
+// parquetColumn is a top-level primitive column's schema element.
+type parquetColumn struct {
+	name       string
+	typ        int64
+	optional   bool
+	converted  int64 // ConvertedType, or -1
+	logical    thriftStruct
+	scale      int
+	typeLength int
+}
+
+func openParquet(r io.ReaderAt, size int64) (*parquetFile, error) {
+	if size < 12 {
+		return nil, errors.New("not a parquet file: too short")
+	}
+	var tail [8]byte
+	if _, err := r.ReadAt(tail[:], size-8); err != nil {
+		return nil, err
+	}
+	var head [4]byte
+	if _, err := r.ReadAt(head[:], 0); err != nil {
+		return nil, err
+	}
+	if string(head[:]) != "PAR1" || string(tail[4:]) != "PAR1" {
+		return nil, errors.New("not a parquet file: bad magic")
+	}
+	n := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if n > size-12 {
+		return nil, errors.New("parquet footer length exceeds the file")
+	}
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, size-8-n); err != nil {
+		return nil, err
+	}
+	tr := &thriftReader{b: buf}
+	meta := tr.readStruct()
+	if tr.err != nil {
+		return nil, fmt.Errorf("parquet footer: %w", tr.err)
+	}
+
+	pf := &parquetFile{r: r, size: size, rows: meta.i64(3)}
+	schema := meta.list(2)
+	if len(schema) == 0 {
+		return nil, errors.New("parquet file has no schema")
+	}
+	// The root's children, skipping groups with their descendants.
+	i := 1
+	var skip func(i int) int
+	skip = func(i int) int {
+		el, _ := schema[i].(thriftStruct)
+		next := i + 1
+		for c := int64(0); c < el.i64(5) && next < len(schema); c++ {
+			next = skip(next)
+		}
+		return next
+	}
+	for c := int64(0); c < schemaStruct(schema, 0).i64(5) && i < len(schema); c++ {
+		el := schemaStruct(schema, i)
+		if el.i64(5) == 0 && el.i64(3) != 2 { // A leaf, not REPEATED
+			col := parquetColumn{
+				name: el.str(4), typ: el.i64(1), optional: el.i64(3) == 1,
+				converted: -1, logical: el.sub(10), scale: int(el.i64(7)), typeLength: int(el.i64(2)),
+			}
+			if el.has(6) {
+				col.converted = el.i64(6)
+			}
+			pf.columns = append(pf.columns, col)
+		}
+		i = skip(i)
+	}
+	for _, rg := range meta.list(4) {
+		if s, ok := rg.(thriftStruct); ok {
+			pf.rowGroups = append(pf.rowGroups, s)
+		}
+	}
+	return pf, nil
+}
+
+func schemaStruct(schema []any, i int) thriftStruct {
+	s, _ := schema[i].(thriftStruct)
+	return s
+}
+
+// read returns the column's values over every row group as text, empty
+// for nulls.
+func (pf *parquetFile) read(c parquetColumn) ([]string, error) {
+	var out []string
+	for g, rg := range pf.rowGroups {
+		var meta thriftStruct
+		for _, cc := range rg.list(1) {
+			chunk, _ := cc.(thriftStruct)
+			m := chunk.sub(3)
+			if path := m.list(3); len(path) == 1 {
+				if name, _ := path[0].([]byte); string(name) == c.name {
+					meta = m
+					break
+				}
+			}
+		}
+		if meta == nil {
+			return nil, fmt.Errorf("row group %d has no chunk", g)
+		}
+		vals, err := pf.readChunk(c, meta)
+		if err != nil {
+			return nil, fmt.Errorf("row group %d: %w", g, err)
+		}
+		out = append(out, vals...)
+	}
+	return out, nil
+}
+
+func (pf *parquetFile) readChunk(c parquetColumn, meta thriftStruct) ([]string, error) {
+	codec := meta.i64(4)
+	if codec != pqUncompressed && codec != pqSnappy && codec != pqGzip {
+		name := strconv.FormatInt(codec, 10)
+		if codec >= 0 && codec < int64(len(pqCodecNames)) {
+			name = pqCodecNames[codec]
+		}
+		return nil, fmt.Errorf("parquet codec %s is not supported", name)
+	}
+	start := meta.i64(9)
+	if d := meta.i64(11); meta.has(11) && d > 0 && d < start {
+		start = d
+	}
+	length := meta.i64(7)
+	if start < 0 || length < 0 || start+length > pf.size {
+		return nil, errors.New("column chunk lies outside the file")
+	}
+	chunk := make([]byte, length)
+	if _, err := pf.r.ReadAt(chunk, start); err != nil {
+		return nil, err
+	}
+
+	want := meta.i64(5)
+	var out []string
+	var dict []string
+	for int64(len(out)) < want {
+		tr := &thriftReader{b: chunk}
+		ph := tr.readStruct()
+		if tr.err != nil {
+			return nil, fmt.Errorf("page header: %w", tr.err)
+		}
+		n := int(ph.i64(3))
+		if n < 0 || tr.pos+n > len(chunk) {
+			return nil, errors.New("page runs past the column chunk")
+		}
+		page := chunk[tr.pos : tr.pos+n]
+		chunk = chunk[tr.pos+n:]
+
+		switch ph.i64(1) {
+		case pqDictionaryPage:
+			h := ph.sub(7)
+			if e := h.i64(2); e != pqPlain && e != pqPlainDictionary {
+				return nil, fmt.Errorf("dictionary encoding %d is not supported", e)
+			}
+			data, err := decompress(codec, page)
+			if err != nil {
+				return nil, err
+			}
+			if dict, _, err = c.plain(data, int(h.i64(1))); err != nil {
+				return nil, err
+			}
+		case pqDataPage:
+			h := ph.sub(5)
+			data, err := decompress(codec, page)
+			if err != nil {
+				return nil, err
+			}
+			num := int(h.i64(1))
+			var defs []uint32
+			if c.optional {
+				if len(data) < 4 {
+					return nil, errors.New("truncated definition levels")
+				}
+				l := int(binary.LittleEndian.Uint32(data))
+				if l > len(data)-4 {
+					return nil, errors.New("truncated definition levels")
+				}
+				if defs, err = decodeHybrid(data[4:4+l], 1, num); err != nil {
+					return nil, err
+				}
+				data = data[4+l:]
+			}
+			vals, err := c.values(data, h.i64(2), num, defs, dict)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, vals...)
+		case pqDataPageV2:
+			h := ph.sub(8)
+			num := int(h.i64(1))
+			defLen, repLen := int(h.i64(5)), int(h.i64(6))
+			if defLen < 0 || repLen < 0 || repLen+defLen > len(page) {
+				return nil, errors.New("levels run past the page")
+			}
+			var defs []uint32
+			if c.optional {
+				var err error
+				if defs, err = decodeHybrid(page[repLen:repLen+defLen], 1, num); err != nil {
+					return nil, err
+				}
+			}
+			data := page[repLen+defLen:]
+			if !h.has(7) || h.flag(7) {
+				var err error
+				if data, err = decompress(codec, data); err != nil {
+					return nil, err
+				}
+			}
+			vals, err := c.values(data, h.i64(4), num, defs, dict)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, vals...)
+		}
+		if len(chunk) == 0 && int64(len(out)) < want {
+			return nil, fmt.Errorf("column chunk ends after %d of %d values", len(out), want)
+		}
+	}
+	return out, nil
+}
+
+// values decodes a data page's n values, of which those with definition
+// level zero are null.
+func (c parquetColumn) values(data []byte, encoding int64, n int, defs []uint32, dict []string) ([]string, error) {
+	present := n
+	if defs != nil {
+		present = 0
+		for _, d := range defs {
+			present += int(d)
+		}
+	}
+	var vals []string
+	switch encoding {
+	case pqPlain:
+		var err error
+		if vals, _, err = c.plain(data, present); err != nil {
+			return nil, err
+		}
+	case pqPlainDictionary, pqRLEDictionary:
+		if dict == nil {
+			return nil, errors.New("dictionary-encoded page without a dictionary")
+		}
+		if len(data) == 0 {
+			return nil, errors.New("truncated dictionary indices")
+		}
+		idx, err := decodeHybrid(data[1:], int(data[0]), present)
+		if err != nil {
+			return nil, err
+		}
+		vals = make([]string, present)
+		for i, k := range idx {
+			if int(k) >= len(dict) {
+				return nil, fmt.Errorf("dictionary index %d out of range", k)
+			}
+			vals[i] = dict[k]
+		}
+	default:
+		return nil, fmt.Errorf("parquet encoding %d is not supported", encoding)
+	}
+	if defs == nil {
+		return vals, nil
+	}
+	out := make([]string, n)
+	j := 0
+	for i, d := range defs {
+		if d != 0 {
+			out[i] = vals[j]
+			j++
+		}
+	}
+	return out, nil
+}
+
+// plain decodes n PLAIN values as text and returns the bytes used.
+func (c parquetColumn) plain(b []byte, n int) ([]string, int, error) {
+	out := make([]string, n)
+	if c.typ == pqBoolean { // Bit-packed from the low bit
+		if (n+7)/8 > len(b) {
+			return nil, 0, errors.New("truncated values")
+		}
+		for i := range out {
+			out[i] = strconv.FormatBool(b[i/8]>>(i%8)&1 == 1)
+		}
+		return out, (n + 7) / 8, nil
+	}
+	pos := 0
+	need := func(k int) error {
+		if k < 0 || pos+k > len(b) {
+			return errors.New("truncated values")
+		}
+		return nil
+	}
+	for i := range out {
+		switch c.typ {
+		case pqInt32:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			out[i] = c.formatInt(int64(int32(binary.LittleEndian.Uint32(b[pos:]))))
+			pos += 4
+		case pqInt64:
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			out[i] = c.formatInt(int64(binary.LittleEndian.Uint64(b[pos:])))
+			pos += 8
+		case pqInt96:
+			if err := need(12); err != nil {
+				return nil, 0, err
+			}
+			// Nanoseconds of the day, then the Julian day number.
+			nanos := int64(binary.LittleEndian.Uint64(b[pos:]))
+			day := int64(binary.LittleEndian.Uint32(b[pos+8:]))
+			out[i] = time.Unix((day-2440588)*86400, nanos).UTC().Format(time.RFC3339Nano)
+			pos += 12
+		case pqFloat:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			out[i] = strconv.FormatFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b[pos:]))), 'g', -1, 32)
+			pos += 4
+		case pqDouble:
+			if err := need(8); err != nil {
+				return nil, 0, err
+			}
+			out[i] = strconv.FormatFloat(math.Float64frombits(binary.LittleEndian.Uint64(b[pos:])), 'g', -1, 64)
+			pos += 8
+		case pqByteArray:
+			if err := need(4); err != nil {
+				return nil, 0, err
+			}
+			l := int(binary.LittleEndian.Uint32(b[pos:]))
+			pos += 4
+			if err := need(l); err != nil {
+				return nil, 0, err
+			}
+			out[i] = c.formatBytes(b[pos : pos+l])
+			pos += l
+		case pqFixedLenByteArray:
+			if err := need(c.typeLength); err != nil {
+				return nil, 0, err
+			}
+			out[i] = c.formatBytes(b[pos : pos+c.typeLength])
+			pos += c.typeLength
+		default:
+			return nil, 0, fmt.Errorf("parquet type %d is not supported", c.typ)
+		}
+	}
+	return out, pos, nil
+}
+
+// formatInt writes an integer as its logical type: a date, a timestamp,
+// a decimal or a plain integer.
+func (c parquetColumn) formatInt(v int64) string {
+	if c.converted == 6 || c.logical.has(6) { // DATE: days since the epoch
+		return time.Unix(v*86400, 0).UTC().Format(time.DateOnly)
+	}
+	unit := time.Duration(0)
+	switch {
+	case c.converted == 9:
+		unit = time.Millisecond
+	case c.converted == 10:
+		unit = time.Microsecond
+	case c.logical.has(8):
+		u := c.logical.sub(8).sub(2)
+		switch {
+		case u.has(1):
+			unit = time.Millisecond
+		case u.has(2):
+			unit = time.Microsecond
+		case u.has(3):
+			unit = time.Nanosecond
+		}
+	}
+	if unit != 0 {
+		return time.Unix(0, 0).Add(time.Duration(v) * unit).UTC().Format(time.RFC3339Nano)
+	}
+	if c.isDecimal() {
+		return strconv.FormatFloat(float64(v)/math.Pow10(c.scale), 'g', -1, 64)
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// formatBytes writes a byte array as text, or as a decimal from its
+// big-endian two's complement.
+func (c parquetColumn) formatBytes(b []byte) string {
+	if !c.isDecimal() {
+		return string(b)
+	}
+	v := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(8*len(b))))
+	}
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return strconv.FormatFloat(f/math.Pow10(c.scale), 'g', -1, 64)
+}
+
+func (c parquetColumn) isDecimal() bool { return c.converted == 5 || c.logical.has(5) }
+
+// decodeHybrid decodes n values of the RLE/bit-packed hybrid encoding.
+func decodeHybrid(b []byte, bitWidth, n int) ([]uint32, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, fmt.Errorf("bit width %d out of range", bitWidth)
+	}
+	out := make([]uint32, 0, n)
+	pos := 0
+	for len(out) < n {
+		h, k := binary.Uvarint(b[pos:])
+		if k <= 0 {
+			return nil, errors.New("truncated run header")
+		}
+		pos += k
+		if h&1 == 0 { // A run of one value
+			w := (bitWidth + 7) / 8
+			if pos+w > len(b) {
+				return nil, errors.New("truncated run")
+			}
+			var v uint32
+			for i := 0; i < w; i++ {
+				v |= uint32(b[pos+i]) << (8 * i)
+			}
+			pos += w
+			for i := uint64(0); i < h>>1 && len(out) < n; i++ {
+				out = append(out, v)
+			}
+			continue
+		}
+		// Groups of eight values bit-packed from the low bit.
+		count := int(h>>1) * 8
+		if pos+count*bitWidth/8 > len(b) {
+			return nil, errors.New("truncated bit-packed run")
+		}
+		for i := 0; i < count; i++ {
+			var v uint32
+			for j := 0; j < bitWidth; j++ {
+				bit := i*bitWidth + j
+				v |= uint32(b[pos+bit/8]>>(bit%8)&1) << j
+			}
+			if len(out) < n {
+				out = append(out, v)
+			}
+		}
+		pos += count * bitWidth / 8
+	}
+	return out, nil
+}
+
+func decompress(codec int64, b []byte) ([]byte, error) {
+	switch codec {
+	case pqSnappy:
+		return snappyDecode(b)
+	case pqGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(zr)
+	}
+	return b, nil
+}
+
+// snappyDecode decodes a raw (unframed) Snappy block.
+func snappyDecode(b []byte) ([]byte, error) {
+	n, k := binary.Uvarint(b)
+	if k <= 0 || n > uint64(len(b))*256 {
+		return nil, errors.New("snappy: bad length")
+	}
+	out := make([]byte, 0, n)
+	for pos := k; pos < len(b); {
+		tag := b[pos]
+		pos++
+		var length, offset int
+		switch tag & 3 {
+		case 0: // Literal
+			length = int(tag>>2) + 1
+			if extra := length - 60; extra > 0 { // The length follows in 1-4 bytes
+				if pos+extra > len(b) {
+					return nil, errors.New("snappy: truncated literal")
+				}
+				length = 0
+				for i := 0; i < extra; i++ {
+					length |= int(b[pos+i]) << (8 * i)
+				}
+				length++
+				pos += extra
+			}
+			if length < 0 || pos+length > len(b) {
+				return nil, errors.New("snappy: truncated literal")
+			}
+			out = append(out, b[pos:pos+length]...)
+			pos += length
+			continue
+		case 1:
+			if pos+1 > len(b) {
+				return nil, errors.New("snappy: truncated copy")
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag>>5)<<8 | int(b[pos])
+			pos++
+		case 2:
+			if pos+2 > len(b) {
+				return nil, errors.New("snappy: truncated copy")
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(b[pos:]))
+			pos += 2
+		case 3:
+			if pos+4 > len(b) {
+				return nil, errors.New("snappy: truncated copy")
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(b[pos:]))
+			pos += 4
+		}
+		if offset <= 0 || offset > len(out) {
+			return nil, errors.New("snappy: bad copy offset")
+		}
+		for i := 0; i < length; i++ { // Copies may overlap their output
+			out = append(out, out[len(out)-offset])
+		}
+	}
+	if uint64(len(out)) != n {
+		return nil, errors.New("snappy: length mismatch")
+	}
+	return out, nil
+}
+
+// thriftStruct is a decoded Thrift struct by field id: int64, float64,
+// bool, []byte, []any or thriftStruct values.
+type thriftStruct map[int16]any
+
+func (s thriftStruct) has(id int16) bool   { _, ok := s[id]; return ok }
+func (s thriftStruct) i64(id int16) int64  { v, _ := s[id].(int64); return v }
+func (s thriftStruct) flag(id int16) bool  { v, _ := s[id].(bool); return v }
+func (s thriftStruct) str(id int16) string { v, _ := s[id].([]byte); return string(v) }
+func (s thriftStruct) list(id int16) []any { v, _ := s[id].([]any); return v }
+func (s thriftStruct) sub(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// thriftReader decodes Thrift's compact protocol, which Parquet uses for
+// its footer and page headers. The first error sticks.
+type thriftReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *thriftReader) fail(msg string) {
+	if r.err == nil {
+		r.err = errors.New(msg)
+	}
+	r.pos = len(r.b)
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.b) {
+		r.fail("truncated thrift data")
+		return 0
+	}
+	r.pos++
+	return r.b[r.pos-1]
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, k := binary.Uvarint(r.b[r.pos:])
+	if k <= 0 {
+		r.fail("bad thrift varint")
+		return 0
+	}
+	r.pos += k
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() thriftStruct {
+	s := thriftStruct{}
+	var last int16
+	for r.err == nil {
+		h := r.byte()
+		if h == 0 {
+			break
+		}
+		id := last + int16(h>>4)
+		if h>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		switch typ := h & 0x0f; typ {
+		case 1, 2: // Booleans are in the field type
+			s[id] = typ == 1
+		default:
+			s[id] = r.readValue(typ)
+		}
+	}
+	return s
+}
+
+func (r *thriftReader) readValue(typ byte) any {
+	switch typ {
+	case 1, 2: // In a container: a byte
+		return r.byte() == 1
+	case 3:
+		return int64(int8(r.byte()))
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		if r.pos+8 > len(r.b) {
+			r.fail("truncated thrift double")
+			return 0.0
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.b[r.pos-8:]))
+	case 8:
+		n := r.uvarint()
+		if n > uint64(len(r.b)-r.pos) {
+			r.fail("truncated thrift binary")
+			return []byte(nil)
+		}
+		r.pos += int(n)
+		return r.b[r.pos-int(n) : r.pos]
+	case 9, 10:
+		h := r.byte()
+		n, et := uint64(h>>4), h&0x0f
+		if n == 15 {
+			n = r.uvarint()
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			r.fail("thrift list longer than its data")
+			return []any(nil)
+		}
+		l := make([]any, n)
+		for i := range l {
+			l[i] = r.readValue(et)
+		}
+		return l
+	case 11:
+		n := r.uvarint()
+		if n == 0 {
+			return nil
+		}
+		if n > uint64(len(r.b)-r.pos) {
+			r.fail("thrift map longer than its data")
+			return nil
+		}
+		kv := r.byte()
+		for i := uint64(0); i < n && r.err == nil; i++ {
+			r.readValue(kv >> 4)
+			r.readValue(kv & 0x0f)
+		}
+		return nil
+	case 12:
+		return r.readStruct()
+	}
+	r.fail(fmt.Sprintf("unknown thrift type %d", typ))
+	return nil
+}
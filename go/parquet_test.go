@@ -0,0 +1,318 @@
+package bsm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// thriftWriter writes Thrift's compact protocol, enough for a footer and
+// page headers.
+type thriftWriter struct {
+	b    bytes.Buffer
+	last []int16
+}
+
+func (w *thriftWriter) field(id int16, typ byte) {
+	last := &w.last[len(w.last)-1]
+	if d := id - *last; d > 0 && d <= 15 {
+		w.b.WriteByte(byte(d)<<4 | typ)
+	} else {
+		w.b.WriteByte(typ)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) varint(v int64)        { w.b.Write(binary.AppendUvarint(nil, uint64(v<<1^v>>63))) }
+func (w *thriftWriter) i32(id int16, v int64) { w.field(id, 5); w.varint(v) }
+func (w *thriftWriter) i64(id int16, v int64) { w.field(id, 6); w.varint(v) }
+func (w *thriftWriter) str(id int16, s string) {
+	w.field(id, 8)
+	w.b.Write(binary.AppendUvarint(nil, uint64(len(s))))
+	w.b.WriteString(s)
+}
+func (w *thriftWriter) list(id int16, elem byte, n int) {
+	w.field(id, 9)
+	w.b.WriteByte(byte(n)<<4 | elem)
+}
+func (w *thriftWriter) sub(id int16) { w.field(id, 12); w.begin() }
+func (w *thriftWriter) begin()       { w.last = append(w.last, 0) }
+func (w *thriftWriter) end()         { w.b.WriteByte(0); w.last = w.last[:len(w.last)-1] }
+
+// testParquetColumn is one column chunk of a test file.
+type testParquetColumn struct {
+	name               string
+	typ, converted     int64 // converted is -1 for none
+	optional           bool
+	codec              int64
+	v2                 bool
+	encoding           int64
+	dict, defs, values []byte // PLAIN dictionary, hybrid levels, page values
+	count              int    // Values, nulls included
+}
+
+func compressTest(t *testing.T, codec int64, b []byte) []byte {
+	switch codec {
+	case pqSnappy: // Literals only
+		out := binary.AppendUvarint(nil, uint64(len(b)))
+		for len(b) > 0 {
+			n := min(len(b), 60)
+			out = append(out, byte(n-1)<<2)
+			out = append(out, b[:n]...)
+			b = b[n:]
+		}
+		return out
+	case pqGzip:
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(b)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	return b
+}
+
+// writeTestParquet writes a one-row-group file of the columns, plus a
+// nested group the reader should skip.
+func writeTestParquet(t *testing.T, rows int, cols []testParquetColumn) []byte {
+	file := bytes.NewBufferString("PAR1")
+	type chunk struct{ dictOff, dataOff, size int64 }
+	chunks := make([]chunk, len(cols))
+	for i, c := range cols {
+		start := int64(file.Len())
+		chunks[i].dataOff = start
+		if c.dict != nil {
+			data := compressTest(t, c.codec, c.dict)
+			w := &thriftWriter{}
+			w.begin()
+			w.i32(1, pqDictionaryPage)
+			w.i32(2, int64(len(c.dict)))
+			w.i32(3, int64(len(data)))
+			w.sub(7)
+			w.i32(1, 2) // Two entries in every test dictionary
+			w.i32(2, pqPlain)
+			w.end()
+			w.end()
+			chunks[i].dictOff = start
+			file.Write(w.b.Bytes())
+			file.Write(data)
+			chunks[i].dataOff = int64(file.Len())
+		}
+		w := &thriftWriter{}
+		w.begin()
+		var page []byte
+		if c.v2 {
+			values := compressTest(t, c.codec, c.values)
+			page = append(append(page, c.defs...), values...)
+			w.i32(1, pqDataPageV2)
+			w.i32(2, int64(len(c.defs)+len(c.values)))
+			w.i32(3, int64(len(page)))
+			w.sub(8)
+			w.i32(1, int64(c.count))
+			w.i32(2, 0)
+			w.i32(3, int64(c.count))
+			w.i32(4, c.encoding)
+			w.i32(5, int64(len(c.defs)))
+			w.i32(6, 0)
+			w.end()
+		} else {
+			raw := c.values
+			if c.optional {
+				raw = binary.LittleEndian.AppendUint32(nil, uint32(len(c.defs)))
+				raw = append(append(raw, c.defs...), c.values...)
+			}
+			page = compressTest(t, c.codec, raw)
+			w.i32(1, pqDataPage)
+			w.i32(2, int64(len(raw)))
+			w.i32(3, int64(len(page)))
+			w.sub(5)
+			w.i32(1, int64(c.count))
+			w.i32(2, c.encoding)
+			w.i32(3, 3) // RLE levels
+			w.i32(4, 3)
+			w.end()
+		}
+		w.end()
+		file.Write(w.b.Bytes())
+		file.Write(page)
+		chunks[i].size = int64(file.Len()) - start
+	}
+
+	w := &thriftWriter{}
+	w.begin()
+	w.i32(1, 1)
+	w.list(2, 12, len(cols)+3)
+	w.begin()
+	w.str(4, "schema")
+	w.i32(5, int64(len(cols)+1))
+	w.end()
+	for _, c := range cols {
+		w.begin()
+		w.i32(1, c.typ)
+		w.i32(3, map[bool]int64{false: 0, true: 1}[c.optional])
+		w.str(4, c.name)
+		if c.converted >= 0 {
+			w.i32(6, c.converted)
+		}
+		w.end()
+	}
+	w.begin()
+	w.i32(3, 1)
+	w.str(4, "greeks")
+	w.i32(5, 1)
+	w.end()
+	w.begin()
+	w.i32(1, pqDouble)
+	w.i32(3, 1)
+	w.str(4, "delta")
+	w.end()
+	w.i64(3, int64(rows))
+	w.list(4, 12, 1)
+	w.begin()
+	w.list(1, 12, len(cols))
+	for i, c := range cols {
+		w.begin()
+		w.i64(2, chunks[i].dataOff)
+		w.sub(3)
+		w.i32(1, c.typ)
+		w.list(2, 5, 1)
+		w.varint(c.encoding)
+		w.list(3, 8, 1)
+		w.b.WriteByte(byte(len(c.name)))
+		w.b.WriteString(c.name)
+		w.i32(4, c.codec)
+		w.i64(5, int64(c.count))
+		w.i64(6, chunks[i].size)
+		w.i64(7, chunks[i].size)
+		w.i64(9, chunks[i].dataOff)
+		if c.dict != nil {
+			w.i64(11, chunks[i].dictOff)
+		}
+		w.end()
+		w.end()
+	}
+	w.i64(3, int64(rows))
+	w.end()
+	w.end()
+
+	file.Write(w.b.Bytes())
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(w.b.Len())))
+	file.WriteString("PAR1")
+	return file.Bytes()
+}
+
+func plainDoubles(vs ...float64) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	return b
+}
+
+// bitPacked writes values as one bit-packed run of the hybrid encoding.
+func bitPacked(width int, vs ...uint32) []byte {
+	groups := (len(vs) + 7) / 8
+	b := binary.AppendUvarint(nil, uint64(groups<<1|1))
+	packed := make([]byte, groups*width)
+	for i, v := range vs {
+		for j := 0; j < width; j++ {
+			bit := i*width + j
+			packed[bit/8] |= byte(v>>j&1) << (bit % 8)
+		}
+	}
+	return append(b, packed...)
+}
+
+func testChainColumns() []testParquetColumn {
+	day := func(s string) uint32 {
+		d, _ := time.Parse(time.DateOnly, s)
+		return uint32(d.Unix() / 86400)
+	}
+	var expiry []byte
+	for _, s := range []string{"2026-12-18", "2026-12-18", "2027-03-19", "2027-03-19"} {
+		expiry = binary.LittleEndian.AppendUint32(expiry, day(s))
+	}
+	var volume []byte
+	for _, v := range []uint64{10, 7, 3} {
+		volume = binary.LittleEndian.AppendUint64(volume, v)
+	}
+	// "call", "put" indices 0, 1, 1, 0: a run of one 0, then bit-packed.
+	typeIdx := append([]byte{1, 1 << 1, 0}, bitPacked(1, 1, 1, 0)...)
+	return []testParquetColumn{
+		{name: "expiry", typ: pqInt32, converted: 6, codec: pqGzip, v2: true, encoding: pqPlain, values: expiry, count: 4},
+		{name: "Strike", typ: pqDouble, converted: -1, encoding: pqPlain, values: plainDoubles(95, 105, 95, 105), count: 4},
+		{name: "type", typ: pqByteArray, converted: 0, codec: pqSnappy, encoding: pqRLEDictionary,
+			dict: []byte("\x04\x00\x00\x00call\x03\x00\x00\x00put"), values: typeIdx, count: 4},
+		{name: "bid", typ: pqDouble, converted: -1, codec: pqSnappy, encoding: pqPlain, values: plainDoubles(7.1, 6.2, 9.4, 8.3), count: 4},
+		{name: "ask", typ: pqDouble, converted: -1, encoding: pqPlain, values: plainDoubles(7.3, 6.5, 9.8, 8.6), count: 4},
+		{name: "volume", typ: pqInt64, converted: -1, optional: true, codec: pqGzip, encoding: pqPlain,
+			defs: bitPacked(1, 1, 0, 1, 1), values: volume, count: 4},
+		{name: "underlying", typ: pqDouble, converted: -1, optional: true, v2: true, encoding: pqPlain,
+			defs: bitPacked(1, 1, 1, 1, 1), values: plainDoubles(100, 100, 100, 100.5), count: 4},
+		{name: "note", typ: pqByteArray, converted: 0, codec: 6, encoding: pqPlain, values: []byte("zstd"), count: 4},
+	}
+}
+
+const testChainCSV = `expiry,strike,type,bid,ask,volume,underlying
+2026-12-18,95,call,7.1,7.3,10,100
+2026-12-18,105,put,6.2,6.5,,100
+2027-03-19,95,put,9.4,9.8,7,100
+2027-03-19,105,call,8.3,8.6,3,100.5
+`
+
+// A Parquet chain reads as the same chain in CSV, across codecs, page
+// versions, dictionary and optional columns, skipping columns it does not
+// use.
+func TestReadChainParquet(t *testing.T) {
+	asOf := time.Date(2026, 10, 15, 14, 0, 0, 0, time.UTC)
+	want, err := ReadChainCSV(strings.NewReader(testChainCSV), asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := writeTestParquet(t, 4, testChainColumns())
+	got, err := ReadChainParquet(bytes.NewReader(file), int64(len(file)), asOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "chain.parquet")
+	if err := os.WriteFile(path, file, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = LoadChainSnapshot(path, asOf); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadChainSnapshot: %v", err)
+	}
+
+	cols := testChainColumns()
+	cols[3].codec = 6
+	file = writeTestParquet(t, 4, cols)
+	if _, err := ReadChainParquet(bytes.NewReader(file), int64(len(file)), asOf); err == nil || !strings.Contains(err.Error(), "ZSTD") {
+		t.Errorf("ZSTD column: got %v, want an unsupported codec error", err)
+	}
+}
+
+func TestSnappyDecodeCopies(t *testing.T) {
+	// "abc", then a 9-byte copy at offset 3 that overlaps its output.
+	got, err := snappyDecode([]byte{12, 2 << 2, 'a', 'b', 'c', 5<<2 | 1, 3})
+	if err != nil || string(got) != "abcabcabcabc" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	// A 2-byte offset copy of 6.
+	got, err = snappyDecode([]byte{8, 1 << 2, 'x', 'y', 5<<2 | 2, 2, 0})
+	if err != nil || string(got) != "xyxyxyxy" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,191 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TimeGrid is a strictly increasing list of simulation times after 0 (years).
+type TimeGrid []float64
+
+// UniformGrid returns steps equal steps from 0 to T.
+func UniformGrid(T float64, steps int) TimeGrid {
+	grid := make(TimeGrid, steps)
+	for i := range grid {
+		grid[i] = T * float64(i+1) / float64(steps)
+	}
+	return grid
+}
+
+// MergeGrids returns the sorted union of grids, e.g. a uniform grid plus
+// monitoring or fixing dates.
+func MergeGrids(grids ...TimeGrid) TimeGrid {
+	var all []float64
+	for _, g := range grids {
+		all = append(all, g...)
+	}
+	sort.Float64s(all)
+	out := TimeGrid{}
+	for _, t := range all {
+		if t > 0 && (len(out) == 0 || t-out[len(out)-1] > 1e-12) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Validate checks the grid is non-empty and strictly increasing from t > 0.
+func (g TimeGrid) Validate() error {
+	if len(g) == 0 {
+		return errors.New("empty time grid")
+	}
+	prev := 0.0
+	for i, t := range g {
+		if !(t > prev) {
+			return fmt.Errorf("time grid not increasing at index %d (%g after %g)", i, t, prev)
+		}
+		prev = t
+	}
+	return nil
+}
+
+// PathGenerator simulates risk-neutral spot paths. Path writes the spot at
+// time 0 to out[0] and at grid[i] to out[i+1], so len(out) must be
+// len(grid)+1. Generators keep no per-path state and may be shared.
+type PathGenerator interface {
+	Path(grid TimeGrid, rng RNG, out []float64)
+}
+
+// GeneratePaths simulates n paths on grid drawing from rng. The paths share
+// one backing array; path i is result[i].
+func GeneratePaths(gen PathGenerator, grid TimeGrid, n int, rng RNG) ([][]float64, error) {
+	if err := grid.Validate(); err != nil {
+		return nil, err
+	}
+	if n <= 0 {
+		return nil, errors.New("number of paths must be positive")
+	}
+	width := len(grid) + 1
+	buf := make([]float64, n*width)
+	paths := make([][]float64, n)
+	for i := range paths {
+		paths[i] = buf[i*width : (i+1)*width : (i+1)*width]
+		gen.Path(grid, rng, paths[i])
+	}
+	return paths, nil
+}
+
+// GBM is geometric Brownian motion, simulated exactly in log space.
+type GBM struct {
+	S0, R, Q, Sigma float64
+}
+
+func (g GBM) Path(grid TimeGrid, rng RNG, out []float64) {
+	out[0] = g.S0
+	logS, prev := math.Log(g.S0), 0.0
+	for i, t := range grid {
+		dt := t - prev
+		logS += (g.R-g.Q-0.5*g.Sigma*g.Sigma)*dt + g.Sigma*math.Sqrt(dt)*rng.NormFloat64()
+		out[i+1] = math.Exp(logS)
+		prev = t
+	}
+}
+
+// HestonPaths simulates the Heston model with Andersen's quadratic-
+// exponential (QE) scheme for the variance and his central discretisation
+// of the log-spot given the variance step.
+type HestonPaths struct {
+	S0, R, Q float64
+	V0       float64 // Initial variance
+	Kappa    float64 // Mean-reversion speed
+	Theta    float64 // Long-run variance
+	Xi       float64 // Vol of variance
+	Rho      float64 // Spot/variance correlation
+}
+
+const hestonPsiCritical = 1.5
+
+func (h HestonPaths) Path(grid TimeGrid, rng RNG, out []float64) {
+	out[0] = h.S0
+	logS, v, prev := math.Log(h.S0), h.V0, 0.0
+	for i, t := range grid {
+		dt := t - prev
+		vNext := h.nextVariance(v, dt, rng)
+
+		// Andersen (2008) eq. 33 with gamma1 = gamma2 = 1/2.
+		k0 := -h.Rho * h.Kappa * h.Theta / h.Xi * dt
+		k1 := 0.5*dt*(h.Kappa*h.Rho/h.Xi-0.5) - h.Rho/h.Xi
+		k2 := 0.5*dt*(h.Kappa*h.Rho/h.Xi-0.5) + h.Rho/h.Xi
+		k3 := 0.5 * dt * (1 - h.Rho*h.Rho)
+		logS += (h.R-h.Q)*dt + k0 + k1*v + k2*vNext + math.Sqrt(math.Max(k3*(v+vNext), 0))*rng.NormFloat64()
+
+		out[i+1] = math.Exp(logS)
+		v, prev = vNext, t
+	}
+}
+
+func (h HestonPaths) nextVariance(v, dt float64, rng RNG) float64 {
+	e := math.Exp(-h.Kappa * dt)
+	m := h.Theta + (v-h.Theta)*e
+	s2 := v*h.Xi*h.Xi*e/h.Kappa*(1-e) + h.Theta*h.Xi*h.Xi/(2*h.Kappa)*(1-e)*(1-e)
+	psi := s2 / (m * m)
+	if psi <= hestonPsiCritical {
+		b2 := 2/psi - 1 + math.Sqrt(2/psi)*math.Sqrt(2/psi-1)
+		a := m / (1 + b2)
+		z := math.Sqrt(b2) + rng.NormFloat64()
+		return a * z * z
+	}
+	p := (psi - 1) / (psi + 1)
+	beta := (1 - p) / m
+	u := rng.Float64()
+	if u <= p {
+		return 0
+	}
+	return math.Log((1-p)/(1-u)) / beta
+}
+
+// MertonJumpPaths is GBM plus compound-Poisson jumps with normally
+// distributed log-jump sizes, compensated so the discounted spot is a
+// martingale.
+type MertonJumpPaths struct {
+	S0, R, Q, Sigma float64
+	Lambda          float64 // Jump intensity (per annum)
+	MuJ             float64 // Mean log-jump
+	SigmaJ          float64 // Std dev of log-jump
+}
+
+func (m MertonJumpPaths) Path(grid TimeGrid, rng RNG, out []float64) {
+	out[0] = m.S0
+	comp := m.Lambda * (math.Exp(m.MuJ+0.5*m.SigmaJ*m.SigmaJ) - 1)
+	drift := m.R - m.Q - comp - 0.5*m.Sigma*m.Sigma
+	logS, prev := math.Log(m.S0), 0.0
+	for i, t := range grid {
+		dt := t - prev
+		logS += drift*dt + m.Sigma*math.Sqrt(dt)*rng.NormFloat64()
+		if n := poisson(m.Lambda*dt, rng); n > 0 {
+			fn := float64(n)
+			logS += fn*m.MuJ + math.Sqrt(fn)*m.SigmaJ*rng.NormFloat64()
+		}
+		out[i+1] = math.Exp(logS)
+		prev = t
+	}
+}
+
+// poisson draws from a Poisson distribution by inversion, which is fast for
+// the small means of a single time step.
+func poisson(mean float64, rng RNG) int {
+	if mean <= 0 {
+		return 0
+	}
+	u := rng.Float64()
+	p := math.Exp(-mean)
+	cdf, n := p, 0
+	for u > cdf && n < 1000 {
+		n++
+		p *= mean / float64(n)
+		cdf += p
+	}
+	return n
+}
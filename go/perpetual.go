@@ -0,0 +1,86 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// PerpetualOutputs are a perpetual American option's price and Greeks with
+// its exercise boundary.
+type PerpetualOutputs struct {
+	Outputs
+	Boundary float64 // Exercise at or above it for a call, at or below for a put
+}
+
+// PricePerpetual prices a perpetual American option under BSM in closed
+// form, ignoring in.T and in.Exercise. Held until the spot first reaches
+// the boundary B, the option is worth (B - K)(S/B)^beta for a call, with
+// beta the root of sigma^2 beta(beta-1)/2 + (r-q) beta - r = 0 above 1 for
+// a call and below 0 for a put, and B = K beta/(beta-1). A call needs
+// Q > 0 and a put R > 0, or exercise is never optimal and the value has no
+// finite boundary. Delta and gamma are analytic, vega, rho and phi by
+// finite differences; with no expiry theta is zero.
+func PricePerpetual(in Inputs, thetaBasis int) (PerpetualOutputs, error) {
+	probe := in
+	probe.T = 0
+	if err := probe.Validate(); err != nil {
+		return PerpetualOutputs{}, err
+	}
+	if err := noDividends(in, "the perpetual pricer"); err != nil {
+		return PerpetualOutputs{}, err
+	}
+	if in.Model != "" && in.Model != BSMModel {
+		return PerpetualOutputs{}, errors.New("perpetual options are priced under BSM only")
+	}
+	if in.Sigma == 0 {
+		return PerpetualOutputs{}, errors.New("perpetual options need a positive volatility")
+	}
+	if in.OptType == Call && in.Q <= 0 {
+		return PerpetualOutputs{}, errors.New("a perpetual call needs a positive dividend yield")
+	}
+	if in.OptType == Put && in.R <= 0 {
+		return PerpetualOutputs{}, errors.New("a perpetual put needs a positive rate")
+	}
+	if thetaBasis <= 0 {
+		return PerpetualOutputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+
+	value := func(x Inputs) float64 {
+		v, _, _ := perpetual(x)
+		return v
+	}
+	probe.T = 1 // Any positive expiry: the value ignores it, so theta is zero
+	out := bumpedGreeks(probe, thetaBasis, value, 1e-3*in.S0)
+	v, beta, boundary := perpetual(in)
+	out.Price, out.Delta, out.Gamma = v, -1, 0
+	if in.OptType == Call {
+		out.Delta = 1
+	}
+	if (in.OptType == Call) == (in.S0 < boundary) {
+		out.Delta = beta * v / in.S0
+		out.Gamma = beta * (beta - 1) * v / (in.S0 * in.S0)
+	}
+	return PerpetualOutputs{Outputs: out, Boundary: boundary}, nil
+}
+
+// perpetual returns the perpetual value at x with its beta and boundary.
+func perpetual(x Inputs) (v, beta, boundary float64) {
+	s2 := x.Sigma * x.Sigma
+	a := 0.5 - (x.R-x.Q)/s2
+	root := math.Sqrt(a*a + 2*x.R/s2)
+	if x.OptType == Call {
+		beta = a + root
+		boundary = x.K * beta / (beta - 1)
+		if x.S0 >= boundary {
+			return x.S0 - x.K, beta, boundary
+		}
+		return (boundary - x.K) * math.Pow(x.S0/boundary, beta), beta, boundary
+	}
+	beta = a - root
+	boundary = x.K * beta / (beta - 1)
+	if x.S0 <= boundary {
+		return x.K - x.S0, beta, boundary
+	}
+	return (x.K - boundary) * math.Pow(x.S0/boundary, beta), beta, boundary
+}
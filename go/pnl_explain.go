@@ -0,0 +1,71 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PnLExplain decomposes a contract's value change between two market
+// states into Taylor terms of its Greeks at the first state. Actual is the
+// full revaluation; Unexplained is Actual less the sum of the terms.
+type PnLExplain struct {
+	Actual float64
+
+	Delta float64 // Delta dS
+	Gamma float64 // Gamma dS^2 / 2
+	Vega  float64 // Vega dSigma
+	Theta float64 // Theta dt
+
+	// ThetaParts splits Theta into interest, dividend and vol decay.
+	ThetaParts ThetaParts
+	Rho        float64 // Rho dr
+	Phi        float64 // Phi dq
+
+	// Cross and second-order terms.
+	Vanna float64 // Vanna dS dSigma
+	Volga float64 // Volga dSigma^2 / 2
+	Charm float64 // Charm dS dt
+
+	Explained   float64
+	Unexplained float64
+}
+
+// ExplainPnL attributes the change in value of one unit of a contract from
+// from to to, which must be the same contract (strike, type, exercise and
+// model) at a later or equal time: dt is from.T - to.T in years. Greeks are
+// taken from Price at from, with opts applied to both revaluations.
+func ExplainPnL(from, to Inputs, opts ...Option) (PnLExplain, error) {
+	if from.K != to.K || from.OptType != to.OptType || from.Exercise != to.Exercise || from.Model != to.Model {
+		return PnLExplain{}, errors.New("explain needs the same contract in both states")
+	}
+	dt := from.T - to.T
+	if dt < 0 {
+		return PnLExplain{}, fmt.Errorf("%w: the second state is %g years before the first", ErrExpired, -dt)
+	}
+	a, err := Price(from, opts...)
+	if err != nil {
+		return PnLExplain{}, fmt.Errorf("from: %w", err)
+	}
+	b, err := Price(to, opts...)
+	if err != nil {
+		return PnLExplain{}, fmt.Errorf("to: %w", err)
+	}
+
+	dS, dv, dr, dq := to.S0-from.S0, to.Sigma-from.Sigma, to.R-from.R, to.Q-from.Q
+	e := PnLExplain{
+		Actual: b.Price - a.Price,
+		Delta:  a.Delta * dS,
+		Gamma:  0.5 * a.Gamma * dS * dS,
+		Vega:   a.VegaPerVol * dv,
+		Theta:  a.ThetaPerYear * dt,
+		Rho:    a.RhoPer1 * dr,
+		Phi:    a.PhiPer1 * dq,
+		Vanna:  a.Vanna * dS * dv,
+		Volga:  0.5 * a.Volga * dv * dv,
+		Charm:  a.CharmPerYear * dS * dt,
+	}
+	e.ThetaParts = a.ThetaParts(from).scale(dt)
+	e.Explained = e.Delta + e.Gamma + e.Vega + e.Theta + e.Rho + e.Phi + e.Vanna + e.Volga + e.Charm
+	e.Unexplained = e.Actual - e.Explained
+	return e, nil
+}
@@ -0,0 +1,284 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+)
+
+// FXRates converts amounts into a base reporting currency. Rates[c] is the
+// number of Base units per one unit of currency c.
+type FXRates struct {
+	Base  string
+	Rates map[string]float64
+}
+
+// Rate returns units of the base currency per one unit of ccy.
+func (fx FXRates) Rate(ccy string) (float64, error) {
+	if ccy == "" || ccy == fx.Base {
+		return 1, nil
+	}
+	rate, ok := fx.Rates[ccy]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no FX rate for %s/%s", ccy, fx.Base)
+	}
+	return rate, nil
+}
+
+// Side is the direction of a position.
+type Side string
+
+const (
+	Long  Side = "long"
+	Short Side = "short"
+)
+
+// Position is a holding of an option contract. Currency is the premium
+// currency; an empty Currency means the portfolio's base currency.
+type Position struct {
+	ID         string
+	Underlying string // For grouping in Exposures
+	Inputs     Inputs
+	Quantity   float64 // Number of contracts, signed (negative = short) if Side is empty
+	Side       Side    // Long or Short overrides the sign of Quantity
+	Multiplier float64 // Units of underlying per contract; 1 if zero
+	Currency   string
+	EntryPrice float64 // Per-unit premium traded at, for P&L
+}
+
+// NewPosition builds a position using a listed contract's multiplier and currency.
+func NewPosition(id string, inputs Inputs, quantity float64, spec ContractSpec) Position {
+	return Position{
+		ID:         id,
+		Inputs:     inputs,
+		Quantity:   quantity,
+		Multiplier: spec.Multiplier,
+		Currency:   spec.Currency,
+	}
+}
+
+// SignedQuantity is the number of contracts held, negative if short.
+func (p Position) SignedQuantity() float64 {
+	switch p.Side {
+	case Long:
+		return math.Abs(p.Quantity)
+	case Short:
+		return -math.Abs(p.Quantity)
+	}
+	return p.Quantity
+}
+
+func (p Position) units() float64 {
+	if p.Multiplier == 0 {
+		return p.SignedQuantity()
+	}
+	return p.SignedQuantity() * p.Multiplier
+}
+
+func (p Position) checkSide() error {
+	if p.Side != "" && p.Side != Long && p.Side != Short {
+		return fmt.Errorf("position %s: unknown side %q", p.ID, p.Side)
+	}
+	return nil
+}
+
+// price prices the position's contract per unit under o, honouring its
+// exercise style, model and dividends as Price does.
+func (p Position) price(o priceOptions) (Outputs, error) {
+	out, err := priceWith(p.Inputs, o)
+	if err != nil {
+		return Outputs{}, fmt.Errorf("position %s: %w", p.ID, err)
+	}
+	return out, nil
+}
+
+// Portfolio is a collection of positions reported in BaseCurrency.
+type Portfolio struct {
+	BaseCurrency string
+	Positions    []Position
+	ThetaBasis   int // Days per year for theta per day; 365 if zero
+}
+
+// pricing returns the options the portfolio prices its positions under.
+func (p *Portfolio) pricing() (priceOptions, error) {
+	o := resolveOptions(nil)
+	if p.ThetaBasis != 0 {
+		o.thetaBasis = p.ThetaBasis
+	}
+	if o.thetaBasis <= 0 {
+		return o, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+	return o, nil
+}
+
+// CurrencyRisk is the aggregate of all positions premium-denominated in one currency.
+type CurrencyRisk struct {
+	Currency string
+	Greeks   Outputs // Summed position outputs, in Currency
+	PnL      float64 // Mark-to-market minus entry, in Currency
+	FXRate   float64 // Base units per one unit of Currency
+}
+
+// PortfolioRisk is the portfolio view in the base currency. Greeks are
+// derivatives of base-currency value with FX held fixed. FXDelta[c] is the
+// change in base value per 1.00 move in the c/base rate, which is the book's
+// mark-to-market held in c.
+type PortfolioRisk struct {
+	BaseCurrency string
+	ByCurrency   []CurrencyRisk
+	Greeks       Outputs
+	PnL          float64
+	FXDelta      map[string]float64
+}
+
+// Risk prices every position as Price does, honouring exercise style,
+// model and dividends, and converts the results into the base currency.
+func (p *Portfolio) Risk(fx FXRates) (PortfolioRisk, error) {
+	return p.RiskContext(context.Background(), fx)
+}
+
+// RiskContext is Risk, logging the book's fingerprint, size and totals.
+func (p *Portfolio) RiskContext(ctx context.Context, fx FXRates) (PortfolioRisk, error) {
+	_, sp := StartSpan(ctx, "portfolio_risk", slog.Int("positions", len(p.Positions)), fingerprintAttr(p.Positions))
+	risk, err := p.risk(fx)
+	sp.End(err, slog.Float64("value", risk.Greeks.Price), slog.Float64("delta", risk.Greeks.Delta), slog.Float64("vega", risk.Greeks.VegaPerVolPt))
+	return risk, err
+}
+
+func (p *Portfolio) risk(fx FXRates) (PortfolioRisk, error) {
+	if p.BaseCurrency == "" {
+		return PortfolioRisk{}, errors.New("portfolio has no base currency")
+	}
+	if fx.Base != p.BaseCurrency {
+		return PortfolioRisk{}, fmt.Errorf("FX table is based in %s, portfolio reports in %s", fx.Base, p.BaseCurrency)
+	}
+	o, err := p.pricing()
+	if err != nil {
+		return PortfolioRisk{}, err
+	}
+
+	byCcy := make(map[string]*CurrencyRisk)
+	for _, pos := range p.Positions {
+		if err := pos.checkSide(); err != nil {
+			return PortfolioRisk{}, err
+		}
+		ccy := pos.Currency
+		if ccy == "" {
+			ccy = p.BaseCurrency
+		}
+		cr, ok := byCcy[ccy]
+		if !ok {
+			rate, err := fx.Rate(ccy)
+			if err != nil {
+				return PortfolioRisk{}, fmt.Errorf("position %s: %w", pos.ID, err)
+			}
+			cr = &CurrencyRisk{Currency: ccy, FXRate: rate}
+			byCcy[ccy] = cr
+		}
+		out, err := pos.price(o)
+		if err != nil {
+			return PortfolioRisk{}, err
+		}
+		units := pos.units()
+		cr.Greeks = cr.Greeks.add(out.scale(units))
+		cr.PnL += (out.Price - pos.EntryPrice) * units
+	}
+
+	risk := PortfolioRisk{
+		BaseCurrency: p.BaseCurrency,
+		FXDelta:      make(map[string]float64),
+	}
+	for _, cr := range byCcy {
+		risk.ByCurrency = append(risk.ByCurrency, *cr)
+		risk.Greeks = risk.Greeks.add(cr.Greeks.scale(cr.FXRate))
+		risk.PnL += cr.PnL * cr.FXRate
+		if cr.Currency != p.BaseCurrency {
+			risk.FXDelta[cr.Currency] = cr.Greeks.Price
+		}
+	}
+	sort.Slice(risk.ByCurrency, func(i, j int) bool {
+		return risk.ByCurrency[i].Currency < risk.ByCurrency[j].Currency
+	})
+	return risk, nil
+}
+
+// Exposure is the risk of the positions on one underlying and expiry, in
+// the base currency. Greeks holds the summed position Greeks, each scaled
+// by the position's units of underlying and its premium currency's FX
+// rate: Delta is the base-currency value change per 1.00 move in the spot
+// (the share-equivalent holding only for positions premiumed in the base
+// currency) and Gamma its change per 1.00 move; vega, theta and rho are
+// base-currency amounts. CashDelta and CashGamma restate delta and gamma
+// as the value of the delta holding, Delta S, and its change for a 1%
+// move in the spot, Gamma S^2 / 100.
+type Exposure struct {
+	Underlying string
+	T          float64
+	Positions  int
+	Contracts  float64 // Net signed contracts
+	Greeks     Outputs
+	CashDelta  float64
+	CashGamma  float64
+}
+
+// Exposures prices every position as Risk does, honouring exercise style,
+// model and dividends, and aggregates the results by underlying and time
+// to expiry. Long positions add their Greeks and short positions subtract
+// them, so a short call has negative delta and vega and, usually, positive
+// theta. The groups are sorted by underlying, then expiry.
+func (p *Portfolio) Exposures(fx FXRates) ([]Exposure, error) {
+	if p.BaseCurrency == "" {
+		return nil, errors.New("portfolio has no base currency")
+	}
+	if fx.Base != p.BaseCurrency {
+		return nil, fmt.Errorf("FX table is based in %s, portfolio reports in %s", fx.Base, p.BaseCurrency)
+	}
+	o, err := p.pricing()
+	if err != nil {
+		return nil, err
+	}
+	type key struct {
+		underlying string
+		T          float64
+	}
+	groups := map[key]*Exposure{}
+	for _, pos := range p.Positions {
+		if err := pos.checkSide(); err != nil {
+			return nil, err
+		}
+		rate, err := fx.Rate(pos.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("position %s: %w", pos.ID, err)
+		}
+		out, err := pos.price(o)
+		if err != nil {
+			return nil, err
+		}
+		k := key{pos.Underlying, pos.Inputs.T}
+		e, ok := groups[k]
+		if !ok {
+			e = &Exposure{Underlying: k.underlying, T: k.T}
+			groups[k] = e
+		}
+		g := out.scale(pos.units() * rate)
+		e.Positions++
+		e.Contracts += pos.SignedQuantity()
+		e.Greeks = e.Greeks.add(g)
+		e.CashDelta += g.Delta * pos.Inputs.S0
+		e.CashGamma += g.Gamma * pos.Inputs.S0 * pos.Inputs.S0 / 100
+	}
+	out := make([]Exposure, 0, len(groups))
+	for _, e := range groups {
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Underlying != out[j].Underlying {
+			return out[i].Underlying < out[j].Underlying
+		}
+		return out[i].T < out[j].T
+	})
+	return out, nil
+}
@@ -0,0 +1,76 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Risk prices positions as Price does, so an American put is worth its
+// early exercise premium in the book too.
+func TestPortfolioRiskHonoursExercise(t *testing.T) {
+	in := Inputs{S0: 100, K: 120, T: 1, Sigma: 0.2, R: 0.05, OptType: Put, Exercise: American}
+	want, err := Price(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	book := &Portfolio{BaseCurrency: "USD", Positions: []Position{{ID: "p", Inputs: in, Quantity: 2}}}
+	risk, err := book.Risk(FXRates{Base: "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := risk.Greeks.Price; math.Abs(got-2*want.Price) > 1e-12 {
+		t.Errorf("book value %g, want %g", got, 2*want.Price)
+	}
+
+	book.ThetaBasis = -1
+	if _, err := book.Risk(FXRates{Base: "USD"}); !errors.Is(err, ErrThetaBasis) {
+		t.Errorf("negative theta basis: got %v, want ErrThetaBasis", err)
+	}
+	book.ThetaBasis = 0
+	book.Positions[0].Inputs.OptType = 0
+	if _, err := book.Risk(FXRates{Base: "USD"}); !errors.Is(err, ErrUnknownOptionType) {
+		t.Errorf("unset option type: got %v, want ErrUnknownOptionType", err)
+	}
+}
+
+func TestPriceTreeThetaBasis(t *testing.T) {
+	in := Inputs{S0: 100, K: 100, T: 1, Sigma: 0.2, R: 0.05, OptType: Put, Exercise: American}
+	for _, basis := range []int{0, -252} {
+		if _, err := PriceTree(in, TreeOptions{}, basis); !errors.Is(err, ErrThetaBasis) {
+			t.Errorf("basis %d: got %v, want ErrThetaBasis", basis, err)
+		}
+	}
+}
+
+// Exposures and Risk share one pricing path, and exposures are FX-scaled
+// into the base currency.
+func TestExposuresMatchRisk(t *testing.T) {
+	book := &Portfolio{BaseCurrency: "USD", Positions: []Position{
+		{ID: "am", Underlying: "X", Quantity: 3, Currency: "EUR",
+			Inputs: Inputs{S0: 100, K: 110, T: 1, Sigma: 0.25, R: 0.04, OptType: Put, Exercise: American}},
+		{ID: "div", Underlying: "X", Quantity: -2,
+			Inputs: Inputs{S0: 100, K: 95, T: 1, Sigma: 0.25, R: 0.04, OptType: Call, Dividends: []CashDividend{{Time: 0.5, Amount: 2}}}},
+	}}
+	fx := FXRates{Base: "USD", Rates: map[string]float64{"EUR": 1.1}}
+	risk, err := book.Risk(fx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp, err := book.Exposures(fx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exp) != 1 {
+		t.Fatalf("got %d exposures, want 1", len(exp))
+	}
+	g := exp[0].Greeks
+	if math.Abs(g.Price-risk.Greeks.Price) > 1e-9 || math.Abs(g.Delta-risk.Greeks.Delta) > 1e-9 {
+		t.Errorf("exposure (%g, %g), risk (%g, %g)", g.Price, g.Delta, risk.Greeks.Price, risk.Greeks.Delta)
+	}
+	am, _ := Price(book.Positions[0].Inputs)
+	div, _ := Price(book.Positions[1].Inputs)
+	if want := 3*1.1*am.Delta - 2*div.Delta; math.Abs(g.Delta-want) > 1e-9 {
+		t.Errorf("delta %g, want %g in USD", g.Delta, want)
+	}
+}
@@ -0,0 +1,108 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ProjectionOptions configures Portfolio.Project. SpotFactor[i] and
+// VolShift[i] give the market on step i (0 is the start): each position's
+// spot times the factor, and its vol plus the shift. Beyond the end of a
+// path its last value holds; an empty path keeps the market static.
+type ProjectionOptions struct {
+	Start       time.Time
+	Horizon     time.Time
+	TradingDays bool // Step over weekends
+	SpotFactor  []float64
+	VolShift    []float64
+}
+
+// ProjectionDay is the book on one projected date. Greeks are in the base
+// currency; CharmPerDay is the book's daily change in delta from time
+// alone. Expired lists positions that expired since the previous step and
+// have left the book.
+type ProjectionDay struct {
+	Date        time.Time
+	SpotFactor  float64
+	VolShift    float64
+	Greeks      Outputs
+	CharmPerDay float64
+	Expired     []string
+	GammaFlip   bool // Gamma changed sign since the previous step
+	CharmFlip   bool
+}
+
+// Projection is the path of a book's Greeks through time.
+type Projection struct {
+	Days       []ProjectionDay
+	GammaFlips []time.Time
+	CharmFlips []time.Time
+}
+
+// Project rolls the book forward in daily steps from opt.Start to
+// opt.Horizon, ageing every position and its dividends by the calendar
+// time elapsed and repricing it as Risk does on the given spot and vol
+// paths.
+func (p *Portfolio) Project(fx FXRates, opt ProjectionOptions) (Projection, error) {
+	if opt.Horizon.Before(opt.Start) {
+		return Projection{}, errors.New("horizon is before the start")
+	}
+	at := func(path []float64, i int, def float64) float64 {
+		switch {
+		case len(path) == 0:
+			return def
+		case i < len(path):
+			return path[i]
+		}
+		return path[len(path)-1]
+	}
+
+	var out Projection
+	expired := map[string]bool{}
+	for step, date := 0, opt.Start; !date.After(opt.Horizon); date = date.AddDate(0, 0, 1) {
+		if opt.TradingDays && step > 0 && !isWeekday(date) {
+			continue
+		}
+		day := ProjectionDay{Date: date, SpotFactor: at(opt.SpotFactor, step, 1), VolShift: at(opt.VolShift, step, 0)}
+		elapsed := date.Sub(opt.Start).Hours() / 24 / calendarDaysPerYear
+		book := Portfolio{BaseCurrency: p.BaseCurrency, ThetaBasis: p.ThetaBasis}
+		for _, pos := range p.Positions {
+			pos.Inputs = pos.Inputs.aged(elapsed)
+			if pos.Inputs.T <= 0 {
+				if !expired[pos.ID] {
+					expired[pos.ID] = true
+					day.Expired = append(day.Expired, pos.ID)
+				}
+				continue
+			}
+			pos.Inputs.S0 *= day.SpotFactor
+			pos.Inputs.Sigma = math.Max(pos.Inputs.Sigma+day.VolShift, 0)
+			book.Positions = append(book.Positions, pos)
+		}
+		risk, err := book.risk(fx)
+		if err != nil {
+			return Projection{}, err
+		}
+		day.Greeks, day.CharmPerDay = risk.Greeks, risk.Greeks.CharmPerDay
+		if n := len(out.Days); n > 0 {
+			prev := out.Days[n-1]
+			if day.GammaFlip = signFlip(prev.Greeks.Gamma, day.Greeks.Gamma); day.GammaFlip {
+				out.GammaFlips = append(out.GammaFlips, date)
+			}
+			if day.CharmFlip = signFlip(prev.CharmPerDay, day.CharmPerDay); day.CharmFlip {
+				out.CharmFlips = append(out.CharmFlips, date)
+			}
+		}
+		out.Days = append(out.Days, day)
+		step++
+	}
+	return out, nil
+}
+
+// signFlip reports a strict change of sign, ignoring values within
+// rounding of zero.
+func signFlip(a, b float64) bool {
+	const eps = 1e-12
+	return (a > eps && b < -eps) || (a < -eps && b > eps)
+}
@@ -0,0 +1,41 @@
+package bsm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Projected days price the aged book as Price does: early exercise,
+// dividends drawing nearer and dropping once paid.
+func TestProjectPricesAgedBook(t *testing.T) {
+	put := Inputs{S0: 100, K: 120, T: 1, Sigma: 0.2, R: 0.05, OptType: Put, Exercise: American}
+	call := Inputs{S0: 100, K: 100, T: 0.5, Sigma: 0.2, R: 0.05, OptType: Call,
+		Dividends: []CashDividend{{Time: 2 / calendarDaysPerYear, Amount: 3}}}
+	book := &Portfolio{BaseCurrency: "USD", Positions: []Position{
+		{ID: "put", Inputs: put, Quantity: 1},
+		{ID: "call", Inputs: call, Quantity: -1},
+	}}
+	start := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	proj, err := book.Project(FXRates{Base: "USD"}, ProjectionOptions{Start: start, Horizon: start.AddDate(0, 0, 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proj.Days) != 4 {
+		t.Fatalf("got %d days, want 4", len(proj.Days))
+	}
+	for i, day := range proj.Days {
+		elapsed := float64(i) / calendarDaysPerYear
+		p, _ := Price(put.aged(elapsed))
+		c, _ := Price(call.aged(elapsed))
+		if want := p.Price - c.Price; math.Abs(day.Greeks.Price-want) > 1e-9 {
+			t.Errorf("day %d: value %g, want %g", i, day.Greeks.Price, want)
+		}
+		if want := p.CharmPerDay - c.CharmPerDay; math.Abs(day.CharmPerDay-want) > 1e-9 {
+			t.Errorf("day %d: charm %g, want %g", i, day.CharmPerDay, want)
+		}
+	}
+	if n := len(call.aged(3 / calendarDaysPerYear).Dividends); n != 0 {
+		t.Errorf("%d dividends left after the ex-date", n)
+	}
+}
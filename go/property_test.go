@@ -0,0 +1,289 @@
+package bsm
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// Property tests: invariants every model must keep, checked on random
+// contracts from a fixed seed so failures reproduce. New models join by
+// adding a row to propertyModels.
+
+const propertyCases = 500
+
+var propertyModels = []PricingModel{BSMModel, Black76Model, BachelierModel}
+
+// randomInputs draws a contract within a couple of standard deviations of
+// the money, where the tolerances below are meaningful. Under Bachelier
+// Sigma is the lognormal vol restated as a normal one.
+func randomInputs(r *rand.Rand, model PricingModel, optType OptionType) Inputs {
+	in := Inputs{
+		S0:      20 * math.Exp(r.Float64()*math.Log(25)), // 20 to 500, log-uniform
+		T:       0.05 + 2.95*r.Float64(),
+		Sigma:   0.05 + 0.95*r.Float64(),
+		R:       -0.01 + 0.09*r.Float64(),
+		OptType: optType,
+		Model:   model,
+	}
+	if model == BSMModel {
+		in.Q = 0.06 * r.Float64()
+	}
+	in.K = in.S0 * math.Exp((2*r.Float64()-1)*2*in.Sigma*math.Sqrt(in.T))
+	if model == BachelierModel {
+		in.Sigma *= in.S0
+	}
+	return in
+}
+
+// carry is the yield that takes the spot to its discounted forward:
+// the dividend yield under BSM and the rate for options on forwards.
+func carry(in Inputs) float64 {
+	if in.Model == BSMModel || in.Model == "" {
+		return in.Q
+	}
+	return in.R
+}
+
+func mustPrice(t *testing.T, in Inputs) Outputs {
+	t.Helper()
+	out, err := Price(in)
+	if err != nil {
+		t.Fatalf("%+v: %v", in, err)
+	}
+	return out
+}
+
+func TestPropertyPutCallParity(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 1))
+	for _, m := range propertyModels {
+		for range propertyCases {
+			call := randomInputs(r, m, Call)
+			put := call
+			put.OptType = Put
+			c, p := mustPrice(t, call), mustPrice(t, put)
+			want := call.S0*math.Exp(-carry(call)*call.T) - call.K*math.Exp(-call.R*call.T)
+			if got := c.Price - p.Price; math.Abs(got-want) > 1e-10*(call.S0+call.K) {
+				t.Errorf("%s %+v: C - P = %.15g, want %.15g", m, call, got, want)
+			}
+			if got := c.Delta - p.Delta; math.Abs(got-math.Exp(-carry(call)*call.T)) > 1e-12 {
+				t.Errorf("%s %+v: delta C - P = %.15g", m, call, got)
+			}
+			if math.Abs(c.Gamma-p.Gamma) > 1e-12*c.Gamma+1e-15 || math.Abs(c.VegaPerVol-p.VegaPerVol) > 1e-10*(c.VegaPerVol+1) {
+				t.Errorf("%s %+v: call and put gamma or vega differ: %+v, %+v", m, call, c, p)
+			}
+		}
+	}
+}
+
+func TestPropertyNoArbitrageBounds(t *testing.T) {
+	r := rand.New(rand.NewPCG(2, 2))
+	for _, m := range propertyModels {
+		for range propertyCases {
+			for _, typ := range []OptionType{Call, Put} {
+				in := randomInputs(r, m, typ)
+				out := mustPrice(t, in)
+				fwd := in.S0 * math.Exp(-carry(in)*in.T)
+				pv := in.K * math.Exp(-in.R*in.T)
+				lo, hi := math.Max(fwd-pv, 0), fwd
+				if typ == Put {
+					lo, hi = math.Max(pv-fwd, 0), pv
+				}
+				if m == BachelierModel {
+					hi = math.Inf(1) // Normal forwards can go negative, so no cap
+				}
+				tol := 1e-12 * (in.S0 + in.K)
+				if out.Price < lo-tol || out.Price > hi+tol {
+					t.Errorf("%s %+v: price %.15g outside [%.15g, %.15g]", m, in, out.Price, lo, hi)
+				}
+				if out.ProbITM < 0 || out.ProbITM > 1 {
+					t.Errorf("%s %+v: P(ITM) %g", m, in, out.ProbITM)
+				}
+			}
+		}
+	}
+}
+
+func TestPropertyAmericanBounds(t *testing.T) {
+	r := rand.New(rand.NewPCG(3, 3))
+	for range propertyCases / 10 {
+		for _, typ := range []OptionType{Call, Put} {
+			// Compare on one tree, so its discretization error cancels.
+			in := randomInputs(r, BSMModel, typ)
+			euro, err := PriceTree(in, TreeOptions{}, 365)
+			if err != nil {
+				t.Fatal(err)
+			}
+			in.Exercise = American
+			amer, err := PriceTree(in, TreeOptions{}, 365)
+			if err != nil {
+				t.Fatal(err)
+			}
+			intrinsic := math.Max(in.S0-in.K, 0)
+			if typ == Put {
+				intrinsic = math.Max(in.K-in.S0, 0)
+			}
+			if tol := 1e-12 * (in.S0 + in.K); amer.Price < euro.Price-tol || amer.Price < intrinsic-tol {
+				t.Errorf("%+v: American %.15g below European %.15g or intrinsic %.15g", in, amer.Price, euro.Price, intrinsic)
+			}
+		}
+	}
+}
+
+func TestPropertyMonotonicity(t *testing.T) {
+	r := rand.New(rand.NewPCG(4, 4))
+	for _, m := range propertyModels {
+		for range propertyCases {
+			for _, typ := range []OptionType{Call, Put} {
+				in := randomInputs(r, m, typ)
+				base := mustPrice(t, in)
+				if base.Gamma < 0 || base.VegaPerVol < 0 {
+					t.Errorf("%s %+v: negative gamma %g or vega %g", m, in, base.Gamma, base.VegaPerVol)
+				}
+				if (typ == Call && base.Delta < 0) || (typ == Put && base.Delta > 0) {
+					t.Errorf("%s %+v: delta %g has the wrong sign", m, in, base.Delta)
+				}
+				up := in
+				up.S0 *= 1.01
+				dS := mustPrice(t, up).Price - base.Price
+				if (typ == Call && dS < -1e-12) || (typ == Put && dS > 1e-12) {
+					t.Errorf("%s %+v: price moved %g the wrong way with spot", m, in, dS)
+				}
+				up = in
+				up.Sigma *= 1.01
+				if dV := mustPrice(t, up).Price - base.Price; dV < -1e-12 {
+					t.Errorf("%s %+v: price fell %g as vol rose", m, in, dV)
+				}
+			}
+		}
+	}
+}
+
+// bumpGreeks lists each Greek with the input it differentiates and the
+// lower-order output it is the derivative of, so second-order Greeks are
+// checked against bumps of the analytic first-order ones. Time Greeks are
+// minus the T derivative.
+var bumpGreeks = []struct {
+	name  string
+	greek func(Outputs) float64
+	of    func(Outputs) float64
+	input func(*Inputs) *float64
+	sign  float64
+}{
+	{"Delta", func(o Outputs) float64 { return o.Delta }, ofPrice, bumpSpot, 1},
+	{"Gamma", func(o Outputs) float64 { return o.Gamma }, ofDelta, bumpSpot, 1},
+	{"Speed", func(o Outputs) float64 { return o.Speed }, ofGamma, bumpSpot, 1},
+	{"VegaPerVol", func(o Outputs) float64 { return o.VegaPerVol }, ofPrice, bumpVol, 1},
+	{"Vanna", func(o Outputs) float64 { return o.Vanna }, ofDelta, bumpVol, 1},
+	{"Volga", func(o Outputs) float64 { return o.Volga }, ofVega, bumpVol, 1},
+	{"Zomma", func(o Outputs) float64 { return o.Zomma }, ofGamma, bumpVol, 1},
+	{"ThetaPerYear", func(o Outputs) float64 { return o.ThetaPerYear }, ofPrice, bumpExpiry, -1},
+	{"CharmPerYear", func(o Outputs) float64 { return o.CharmPerYear }, ofDelta, bumpExpiry, -1},
+	{"ColorPerYear", func(o Outputs) float64 { return o.ColorPerYear }, ofGamma, bumpExpiry, -1},
+	{"RhoPer1", func(o Outputs) float64 { return o.RhoPer1 }, ofPrice, bumpRate, 1},
+	{"PhiPer1", func(o Outputs) float64 { return o.PhiPer1 }, ofPrice, bumpYield, 1},
+	{"DualDelta", func(o Outputs) float64 { return o.DualDelta }, ofPrice, bumpStrike, 1},
+}
+
+func ofPrice(o Outputs) float64 { return o.Price }
+func ofDelta(o Outputs) float64 { return o.Delta }
+func ofGamma(o Outputs) float64 { return o.Gamma }
+func ofVega(o Outputs) float64  { return o.VegaPerVol }
+
+func bumpSpot(in *Inputs) *float64   { return &in.S0 }
+func bumpStrike(in *Inputs) *float64 { return &in.K }
+func bumpVol(in *Inputs) *float64    { return &in.Sigma }
+func bumpExpiry(in *Inputs) *float64 { return &in.T }
+func bumpRate(in *Inputs) *float64   { return &in.R }
+func bumpYield(in *Inputs) *float64  { return &in.Q }
+
+func TestPropertyGreeksMatchBumps(t *testing.T) {
+	r := rand.New(rand.NewPCG(5, 5))
+	for _, m := range propertyModels {
+		for range propertyCases / 5 {
+			for _, typ := range []OptionType{Call, Put} {
+				in := randomInputs(r, m, typ)
+				base := mustPrice(t, in)
+				for _, g := range bumpGreeks {
+					if g.name == "PhiPer1" && m != BSMModel {
+						continue // Q must be zero
+					}
+					x := *g.input(&in)
+					h := 1e-4 * math.Max(math.Abs(x), 0.01)
+					up, down := in, in
+					*g.input(&up) += h
+					*g.input(&down) -= h
+					fd := g.sign * (g.of(mustPrice(t, up)) - g.of(mustPrice(t, down))) / (2 * h)
+					want := g.greek(base)
+					// Relative to the Greek, or to the scale of the output
+					// differentiated over the scale of the input.
+					scale := (math.Abs(g.of(base)) + 1e-3*in.S0) / math.Max(math.Abs(x), 0.01)
+					if math.Abs(fd-want) > 1e-5*(math.Abs(want)+scale) {
+						t.Errorf("%s %+v: %s = %.10g, bump gives %.10g", m, in, g.name, want, fd)
+					}
+				}
+			}
+		}
+	}
+}
+
+// FuzzPriceInvariants prices arbitrary valid contracts and checks what
+// must hold everywhere, not only near the money: finite outputs, parity,
+// the no-arbitrage bounds and non-negative gamma and vega. Run it with
+// go test -fuzz FuzzPriceInvariants.
+func FuzzPriceInvariants(f *testing.F) {
+	f.Add(100.0, 100.0, 0.5, 0.2, 0.03, 0.01, uint8(0))
+	f.Add(100.0, 150.0, 0.01, 0.8, 0.0, 0.0, uint8(1))
+	f.Add(50.0, 10.0, 5.0, 0.05, 0.1, 0.0, uint8(2))
+	f.Add(1e-3, 1e4, 30.0, 3.0, -0.02, 0.05, uint8(0))
+	f.Fuzz(func(t *testing.T, s, k, tt, sigma, r, q float64, model uint8) {
+		m := propertyModels[int(model)%len(propertyModels)]
+		if m != BSMModel {
+			q = 0
+		}
+		call := Inputs{S0: s, K: k, T: tt, Sigma: sigma, R: r, Q: q, OptType: Call, Model: m}
+		if call.Validate() != nil || s <= 0 || k <= 0 || s > 1e6 || k > 1e6 || tt > 30 ||
+			math.Abs(r) > 0.5 || q > 0.5 || q < -0.5 {
+			t.Skip()
+		}
+		if m == BachelierModel {
+			call.Sigma *= s
+		}
+		if sigma > 5 || call.Sigma*math.Sqrt(tt) > 50*(s+k) {
+			t.Skip()
+		}
+		put := call
+		put.OptType = Put
+		c, err := Price(call)
+		if err != nil {
+			t.Fatalf("%+v: %v", call, err)
+		}
+		p, err := Price(put)
+		if err != nil {
+			t.Fatalf("%+v: %v", put, err)
+		}
+		for _, o := range []Outputs{c, p} {
+			for _, v := range []float64{o.Price, o.Delta, o.Gamma, o.VegaPerVol, o.ThetaPerYear, o.RhoPer1} {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					t.Fatalf("%+v: non-finite output %+v", call, o)
+				}
+			}
+			if o.Gamma < 0 || o.VegaPerVol < 0 {
+				t.Errorf("%+v: negative gamma %g or vega %g", call, o.Gamma, o.VegaPerVol)
+			}
+		}
+		tt = math.Max(tt, 1e-6) // The kernel's floor, as Price sees the contract
+		fwd := s * math.Exp(-carry(call)*tt)
+		pv := k * math.Exp(-r*tt)
+		tol := 1e-9 * (fwd + pv)
+		if got, want := c.Price-p.Price, fwd-pv; math.Abs(got-want) > tol {
+			t.Errorf("%+v: C - P = %.15g, want %.15g", call, got, want)
+		}
+		if c.Price < math.Max(fwd-pv, 0)-tol || p.Price < math.Max(pv-fwd, 0)-tol {
+			t.Errorf("%+v: below intrinsic: call %.15g, put %.15g", call, c.Price, p.Price)
+		}
+		if m != BachelierModel && (c.Price > fwd+tol || p.Price > pv+tol) {
+			t.Errorf("%+v: above the bound: call %.15g, put %.15g", call, c.Price, p.Price)
+		}
+	})
+}
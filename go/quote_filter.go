@@ -0,0 +1,175 @@
+package bsm
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Reasons recorded by FilterQuotes.
+const (
+	DropInvalid      = "invalid market"
+	DropCrossed      = "crossed market"
+	DropLocked       = "locked market"
+	DropStale        = "stale quote"
+	DropOpenInterest = "open interest below minimum"
+	DropZeroBidWing  = "zero-bid wing"
+	DropWideSpread   = "spread too wide"
+	DropParity       = "put-call parity outlier"
+)
+
+// QuoteFilters configures FilterQuotes. Invalid and crossed markets are
+// always dropped; every other filter is off at its zero value.
+type QuoteFilters struct {
+	DropLocked      bool          // Drop bid == ask markets
+	MaxAge          time.Duration // Drop quotes last updated longer ago than this
+	MinOpenInterest float64
+	ZeroBidWings    bool    // Drop each wing from its first zero-bid strike outwards
+	MaxRelSpread    float64 // Drop if (ask - bid)/mid exceeds this...
+	SpreadFloor     float64 // ...and the spread also exceeds this absolute amount
+	ParityTolerance float64 // Drop call/put pairs whose parity forward is this fraction away from the expiry's median
+}
+
+// DefaultQuoteFilters returns a reasonable set of filters for listed
+// equity options.
+func DefaultQuoteFilters() QuoteFilters {
+	return QuoteFilters{
+		DropLocked:      true,
+		MaxAge:          15 * time.Minute,
+		ZeroBidWings:    true,
+		MaxRelSpread:    0.5,
+		SpreadFloor:     0.05,
+		ParityTolerance: 0.01,
+	}
+}
+
+// QuoteFilterReport lists the quotes FilterQuotes kept and dropped.
+type QuoteFilterReport struct {
+	Kept    []ChainQuote
+	Dropped []DroppedQuote
+}
+
+// Counts returns the number of dropped quotes per reason.
+func (r QuoteFilterReport) Counts() map[string]int {
+	out := map[string]int{}
+	for _, d := range r.Dropped {
+		out[d.Reason]++
+	}
+	return out
+}
+
+// FilterQuotes applies f to snap's quotes. r discounts put-call parity
+// forwards. Each dropped quote records the first filter that rejected it.
+func FilterQuotes(snap *ChainSnapshot, r float64, f QuoteFilters) QuoteFilterReport {
+	var rep QuoteFilterReport
+	drop := func(q ChainQuote, reason string) { rep.Dropped = append(rep.Dropped, DroppedQuote{q, reason}) }
+
+	byExpiry := map[float64][]ChainQuote{}
+	for _, q := range snap.Quotes {
+		spread := q.Ask - q.Bid
+		switch {
+		case q.Bid < 0 || q.Ask <= 0 || q.Strike <= 0:
+			drop(q, DropInvalid)
+		case spread < 0:
+			drop(q, DropCrossed)
+		case f.DropLocked && spread == 0:
+			drop(q, DropLocked)
+		case f.MaxAge > 0 && !q.Timestamp.IsZero() && snap.AsOf.Sub(q.Timestamp) > f.MaxAge:
+			drop(q, DropStale)
+		case q.OpenInterest < f.MinOpenInterest:
+			drop(q, DropOpenInterest)
+		default:
+			byExpiry[q.T] = append(byExpiry[q.T], q)
+		}
+	}
+
+	expiries := make([]float64, 0, len(byExpiry))
+	for T := range byExpiry {
+		expiries = append(expiries, T)
+	}
+	sort.Float64s(expiries)
+	for _, T := range expiries {
+		quotes := byExpiry[T]
+		if f.ZeroBidWings {
+			quotes = dropZeroBidWings(quotes, drop)
+		}
+		if f.MaxRelSpread > 0 {
+			kept := quotes[:0]
+			for _, q := range quotes {
+				if s := q.Ask - q.Bid; s > f.SpreadFloor && s > f.MaxRelSpread*q.Mid() {
+					drop(q, DropWideSpread)
+				} else {
+					kept = append(kept, q)
+				}
+			}
+			quotes = kept
+		}
+		if f.ParityTolerance > 0 {
+			quotes = dropParityOutliers(quotes, math.Exp(-r*T), f.ParityTolerance, drop)
+		}
+		rep.Kept = append(rep.Kept, quotes...)
+	}
+	return rep
+}
+
+// dropZeroBidWings drops calls at and above the lowest zero-bid call strike,
+// and puts at and below the highest zero-bid put strike.
+func dropZeroBidWings(quotes []ChainQuote, drop func(ChainQuote, string)) []ChainQuote {
+	callCut, putCut := math.Inf(1), math.Inf(-1)
+	for _, q := range quotes {
+		if q.Bid > 0 {
+			continue
+		}
+		if q.OptType == Call {
+			callCut = math.Min(callCut, q.Strike)
+		} else {
+			putCut = math.Max(putCut, q.Strike)
+		}
+	}
+	kept := quotes[:0]
+	for _, q := range quotes {
+		if (q.OptType == Call && q.Strike >= callCut) || (q.OptType != Call && q.Strike <= putCut) {
+			drop(q, DropZeroBidWing)
+		} else {
+			kept = append(kept, q)
+		}
+	}
+	return kept
+}
+
+// dropParityOutliers drops both legs of any call/put pair whose parity
+// forward K + (C - P)/DF is more than tol (relative) from the median of
+// all pairs at the expiry. Fewer than three pairs give no reliable median
+// and are left alone.
+func dropParityOutliers(quotes []ChainQuote, df, tol float64, drop func(ChainQuote, string)) []ChainQuote {
+	calls, puts := map[float64]float64{}, map[float64]float64{}
+	for _, q := range quotes {
+		if q.OptType == Call {
+			calls[q.Strike] = q.Mid()
+		} else {
+			puts[q.Strike] = q.Mid()
+		}
+	}
+	fwd := map[float64]float64{}
+	var all []float64
+	for K, c := range calls {
+		if p, ok := puts[K]; ok {
+			fwd[K] = K + (c-p)/df
+			all = append(all, fwd[K])
+		}
+	}
+	if len(all) < 3 {
+		return quotes
+	}
+	sort.Float64s(all)
+	median := all[len(all)/2]
+	kept := quotes[:0]
+	for _, q := range quotes {
+		if F, ok := fwd[q.Strike]; ok && math.Abs(F-median) > tol*median {
+			drop(q, DropParity)
+		} else {
+			kept = append(kept, q)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,174 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Graph is a dependency graph of inputs and computed nodes. Setting an
+// input marks everything downstream of it stale; stale nodes recompute
+// when read or on Recalculate, and nodes not downstream of a change keep
+// their values. Nodes can only depend on nodes created before them, so
+// creation order is a topological order and cycles cannot arise.
+//
+// A Graph is not safe for concurrent use; drive it from one goroutine.
+type Graph struct {
+	nodes  []*graphNode
+	byName map[string]*graphNode
+}
+
+type graphNode struct {
+	name       string
+	index      int
+	compute    func() (any, error) // Nil for inputs
+	dependents []*graphNode
+	value      any
+	err        error
+	stale      bool
+	runs       int
+	watchers   []func()
+}
+
+// NewGraph returns an empty graph.
+func NewGraph() *Graph {
+	return &Graph{byName: map[string]*graphNode{}}
+}
+
+// Ref is any input or node handle, used to declare dependencies.
+type Ref interface {
+	node() *graphNode
+}
+
+// Input is a value set from outside the graph.
+type Input[T any] struct{ n *graphNode }
+
+// Node is a value computed from other inputs and nodes.
+type Node[T any] struct{ n *graphNode }
+
+func (in Input[T]) node() *graphNode { return in.n }
+func (nd Node[T]) node() *graphNode  { return nd.n }
+
+func (g *Graph) add(name string, compute func() (any, error), deps []Ref) (*graphNode, error) {
+	if _, ok := g.byName[name]; ok {
+		return nil, fmt.Errorf("graph already has a node %q", name)
+	}
+	n := &graphNode{name: name, index: len(g.nodes), compute: compute, stale: compute != nil}
+	for _, d := range deps {
+		dn := d.node()
+		if dn == nil || dn.index >= len(g.nodes) || g.nodes[dn.index] != dn {
+			return nil, fmt.Errorf("node %q depends on a node from another graph", name)
+		}
+		dn.dependents = append(dn.dependents, n)
+	}
+	g.nodes = append(g.nodes, n)
+	g.byName[name] = n
+	return n, nil
+}
+
+// NewInput adds an input with an initial value.
+func NewInput[T any](g *Graph, name string, v T) (Input[T], error) {
+	n, err := g.add(name, nil, nil)
+	if err != nil {
+		return Input[T]{}, err
+	}
+	n.value = v
+	return Input[T]{n}, nil
+}
+
+// NewNode adds a node computed by f from deps. f must read only the
+// inputs and nodes listed in deps (with Value and Get), or the graph will
+// not know to recompute it when they change.
+func NewNode[T any](g *Graph, name string, deps []Ref, f func() (T, error)) (Node[T], error) {
+	n, err := g.add(name, func() (any, error) { return f() }, deps)
+	if err != nil {
+		return Node[T]{}, err
+	}
+	return Node[T]{n}, nil
+}
+
+// Value returns the input's current value.
+func (in Input[T]) Value() T { return in.n.value.(T) }
+
+// Set replaces the input's value and marks its dependents stale.
+func (in Input[T]) Set(v T) {
+	in.n.value = v
+	for _, d := range in.n.dependents {
+		d.invalidate()
+	}
+}
+
+// Get returns the node's value, recomputing it first if it is stale.
+func (nd Node[T]) Get() (T, error) {
+	if err := nd.n.refresh(); err != nil {
+		var zero T
+		return zero, err
+	}
+	return nd.n.value.(T), nil
+}
+
+// Runs returns how many times the node has been computed.
+func (nd Node[T]) Runs() int { return nd.n.runs }
+
+// Watch registers fn to be called with the node's new value whenever
+// Recalculate recomputes it successfully.
+func (nd Node[T]) Watch(fn func(T)) {
+	nd.n.watchers = append(nd.n.watchers, func() { fn(nd.n.value.(T)) })
+}
+
+func (n *graphNode) invalidate() {
+	if n.stale {
+		return
+	}
+	n.stale = true
+	for _, d := range n.dependents {
+		d.invalidate()
+	}
+}
+
+func (n *graphNode) refresh() error {
+	if !n.stale {
+		return n.err
+	}
+	v, err := n.compute()
+	n.value, n.err, n.stale = v, err, false
+	n.runs++
+	return err
+}
+
+// Recalculate recomputes every stale node in dependency order, calls the
+// watchers of those that succeeded, and returns the names recomputed. The
+// error joins those of nodes that failed; they stay readable and report
+// their error from Get.
+func (g *Graph) Recalculate() ([]string, error) {
+	var names []string
+	var errs []error
+	var changed []*graphNode
+	for _, n := range g.nodes {
+		if !n.stale {
+			continue
+		}
+		names = append(names, n.name)
+		if err := n.refresh(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.name, err))
+			continue
+		}
+		changed = append(changed, n)
+	}
+	for _, n := range changed {
+		for _, w := range n.watchers {
+			w()
+		}
+	}
+	return names, errors.Join(errs...)
+}
+
+// Stale lists the nodes awaiting recomputation.
+func (g *Graph) Stale() []string {
+	var out []string
+	for _, n := range g.nodes {
+		if n.stale {
+			out = append(out, n.name)
+		}
+	}
+	return out
+}
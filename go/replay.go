@@ -0,0 +1,242 @@
+package bsm
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MarketMove is one day's market change in a historical episode.
+type MarketMove struct {
+	Date       time.Time
+	SpotReturn float64 // Relative, e.g. -0.04
+	VolChange  float64 // Absolute change in the vol level, e.g. 0.2 for VIX 17 to 37
+	VolReturn  float64 // Relative change in the vol level
+}
+
+// Episode is a sequence of daily market moves from the close on Start.
+type Episode struct {
+	Name  string
+	Start time.Time
+	Moves []MarketMove
+}
+
+// EpisodeFromLevels builds an episode from daily closes of a spot index
+// and a vol index (as a decimal, e.g. VIX/100). The first day is the
+// starting point, so there is one move fewer than there are closes.
+func EpisodeFromLevels(name string, dates []time.Time, spots, vols []float64) (Episode, error) {
+	if len(dates) != len(spots) || len(dates) != len(vols) {
+		return Episode{}, errors.New("dates, spots and vols must have the same length")
+	}
+	if len(dates) < 2 {
+		return Episode{}, errors.New("need at least two closes")
+	}
+	ep := Episode{Name: name, Start: dates[0]}
+	for i := 1; i < len(dates); i++ {
+		if spots[i-1] <= 0 || vols[i-1] <= 0 {
+			return Episode{}, fmt.Errorf("%s: spot and vol levels must be positive", dates[i-1].Format("2006-01-02"))
+		}
+		ep.Moves = append(ep.Moves, MarketMove{
+			Date:       dates[i],
+			SpotReturn: spots[i]/spots[i-1] - 1,
+			VolChange:  vols[i] - vols[i-1],
+			VolReturn:  vols[i]/vols[i-1] - 1,
+		})
+	}
+	return ep, nil
+}
+
+// ReadEpisodeCSV reads an episode from CSV with a header row of date
+// (YYYY-MM-DD), spot and vol closes, the vol as a decimal.
+func ReadEpisodeCSV(r io.Reader, name string) (Episode, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return Episode{}, err
+	}
+	if len(rows) == 0 {
+		return Episode{}, errors.New("empty episode file")
+	}
+	col := map[string]int{}
+	for i, h := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, req := range []string{"date", "spot", "vol"} {
+		if _, ok := col[req]; !ok {
+			return Episode{}, fmt.Errorf("missing column %q", req)
+		}
+	}
+	var dates []time.Time
+	var spots, vols []float64
+	for i, row := range rows[1:] {
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(row[col["date"]]))
+		if err != nil {
+			return Episode{}, fmt.Errorf("line %d: %w", i+2, err)
+		}
+		s, err := strconv.ParseFloat(strings.TrimSpace(row[col["spot"]]), 64)
+		if err != nil {
+			return Episode{}, fmt.Errorf("line %d: spot: %w", i+2, err)
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(row[col["vol"]]), 64)
+		if err != nil {
+			return Episode{}, fmt.Errorf("line %d: vol: %w", i+2, err)
+		}
+		dates, spots, vols = append(dates, d), append(spots, s), append(vols, v)
+	}
+	return EpisodeFromLevels(name, dates, spots, vols)
+}
+
+// cannedEpisodes are S&P 500 and VIX closes, rounded, for well-known stress
+// periods. They are approximate; replay exact data with ReadEpisodeCSV when
+// it matters.
+var cannedEpisodes = map[string]struct {
+	start string
+	spots []float64
+	vix   []float64
+}{
+	"oct-2008": {"2008-09-30",
+		[]float64{1166.4, 1161.1, 1114.3, 1099.2, 1056.9, 996.2, 984.9, 909.9, 899.2, 1003.4, 998.0, 907.8, 946.4, 940.6, 985.4, 955.1, 896.8, 908.1, 876.8, 848.9, 940.5, 930.1, 954.1, 968.8},
+		[]float64{39.4, 39.8, 45.3, 45.1, 52.1, 53.7, 57.5, 63.9, 70.0, 55.0, 53.1, 69.3, 67.6, 70.3, 53.0, 53.1, 69.7, 67.8, 79.1, 80.1, 67.0, 70.0, 62.9, 59.9}},
+	"feb-2018": {"2018-01-26",
+		[]float64{2872.9, 2853.5, 2822.4, 2823.8, 2822.0, 2762.1, 2648.9, 2695.1, 2681.7, 2581.0, 2619.6, 2656.0, 2662.9, 2698.6},
+		[]float64{11.1, 13.8, 14.8, 13.5, 13.5, 17.3, 37.3, 30.0, 27.7, 33.5, 29.1, 25.6, 25.0, 19.3}},
+	"mar-2020": {"2020-02-21",
+		[]float64{3337.8, 3225.9, 3128.2, 3116.4, 2978.8, 2954.2, 3090.2, 3003.4, 3130.1, 3023.9, 2972.4, 2746.6, 2882.2, 2741.4, 2480.6, 2711.0, 2386.1, 2529.2, 2398.1, 2409.4, 2304.9, 2237.4, 2447.3},
+		[]float64{17.1, 25.0, 27.9, 27.6, 39.2, 40.1, 33.4, 36.8, 32.0, 39.6, 41.9, 54.5, 47.3, 53.9, 75.5, 57.8, 82.7, 75.9, 76.5, 72.0, 66.0, 61.6, 61.7}},
+}
+
+// HistoricalEpisodes lists the built-in episode names.
+func HistoricalEpisodes() []string {
+	names := make([]string, 0, len(cannedEpisodes))
+	for n := range cannedEpisodes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HistoricalEpisode returns a built-in episode: "oct-2008", "feb-2018" or
+// "mar-2020".
+func HistoricalEpisode(name string) (Episode, error) {
+	c, ok := cannedEpisodes[strings.ToLower(name)]
+	if !ok {
+		return Episode{}, fmt.Errorf("unknown episode %q (have %s)", name, strings.Join(HistoricalEpisodes(), ", "))
+	}
+	start, _ := time.Parse("2006-01-02", c.start)
+	dates := []time.Time{start}
+	for d := start; len(dates) < len(c.spots); {
+		if d = d.AddDate(0, 0, 1); isWeekday(d) {
+			dates = append(dates, d)
+		}
+	}
+	vols := make([]float64, len(c.vix))
+	for i, v := range c.vix {
+		vols[i] = v / 100
+	}
+	return EpisodeFromLevels(strings.ToLower(name), dates, c.spots, vols)
+}
+
+// ReplayOptions configures Portfolio.Replay.
+type ReplayOptions struct {
+	Beta        float64 // Book spot moves per index move (1)
+	AbsoluteVol bool    // Add vol-level changes to each vol rather than scale it
+	NoAgeing    bool    // Apply the moves instantaneously, without time decay
+}
+
+// ReplayStep is the book after one day of an episode.
+type ReplayStep struct {
+	Date       time.Time
+	SpotFactor float64 // Cumulative spot multiplier
+	VolFactor  float64 // Cumulative vol multiplier (relative mode)
+	VolShift   float64 // Cumulative vol shift (absolute mode)
+	Value      float64 // Book value in the base currency
+	DailyPnL   float64
+	PnL        float64 // Since the start
+}
+
+// ReplayResult is the P&L path of a book through an episode.
+type ReplayResult struct {
+	Episode     string
+	StartValue  float64
+	Steps       []ReplayStep
+	WorstPnL    float64
+	WorstDate   time.Time
+	MaxDrawdown float64 // Largest peak-to-trough fall in value
+}
+
+// Replay applies an episode's daily moves cumulatively to the current book
+// and reports its P&L path. Each position's spot moves by Beta times the
+// index return; its vol is scaled by the vol index's relative change, or
+// shifted by its absolute change with AbsoluteVol. Unless NoAgeing is set,
+// positions also age by the calendar days elapsed in the episode, dropping
+// the dividends paid in it, and those that expire are held at intrinsic
+// value. The book is valued as Risk values it.
+func (p *Portfolio) Replay(fx FXRates, ep Episode, opt ReplayOptions) (ReplayResult, error) {
+	if len(ep.Moves) == 0 {
+		return ReplayResult{}, errors.New("episode has no moves")
+	}
+	if opt.Beta == 0 {
+		opt.Beta = 1
+	}
+	value := func(spotFactor, volFactor, volShift, elapsed float64) (float64, error) {
+		book := Portfolio{BaseCurrency: p.BaseCurrency, ThetaBasis: p.ThetaBasis}
+		for _, pos := range p.Positions {
+			pos.Inputs.S0 *= spotFactor
+			pos.Inputs.Sigma = math.Max(pos.Inputs.Sigma*volFactor+volShift, 0)
+			pos.Inputs = pos.Inputs.aged(elapsed)
+			pos.Inputs.T = math.Max(pos.Inputs.T, 0)
+			book.Positions = append(book.Positions, pos)
+		}
+		risk, err := book.risk(fx)
+		return risk.Greeks.Price, err
+	}
+
+	start, err := value(1, 1, 0, 0)
+	if err != nil {
+		return ReplayResult{}, err
+	}
+	res := ReplayResult{Episode: ep.Name, StartValue: start}
+	origin := ep.Start
+	if origin.IsZero() {
+		origin = previousWeekday(ep.Moves[0].Date)
+	}
+	spotFactor, volFactor, volShift := 1.0, 1.0, 0.0
+	prev, peak := start, start
+	for i, m := range ep.Moves {
+		spotFactor *= 1 + opt.Beta*m.SpotReturn
+		if opt.AbsoluteVol {
+			volShift += m.VolChange
+		} else {
+			volFactor *= 1 + m.VolReturn
+		}
+		elapsed := 0.0
+		if !opt.NoAgeing {
+			elapsed = m.Date.Sub(origin).Hours() / 24 / calendarDaysPerYear
+		}
+		v, err := value(spotFactor, volFactor, volShift, elapsed)
+		if err != nil {
+			return ReplayResult{}, err
+		}
+		step := ReplayStep{Date: m.Date, SpotFactor: spotFactor, VolFactor: volFactor, VolShift: volShift,
+			Value: v, DailyPnL: v - prev, PnL: v - start}
+		res.Steps = append(res.Steps, step)
+		if i == 0 || step.PnL < res.WorstPnL {
+			res.WorstPnL, res.WorstDate = step.PnL, m.Date
+		}
+		peak = math.Max(peak, v)
+		res.MaxDrawdown = math.Max(res.MaxDrawdown, peak-v)
+		prev = v
+	}
+	return res, nil
+}
+
+func previousWeekday(t time.Time) time.Time {
+	for t = t.AddDate(0, 0, -1); !isWeekday(t); t = t.AddDate(0, 0, -1) {
+	}
+	return t
+}
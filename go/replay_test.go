@@ -0,0 +1,49 @@
+package bsm
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// Replay values each day's book as Price does, paying dividends as the
+// episode passes their dates and holding expired options at intrinsic.
+func TestReplayPricesAgedBook(t *testing.T) {
+	put := Inputs{S0: 100, K: 110, T: 1, Sigma: 0.2, R: 0.05, OptType: Put, Exercise: American}
+	call := Inputs{S0: 100, K: 90, T: 3 / calendarDaysPerYear, Sigma: 0.2, R: 0.05, OptType: Call,
+		Dividends: []CashDividend{{Time: 1.5 / calendarDaysPerYear, Amount: 2}}}
+	book := &Portfolio{BaseCurrency: "USD", Positions: []Position{
+		{ID: "put", Inputs: put, Quantity: 1},
+		{ID: "call", Inputs: call, Quantity: 2},
+	}}
+	day := func(d int) time.Time { return time.Date(2026, 10, 12+d, 0, 0, 0, 0, time.UTC) }
+	ep := Episode{Name: "test", Start: day(0)}
+	for d, r := range []float64{-0.02, 0.01, 0.03, -0.01} {
+		ep.Moves = append(ep.Moves, MarketMove{Date: day(d + 1), SpotReturn: r})
+	}
+	res, err := book.Replay(FXRates{Base: "USD"}, ep, ReplayOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spot := 100.0
+	for i, step := range res.Steps {
+		spot *= 1 + ep.Moves[i].SpotReturn
+		elapsed := float64(i+1) / calendarDaysPerYear
+		want := 0.0
+		for _, pos := range book.Positions {
+			in := pos.Inputs.aged(elapsed)
+			in.S0, in.T = spot, math.Max(in.T, 0)
+			out, err := Price(in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if in.T == 0 && math.Abs(out.Price-math.Max(spot-in.K, 0)) > 1e-4 {
+				t.Errorf("day %d: expired call worth %g at spot %g", i+1, out.Price, spot)
+			}
+			want += pos.Quantity * out.Price
+		}
+		if math.Abs(step.Value-want) > 1e-9 {
+			t.Errorf("day %d: value %g, want %g", i+1, step.Value, want)
+		}
+	}
+}
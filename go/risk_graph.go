@@ -0,0 +1,264 @@
+package bsm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ZeroCurve is a continuously compounded zero-rate curve, interpolated
+// linearly in T and flat beyond its ends.
+type ZeroCurve struct {
+	Tenors []float64 // Sorted
+	Rates  []float64
+}
+
+// FlatCurve is a curve with one rate at every tenor.
+func FlatCurve(r float64) ZeroCurve {
+	return ZeroCurve{Tenors: []float64{0}, Rates: []float64{r}}
+}
+
+// Rate returns the zero rate to T.
+func (c ZeroCurve) Rate(T float64) float64 {
+	n := len(c.Tenors)
+	j := sort.SearchFloat64s(c.Tenors, T)
+	switch {
+	case n == 0:
+		return 0
+	case j == 0:
+		return c.Rates[0]
+	case j == n:
+		return c.Rates[n-1]
+	}
+	w := (T - c.Tenors[j-1]) / (c.Tenors[j] - c.Tenors[j-1])
+	return (1-w)*c.Rates[j-1] + w*c.Rates[j]
+}
+
+// ScenarioGrid is a book's P&L under combined spot and vol shocks.
+type ScenarioGrid struct {
+	SpotShocks []float64   // Relative, e.g. -0.1 for a 10% fall
+	VolShocks  []float64   // Absolute, e.g. 0.05 for +5 vol points
+	PnL        [][]float64 // PnL[i][j] for SpotShocks[i], VolShocks[j]
+}
+
+// RiskGraph wires market inputs (spots and vol surfaces by underlying,
+// curves by currency) to position Greeks, aggregates and scenario grids
+// on a Graph, so a market update recomputes only what depends on it.
+// Add market inputs first, then positions, then aggregates and grids.
+type RiskGraph struct {
+	Graph      *Graph
+	ThetaBasis int
+
+	spots     map[string]Input[float64]
+	surfaces  map[string]Input[*VolSurface]
+	curves    map[string]Input[ZeroCurve]
+	positions map[string]riskPosition
+}
+
+type riskPosition struct {
+	id         string
+	underlying string
+	units      float64
+	deps       []Ref
+	node       Node[Outputs]
+	inputs     func(spotShock, volShock float64) Inputs // From the current market
+}
+
+// price prices the position per unit under the shocks, as Price does.
+func (rp riskPosition) price(spotShock, volShock float64, o priceOptions) (Outputs, error) {
+	out, err := priceWith(rp.inputs(spotShock, volShock), o)
+	if err != nil {
+		return Outputs{}, fmt.Errorf("position %s: %w", rp.id, err)
+	}
+	return out, nil
+}
+
+// NewRiskGraph returns an empty risk graph; thetaBasis is days per year
+// for theta per day (365 if zero).
+func NewRiskGraph(thetaBasis int) *RiskGraph {
+	if thetaBasis == 0 {
+		thetaBasis = 365
+	}
+	return &RiskGraph{
+		Graph:      NewGraph(),
+		ThetaBasis: thetaBasis,
+		spots:      map[string]Input[float64]{},
+		surfaces:   map[string]Input[*VolSurface]{},
+		curves:     map[string]Input[ZeroCurve]{},
+		positions:  map[string]riskPosition{},
+	}
+}
+
+// pricing returns the options the graph prices positions under.
+func (g *RiskGraph) pricing() (priceOptions, error) {
+	o := resolveOptions(nil)
+	o.thetaBasis = g.ThetaBasis
+	if o.thetaBasis <= 0 {
+		return o, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+	return o, nil
+}
+
+// SetSpot sets an underlying's spot, adding the input on first use.
+func (g *RiskGraph) SetSpot(underlying string, s float64) error {
+	return setInput(g.Graph, g.spots, "spot/"+underlying, underlying, s)
+}
+
+// SetSurface sets an underlying's vol surface, adding the input on first
+// use. Positions on underlyings without a surface use their own Sigma.
+func (g *RiskGraph) SetSurface(underlying string, v *VolSurface) error {
+	return setInput(g.Graph, g.surfaces, "surface/"+underlying, underlying, v)
+}
+
+// SetCurve sets a currency's discount curve, adding the input on first
+// use. The empty currency is the book's default.
+func (g *RiskGraph) SetCurve(currency string, c ZeroCurve) error {
+	return setInput(g.Graph, g.curves, "curve/"+currency, currency, c)
+}
+
+func setInput[T any](g *Graph, m map[string]Input[T], name, key string, v T) error {
+	if in, ok := m[key]; ok {
+		in.Set(v)
+		return nil
+	}
+	in, err := NewInput(g, name, v)
+	if err != nil {
+		return err
+	}
+	m[key] = in
+	return nil
+}
+
+// AddPosition adds a node for pos's Greeks, scaled by its size, on the
+// given underlying, priced as Price does with its exercise style, model
+// and dividends. Its spot, and rate unless its currency has no curve,
+// come from the graph; so does its vol when the underlying has a surface.
+func (g *RiskGraph) AddPosition(underlying string, pos Position) (Node[Outputs], error) {
+	if _, ok := g.positions[pos.ID]; ok {
+		return Node[Outputs]{}, fmt.Errorf("duplicate position %q", pos.ID)
+	}
+	spot, ok := g.spots[underlying]
+	if !ok {
+		return Node[Outputs]{}, fmt.Errorf("position %s: no spot for %q", pos.ID, underlying)
+	}
+	deps := []Ref{spot}
+	surf, hasSurf := g.surfaces[underlying]
+	if hasSurf {
+		deps = append(deps, surf)
+	}
+	curve, hasCurve := g.curves[pos.Currency]
+	if hasCurve {
+		deps = append(deps, curve)
+	}
+	inputs := func(spotShock, volShock float64) Inputs {
+		in := pos.Inputs
+		in.S0 = spot.Value() * (1 + spotShock)
+		if hasSurf {
+			in.Sigma = surf.Value().Vol(in.K, in.T)
+		}
+		in.Sigma = math.Max(in.Sigma+volShock, 0)
+		if hasCurve {
+			in.R = curve.Value().Rate(in.T)
+		}
+		return in
+	}
+	rp := riskPosition{id: pos.ID, underlying: underlying, units: pos.units(), deps: deps, inputs: inputs}
+	node, err := NewNode(g.Graph, "position/"+pos.ID, deps, func() (Outputs, error) {
+		o, err := g.pricing()
+		if err != nil {
+			return Outputs{}, err
+		}
+		out, err := rp.price(0, 0, o)
+		return out.scale(rp.units), err
+	})
+	if err != nil {
+		return Node[Outputs]{}, err
+	}
+	rp.node = node
+	g.positions[pos.ID] = rp
+	return node, nil
+}
+
+// AddAggregate adds a node summing the Greeks of the named positions.
+// Amounts are in each position's premium currency, so the positions
+// should share one.
+func (g *RiskGraph) AddAggregate(name string, ids []string) (Node[Outputs], error) {
+	var nodes []Node[Outputs]
+	var deps []Ref
+	for _, id := range ids {
+		rp, ok := g.positions[id]
+		if !ok {
+			return Node[Outputs]{}, fmt.Errorf("aggregate %s: unknown position %q", name, id)
+		}
+		nodes = append(nodes, rp.node)
+		deps = append(deps, rp.node)
+	}
+	return NewNode(g.Graph, "aggregate/"+name, deps, func() (Outputs, error) {
+		var total Outputs
+		for _, n := range nodes {
+			o, err := n.Get()
+			if err != nil {
+				return Outputs{}, err
+			}
+			total = total.add(o)
+		}
+		return total, nil
+	})
+}
+
+// AddScenarioGrid adds a node revaluing every position on underlying under
+// each combination of relative spot and absolute vol shocks.
+func (g *RiskGraph) AddScenarioGrid(name, underlying string, spotShocks, volShocks []float64) (Node[ScenarioGrid], error) {
+	var book []riskPosition
+	seen := map[Ref]bool{}
+	var deps []Ref
+	ids := make([]string, 0, len(g.positions))
+	for id := range g.positions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rp := g.positions[id]
+		if rp.underlying != underlying {
+			continue
+		}
+		book = append(book, rp)
+		for _, d := range rp.deps {
+			if !seen[d] {
+				seen[d] = true
+				deps = append(deps, d)
+			}
+		}
+	}
+	if len(book) == 0 {
+		return Node[ScenarioGrid]{}, fmt.Errorf("scenario grid %s: no positions on %q", name, underlying)
+	}
+	return NewNode(g.Graph, "scenarios/"+name, deps, func() (ScenarioGrid, error) {
+		o, err := g.pricing()
+		if err != nil {
+			return ScenarioGrid{}, err
+		}
+		base := make([]float64, len(book))
+		for k, rp := range book {
+			out, err := rp.price(0, 0, o)
+			if err != nil {
+				return ScenarioGrid{}, err
+			}
+			base[k] = out.Price
+		}
+		grid := ScenarioGrid{SpotShocks: spotShocks, VolShocks: volShocks, PnL: make([][]float64, len(spotShocks))}
+		for i, ds := range spotShocks {
+			grid.PnL[i] = make([]float64, len(volShocks))
+			for j, dv := range volShocks {
+				for k, rp := range book {
+					out, err := rp.price(ds, dv, o)
+					if err != nil {
+						return ScenarioGrid{}, err
+					}
+					grid.PnL[i][j] += (out.Price - base[k]) * rp.units
+				}
+			}
+		}
+		return grid, nil
+	})
+}
@@ -0,0 +1,45 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// The graph revalues positions as Price does, early exercise included,
+// and reports an invalid position from its node.
+func TestRiskGraphPricesAsPrice(t *testing.T) {
+	in := Inputs{S0: 100, K: 120, T: 1, Sigma: 0.2, R: 0.05, OptType: Put, Exercise: American}
+	g := NewRiskGraph(0)
+	if err := g.SetSpot("X", 100); err != nil {
+		t.Fatal(err)
+	}
+	node, err := g.AddPosition("X", Position{ID: "p", Inputs: in, Quantity: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grid, err := g.AddScenarioGrid("g", "X", []float64{-0.1}, []float64{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Price(in)
+	if got, err := node.Get(); err != nil || math.Abs(got.Price-2*want.Price) > 1e-12 {
+		t.Errorf("position value %g, %v, want %g", got.Price, err, 2*want.Price)
+	}
+	down := in
+	down.S0 = 90
+	shocked, _ := Price(down)
+	if sg, err := grid.Get(); err != nil || math.Abs(sg.PnL[0][0]-2*(shocked.Price-want.Price)) > 1e-9 {
+		t.Errorf("scenario P&L %v, %v, want %g", sg.PnL, err, 2*(shocked.Price-want.Price))
+	}
+
+	bad := in
+	bad.OptType = 0
+	badNode, err := g.AddPosition("X", Position{ID: "bad", Inputs: bad, Quantity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := badNode.Get(); !errors.Is(err, ErrUnknownOptionType) {
+		t.Errorf("unset option type: got %v, want ErrUnknownOptionType", err)
+	}
+}
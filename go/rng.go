@@ -0,0 +1,237 @@
+package bsm
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Source is a stream of uniformly distributed 64-bit words. It matches
+// math/rand/v2's Source, so generators here can also drive that package.
+type Source interface {
+	Uint64() uint64
+}
+
+// SplittableSource can produce statistically independent, reproducible
+// sub-streams, e.g. one per worker or per batch of paths.
+type SplittableSource interface {
+	Source
+	Split(stream uint64) SplittableSource
+}
+
+// RNG is the random interface used by the simulation code.
+type RNG interface {
+	Float64() float64     // Uniform on [0, 1)
+	NormFloat64() float64 // Standard normal
+}
+
+// Generator turns a Source into uniform and normal deviates.
+type Generator struct {
+	src      Source
+	spare    float64
+	hasSpare bool
+}
+
+// NewRNG wraps src.
+func NewRNG(src Source) *Generator {
+	return &Generator{src: src}
+}
+
+// NewDefaultRNG returns a PCG64 generator seeded with seed.
+func NewDefaultRNG(seed uint64) *Generator {
+	return NewRNG(NewPCG64(seed, 0))
+}
+
+// Float64 uses the top 53 bits of the next word.
+func (g *Generator) Float64() float64 {
+	return float64(g.src.Uint64()>>11) * (1.0 / (1 << 53))
+}
+
+// NormFloat64 uses Marsaglia's polar method, caching the second deviate.
+func (g *Generator) NormFloat64() float64 {
+	if g.hasSpare {
+		g.hasSpare = false
+		return g.spare
+	}
+	for {
+		u := 2*g.Float64() - 1
+		v := 2*g.Float64() - 1
+		s := u*u + v*v
+		if s > 0 && s < 1 {
+			m := math.Sqrt(-2 * math.Log(s) / s)
+			g.spare, g.hasSpare = v*m, true
+			return u * m
+		}
+	}
+}
+
+// PCG64 is O'Neill's 128-bit LCG with the XSL-RR output function. Streams
+// are selected by the (odd) increment.
+type PCG64 struct {
+	hi, lo       uint64 // State
+	incHi, incLo uint64 // Increment
+	seed         uint64
+}
+
+const (
+	pcgMulHi = 0x2360ed051fc65da4
+	pcgMulLo = 0x4385df649fccf645
+)
+
+// NewPCG64 seeds a generator on the given stream.
+func NewPCG64(seed, stream uint64) *PCG64 {
+	p := &PCG64{seed: seed}
+	// Distinct odd increments per stream; the high word mixes the stream id.
+	p.incHi = splitMix64(&stream)
+	p.incLo = stream<<1 | 1
+	p.step()
+	p.lo, _ = bits.Add64(p.lo, seed, 0)
+	p.step()
+	return p
+}
+
+func (p *PCG64) step() {
+	hi, lo := bits.Mul64(p.lo, pcgMulLo)
+	hi += p.hi*pcgMulLo + p.lo*pcgMulHi
+	var carry uint64
+	p.lo, carry = bits.Add64(lo, p.incLo, 0)
+	p.hi, _ = bits.Add64(hi, p.incHi, carry)
+}
+
+func (p *PCG64) Uint64() uint64 {
+	p.step()
+	return bits.RotateLeft64(p.hi^p.lo, -int(p.hi>>58))
+}
+
+// Split returns the generator with the same seed on another stream.
+func (p *PCG64) Split(stream uint64) SplittableSource {
+	return NewPCG64(p.seed, stream)
+}
+
+// Xoshiro256 is Blackman and Vigna's xoshiro256** generator. Streams are
+// 2^128-step jumps apart.
+type Xoshiro256 struct {
+	s [4]uint64
+}
+
+// NewXoshiro256 seeds the state from seed with SplitMix64, as recommended.
+func NewXoshiro256(seed uint64) *Xoshiro256 {
+	x := &Xoshiro256{}
+	for i := range x.s {
+		x.s[i] = splitMix64(&seed)
+	}
+	return x
+}
+
+func (x *Xoshiro256) Uint64() uint64 {
+	s := &x.s
+	result := bits.RotateLeft64(s[1]*5, 7) * 9
+	t := s[1] << 17
+	s[2] ^= s[0]
+	s[3] ^= s[1]
+	s[1] ^= s[2]
+	s[0] ^= s[3]
+	s[2] ^= t
+	s[3] = bits.RotateLeft64(s[3], 45)
+	return result
+}
+
+var xoshiroJump = [4]uint64{0x180ec6d33cfd0aba, 0xd5a61266f0c9392c, 0xa9582618e03fc9aa, 0x39abdc4529b1661c}
+
+// Jump advances the generator by 2^128 steps.
+func (x *Xoshiro256) Jump() {
+	var s [4]uint64
+	for _, j := range xoshiroJump {
+		for b := 0; b < 64; b++ {
+			if j&(1<<uint(b)) != 0 {
+				for i := range s {
+					s[i] ^= x.s[i]
+				}
+			}
+			x.Uint64()
+		}
+	}
+	x.s = s
+}
+
+// Split returns a copy jumped stream+1 times, so stream 0 does not overlap
+// the parent. Cost is linear in stream; split sequentially for many streams.
+func (x *Xoshiro256) Split(stream uint64) SplittableSource {
+	c := &Xoshiro256{s: x.s}
+	for i := uint64(0); i <= stream; i++ {
+		c.Jump()
+	}
+	return c
+}
+
+// Philox4x32 is the counter-based Philox-4x32-10 generator of Salmon et al.
+// Output is a pure function of (key, counter), so any position of any stream
+// can be reached in O(1): the stream id occupies the upper counter words.
+type Philox4x32 struct {
+	key     [2]uint32
+	counter [4]uint32
+	buf     [4]uint32
+	idx     int
+}
+
+const (
+	philoxM0 = 0xD2511F53
+	philoxM1 = 0xCD9E8D57
+	philoxW0 = 0x9E3779B9
+	philoxW1 = 0xBB67AE85
+)
+
+// NewPhilox4x32 keys the generator with seed, positioned at the start of stream.
+func NewPhilox4x32(seed, stream uint64) *Philox4x32 {
+	p := &Philox4x32{key: [2]uint32{uint32(seed), uint32(seed >> 32)}, idx: 4}
+	p.counter[2], p.counter[3] = uint32(stream), uint32(stream>>32)
+	return p
+}
+
+// philoxBlock computes the 10-round Philox bijection of ctr under key.
+func philoxBlock(ctr [4]uint32, key [2]uint32) [4]uint32 {
+	for r := 0; r < 10; r++ {
+		hi0, lo0 := bits.Mul32(philoxM0, ctr[0])
+		hi1, lo1 := bits.Mul32(philoxM1, ctr[2])
+		ctr = [4]uint32{hi1 ^ ctr[1] ^ key[0], lo1, hi0 ^ ctr[3] ^ key[1], lo0}
+		key[0] += philoxW0
+		key[1] += philoxW1
+	}
+	return ctr
+}
+
+func (p *Philox4x32) Uint64() uint64 {
+	if p.idx >= 4 {
+		p.buf = philoxBlock(p.counter, p.key)
+		p.idx = 0
+		// Increment the low 64 bits of the counter; the high half is the stream.
+		p.counter[0]++
+		if p.counter[0] == 0 {
+			p.counter[1]++
+		}
+	}
+	v := uint64(p.buf[p.idx]) | uint64(p.buf[p.idx+1])<<32
+	p.idx += 2
+	return v
+}
+
+// Skip advances the generator by n blocks (2n outputs) within its stream.
+func (p *Philox4x32) Skip(n uint64) {
+	lo := uint64(p.counter[0]) | uint64(p.counter[1])<<32
+	lo += n
+	p.counter[0], p.counter[1] = uint32(lo), uint32(lo>>32)
+	p.idx = 4
+}
+
+// Split returns the generator with the same key at the start of another stream.
+func (p *Philox4x32) Split(stream uint64) SplittableSource {
+	return NewPhilox4x32(uint64(p.key[0])|uint64(p.key[1])<<32, stream)
+}
+
+// splitMix64 advances *x and returns the next SplitMix64 output.
+func splitMix64(x *uint64) uint64 {
+	*x += 0x9e3779b97f4a7c15
+	z := *x
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,288 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// gRPC status codes used here.
+const (
+	CodeOK              = 0
+	CodeInvalidArgument = 3
+	CodeUnimplemented   = 12
+	CodeInternal        = 13
+)
+
+const (
+	pricePath = "/bsm.v1.Pricer/Price"
+	batchPath = "/bsm.v1.Pricer/PriceBatch"
+
+	maxMessage = 64 << 20 // Largest accepted message; 500k contracts take about 32 MB
+	batchChunk = 4096     // Contracts priced between stream flushes
+)
+
+// StatusError is a non-OK gRPC status.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("rpc error: code = %d desc = %s", e.Code, e.Message)
+}
+
+// writeFrame writes one length-prefixed, uncompressed gRPC message.
+func writeFrame(w io.Writer, msg []byte) error {
+	var hdr [5]byte
+	binary.BigEndian.PutUint32(hdr[1:], uint32(len(msg)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// readFrame reads one gRPC message; io.EOF means the stream ended cleanly.
+func readFrame(r io.Reader) ([]byte, error) {
+	var hdr [5]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, errors.New("grpc: truncated frame header")
+		}
+		return nil, err
+	}
+	if hdr[0] != 0 {
+		return nil, &StatusError{CodeUnimplemented, "compressed messages are not supported"}
+	}
+	n := binary.BigEndian.Uint32(hdr[1:])
+	if n > maxMessage {
+		return nil, &StatusError{CodeInvalidArgument, fmt.Sprintf("message of %d bytes exceeds %d", n, maxMessage)}
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, errors.New("grpc: truncated message")
+	}
+	return msg, nil
+}
+
+// Handler serves the Pricer service. gRPC needs HTTP/2; see NewServer.
+// Each call is a "grpc_call" span, logged through bsm.SetLogger, with the
+// method, the contract count and how many contracts failed.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		w.WriteHeader(http.StatusOK)
+		ctx, sp := bsm.StartSpan(r.Context(), "grpc_call", slog.String("method", r.URL.Path))
+		contracts, failed, err := serve(w, r.WithContext(ctx))
+		sp.End(err, slog.String("method", r.URL.Path), slog.Int("contracts", contracts), slog.Int("errors", failed))
+		code, msg := CodeOK, ""
+		if err != nil {
+			var st *StatusError
+			if !errors.As(err, &st) {
+				st = &StatusError{CodeInternal, err.Error()}
+			}
+			code, msg = st.Code, st.Message
+		}
+		w.Header().Set("Grpc-Status", strconv.Itoa(code))
+		if msg != "" {
+			w.Header().Set("Grpc-Message", url.PathEscape(msg))
+		}
+	})
+}
+
+// serve handles one call after the response headers are sent, returning
+// how many contracts it took and how many of them failed.
+func serve(w http.ResponseWriter, r *http.Request) (contracts, failed int, err error) {
+	if r.URL.Path != pricePath && r.URL.Path != batchPath {
+		return 0, 0, &StatusError{CodeUnimplemented, "unknown method " + r.URL.Path}
+	}
+	msg, err := readFrame(r.Body)
+	if err == io.EOF {
+		return 0, 0, &StatusError{CodeInvalidArgument, "missing request message"}
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	req, err := unmarshalPriceRequest(msg)
+	if err != nil {
+		return 0, 0, &StatusError{CodeInvalidArgument, err.Error()}
+	}
+	if req.thetaBasis == 0 {
+		req.thetaBasis = 365
+	}
+	opts := []bsm.Option{bsm.WithThetaBasis(int(req.thetaBasis))}
+
+	if r.URL.Path == pricePath {
+		var in bsm.Inputs
+		var invalid error
+		if n := len(req.contracts); n > 0 {
+			in, invalid = req.contracts[n-1], req.invalid[n-1] // Last one wins for a singular field
+		}
+		if invalid != nil {
+			return 1, 1, &StatusError{CodeInvalidArgument, invalid.Error()}
+		}
+		out, err := bsm.Price(in, opts...)
+		if err != nil {
+			return 1, 1, &StatusError{CodeInvalidArgument, err.Error()}
+		}
+		return 1, 0, writeFrame(w, priceResponse{outputs: out}.marshal())
+	}
+
+	flusher, _ := w.(http.Flusher)
+	outs := make([]bsm.Outputs, min(batchChunk, len(req.contracts)))
+	for start := 0; start < len(req.contracts); start += batchChunk {
+		chunk := req.contracts[start:min(start+batchChunk, len(req.contracts))]
+		buf := outs[:len(chunk)]
+		if err := bsm.PriceBatchIntoContext(r.Context(), buf, chunk, opts...); err != nil {
+			// Reprice to attribute the errors; only the failures take this path.
+			for i := range chunk {
+				err := req.invalid[start+i]
+				if err == nil {
+					_, err = bsm.Price(chunk[i], opts...)
+				}
+				if err != nil {
+					failed++
+					if err := writeFrame(w, priceResponse{index: int32(start + i), err: err.Error()}.marshal()); err != nil {
+						return len(req.contracts), failed, err
+					}
+					continue
+				}
+				if err := writeFrame(w, priceResponse{outputs: buf[i], index: int32(start + i)}.marshal()); err != nil {
+					return len(req.contracts), failed, err
+				}
+			}
+		} else {
+			for i := range chunk {
+				if err := writeFrame(w, priceResponse{outputs: buf[i], index: int32(start + i)}.marshal()); err != nil {
+					return len(req.contracts), failed, err
+				}
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if err := r.Context().Err(); err != nil {
+			return len(req.contracts), failed, err
+		}
+	}
+	return len(req.contracts), failed, nil
+}
+
+// NewServer returns a server for the Pricer service on addr over
+// cleartext HTTP/2, which is what gRPC clients use without TLS. Call
+// ListenAndServe, or ServeTLS with certificates for HTTP/2 over TLS.
+func NewServer(addr string) *http.Server {
+	var p http.Protocols
+	p.SetHTTP2(true)
+	p.SetUnencryptedHTTP2(true)
+	return &http.Server{Addr: addr, Handler: Handler(), Protocols: &p}
+}
+
+// Client calls a Pricer service over cleartext HTTP/2.
+type Client struct {
+	base string
+	hc   *http.Client
+}
+
+// NewClient returns a client for the service at addr ("host:port").
+func NewClient(addr string) *Client {
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	return &Client{base: "http://" + addr, hc: &http.Client{Transport: &http.Transport{Protocols: &p}}}
+}
+
+// Price prices one contract remotely. thetaBasis 0 means 365.
+func (c *Client) Price(ctx context.Context, in bsm.Inputs, thetaBasis int) (bsm.Outputs, error) {
+	var out bsm.Outputs
+	n := 0
+	err := c.call(ctx, pricePath, priceRequest{contracts: []bsm.Inputs{in}, thetaBasis: int32(thetaBasis)}, func(r priceResponse) error {
+		out, n = r.outputs, n+1
+		return nil
+	})
+	if err == nil && n != 1 {
+		err = fmt.Errorf("grpc: expected one response, got %d", n)
+	}
+	return out, err
+}
+
+// PriceBatch prices contracts remotely, calling fn as each result arrives,
+// in order; err is set for contracts that could not be priced. An error
+// from fn cancels the call and is returned.
+func (c *Client) PriceBatch(ctx context.Context, contracts []bsm.Inputs, thetaBasis int, fn func(index int, out bsm.Outputs, err error) error) error {
+	return c.call(ctx, batchPath, priceRequest{contracts: contracts, thetaBasis: int32(thetaBasis)}, func(r priceResponse) error {
+		if r.err != "" {
+			return fn(int(r.index), bsm.Outputs{}, errors.New(r.err))
+		}
+		return fn(int(r.index), r.outputs, nil)
+	})
+}
+
+func (c *Client) call(ctx context.Context, path string, req priceRequest, fn func(priceResponse) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var body bytes.Buffer
+	writeFrame(&body, req.marshal())
+	hr, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+path, &body)
+	if err != nil {
+		return err
+	}
+	hr.Header.Set("Content-Type", "application/grpc")
+	hr.Header.Set("Te", "trailers")
+	resp, err := c.hc.Do(hr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("grpc: HTTP status %s", resp.Status)
+	}
+	for {
+		msg, err := readFrame(resp.Body)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		r, err := unmarshalPriceResponse(msg)
+		if err != nil {
+			return err
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	// A trailers-only response carries the status in the headers.
+	status := resp.Trailer.Get("Grpc-Status")
+	msg := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, msg = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return fmt.Errorf("grpc: missing or bad grpc-status %q", status)
+	}
+	if code != CodeOK {
+		if m, err := url.PathUnescape(msg); err == nil {
+			msg = m
+		}
+		return &StatusError{code, msg}
+	}
+	return nil
+}
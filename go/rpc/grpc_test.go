@@ -0,0 +1,230 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// startServer serves Handler over cleartext HTTP/2, as NewServer does.
+func startServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewUnstartedServer(Handler())
+	var p http.Protocols
+	p.SetUnencryptedHTTP2(true)
+	srv.Config.Protocols = &p
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestClient(srv *httptest.Server) *Client {
+	return NewClient(strings.TrimPrefix(srv.URL, "http://"))
+}
+
+func TestPriceRoundTrip(t *testing.T) {
+	c := newTestClient(startServer(t))
+	in := bsm.Inputs{S0: 100, K: 105, T: 0.5, Sigma: 0.25, R: 0.03, Q: 0.01, OptType: bsm.Call,
+		Dividends: []bsm.CashDividend{{Time: 0.25, Amount: 1}}}
+	got, err := c.Price(context.Background(), in, 252)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := bsm.Price(in, bsm.WithThetaBasis(252))
+	if got != want {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	in.K = -1
+	var st *StatusError
+	if _, err := c.Price(context.Background(), in, 0); !errors.As(err, &st) || st.Code != CodeInvalidArgument {
+		t.Errorf("negative strike: got %v, want INVALID_ARGUMENT", err)
+	}
+}
+
+// A batch streams every contract in order, failing the invalid ones on
+// their own index.
+func TestPriceBatchRoundTrip(t *testing.T) {
+	c := newTestClient(startServer(t))
+	contracts := make([]bsm.Inputs, batchChunk+3) // Spans two chunks
+	for i := range contracts {
+		contracts[i] = bsm.Inputs{S0: 100, K: 80 + float64(i%40), T: 1, Sigma: 0.2, R: 0.03, OptType: bsm.Put}
+	}
+	bad := map[int]bool{1: true, batchChunk + 1: true}
+	contracts[1].Sigma = -0.2
+	contracts[batchChunk+1].OptType = 0
+	next := 0
+	err := c.PriceBatch(context.Background(), contracts, 0, func(i int, out bsm.Outputs, err error) error {
+		if i != next {
+			t.Fatalf("got index %d, want %d", i, next)
+		}
+		next++
+		if bad[i] {
+			if err == nil {
+				t.Errorf("contract %d: priced an invalid contract", i)
+			}
+			return nil
+		}
+		want, _ := bsm.Price(contracts[i])
+		if err != nil || math.Abs(out.Price-want.Price) > 1e-12 {
+			t.Errorf("contract %d: got %g, %v, want %g", i, out.Price, err, want.Price)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != len(contracts) {
+		t.Errorf("got %d responses, want %d", next, len(contracts))
+	}
+}
+
+// call posts raw gRPC body bytes and returns the response messages and
+// grpc-status.
+func call(t *testing.T, srv *httptest.Server, path string, body []byte) ([]priceResponse, string) {
+	t.Helper()
+	c := newTestClient(srv)
+	req, _ := http.NewRequest(http.MethodPost, c.base+path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/grpc")
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("served over %s, want HTTP/2", resp.Proto)
+	}
+	var out []priceResponse
+	for {
+		msg, err := readFrame(resp.Body)
+		if err != nil {
+			break
+		}
+		r, err := unmarshalPriceResponse(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, r)
+	}
+	return out, resp.Trailer.Get("Grpc-Status")
+}
+
+// An opt_type the pricer does not know fails only its contract.
+func TestPriceBatchBadOptType(t *testing.T) {
+	srv := startServer(t)
+	var req encoder
+	good := marshalInputs(bsm.Inputs{S0: 100, K: 100, T: 1, Sigma: 0.2, OptType: bsm.Call})
+	var bad encoder
+	bad.double(1, 100)
+	bad.double(2, 100)
+	bad.double(3, 1)
+	bad.string(7, "straddle")
+	req.bytes(1, good)
+	req.bytes(1, bad.b)
+	req.bytes(1, good)
+	var body bytes.Buffer
+	writeFrame(&body, req.b)
+	resps, status := call(t, srv, batchPath, body.Bytes())
+	if status != "0" || len(resps) != 3 {
+		t.Fatalf("status %s, %d responses", status, len(resps))
+	}
+	for i, r := range resps {
+		if r.index != int32(i) || (r.err != "") != (i == 1) {
+			t.Errorf("response %d: %+v", i, r)
+		}
+	}
+	if !strings.Contains(resps[1].err, "opt_type") {
+		t.Errorf("error %q does not name opt_type", resps[1].err)
+	}
+}
+
+func TestFrameLimits(t *testing.T) {
+	srv := startServer(t)
+	big := binary.BigEndian.AppendUint32([]byte{0}, maxMessage+1)
+	compressed := append(binary.BigEndian.AppendUint32([]byte{1}, 2), 0, 0)
+	for _, c := range []struct {
+		name  string
+		frame []byte
+		code  int
+	}{
+		{"oversized", big, CodeInvalidArgument},
+		{"compressed", compressed, CodeUnimplemented},
+	} {
+		var st *StatusError
+		if _, err := readFrame(bytes.NewReader(c.frame)); !errors.As(err, &st) || st.Code != c.code {
+			t.Errorf("%s: readFrame got %v, want code %d", c.name, err, c.code)
+		}
+		if _, status := call(t, srv, pricePath, c.frame); status != strconv.Itoa(c.code) {
+			t.Errorf("%s: server status %s, want %d", c.name, status, c.code)
+		}
+	}
+}
+
+// recorder is a slog.Handler keeping every record's message and attributes.
+type recorder struct {
+	mu      sync.Mutex
+	records []map[string]slog.Value
+}
+
+func (h *recorder) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recorder) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recorder) WithGroup(string) slog.Handler            { return h }
+func (h *recorder) Handle(_ context.Context, r slog.Record) error {
+	attrs := map[string]slog.Value{"msg": slog.StringValue(r.Message)}
+	r.Attrs(func(a slog.Attr) bool { attrs[a.Key] = a.Value; return true })
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, attrs)
+	return nil
+}
+
+func (h *recorder) find(msg string) map[string]slog.Value {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r["msg"].String() == msg {
+			return r
+		}
+	}
+	return nil
+}
+
+// Each call is a span, and the batches it prices log under its trace ID.
+func TestCallSpans(t *testing.T) {
+	rec := &recorder{}
+	bsm.SetLogger(slog.New(rec))
+	t.Cleanup(func() { bsm.SetLogger(nil) })
+	c := newTestClient(startServer(t))
+
+	ok := bsm.Inputs{S0: 100, K: 100, T: 1, Sigma: 0.2, OptType: bsm.Call}
+	bad := ok
+	bad.T = -1
+	err := c.PriceBatch(context.Background(), []bsm.Inputs{ok, bad, bad, ok}, 0, func(int, bsm.Outputs, error) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := rec.find("grpc_call done")
+	if call == nil {
+		t.Fatal("no grpc_call record")
+	}
+	if call["method"].String() != batchPath || call["contracts"].Int64() != 4 || call["errors"].Int64() != 2 {
+		t.Errorf("grpc_call record %v", call)
+	}
+	batch := rec.find("price_batch done")
+	if batch == nil {
+		t.Fatal("no price_batch record")
+	}
+	if id := call["trace_id"].String(); id == "" || batch["trace_id"].String() != id {
+		t.Errorf("trace IDs %v and %v, want one shared ID", call["trace_id"], batch["trace_id"])
+	}
+}
@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+var (
+	latencyBuckets   = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	iterationBuckets = []float64{1, 2, 5, 10, 20, 50, 100, 200, 500, 1000}
+)
+
+// Metrics collects the service's Prometheus metrics and serves them in the
+// text exposition format:
+//
+//	bsm_requests_total{method, code}                 requests by route and status (gRPC or HTTP)
+//	bsm_request_duration_seconds{method}             latency histogram
+//	bsm_solver_iterations{operation}                 iterations of library solvers
+//	bsm_solver_convergence_failures_total{operation} solver runs that failed or did not converge
+//
+// The solver metrics come from the library's spans: install the Metrics
+// with bsm.SetSpanHook, and every calibration in the process is counted.
+type Metrics struct {
+	mu         sync.Mutex
+	requests   map[[2]string]uint64
+	latency    map[string]*histogram
+	iterations map[string]*histogram
+	failures   map[string]uint64
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64 // Per bucket, not cumulative; one more than bounds for +Inf
+	sum    float64
+	n      uint64
+}
+
+func (h *histogram) observe(v float64) {
+	h.counts[sort.SearchFloat64s(h.bounds, v)]++
+	h.sum += v
+	h.n++
+}
+
+// NewMetrics returns an empty set of metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:   map[[2]string]uint64{},
+		latency:    map[string]*histogram{},
+		iterations: map[string]*histogram{},
+		failures:   map[string]uint64{},
+	}
+}
+
+func observe(m map[string]*histogram, bounds []float64, key string, v float64) {
+	h := m[key]
+	if h == nil {
+		h = &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+		m[key] = h
+	}
+	h.observe(v)
+}
+
+// ObserveRequest records one request to method that ended with code.
+func (m *Metrics) ObserveRequest(method, code string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[2]string{method, code}]++
+	observe(m.latency, latencyBuckets, method, d.Seconds())
+}
+
+// StartSpan implements bsm.SpanHook, recording the "iterations" and
+// "converged" attributes that solver spans end with.
+func (m *Metrics) StartSpan(ctx context.Context, name string, _ []slog.Attr) (context.Context, func(error, []slog.Attr)) {
+	return ctx, func(err error, attrs []slog.Attr) {
+		failed := err != nil
+		iters := -1
+		for _, a := range attrs {
+			switch a.Key {
+			case "iterations":
+				iters = int(a.Value.Int64())
+			case "converged":
+				failed = failed || !a.Value.Bool()
+			}
+		}
+		if iters < 0 && !failed {
+			return // Not a solver
+		}
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if iters >= 0 {
+			observe(m.iterations, iterationBuckets, name, float64(iters))
+		}
+		if failed {
+			m.failures[name]++
+		}
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(bw, "# HELP bsm_requests_total Requests served, by method and status code.")
+	fmt.Fprintln(bw, "# TYPE bsm_requests_total counter")
+	keys := make([][2]string, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return keys[a][0] < keys[b][0] || (keys[a][0] == keys[b][0] && keys[a][1] < keys[b][1])
+	})
+	for _, k := range keys {
+		fmt.Fprintf(bw, "bsm_requests_total{method=%q,code=%q} %d\n", k[0], k[1], m.requests[k])
+	}
+	writeHistograms(bw, "bsm_request_duration_seconds", "Request latency in seconds.", "method", m.latency)
+	writeHistograms(bw, "bsm_solver_iterations", "Iterations per library solver run.", "operation", m.iterations)
+	fmt.Fprintln(bw, "# HELP bsm_solver_convergence_failures_total Solver runs that failed or did not converge.")
+	fmt.Fprintln(bw, "# TYPE bsm_solver_convergence_failures_total counter")
+	for _, k := range sortedKeys(m.failures) {
+		fmt.Fprintf(bw, "bsm_solver_convergence_failures_total{operation=%q} %d\n", k, m.failures[k])
+	}
+}
+
+func writeHistograms(w *bufio.Writer, name, help, label string, hs map[string]*histogram) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, k := range sortedKeys(hs) {
+		h := hs[k]
+		cum := uint64(0)
+		for i, b := range h.bounds {
+			cum += h.counts[i]
+			fmt.Fprintf(w, "%s_bucket{%s=%q,le=%q} %d\n", name, label, k, strconv.FormatFloat(b, 'g', -1, 64), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", name, label, k, h.n)
+		fmt.Fprintf(w, "%s_sum{%s=%q} %g\n%s_count{%s=%q} %d\n", name, label, k, h.sum, name, label, k, h.n)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Healthz answers liveness checks with 200 "ok".
+func Healthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintln(w, "ok")
+}
+
+// Instrument wraps h to give each request an ID, taken from the
+// X-Request-Id header or else a new trace ID, which is echoed back, set as
+// the bsm trace ID on the request context and logged; and to log and
+// record in m each request's method, status and latency. gRPC calls are
+// labelled by their service method and gRPC status, the rest by route
+// pattern and HTTP status.
+func Instrument(h http.Handler, m *Metrics, log *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get("X-Request-Id")
+		if id == "" {
+			id = bsm.NewTraceID()
+		}
+		w.Header().Set("X-Request-Id", id)
+		r = r.WithContext(bsm.WithTraceID(r.Context(), id))
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+
+		method, code := r.URL.Path, strconv.Itoa(sw.status)
+		if g := sw.Header().Get("Grpc-Status"); g != "" {
+			code = "grpc_" + g
+			if g == strconv.Itoa(CodeUnimplemented) {
+				method = "unmatched" // Keep unknown paths out of the labels
+			}
+		} else if r.Pattern != "" {
+			method = r.Pattern
+		} else {
+			method = "unmatched"
+		}
+		d := time.Since(start)
+		m.ObserveRequest(method, code, d)
+		level := slog.LevelInfo
+		if sw.status >= 500 || (strings.HasPrefix(code, "grpc_") && code != "grpc_0") {
+			level = slog.LevelWarn
+		}
+		log.LogAttrs(r.Context(), level, "request",
+			slog.String("request_id", id), slog.String("method", method), slog.String("code", code),
+			slog.Float64("duration_ms", float64(d.Microseconds())/1000), slog.String("remote", r.RemoteAddr))
+	})
+}
+
+// statusWriter records the status code and keeps streaming working.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,357 @@
+package rpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// Streamer serves live repricing over HTTP with server-sent events. A
+// client registers contracts, listens for their Greeks and pushes spot and
+// vol ticks:
+//
+//	POST   /v1/streams               {"contracts": [{"id", "underlying", "inputs"}], "theta_basis", "throttle_ms"} -> {"id"}
+//	GET    /v1/streams/{id}/events   text/event-stream of {"id", "greeks"} or {"id", "error"}
+//	POST   /v1/streams/{id}/ticks    {"underlying", "contract", "spot", "vol"}, or an array of them
+//	DELETE /v1/streams/{id}
+//
+// Each contract is repriced at most once per throttle interval: ticks in
+// between are coalesced and the latest state is sent when the interval
+// ends. A session has one listener, which gets every contract's Greeks
+// when it connects; events it is too slow to take are dropped, and the
+// next update for the contract supersedes them.
+type Streamer struct {
+	throttle time.Duration
+	mu       sync.Mutex
+	sessions map[string]*streamSession
+	mux      *http.ServeMux
+}
+
+// NewStreamer returns a Streamer repricing each contract at most once per
+// throttle by default (100ms if zero).
+func NewStreamer(throttle time.Duration) *Streamer {
+	if throttle == 0 {
+		throttle = 100 * time.Millisecond
+	}
+	s := &Streamer{throttle: throttle, sessions: map[string]*streamSession{}, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /v1/streams", s.create)
+	s.mux.HandleFunc("GET /v1/streams/{id}/events", s.events)
+	s.mux.HandleFunc("POST /v1/streams/{id}/ticks", s.ticks)
+	s.mux.HandleFunc("DELETE /v1/streams/{id}", s.remove)
+	return s
+}
+
+func (s *Streamer) ServeHTTP(w http.ResponseWriter, r *http.Request) { s.mux.ServeHTTP(w, r) }
+
+// Mux serves gRPC requests with Handler and everything else with h, so
+// one HTTP/2 port carries both.
+func Mux(h http.Handler) http.Handler {
+	grpc := Handler()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpc.ServeHTTP(w, r)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// StreamContract is a contract registered for streaming.
+type StreamContract struct {
+	ID         string     `json:"id"`
+	Underlying string     `json:"underlying,omitempty"`
+	Inputs     bsm.Inputs `json:"inputs"`
+}
+
+// Tick moves the spot and/or the vol of the contracts on Underlying, or of
+// the one contract with ID Contract, or of every contract if both are
+// empty.
+type Tick struct {
+	Underlying string   `json:"underlying,omitempty"`
+	Contract   string   `json:"contract,omitempty"`
+	Spot       *float64 `json:"spot,omitempty"`
+	Vol        *float64 `json:"vol,omitempty"`
+}
+
+// StreamEvent is one repriced contract as sent to the listener.
+type StreamEvent struct {
+	ID     string       `json:"id"`
+	Greeks *bsm.Outputs `json:"greeks,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type streamSession struct {
+	mu        sync.Mutex
+	contracts map[string]*streamState
+	order     []*streamState // Registration order, for the first snapshot
+	opts      []bsm.Option
+	throttle  time.Duration
+	events    chan []byte
+	done      chan struct{} // Closed when the session is deleted
+	closed    bool
+	listening bool
+}
+
+type streamState struct {
+	StreamContract
+	last  time.Time   // When its Greeks were last sent
+	timer *time.Timer // Pending trailing update, if any
+}
+
+func (s *Streamer) create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Contracts  []StreamContract `json:"contracts"`
+		ThetaBasis int              `json:"theta_basis"`
+		ThrottleMS int              `json:"throttle_ms"`
+	}
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Contracts) == 0 {
+		http.Error(w, "no contracts", http.StatusBadRequest)
+		return
+	}
+	if req.ThetaBasis == 0 {
+		req.ThetaBasis = 365
+	}
+	if req.ThetaBasis < 0 || req.ThrottleMS < 0 {
+		http.Error(w, "theta_basis and throttle_ms must be non-negative", http.StatusBadRequest)
+		return
+	}
+	sess := &streamSession{
+		contracts: map[string]*streamState{},
+		opts:      []bsm.Option{bsm.WithThetaBasis(req.ThetaBasis)},
+		throttle:  s.throttle,
+		events:    make(chan []byte, 4*len(req.Contracts)),
+		done:      make(chan struct{}),
+	}
+	if req.ThrottleMS > 0 {
+		sess.throttle = time.Duration(req.ThrottleMS) * time.Millisecond
+	}
+	for _, c := range req.Contracts {
+		if c.ID == "" || sess.contracts[c.ID] != nil {
+			http.Error(w, fmt.Sprintf("contract ids must be unique and non-empty, got %q", c.ID), http.StatusBadRequest)
+			return
+		}
+		st := &streamState{StreamContract: c}
+		sess.contracts[c.ID] = st
+		sess.order = append(sess.order, st)
+	}
+
+	var b [8]byte
+	rand.Read(b[:])
+	id := hex.EncodeToString(b[:])
+	s.mu.Lock()
+	s.sessions[id] = sess
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]string{"id": id})
+}
+
+func (s *Streamer) session(w http.ResponseWriter, r *http.Request) *streamSession {
+	s.mu.Lock()
+	sess := s.sessions[r.PathValue("id")]
+	s.mu.Unlock()
+	if sess == nil {
+		http.Error(w, "unknown stream", http.StatusNotFound)
+	}
+	return sess
+}
+
+func (s *Streamer) events(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(w, r)
+	if sess == nil {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sess.mu.Lock()
+	if sess.listening {
+		sess.mu.Unlock()
+		http.Error(w, "stream already has a listener", http.StatusConflict)
+		return
+	}
+	sess.listening = true
+	for len(sess.events) > 0 {
+		<-sess.events // Left over from an earlier listener; the snapshot supersedes them
+	}
+	for _, st := range sess.order {
+		sess.emit(st)
+	}
+	sess.mu.Unlock()
+	defer func() {
+		sess.mu.Lock()
+		sess.listening = false
+		sess.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case ev := <-sess.events:
+			if _, err := fmt.Fprintf(w, "event: greeks\ndata: %s\n\n", ev); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-sess.done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (s *Streamer) ticks(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(w, r)
+	if sess == nil {
+		return
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var ticks []Tick
+	if strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+		if err := json.Unmarshal(raw, &ticks); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		var t Tick
+		if err := json.Unmarshal(raw, &t); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ticks = []Tick{t}
+	}
+	if err := sess.apply(ticks); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Streamer) remove(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	sess := s.sessions[r.PathValue("id")]
+	delete(s.sessions, r.PathValue("id"))
+	s.mu.Unlock()
+	if sess == nil {
+		http.Error(w, "unknown stream", http.StatusNotFound)
+		return
+	}
+	sess.mu.Lock()
+	sess.closed = true
+	for _, st := range sess.order {
+		if st.timer != nil {
+			st.timer.Stop()
+		}
+	}
+	close(sess.done)
+	sess.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apply moves the ticked contracts and schedules their repricing. A tick
+// that matches no contract is an error, and none of the ticks apply.
+func (sess *streamSession) apply(ticks []Tick) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.closed {
+		return errors.New("stream closed")
+	}
+	var hit [][]*streamState
+	for _, t := range ticks {
+		if t.Spot == nil && t.Vol == nil {
+			return errors.New("tick sets neither spot nor vol")
+		}
+		var matched []*streamState
+		for _, st := range sess.order {
+			if (t.Contract == "" || st.ID == t.Contract) && (t.Underlying == "" || st.Underlying == t.Underlying) {
+				matched = append(matched, st)
+			}
+		}
+		if len(matched) == 0 {
+			return fmt.Errorf("tick for underlying %q contract %q matches no contract", t.Underlying, t.Contract)
+		}
+		hit = append(hit, matched)
+	}
+	for i, t := range ticks {
+		for _, st := range hit[i] {
+			if t.Spot != nil {
+				st.Inputs.S0 = *t.Spot
+			}
+			if t.Vol != nil {
+				st.Inputs.Sigma = *t.Vol
+			}
+		}
+	}
+	for _, matched := range hit {
+		for _, st := range matched {
+			sess.schedule(st)
+		}
+	}
+	return nil
+}
+
+// schedule sends st's Greeks now if the throttle allows, or else once at
+// the end of its interval. The caller holds sess.mu.
+func (sess *streamSession) schedule(st *streamState) {
+	if st.timer != nil {
+		return // The pending update will price the latest inputs
+	}
+	wait := sess.throttle - time.Since(st.last)
+	if wait <= 0 {
+		sess.emit(st)
+		return
+	}
+	st.timer = time.AfterFunc(wait, func() {
+		sess.mu.Lock()
+		defer sess.mu.Unlock()
+		st.timer = nil
+		if !sess.closed {
+			sess.emit(st)
+		}
+	})
+}
+
+// emit prices st and queues the event, dropping it if the listener is
+// behind; with no listener there is nothing to send, as the next one gets
+// a snapshot. The caller holds sess.mu.
+func (sess *streamSession) emit(st *streamState) {
+	if !sess.listening {
+		return
+	}
+	st.last = time.Now()
+	ev := StreamEvent{ID: st.ID}
+	out, err := bsm.Price(st.Inputs, sess.opts...)
+	if err != nil {
+		ev.Error = err.Error()
+	} else {
+		ev.Greeks = &out
+	}
+	b, _ := json.Marshal(ev) // Outputs always marshal
+	select {
+	case sess.events <- b:
+	default:
+	}
+}
@@ -0,0 +1,304 @@
+// Package rpc serves the pricer over gRPC with the schema in
+// proto/bsm/v1/bsm.proto, so the other language ports can call it, or
+// serve it, from generated stubs. The protobuf encoding and gRPC framing
+// are implemented here on net/http's HTTP/2 so the module needs no
+// dependencies; only the messages in that file are supported.
+package rpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// encoder appends proto3 fields, omitting zero values as proto3 does.
+type encoder struct{ b []byte }
+
+func (e *encoder) tag(field, wire int) {
+	e.b = binary.AppendUvarint(e.b, uint64(field<<3|wire))
+}
+
+func (e *encoder) double(field int, v float64) {
+	if v == 0 && !math.Signbit(v) {
+		return
+	}
+	e.tag(field, wireFixed64)
+	e.b = binary.LittleEndian.AppendUint64(e.b, math.Float64bits(v))
+}
+
+func (e *encoder) int32(field int, v int32) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.b = binary.AppendUvarint(e.b, uint64(int64(v))) // Negative int32s take ten bytes
+}
+
+func (e *encoder) bytes(field int, v []byte) {
+	e.tag(field, wireBytes)
+	e.b = binary.AppendUvarint(e.b, uint64(len(v)))
+	e.b = append(e.b, v...)
+}
+
+func (e *encoder) string(field int, v string) {
+	if v != "" {
+		e.bytes(field, []byte(v))
+	}
+}
+
+// decodeFields calls fn for each field in a message; val is the payload
+// of length-delimited fields and the raw value of the others (a float64's
+// bits for fixed64). Unknown fields are the caller's to ignore.
+func decodeFields(b []byte, fn func(field, wire int, val uint64, payload []byte) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("protobuf: bad field key")
+		}
+		b = b[n:]
+		field, wire := int(key>>3), int(key&7)
+		var val uint64
+		var payload []byte
+		switch wire {
+		case wireVarint:
+			if val, n = binary.Uvarint(b); n <= 0 {
+				return errors.New("protobuf: bad varint")
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errors.New("protobuf: truncated fixed64")
+			}
+			val, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return errors.New("protobuf: truncated fixed32")
+			}
+			val, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return errors.New("protobuf: bad length")
+			}
+			payload, b = b[n:n+int(l)], b[n+int(l):]
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d", wire)
+		}
+		if err := fn(field, wire, val, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expect checks a known field's wire type.
+func expect(field, wire, want int) error {
+	if wire != want {
+		return fmt.Errorf("protobuf: field %d has wire type %d, want %d", field, wire, want)
+	}
+	return nil
+}
+
+func marshalInputs(in bsm.Inputs) []byte {
+	var e encoder
+	for i, v := range []float64{in.S0, in.K, in.T, in.Sigma, in.R, in.Q} {
+		e.double(i+1, v)
+	}
+	if in.OptType.Valid() {
+		e.string(7, in.OptType.String())
+	}
+	e.string(8, string(in.Exercise))
+	e.string(9, string(in.Model))
+	for _, d := range in.Dividends {
+		var de encoder
+		de.double(1, d.Time)
+		de.double(2, d.Amount)
+		e.bytes(10, de.b)
+	}
+	return e.b
+}
+
+// unmarshalInputs decodes a contract. A malformed message is err, failing
+// the request; a well-formed field the pricer cannot take, such as an
+// unknown opt_type, is invalid, failing this contract only.
+func unmarshalInputs(b []byte) (in bsm.Inputs, invalid, err error) {
+	doubles := []*float64{&in.S0, &in.K, &in.T, &in.Sigma, &in.R, &in.Q}
+	err = decodeFields(b, func(field, wire int, val uint64, payload []byte) error {
+		switch {
+		case field >= 1 && field <= 6:
+			if err := expect(field, wire, wireFixed64); err != nil {
+				return err
+			}
+			*doubles[field-1] = math.Float64frombits(val)
+			return nil
+		case field == 7:
+			if err := expect(field, wire, wireBytes); err != nil {
+				return err
+			}
+			var bad error
+			if in.OptType, bad = bsm.ParseOptionType(string(payload)); bad != nil {
+				invalid = fmt.Errorf("opt_type: %w", bad)
+			}
+			return nil
+		case field == 8:
+			in.Exercise = bsm.ExerciseStyle(payload)
+		case field == 9:
+			in.Model = bsm.PricingModel(payload)
+		case field == 10:
+			if err := expect(field, wire, wireBytes); err != nil {
+				return err
+			}
+			d, err := unmarshalDividend(payload)
+			in.Dividends = append(in.Dividends, d)
+			return err
+		default:
+			return nil
+		}
+		return expect(field, wire, wireBytes)
+	})
+	return in, invalid, err
+}
+
+func unmarshalDividend(b []byte) (bsm.CashDividend, error) {
+	var d bsm.CashDividend
+	err := decodeFields(b, func(field, wire int, val uint64, _ []byte) error {
+		var dst *float64
+		switch field {
+		case 1:
+			dst = &d.Time
+		case 2:
+			dst = &d.Amount
+		default:
+			return nil
+		}
+		if err := expect(field, wire, wireFixed64); err != nil {
+			return err
+		}
+		*dst = math.Float64frombits(val)
+		return nil
+	})
+	return d, err
+}
+
+// outputFields lists bsm.Outputs in proto field order.
+func outputFields(o *bsm.Outputs) []*float64 {
+	return []*float64{
+		&o.Price, &o.Delta, &o.Gamma, &o.VegaPerVol, &o.VegaPerVolPt,
+		&o.ThetaPerYear, &o.ThetaPerDay, &o.RhoPer1, &o.RhoPerBp, &o.PhiPer1, &o.PhiPerBp,
+		&o.Vanna, &o.Volga, &o.CharmPerYear, &o.CharmPerDay, &o.Speed, &o.Zomma,
+		&o.ColorPerYear, &o.ColorPerDay,
+		&o.ProbITM, &o.ProbITMStock, &o.ExpectedPayoff, &o.BreakEven, &o.LogMoneyness, &o.StdMoneyness,
+		&o.DualDelta, &o.Lambda,
+	}
+}
+
+func marshalOutputs(o bsm.Outputs) []byte {
+	var e encoder
+	for i, v := range outputFields(&o) {
+		e.double(i+1, *v)
+	}
+	return e.b
+}
+
+func unmarshalOutputs(b []byte) (bsm.Outputs, error) {
+	var o bsm.Outputs
+	fields := outputFields(&o)
+	err := decodeFields(b, func(field, wire int, val uint64, _ []byte) error {
+		if field < 1 || field > len(fields) {
+			return nil
+		}
+		if err := expect(field, wire, wireFixed64); err != nil {
+			return err
+		}
+		*fields[field-1] = math.Float64frombits(val)
+		return nil
+	})
+	return o, err
+}
+
+// priceRequest is both PriceRequest (one contract) and BatchRequest
+// (contracts field); they share field numbers.
+type priceRequest struct {
+	contracts  []bsm.Inputs
+	invalid    []error // Per contract, from unmarshalInputs; nil when it decoded cleanly
+	thetaBasis int32
+}
+
+func (r priceRequest) marshal() []byte {
+	var e encoder
+	for _, c := range r.contracts {
+		e.bytes(1, marshalInputs(c))
+	}
+	e.int32(2, r.thetaBasis)
+	return e.b
+}
+
+func unmarshalPriceRequest(b []byte) (priceRequest, error) {
+	var r priceRequest
+	err := decodeFields(b, func(field, wire int, val uint64, payload []byte) error {
+		switch field {
+		case 1:
+			if err := expect(field, wire, wireBytes); err != nil {
+				return err
+			}
+			in, invalid, err := unmarshalInputs(payload)
+			r.contracts = append(r.contracts, in)
+			r.invalid = append(r.invalid, invalid)
+			return err
+		case 2:
+			r.thetaBasis = int32(val)
+			return expect(field, wire, wireVarint)
+		}
+		return nil
+	})
+	return r, err
+}
+
+// priceResponse is a PriceResponse.
+type priceResponse struct {
+	outputs bsm.Outputs
+	index   int32
+	err     string
+}
+
+func (r priceResponse) marshal() []byte {
+	var e encoder
+	if r.err == "" {
+		e.bytes(1, marshalOutputs(r.outputs))
+	}
+	e.int32(2, r.index)
+	e.string(3, r.err)
+	return e.b
+}
+
+func unmarshalPriceResponse(b []byte) (priceResponse, error) {
+	var r priceResponse
+	err := decodeFields(b, func(field, wire int, val uint64, payload []byte) error {
+		var err error
+		switch field {
+		case 1:
+			if err = expect(field, wire, wireBytes); err == nil {
+				r.outputs, err = unmarshalOutputs(payload)
+			}
+		case 2:
+			r.index = int32(val)
+			err = expect(field, wire, wireVarint)
+		case 3:
+			r.err = string(payload)
+			err = expect(field, wire, wireBytes)
+		}
+		return err
+	})
+	return r, err
+}
@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	bsm "github.com/ag-enzo/black-scholes-greeks-multilang/go"
+)
+
+// outputsByField is bsm.Outputs by field number in proto/bsm/v1/bsm.proto.
+var outputsByField = map[int]string{
+	1: "Price", 2: "Delta", 3: "Gamma", 4: "VegaPerVol", 5: "VegaPerVolPt",
+	6: "ThetaPerYear", 7: "ThetaPerDay", 8: "RhoPer1", 9: "RhoPerBp", 10: "PhiPer1", 11: "PhiPerBp",
+	12: "Vanna", 13: "Volga", 14: "CharmPerYear", 15: "CharmPerDay", 16: "Speed", 17: "Zomma",
+	18: "ColorPerYear", 19: "ColorPerDay", 20: "ProbITM", 21: "ProbITMStock", 22: "ExpectedPayoff",
+	23: "BreakEven", 24: "LogMoneyness", 25: "StdMoneyness", 26: "DualDelta", 27: "Lambda",
+}
+
+// fields decodes a message into its values by field number, collecting
+// field 10, Inputs.dividends, as the one repeated field.
+func fields(t *testing.T, b []byte) map[int]any {
+	t.Helper()
+	got := map[int]any{}
+	err := decodeFields(b, func(field, wire int, val uint64, payload []byte) error {
+		switch wire {
+		case wireFixed64:
+			got[field] = math.Float64frombits(val)
+		case wireBytes:
+			if field == 10 { // Repeated dividends
+				divs, _ := got[field].([][]byte)
+				got[field] = append(divs, payload)
+				return nil
+			}
+			got[field] = string(payload)
+		default:
+			got[field] = val
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return got
+}
+
+func TestInputsWire(t *testing.T) {
+	in := bsm.Inputs{S0: 101, K: 102, T: 0.75, Sigma: 0.21, R: 0.04, Q: -0.01, OptType: bsm.Put,
+		Exercise: bsm.American, Model: bsm.BSMModel,
+		Dividends: []bsm.CashDividend{{Time: 0.25, Amount: 1.5}, {Time: 0.5, Amount: 2}}}
+	b := marshalInputs(in)
+	got := fields(t, b)
+	divs, _ := got[10].([][]byte)
+	delete(got, 10)
+	if len(divs) != len(in.Dividends) {
+		t.Fatalf("%d dividends on the wire, want %d", len(divs), len(in.Dividends))
+	}
+	want := map[int]any{1: 101.0, 2: 102.0, 3: 0.75, 4: 0.21, 5: 0.04, 6: -0.01,
+		7: "put", 8: "american", 9: "bsm"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fields %v, want %v", got, want)
+	}
+	for i, d := range divs {
+		if f := fields(t, d); f[1] != in.Dividends[i].Time || f[2] != in.Dividends[i].Amount {
+			t.Errorf("dividend %d: fields %v, want %+v", i, f, in.Dividends[i])
+		}
+	}
+
+	back, invalid, err := unmarshalInputs(b)
+	if err != nil || invalid != nil || !reflect.DeepEqual(back, in) {
+		t.Errorf("round trip: %+v, %v, %v", back, invalid, err)
+	}
+}
+
+func TestOutputsWire(t *testing.T) {
+	var o bsm.Outputs
+	v := reflect.ValueOf(&o).Elem()
+	if v.NumField() != len(outputsByField) {
+		t.Fatalf("bsm.Outputs has %d fields, the proto %d", v.NumField(), len(outputsByField))
+	}
+	for n, name := range outputsByField {
+		v.FieldByName(name).SetFloat(float64(n) + 0.5)
+	}
+	b := marshalOutputs(o)
+	for n, val := range fields(t, b) {
+		if want := float64(n) + 0.5; val != want {
+			t.Errorf("field %d (%s) = %v, want %v", n, outputsByField[n], val, want)
+		}
+	}
+	back, err := unmarshalOutputs(b)
+	if err != nil || back != o {
+		t.Errorf("round trip: %+v, %v", back, err)
+	}
+}
+
+func TestPriceMessagesWire(t *testing.T) {
+	req := priceRequest{contracts: []bsm.Inputs{{S0: 100, K: 95, T: 1, OptType: bsm.Call}, {S0: 50, K: 55, T: 2, OptType: bsm.Put}},
+		thetaBasis: 252}
+	back, err := unmarshalPriceRequest(req.marshal())
+	if err != nil || !reflect.DeepEqual(back.contracts, req.contracts) || back.thetaBasis != 252 {
+		t.Errorf("request round trip: %+v, %v", back, err)
+	}
+	if len(back.invalid) != 2 || back.invalid[0] != nil || back.invalid[1] != nil {
+		t.Errorf("invalid %v, want two nils", back.invalid)
+	}
+
+	resp := priceResponse{outputs: bsm.Outputs{Price: 4.2, Lambda: -3}, index: 7}
+	if got := fields(t, resp.marshal()); got[2] != uint64(7) {
+		t.Errorf("index field %v, want 7", got[2])
+	}
+	if r, err := unmarshalPriceResponse(resp.marshal()); err != nil || r != resp {
+		t.Errorf("response round trip: %+v, %v", r, err)
+	}
+	fail := priceResponse{index: 3, err: "bad"}
+	if got := fields(t, fail.marshal()); got[3] != "bad" || got[1] != nil {
+		t.Errorf("error response fields %v", got)
+	}
+}
+
+// An opt_type the pricer does not know fails the contract, not the message.
+func TestUnmarshalInputsBadOptType(t *testing.T) {
+	var e encoder
+	e.double(1, 100)
+	e.string(7, "straddle")
+	in, invalid, err := unmarshalInputs(e.b)
+	if err != nil || invalid == nil || in.S0 != 100 {
+		t.Errorf("got %+v, %v, %v; want an invalid contract", in, invalid, err)
+	}
+	e.b = append(e.b, 1<<3|wireBytes, 1, 0) // s0 as bytes
+	if _, _, err := unmarshalInputs(e.b); err == nil {
+		t.Error("mistyped s0 decoded")
+	}
+}
@@ -0,0 +1,202 @@
+package bsm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// RunPosition is one position as priced in a run, with its Greeks scaled
+// by size.
+type RunPosition struct {
+	ID         string  `json:"id"`
+	Inputs     Inputs  `json:"inputs"`
+	Quantity   float64 `json:"quantity"`
+	Multiplier float64 `json:"multiplier,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	Greeks     Outputs `json:"greeks"`
+}
+
+// PricingRun is a persisted pricing of a book.
+type PricingRun struct {
+	ID         string        `json:"id"`
+	AsOf       time.Time     `json:"as_of"`
+	ThetaBasis int           `json:"theta_basis"`
+	Positions  []RunPosition `json:"positions"`
+}
+
+// NewPricingRun prices every position of p as Risk does.
+func NewPricingRun(id string, asOf time.Time, p *Portfolio) (PricingRun, error) {
+	o, err := p.pricing()
+	if err != nil {
+		return PricingRun{}, err
+	}
+	run := PricingRun{ID: id, AsOf: asOf, ThetaBasis: o.thetaBasis}
+	for _, pos := range p.Positions {
+		out, err := pos.price(o)
+		if err != nil {
+			return PricingRun{}, err
+		}
+		run.Positions = append(run.Positions, RunPosition{
+			ID: pos.ID, Inputs: pos.Inputs, Quantity: pos.SignedQuantity(), Multiplier: pos.Multiplier, Currency: pos.Currency,
+			Greeks: out.scale(pos.units()),
+		})
+	}
+	return run, nil
+}
+
+func (rp RunPosition) units() float64 {
+	return Position{Quantity: rp.Quantity, Multiplier: rp.Multiplier}.units()
+}
+
+// SavePricingRun writes run to path as JSON.
+func SavePricingRun(path string, run PricingRun) error {
+	b, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
+
+// LoadPricingRun reads a run saved by SavePricingRun.
+func LoadPricingRun(path string) (PricingRun, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return PricingRun{}, err
+	}
+	var run PricingRun
+	if err := json.Unmarshal(b, &run); err != nil {
+		return PricingRun{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return run, nil
+}
+
+// GreekAttribution splits the change from From to To into the passage of
+// time, market moves and position changes; Residual is what the
+// repricing does not reproduce, e.g. if To was priced with another model.
+type GreekAttribution struct {
+	From     Outputs `json:"from"`
+	To       Outputs `json:"to"`
+	Time     Outputs `json:"time"`
+	Market   Outputs `json:"market"`
+	Position Outputs `json:"position"`
+	Residual Outputs `json:"residual"`
+}
+
+func (a GreekAttribution) add(b GreekAttribution) GreekAttribution {
+	return GreekAttribution{
+		From: a.From.add(b.From), To: a.To.add(b.To), Time: a.Time.add(b.Time),
+		Market: a.Market.add(b.Market), Position: a.Position.add(b.Position), Residual: a.Residual.add(b.Residual),
+	}
+}
+
+// Position diff statuses.
+const (
+	PositionAdded     = "added"
+	PositionRemoved   = "removed"
+	PositionChanged   = "changed"
+	PositionUnchanged = "unchanged"
+)
+
+// PositionDiff is one position's change between runs.
+type PositionDiff struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	GreekAttribution
+}
+
+// RunDiff is the structured difference between two runs. Totals sum
+// position amounts in their premium currencies, so the book should share
+// one.
+type RunDiff struct {
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Elapsed   float64          `json:"elapsed_years"`
+	Positions []PositionDiff   `json:"positions"`
+	Total     GreekAttribution `json:"total"`
+}
+
+// DiffRuns attributes the change from run a to run b. For a position in
+// both, the steps are: age a's position by the time between the runs,
+// dropping dividends paid in it, move it to b's market (spot, vol, rates,
+// dividends, remaining expiry), then change it to b's size and terms
+// (strike, type, exercise). Each step is priced as Risk prices a position,
+// at the runs' theta basis. Added and removed positions are all position change.
+func DiffRuns(a, b PricingRun) (RunDiff, error) {
+	if b.ThetaBasis != a.ThetaBasis {
+		return RunDiff{}, fmt.Errorf("runs use different theta bases (%d and %d)", a.ThetaBasis, b.ThetaBasis)
+	}
+	o, err := (&Portfolio{ThetaBasis: a.ThetaBasis}).pricing()
+	if err != nil {
+		return RunDiff{}, err
+	}
+	price := func(id string, in Inputs, units float64) (Outputs, error) {
+		out, err := Position{ID: id, Inputs: in}.price(o)
+		return out.scale(units), err
+	}
+	d := RunDiff{From: a.ID, To: b.ID, Elapsed: b.AsOf.Sub(a.AsOf).Hours() / 24 / calendarDaysPerYear}
+	if d.Elapsed < 0 {
+		return RunDiff{}, errors.New("second run is earlier than the first")
+	}
+
+	inB := map[string]RunPosition{}
+	for _, p := range b.Positions {
+		inB[p.ID] = p
+	}
+	seen := map[string]bool{}
+	for _, pa := range a.Positions {
+		seen[pa.ID] = true
+		pb, ok := inB[pa.ID]
+		if !ok {
+			d.Positions = append(d.Positions, PositionDiff{ID: pa.ID, Status: PositionRemoved, GreekAttribution: GreekAttribution{
+				From: pa.Greeks, Position: pa.Greeks.scale(-1),
+			}})
+			continue
+		}
+		aged := pa.Inputs.aged(d.Elapsed)
+		aged.T = math.Max(aged.T, 0)
+		atTime, err := price(pa.ID, aged, pa.units())
+		if err != nil {
+			return RunDiff{}, err
+		}
+		moved := pb.Inputs
+		moved.K, moved.OptType, moved.Exercise = pa.Inputs.K, pa.Inputs.OptType, pa.Inputs.Exercise
+		atMarket, err := price(pa.ID, moved, pa.units())
+		if err != nil {
+			return RunDiff{}, err
+		}
+		atB, err := price(pb.ID, pb.Inputs, pb.units())
+		if err != nil {
+			return RunDiff{}, err
+		}
+		status := PositionChanged
+		if pa.units() == pb.units() && pa.Inputs.K == pb.Inputs.K && pa.Inputs.OptType == pb.Inputs.OptType &&
+			pa.Inputs.Exercise == pb.Inputs.Exercise {
+			status = PositionUnchanged
+		}
+		d.Positions = append(d.Positions, PositionDiff{ID: pa.ID, Status: status, GreekAttribution: GreekAttribution{
+			From:     pa.Greeks,
+			To:       pb.Greeks,
+			Time:     atTime.add(pa.Greeks.scale(-1)),
+			Market:   atMarket.add(atTime.scale(-1)),
+			Position: atB.add(atMarket.scale(-1)),
+			Residual: pb.Greeks.add(atB.scale(-1)),
+		}})
+	}
+	for _, pb := range b.Positions {
+		if !seen[pb.ID] {
+			d.Positions = append(d.Positions, PositionDiff{ID: pb.ID, Status: PositionAdded, GreekAttribution: GreekAttribution{
+				To: pb.Greeks, Position: pb.Greeks,
+			}})
+		}
+	}
+	sort.Slice(d.Positions, func(i, j int) bool { return d.Positions[i].ID < d.Positions[j].ID })
+	for _, p := range d.Positions {
+		d.Total = d.Total.add(p.GreekAttribution)
+	}
+	return d, nil
+}
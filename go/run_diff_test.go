@@ -0,0 +1,58 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Runs and their diffs price positions as Price does, and the steps of an
+// unchanged position add up to the change between runs.
+func TestDiffRunsPricesAsPrice(t *testing.T) {
+	in := Inputs{S0: 100, K: 110, T: 1, Sigma: 0.2, R: 0.05, OptType: Put, Exercise: American,
+		Dividends: []CashDividend{{Time: 2 / calendarDaysPerYear, Amount: 1}}}
+	book := &Portfolio{BaseCurrency: "USD", Positions: []Position{{ID: "p", Inputs: in, Quantity: 2}}}
+	asOf := time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)
+	a, err := NewPricingRun("a", asOf, book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Price(in)
+	if got := a.Positions[0].Greeks.Price; math.Abs(got-2*want.Price) > 1e-12 {
+		t.Errorf("run value %g, want %g", got, 2*want.Price)
+	}
+
+	// Three days on the dividend is paid and the market moved.
+	later := in.aged(3 / calendarDaysPerYear)
+	later.S0, later.Sigma = 97, 0.22
+	book.Positions[0].Inputs = later
+	b, err := NewPricingRun("b", asOf.AddDate(0, 0, 3), book)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := DiffRuns(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := d.Positions[0]
+	if pd.Status != PositionUnchanged {
+		t.Errorf("status %q, want %q", pd.Status, PositionUnchanged)
+	}
+	aged, _ := Price(in.aged(3 / calendarDaysPerYear))
+	if got := pd.From.Price + pd.Time.Price; math.Abs(got-2*aged.Price) > 1e-9 {
+		t.Errorf("aged value %g, want %g", got, 2*aged.Price)
+	}
+	if math.Abs(pd.Residual.Price) > 1e-9 || math.Abs(pd.Position.Price) > 1e-9 {
+		t.Errorf("residual %g, position %g, want 0", pd.Residual.Price, pd.Position.Price)
+	}
+
+	book.ThetaBasis = -1
+	if _, err := NewPricingRun("c", asOf, book); !errors.Is(err, ErrThetaBasis) {
+		t.Errorf("negative theta basis: got %v, want ErrThetaBasis", err)
+	}
+	a.Positions[0].Inputs.OptType = 0
+	if _, err := DiffRuns(a, b); !errors.Is(err, ErrUnknownOptionType) {
+		t.Errorf("unset option type: got %v, want ErrUnknownOptionType", err)
+	}
+}
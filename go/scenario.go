@@ -0,0 +1,151 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ShockKind says how a Shock moves an input.
+type ShockKind string
+
+const (
+	AbsoluteShock ShockKind = "absolute" // Input plus Size
+	RelativeShock ShockKind = "relative" // Input times 1 + Size
+	BpShock       ShockKind = "bp"       // Input plus Size basis points
+)
+
+// Shock is one move of a scenario input. The zero Shock leaves the input
+// unchanged.
+type Shock struct {
+	Kind ShockKind // AbsoluteShock if empty
+	Size float64
+}
+
+// Apply returns x moved by the shock.
+func (s Shock) Apply(x float64) float64 {
+	switch s.Kind {
+	case RelativeShock:
+		return x * (1 + s.Size)
+	case BpShock:
+		return x + s.Size*1e-4
+	}
+	return x + s.Size
+}
+
+// ScenarioAxes are the shocks a ladder crosses. An empty axis is the single
+// unshocked point.
+type ScenarioAxes struct {
+	Spot []Shock
+	Vol  []Shock
+	Rate []Shock
+	Days []float64 // Time decay, in days of the theta basis
+}
+
+// ScenarioPoint is a book revalued at one combination of shocks. PnL and
+// Greeks are summed over the positions, each in its premium currency.
+type ScenarioPoint struct {
+	Spot, Vol, Rate Shock
+	Days            float64
+	PnL             float64 // Value minus the unshocked value
+	Greeks          Outputs
+}
+
+// ScenarioLadder is every combination of the axes' shocks, spot-major.
+type ScenarioLadder struct {
+	Axes   ScenarioAxes
+	Base   Outputs // Unshocked
+	Points []ScenarioPoint
+}
+
+// At returns the point for the i-th spot, j-th vol, k-th rate and l-th
+// days shock (0 on an empty axis).
+func (l ScenarioLadder) At(i, j, k, d int) ScenarioPoint {
+	nv, nr, nd := axisLen(len(l.Axes.Vol)), axisLen(len(l.Axes.Rate)), axisLen(len(l.Axes.Days))
+	return l.Points[((i*nv+j)*nr+k)*nd+d]
+}
+
+func axisLen(n int) int { return max(n, 1) }
+
+// RunScenarios revalues the positions with Price under every combination
+// of the axes' shocks. Vols are floored at zero and times to expiry at
+// zero, so a decay step past expiry leaves intrinsic value. Every
+// revaluation goes through PriceBatch, so large books are priced in
+// parallel. Ladders are per position currency: pass positions that share
+// one, or convert the results.
+func RunScenarios(positions []Position, axes ScenarioAxes, opts ...Option) (ScenarioLadder, error) {
+	if len(positions) == 0 {
+		return ScenarioLadder{}, errors.New("scenarios need at least one position")
+	}
+	o := resolveOptions(opts)
+	if o.thetaBasis <= 0 {
+		return ScenarioLadder{}, fmt.Errorf("%w, got %d", ErrThetaBasis, o.thetaBasis)
+	}
+	for _, pos := range positions {
+		if err := pos.checkSide(); err != nil {
+			return ScenarioLadder{}, err
+		}
+	}
+	spot, vol, rate, days := axes.Spot, axes.Vol, axes.Rate, axes.Days
+	if len(spot) == 0 {
+		spot = []Shock{{}}
+	}
+	if len(vol) == 0 {
+		vol = []Shock{{}}
+	}
+	if len(rate) == 0 {
+		rate = []Shock{{}}
+	}
+	if len(days) == 0 {
+		days = []float64{0}
+	}
+
+	// The unshocked book comes first, then the points in ladder order.
+	n := len(positions)
+	inputs := make([]Inputs, 0, n*(1+len(spot)*len(vol)*len(rate)*len(days)))
+	for _, pos := range positions {
+		inputs = append(inputs, pos.Inputs)
+	}
+	var points []ScenarioPoint
+	for _, ds := range spot {
+		for _, dv := range vol {
+			for _, dr := range rate {
+				for _, dd := range days {
+					points = append(points, ScenarioPoint{Spot: ds, Vol: dv, Rate: dr, Days: dd})
+					for _, pos := range positions {
+						in := pos.Inputs.aged(math.Min(dd/float64(o.thetaBasis), pos.Inputs.T))
+						in.S0 = ds.Apply(in.S0)
+						in.Sigma = math.Max(dv.Apply(in.Sigma), 0)
+						in.R = dr.Apply(in.R)
+						inputs = append(inputs, in)
+					}
+				}
+			}
+		}
+	}
+	out, err := PriceBatch(inputs, opts...)
+	if err != nil {
+		return ScenarioLadder{}, err
+	}
+
+	ladder := ScenarioLadder{Axes: axes, Points: points}
+	for i, pos := range positions {
+		ladder.Base = ladder.Base.add(out[i].scale(pos.units()))
+	}
+	for p := range points {
+		for i, pos := range positions {
+			points[p].Greeks = points[p].Greeks.add(out[n*(p+1)+i].scale(pos.units()))
+		}
+		points[p].PnL = points[p].Greeks.Price - ladder.Base.Price
+	}
+	return ladder, nil
+}
+
+// Scenarios runs RunScenarios on the portfolio's positions at its theta
+// basis. Amounts are in each position's premium currency.
+func (p *Portfolio) Scenarios(axes ScenarioAxes, opts ...Option) (ScenarioLadder, error) {
+	if p.ThetaBasis != 0 {
+		opts = append([]Option{WithThetaBasis(p.ThetaBasis)}, opts...)
+	}
+	return RunScenarios(p.Positions, axes, opts...)
+}
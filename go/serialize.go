@@ -0,0 +1,190 @@
+package bsm
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// RoundingMode selects how RoundDecimal breaks ties.
+type RoundingMode string
+
+const (
+	RoundHalfAwayFromZero RoundingMode = "half-away-from-zero" // 2.5 -> 3, -2.5 -> -3
+	RoundHalfEven         RoundingMode = "half-even"           // Banker's rounding: 2.5 -> 2, 3.5 -> 4
+)
+
+// RoundDecimal formats v with exactly places decimals. It rounds the
+// shortest decimal that reads back as v, not v's binary expansion, so
+// 2.675 rounds to 2.68 half away from zero as it reads, and every port
+// that starts from the same shortest form (Python's repr, JavaScript's
+// toString) rounds identically. Negative zero prints as zero; NaN and
+// infinities print as strconv formats them.
+func RoundDecimal(v float64, places int, mode RoundingMode) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	intPart, frac, _ := strings.Cut(strconv.FormatFloat(math.Abs(v), 'f', -1, 64), ".")
+	digits := []byte(intPart + frac)
+	if len(frac) < places {
+		digits = append(digits, strings.Repeat("0", places-len(frac))...)
+	} else if len(frac) > places {
+		rest := frac[places:]
+		digits = digits[:len(intPart)+places]
+		up := rest[0] > '5'
+		if rest[0] == '5' {
+			up = strings.TrimRight(rest[1:], "0") != "" || mode != RoundHalfEven ||
+				(len(digits) > 0 && (digits[len(digits)-1]-'0')%2 == 1)
+		}
+		if up {
+			i := len(digits) - 1
+			for ; i >= 0 && digits[i] == '9'; i-- {
+				digits[i] = '0'
+			}
+			if i < 0 {
+				digits = append([]byte{'1'}, digits...)
+			} else {
+				digits[i]++
+			}
+		}
+	}
+	n := len(digits) - places
+	if n == 0 {
+		digits = append([]byte{'0'}, digits...)
+		n = 1
+	}
+	s := string(digits[:n])
+	if places > 0 {
+		s += "." + string(digits[n:])
+	}
+	if v < 0 && strings.Trim(s, "0.") != "" {
+		s = "-" + s
+	}
+	return s
+}
+
+// RoundingPolicy says how to print Outputs so runs can be diffed without
+// float noise, across runs and across the ports of this library. Zero
+// fields take the defaults in parentheses.
+type RoundingPolicy struct {
+	Places int            // Decimal places for fields not in Fields (0: unrounded, the shortest form that reads back exactly)
+	Fields map[string]int // Decimal places by Outputs field name ("Price", "Delta", ...)
+	Mode   RoundingMode   // Tie-breaking (RoundHalfAwayFromZero)
+}
+
+// Validate checks the mode, the places and that Fields names only Outputs
+// fields, so a misspelt field is not silently left unrounded.
+func (p RoundingPolicy) Validate() error {
+	if p.Mode != "" && p.Mode != RoundHalfAwayFromZero && p.Mode != RoundHalfEven {
+		return fmt.Errorf("unknown rounding mode %q", p.Mode)
+	}
+	if p.Places < 0 {
+		return fmt.Errorf("decimal places must be non-negative, got %d", p.Places)
+	}
+	for name, places := range p.Fields {
+		if f, ok := reflect.TypeOf(Outputs{}).FieldByName(name); !ok || f.Type.Kind() != reflect.Float64 {
+			return fmt.Errorf("no output named %q", name)
+		}
+		if places < 0 {
+			return fmt.Errorf("decimal places for %s must be non-negative, got %d", name, places)
+		}
+	}
+	return nil
+}
+
+// Format prints the value of the named field under the policy.
+func (p RoundingPolicy) Format(field string, v float64) string {
+	places, ok := p.Fields[field]
+	if !ok {
+		if p.Places == 0 {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+		places = p.Places
+	}
+	return RoundDecimal(v, places, p.Mode)
+}
+
+// outputFields lists Outputs' float fields in declaration order, the
+// stable order of every serialization below.
+var outputFields = func() []reflect.StructField {
+	var fields []reflect.StructField
+	t := reflect.TypeOf(Outputs{})
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Type.Kind() == reflect.Float64 {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}()
+
+// RoundedOutputs marshals Outputs to JSON under Policy: fields in
+// declaration order, numbers as Policy.Format prints them and null where
+// they are not finite.
+type RoundedOutputs struct {
+	Outputs
+	Policy RoundingPolicy
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r RoundedOutputs) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	v := reflect.ValueOf(r.Outputs)
+	for i, f := range outputFields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		x := v.FieldByIndex(f.Index).Float()
+		b.WriteString(strconv.Quote(f.Name) + ":")
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			b.WriteString("null")
+		} else {
+			b.WriteString(r.Policy.Format(f.Name, x))
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// WriteOutputsCSV writes outs as CSV under p, with a header of every
+// Outputs field in declaration order and one row per Outputs.
+func WriteOutputsCSV(w io.Writer, outs []Outputs, p RoundingPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	row := make([]string, len(outputFields))
+	for i, f := range outputFields {
+		row[i] = f.Name
+	}
+	cw.Write(row)
+	for _, o := range outs {
+		v := reflect.ValueOf(o)
+		for i, f := range outputFields {
+			row[i] = p.Format(f.Name, v.FieldByIndex(f.Index).Float())
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteOutputsJSON writes outs as JSON lines, one RoundedOutputs under p
+// per line.
+func WriteOutputsJSON(w io.Writer, outs []Outputs, p RoundingPolicy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	for _, o := range outs {
+		b, _ := RoundedOutputs{o, p}.MarshalJSON() // Never fails
+		if _, err := w.Write(append(b, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
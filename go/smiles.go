@@ -0,0 +1,324 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SmileModel names a parametric smile.
+type SmileModel string
+
+const (
+	SVIModel  SmileModel = "svi"
+	SABRModel SmileModel = "sabr"
+)
+
+// SVISmile is Gatheral's raw SVI parametrisation of total implied variance
+// in log-moneyness k = ln(K/F):
+//
+//	w(k) = a + b (rho (k - m) + sqrt((k - m)^2 + sigma^2))
+type SVISmile struct {
+	T, Forward          float64
+	A, B, Rho, M, Sigma float64
+}
+
+// TotalVariance returns w(k).
+func (s SVISmile) TotalVariance(k float64) float64 {
+	d := k - s.M
+	return s.A + s.B*(s.Rho*d+math.Sqrt(d*d+s.Sigma*s.Sigma))
+}
+
+func (s SVISmile) Vol(K float64) float64 {
+	w := s.TotalVariance(math.Log(K / s.Forward))
+	if w <= 0 {
+		return 0
+	}
+	return math.Sqrt(w / s.T)
+}
+
+// SABRSmile is the SABR model's implied lognormal vol from Hagan et al.'s
+// expansion, or its normal vol when Normal is set.
+type SABRSmile struct {
+	T, Forward           float64
+	Alpha, Beta, Rho, Nu float64
+	Normal               bool // Vol returns a Bachelier (absolute) vol
+}
+
+func (s SABRSmile) Vol(K float64) float64 {
+	if s.Normal {
+		return s.normalVol(K)
+	}
+	F, a, b, r, n := s.Forward, s.Alpha, s.Beta, s.Rho, s.Nu
+	omb := 1 - b
+	fk := math.Pow(F*K, omb/2)
+	lfk := math.Log(F / K)
+	corr := 1 + (omb*omb/24*a*a/(fk*fk)+0.25*r*b*n*a/fk+(2-3*r*r)/24*n*n)*s.T
+	denom := fk * (1 + omb*omb/24*lfk*lfk + math.Pow(omb, 4)/1920*math.Pow(lfk, 4))
+	z := n / a * fk * lfk
+	zx := 1.0
+	if math.Abs(z) > 1e-8 {
+		x := math.Log((math.Sqrt(1-2*r*z+z*z) + z - r) / (1 - r))
+		zx = z / x
+	}
+	return a / denom * zx * corr
+}
+
+// normalVol is Hagan et al.'s normal-vol expansion. With Beta zero the
+// forward and strike may be zero or negative.
+func (s SABRSmile) normalVol(K float64) float64 {
+	F, a, b, r, n := s.Forward, s.Alpha, s.Beta, s.Rho, s.Nu
+	fmid := 1.0 // sqrt(F K), which enters only raised to multiples of beta
+	if b > 0 {
+		fmid = math.Sqrt(F * K)
+	}
+	var lead float64 // (1-beta) (F-K) / (F^(1-beta) - K^(1-beta))
+	switch {
+	case math.Abs(F-K) <= 1e-12*math.Max(1, math.Abs(F)):
+		lead = math.Pow(F, b)
+	case b == 1:
+		lead = (F - K) / math.Log(F/K)
+	default:
+		lead = (1 - b) * (F - K) / (math.Pow(F, 1-b) - math.Pow(K, 1-b))
+	}
+	corr := 1 + (-b*(2-b)/24*a*a/math.Pow(fmid, 2-2*b)+0.25*r*b*n*a/math.Pow(fmid, 1-b)+(2-3*r*r)/24*n*n)*s.T
+	z := n / a * (F - K) / math.Pow(fmid, b)
+	zx := 1.0
+	if math.Abs(z) > 1e-8 {
+		x := math.Log((math.Sqrt(1-2*r*z+z*z) + z - r) / (1 - r))
+		zx = z / x
+	}
+	return a * lead * zx * corr
+}
+
+// SmileFit is a fitted smile with its parameters by name and the fit error
+// in implied vol.
+type SmileFit struct {
+	Model  SmileModel
+	Smile  Smile
+	Params map[string]float64
+	RMSE   float64
+	Result CalibrationResult
+}
+
+// FitSVI fits raw SVI to implied vols at one expiry. weights may be nil.
+// A penalty keeps the minimum total variance a + b sigma sqrt(1-rho^2)
+// non-negative. Several starts are tried and the best fit kept.
+func FitSVI(T, F float64, strikes, vols, weights []float64) (SmileFit, error) {
+	if err := checkSmileData(T, F, strikes, vols, 5); err != nil {
+		return SmileFit{}, err
+	}
+	problem, build := sviProblem(T, F, strikes, vols, weights)
+	atm := (VolSlice{Strikes: strikes, Vols: vols}).Vol(F)
+	w0 := atm * atm * T
+	sd := math.Sqrt(w0)
+	sig0 := math.Max(0.5*sd, 0.01)
+
+	var best SmileFit
+	best.RMSE = math.Inf(1)
+	for _, rho := range []float64{-0.6, 0} {
+		for _, m := range []float64{0, -0.5 * sd} {
+			problem.Initial = []float64{w0 / 2, w0 / (2 * sig0), rho, m, sig0}
+			res, err := Calibrate(problem, LMOptions{})
+			if err != nil {
+				continue
+			}
+			s := build(res.Params)
+			if rmse := smileRMSE(s, strikes, vols); rmse < best.RMSE {
+				best = sviFit(s, rmse, res)
+			}
+		}
+	}
+	if best.Smile == nil {
+		return SmileFit{}, errors.New("svi fit failed from every start")
+	}
+	return best, nil
+}
+
+// sviProblem is the raw SVI least-squares problem without a start point.
+func sviProblem(T, F float64, strikes, vols, weights []float64) (CalibrationProblem, func([]float64) SVISmile) {
+	kMin, kMax, wMax := math.Inf(1), math.Inf(-1), 0.0
+	for i, K := range strikes {
+		k := math.Log(K / F)
+		kMin, kMax = math.Min(kMin, k), math.Max(kMax, k)
+		wMax = math.Max(wMax, vols[i]*vols[i]*T)
+	}
+	build := func(x []float64) SVISmile {
+		return SVISmile{T: T, Forward: F, A: x[0], B: x[1], Rho: x[2], M: x[3], Sigma: x[4]}
+	}
+	w := append([]float64(nil), weights...)
+	if weights != nil {
+		w = append(w, 1)
+	}
+	return CalibrationProblem{
+		Names:   []string{"a", "b", "rho", "m", "sigma"},
+		Lower:   []float64{-wMax, 0, -0.999, kMin - 1, 1e-4},
+		Upper:   []float64{wMax, 10, 0.999, kMax + 1, 5},
+		Weights: w,
+		Residuals: func(x []float64) ([]float64, error) {
+			s := build(x)
+			r := make([]float64, len(strikes)+1)
+			for i, K := range strikes {
+				r[i] = s.Vol(K) - vols[i]
+			}
+			minW := s.A + s.B*s.Sigma*math.Sqrt(1-s.Rho*s.Rho)
+			r[len(strikes)] = 10 * math.Max(-minW, 0)
+			return r, nil
+		},
+	}, build
+}
+
+func sviFit(s SVISmile, rmse float64, res CalibrationResult) SmileFit {
+	return SmileFit{Model: SVIModel, Smile: s, RMSE: rmse, Result: res, Params: map[string]float64{
+		"a": s.A, "b": s.B, "rho": s.Rho, "m": s.M, "sigma": s.Sigma}}
+}
+
+// FitSABR fits SABR alpha, rho and nu with beta fixed to implied vols at
+// one expiry. weights may be nil.
+func FitSABR(T, F, beta float64, strikes, vols, weights []float64) (SmileFit, error) {
+	if err := checkSmileData(T, F, strikes, vols, 3); err != nil {
+		return SmileFit{}, err
+	}
+	if beta < 0 || beta > 1 {
+		return SmileFit{}, fmt.Errorf("sabr beta %g outside [0, 1]", beta)
+	}
+	return fitSABR(T, F, beta, false, strikes, vols, weights)
+}
+
+// FitSABRNormal is FitSABR for normal (Bachelier) implied vols, fitting
+// the SABR normal-vol expansion. With beta zero the forward and strikes
+// may be zero or negative, as for rates near or below zero.
+func FitSABRNormal(T, F, beta float64, strikes, normalVols, weights []float64) (SmileFit, error) {
+	if err := checkNormalSmileData(T, F, beta, strikes, normalVols, 3); err != nil {
+		return SmileFit{}, err
+	}
+	if beta < 0 || beta > 1 {
+		return SmileFit{}, fmt.Errorf("sabr beta %g outside [0, 1]", beta)
+	}
+	return fitSABR(T, F, beta, true, strikes, normalVols, weights)
+}
+
+func fitSABR(T, F, beta float64, normal bool, strikes, vols, weights []float64) (SmileFit, error) {
+	problem, build := sabrProblem(T, F, beta, normal, strikes, vols, weights)
+	problem.Initial = []float64{sabrAlphaGuess(F, beta, normal, strikes, vols), -0.3, 0.5}
+	res, err := Calibrate(problem, LMOptions{})
+	if err != nil {
+		return SmileFit{}, err
+	}
+	s := build(res.Params)
+	return sabrFit(s, smileRMSE(s, strikes, vols), res), nil
+}
+
+// sabrProblem is the SABR least-squares problem with beta fixed and no
+// start point.
+func sabrProblem(T, F, beta float64, normal bool, strikes, vols, weights []float64) (CalibrationProblem, func([]float64) SABRSmile) {
+	a0 := sabrAlphaGuess(F, beta, normal, strikes, vols)
+	build := func(x []float64) SABRSmile {
+		return SABRSmile{T: T, Forward: F, Alpha: x[0], Beta: beta, Rho: x[1], Nu: x[2], Normal: normal}
+	}
+	return CalibrationProblem{
+		Names:   []string{"alpha", "rho", "nu"},
+		Lower:   []float64{1e-6 * a0, -0.999, 1e-4},
+		Upper:   []float64{10 * a0, 0.999, 10},
+		Weights: weights,
+		Residuals: func(x []float64) ([]float64, error) {
+			s := build(x)
+			r := make([]float64, len(strikes))
+			for i, K := range strikes {
+				r[i] = s.Vol(K) - vols[i]
+			}
+			return r, nil
+		},
+	}, build
+}
+
+// sabrAlphaGuess is the alpha that matches the at-the-money vol to leading
+// order: lognormal vol ~ alpha F^(beta-1), normal vol ~ alpha F^beta.
+func sabrAlphaGuess(F, beta float64, normal bool, strikes, vols []float64) float64 {
+	atm := (VolSlice{Strikes: strikes, Vols: vols}).Vol(F)
+	if normal {
+		return atm / math.Pow(F, beta)
+	}
+	return atm * math.Pow(F, 1-beta)
+}
+
+func sabrFit(s SABRSmile, rmse float64, res CalibrationResult) SmileFit {
+	return SmileFit{Model: SABRModel, Smile: s, RMSE: rmse, Result: res, Params: map[string]float64{
+		"alpha": s.Alpha, "beta": s.Beta, "rho": s.Rho, "nu": s.Nu}}
+}
+
+// RefitSmile refits prev, an SVI or SABR smile, to new quotes at the same
+// expiry with a single LM run started from prev's parameters. It is much
+// cheaper than a fresh fit when the smile has moved only a little. A
+// SABR smile keeps its beta.
+func RefitSmile(prev Smile, F float64, strikes, vols, weights []float64, opt LMOptions) (SmileFit, error) {
+	switch s := prev.(type) {
+	case SVISmile:
+		if err := checkSmileData(s.T, F, strikes, vols, 5); err != nil {
+			return SmileFit{}, err
+		}
+		problem, build := sviProblem(s.T, F, strikes, vols, weights)
+		problem.Initial = clampToBounds([]float64{s.A, s.B, s.Rho, s.M, s.Sigma}, problem.Lower, problem.Upper)
+		res, err := Calibrate(problem, opt)
+		if err != nil {
+			return SmileFit{}, err
+		}
+		fit := build(res.Params)
+		return sviFit(fit, smileRMSE(fit, strikes, vols), res), nil
+	case SABRSmile:
+		check := checkSmileData(s.T, F, strikes, vols, 3)
+		if s.Normal {
+			check = checkNormalSmileData(s.T, F, s.Beta, strikes, vols, 3)
+		}
+		if check != nil {
+			return SmileFit{}, check
+		}
+		problem, build := sabrProblem(s.T, F, s.Beta, s.Normal, strikes, vols, weights)
+		problem.Initial = clampToBounds([]float64{s.Alpha, s.Rho, s.Nu}, problem.Lower, problem.Upper)
+		res, err := Calibrate(problem, opt)
+		if err != nil {
+			return SmileFit{}, err
+		}
+		fit := build(res.Params)
+		return sabrFit(fit, smileRMSE(fit, strikes, vols), res), nil
+	}
+	return SmileFit{}, fmt.Errorf("cannot refit smile of type %T", prev)
+}
+
+func clampToBounds(x, lower, upper []float64) []float64 {
+	for i := range x {
+		x[i] = math.Min(math.Max(x[i], lower[i]), upper[i])
+	}
+	return x
+}
+
+func checkSmileData(T, F float64, strikes, vols []float64, min int) error {
+	if T <= 0 || F <= 0 {
+		return errors.New("expiry and forward must be positive")
+	}
+	if len(strikes) != len(vols) {
+		return errors.New("strikes and vols must have the same length")
+	}
+	if len(strikes) < min {
+		return fmt.Errorf("need at least %d quotes, have %d", min, len(strikes))
+	}
+	return nil
+}
+
+// checkNormalSmileData is checkSmileData allowing a non-positive forward
+// when beta is zero.
+func checkNormalSmileData(T, F, beta float64, strikes, vols []float64, min int) error {
+	if beta == 0 {
+		F = 1
+	}
+	return checkSmileData(T, F, strikes, vols, min)
+}
+
+func smileRMSE(s Smile, strikes, vols []float64) float64 {
+	sum := 0.0
+	for i, K := range strikes {
+		d := s.Vol(K) - vols[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(strikes)))
+}
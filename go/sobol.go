@@ -0,0 +1,88 @@
+package bsm
+
+import "fmt"
+
+// Primitive polynomials and initial direction numbers for Sobol dimensions
+// 2 and up, from Joe and Kuo's new-joe-kuo-6.21201 table. Dimension 1 is the
+// van der Corput sequence.
+var sobolParams = []struct {
+	s, a uint32
+	m    []uint32
+}{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+	{6, 19, []uint32{1, 1, 1, 15, 7, 5}},
+	{6, 22, []uint32{1, 3, 1, 15, 13, 25}},
+	{6, 25, []uint32{1, 1, 5, 5, 19, 61}},
+	{7, 1, []uint32{1, 3, 7, 11, 23, 15, 103}},
+	{7, 4, []uint32{1, 3, 7, 13, 13, 15, 69}},
+}
+
+// MaxSobolDim is the number of dimensions with built-in direction numbers.
+var MaxSobolDim = len(sobolParams) + 1
+
+const sobolBits = 32
+
+// Sobol generates a Sobol low-discrepancy sequence in Gray-code order,
+// skipping the initial all-zero point.
+type Sobol struct {
+	v [][sobolBits]uint32
+	x []uint32
+	n uint32
+}
+
+// NewSobol returns a dim-dimensional Sobol generator.
+func NewSobol(dim int) (*Sobol, error) {
+	if dim < 1 || dim > MaxSobolDim {
+		return nil, fmt.Errorf("sobol dimension %d out of range [1, %d]", dim, MaxSobolDim)
+	}
+	s := &Sobol{v: make([][sobolBits]uint32, dim), x: make([]uint32, dim)}
+	for k := 0; k < sobolBits; k++ {
+		s.v[0][k] = 1 << (sobolBits - 1 - k)
+	}
+	for d := 1; d < dim; d++ {
+		p := sobolParams[d-1]
+		v := &s.v[d]
+		for k := 0; k < int(p.s); k++ {
+			v[k] = p.m[k] << (sobolBits - 1 - k)
+		}
+		for k := int(p.s); k < sobolBits; k++ {
+			v[k] = v[k-int(p.s)] ^ (v[k-int(p.s)] >> p.s)
+			for j := 1; j < int(p.s); j++ {
+				if (p.a>>(p.s-1-uint32(j)))&1 == 1 {
+					v[k] ^= v[k-j]
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// Dim returns the dimension of the sequence.
+func (s *Sobol) Dim() int { return len(s.x) }
+
+// Next writes the next point, in (0, 1)^dim, to out.
+func (s *Sobol) Next(out []float64) {
+	c := 0
+	for n := s.n; n&1 == 1; n >>= 1 {
+		c++
+	}
+	s.n++
+	for d := range s.x {
+		s.x[d] ^= s.v[d][c]
+		out[d] = float64(s.x[d]) / (1 << sobolBits)
+	}
+}
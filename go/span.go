@@ -0,0 +1,133 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SpanParams are the scanning ranges of a simplified SPAN risk array for
+// one underlying. Zero fields take the defaults in parentheses.
+type SpanParams struct {
+	PriceScan       float64 // Largest ordinary spot move, as a fraction of spot (required, e.g. 0.15)
+	VolScan         Shock   // Vol move, applied up and down (none)
+	ExtremeMultiple float64 // Extreme spot moves as a multiple of PriceScan (3)
+	ExtremeCover    float64 // Fraction of the extreme-move loss that counts (0.35)
+	Days            float64 // Time decay applied in every scenario, in days of the theta basis (0)
+}
+
+func (p SpanParams) resolve() (SpanParams, error) {
+	if !(p.PriceScan > 0) || math.IsInf(p.PriceScan, 0) {
+		return p, fmt.Errorf("price scan range must be positive and finite, got %g", p.PriceScan)
+	}
+	if p.ExtremeMultiple == 0 {
+		p.ExtremeMultiple = 3
+	}
+	if p.ExtremeCover == 0 {
+		p.ExtremeCover = 0.35
+	}
+	if p.ExtremeMultiple < 0 || p.ExtremeCover < 0 || p.ExtremeCover > 1 || p.Days < 0 {
+		return p, errors.New("extreme multiple, days and extreme cover must be non-negative, and cover at most 1")
+	}
+	return p, nil
+}
+
+// RiskArrayScenario is one of the sixteen SPAN scenarios.
+type RiskArrayScenario struct {
+	Spot, Vol Shock
+	Cover     float64 // Fraction of the P&L that counts: 1, or ExtremeCover
+	PnL       float64 // Book P&L in the scenario times Cover
+}
+
+// RiskArray is a book's P&L over the SPAN scenarios in the standard
+// order: spot unchanged, up and down a third, two thirds and the whole
+// PriceScan, each with vol up then vol down, then the extreme moves up and
+// down with vol unchanged. ScanRisk is the worst loss, zero if no scenario
+// loses, and Worst its index.
+type RiskArray struct {
+	Underlying string
+	Params     SpanParams
+	Scenarios  []RiskArrayScenario
+	Worst      int
+	ScanRisk   float64
+}
+
+// SpanRiskArray revalues the positions over the SPAN scenarios of p with
+// RunScenarios. It is simplified SPAN: no inter-month or inter-commodity
+// spread charges and no short option minimum. The positions should share
+// an underlying and a premium currency.
+func SpanRiskArray(positions []Position, p SpanParams, opts ...Option) (RiskArray, error) {
+	p, err := p.resolve()
+	if err != nil {
+		return RiskArray{}, err
+	}
+	// Spot levels 0, ±1/3, ±2/3, ±1 of the scan and the two extremes, by
+	// vol up, down and unchanged; the ladder holds a few unused points.
+	spot := []Shock{{}}
+	for _, f := range []float64{1.0 / 3, 2.0 / 3, 1, p.ExtremeMultiple} {
+		spot = append(spot, Shock{RelativeShock, f * p.PriceScan}, Shock{RelativeShock, -f * p.PriceScan})
+	}
+	down := p.VolScan
+	down.Size = -down.Size
+	axes := ScenarioAxes{Spot: spot, Vol: []Shock{p.VolScan, down, {}}, Days: []float64{p.Days}}
+	ladder, err := RunScenarios(positions, axes, opts...)
+	if err != nil {
+		return RiskArray{}, err
+	}
+
+	ra := RiskArray{Params: p}
+	add := func(i, j int, cover float64) {
+		pt := ladder.At(i, j, 0, 0)
+		ra.Scenarios = append(ra.Scenarios, RiskArrayScenario{Spot: pt.Spot, Vol: pt.Vol, Cover: cover, PnL: pt.PnL * cover})
+	}
+	for i := 0; i < 7; i++ {
+		add(i, 0, 1)
+		add(i, 1, 1)
+	}
+	add(7, 2, p.ExtremeCover)
+	add(8, 2, p.ExtremeCover)
+	for i, s := range ra.Scenarios {
+		if -s.PnL > ra.ScanRisk {
+			ra.Worst, ra.ScanRisk = i, -s.PnL
+		}
+	}
+	return ra, nil
+}
+
+// SpanReport is the simplified SPAN requirement of a portfolio: one risk
+// array per underlying, sorted by underlying, and the sum of their scan
+// risks. Amounts are in the positions' premium currencies.
+type SpanReport struct {
+	Arrays   []RiskArray
+	ScanRisk float64
+}
+
+// SpanMargin groups the portfolio's positions by underlying and builds
+// each group's risk array with that underlying's params, at the
+// portfolio's theta basis. Every underlying needs params.
+func (p *Portfolio) SpanMargin(params map[string]SpanParams, opts ...Option) (SpanReport, error) {
+	if p.ThetaBasis != 0 {
+		opts = append([]Option{WithThetaBasis(p.ThetaBasis)}, opts...)
+	}
+	groups := map[string][]Position{}
+	for _, pos := range p.Positions {
+		groups[pos.Underlying] = append(groups[pos.Underlying], pos)
+	}
+	var report SpanReport
+	for u, positions := range groups {
+		sp, ok := params[u]
+		if !ok {
+			return SpanReport{}, fmt.Errorf("no SPAN params for underlying %q", u)
+		}
+		ra, err := SpanRiskArray(positions, sp, opts...)
+		if err != nil {
+			return SpanReport{}, fmt.Errorf("underlying %q: %w", u, err)
+		}
+		ra.Underlying = u
+		report.Arrays = append(report.Arrays, ra)
+		report.ScanRisk += ra.ScanRisk
+	}
+	sort.Slice(report.Arrays, func(i, j int) bool { return report.Arrays[i].Underlying < report.Arrays[j].Underlying })
+	return report, nil
+}
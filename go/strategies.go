@@ -0,0 +1,205 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// The strategy constructors build one unit of a common structure as a
+// Portfolio. Spot, vol, rate, yield, model and expiry come from base; the
+// constructors set strike, type and, for calendars, expiry. Side Short
+// reverses every leg. Set BaseCurrency before calling Risk or Exposures.
+
+// VerticalSpread is long optType at longK and short it at shortK: a bull
+// call spread when longK < shortK, a bear put spread when longK > shortK.
+func VerticalSpread(base Inputs, optType OptionType, longK, shortK float64) (*Portfolio, error) {
+	if longK == shortK {
+		return nil, errors.New("vertical spread strikes must differ")
+	}
+	return strategy(Long, strategyLeg(base, optType, longK, 1), strategyLeg(base, optType, shortK, -1)), nil
+}
+
+// Straddle is a call and a put at K.
+func Straddle(base Inputs, K float64, side Side) (*Portfolio, error) {
+	return strategy(side, strategyLeg(base, Call, K, 1), strategyLeg(base, Put, K, 1)), nil
+}
+
+// Strangle is a put at putK and a call at callK > putK.
+func Strangle(base Inputs, putK, callK float64, side Side) (*Portfolio, error) {
+	if putK >= callK {
+		return nil, errors.New("strangle put strike must be below the call strike")
+	}
+	return strategy(side, strategyLeg(base, Put, putK, 1), strategyLeg(base, Call, callK, 1)), nil
+}
+
+// Butterfly is long the wings k1 and k3 and short two of the body k2, with
+// k1 < k2 < k3.
+func Butterfly(base Inputs, optType OptionType, k1, k2, k3 float64, side Side) (*Portfolio, error) {
+	if !(k1 < k2 && k2 < k3) {
+		return nil, errors.New("butterfly strikes must be increasing")
+	}
+	return strategy(side, strategyLeg(base, optType, k1, 1), strategyLeg(base, optType, k2, -2),
+		strategyLeg(base, optType, k3, 1)), nil
+}
+
+// Condor is long k1 and k4 and short k2 and k3, with k1 < k2 < k3 < k4.
+func Condor(base Inputs, optType OptionType, k1, k2, k3, k4 float64, side Side) (*Portfolio, error) {
+	if !(k1 < k2 && k2 < k3 && k3 < k4) {
+		return nil, errors.New("condor strikes must be increasing")
+	}
+	return strategy(side, strategyLeg(base, optType, k1, 1), strategyLeg(base, optType, k2, -1),
+		strategyLeg(base, optType, k3, -1), strategyLeg(base, optType, k4, 1)), nil
+}
+
+// CalendarSpread is short optType at K expiring at nearT and long it
+// expiring at farT > nearT.
+func CalendarSpread(base Inputs, optType OptionType, K, nearT, farT float64, side Side) (*Portfolio, error) {
+	if !(0 < nearT && nearT < farT) {
+		return nil, errors.New("calendar spread expiries must be positive and increasing")
+	}
+	near, far := strategyLeg(base, optType, K, -1), strategyLeg(base, optType, K, 1)
+	near.Inputs.T, far.Inputs.T = nearT, farT
+	near.ID, far.ID = fmt.Sprintf("%s %g %gy", optType, K, nearT), fmt.Sprintf("%s %g %gy", optType, K, farT)
+	return strategy(side, near, far), nil
+}
+
+// RiskReversal is long a call at callK and short a put at putK.
+func RiskReversal(base Inputs, putK, callK float64, side Side) (*Portfolio, error) {
+	return strategy(side, strategyLeg(base, Put, putK, -1), strategyLeg(base, Call, callK, 1)), nil
+}
+
+func strategyLeg(base Inputs, optType OptionType, K, quantity float64) Position {
+	in := base
+	in.K, in.OptType = K, optType
+	return Position{ID: fmt.Sprintf("%s %g", optType, K), Inputs: in, Quantity: quantity}
+}
+
+func strategy(side Side, legs ...Position) *Portfolio {
+	if side == Short {
+		for i := range legs {
+			legs[i].Quantity = -legs[i].Quantity
+		}
+	}
+	return &Portfolio{Positions: legs}
+}
+
+// StrategyReport summarises a structure held to its first expiry.
+type StrategyReport struct {
+	Horizon    float64 // First expiry among the positions
+	NetPremium float64 // Model value paid, negative for a net credit
+	Greeks     Outputs // Summed position Greeks today
+	// MaxProfit and MaxLoss are the best and worst P&L at the horizon
+	// (MaxLoss is negative for a loss), +Inf and -Inf when unbounded as
+	// the spot rises.
+	MaxProfit, MaxLoss float64
+	Breakevens         []float64 // Spots at the horizon where the P&L is zero, ascending
+}
+
+// AnalyzeStrategy prices the positions with Price and reports the
+// structure's P&L at the first expiry against spot, net of the premium
+// and ignoring its financing. Positions expiring then are worth their
+// intrinsic value; later ones are repriced with the remaining time at
+// their inputs' vol, rate and yield.
+func AnalyzeStrategy(p *Portfolio, opts ...Option) (StrategyReport, error) {
+	if len(p.Positions) == 0 {
+		return StrategyReport{}, errors.New("strategy has no positions")
+	}
+	rep := StrategyReport{Horizon: math.Inf(1)}
+	maxK := 0.0
+	for _, pos := range p.Positions {
+		if err := pos.checkSide(); err != nil {
+			return StrategyReport{}, err
+		}
+		out, err := Price(pos.Inputs, opts...)
+		if err != nil {
+			return StrategyReport{}, fmt.Errorf("position %s: %w", pos.ID, err)
+		}
+		rep.Greeks = rep.Greeks.add(out.scale(pos.units()))
+		rep.Horizon = math.Min(rep.Horizon, pos.Inputs.T)
+		maxK = math.Max(maxK, math.Max(pos.Inputs.K, pos.Inputs.S0))
+	}
+	rep.NetPremium = rep.Greeks.Price
+
+	pnl := func(S float64) float64 {
+		v := 0.0
+		for _, pos := range p.Positions {
+			v += pos.units() * horizonValue(pos.Inputs, S, rep.Horizon, opts)
+		}
+		return v - rep.NetPremium
+	}
+
+	// The grid includes every strike, where expiring legs kink.
+	const points = 2000
+	hi := 3 * maxK
+	grid := make([]float64, 0, points+len(p.Positions)+1)
+	for i := 0; i <= points; i++ {
+		grid = append(grid, hi*float64(i)/points)
+	}
+	for _, pos := range p.Positions {
+		grid = append(grid, pos.Inputs.K)
+	}
+	sort.Float64s(grid)
+	rep.MaxProfit, rep.MaxLoss = math.Inf(-1), math.Inf(1)
+	prevS, prev := 0.0, 0.0
+	for i, S := range grid {
+		v := pnl(S)
+		rep.MaxProfit = math.Max(rep.MaxProfit, v)
+		rep.MaxLoss = math.Min(rep.MaxLoss, v)
+		if i > 0 && S > prevS && (prev < 0) != (v < 0) {
+			be, err := numerics.Brent(pnl, prevS, S, numerics.Options{})
+			if err != nil {
+				return StrategyReport{}, fmt.Errorf("breakeven between %g and %g: %w", prevS, S, err)
+			}
+			rep.Breakevens = append(rep.Breakevens, be.X)
+		}
+		prevS, prev = S, v
+	}
+	// Beyond the grid the P&L is linear in the spot (calls) or flat.
+	slope := (pnl(2*hi) - pnl(hi)) / hi
+	tol := 1e-9 * math.Max(1, math.Abs(rep.NetPremium))
+	switch {
+	case slope > tol:
+		rep.MaxProfit = math.Inf(1)
+	case slope < -tol:
+		rep.MaxLoss = math.Inf(-1)
+	}
+	return rep, nil
+}
+
+// horizonValue is one unit of in at spot S after elapsed years.
+func horizonValue(in Inputs, S, elapsed float64, opts []Option) float64 {
+	if in.T <= elapsed {
+		if in.OptType == Call {
+			return math.Max(S-in.K, 0)
+		}
+		return math.Max(in.K-S, 0)
+	}
+	x := in.aged(elapsed)
+	x.S0 = math.Max(S, 1e-9*in.K) // The pricers need a positive spot
+	if dividendPV(x, 0) >= S {
+		x.Dividends = nil
+	}
+	out, err := Price(x, opts...)
+	if err != nil {
+		return math.NaN()
+	}
+	return out.Price
+}
+
+// aged is in after elapsed years: expiry and dividend dates draw nearer,
+// and dividends gone ex are dropped.
+func (in Inputs) aged(elapsed float64) Inputs {
+	x := in
+	x.T = in.T - elapsed
+	x.Dividends = nil
+	for _, d := range in.Dividends {
+		if d.Time > elapsed {
+			x.Dividends = append(x.Dividends, CashDividend{d.Time - elapsed, d.Amount})
+		}
+	}
+	return x
+}
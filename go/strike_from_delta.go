@@ -0,0 +1,95 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/ag-enzo/black-scholes-greeks-multilang/go/numerics"
+)
+
+// StrikeFromDelta returns the strike at which a BSM option on in (with
+// in.K ignored) has delta targetDelta in convention conv: negative for
+// puts, e.g. -0.25 for a 25-delta put. Spot and forward deltas invert in
+// closed form. Premium-adjusted deltas, (K/F) N(d2) before discounting,
+// are solved numerically; a premium-adjusted call delta peaks at some
+// strike and the higher of its two solutions, the market convention, is
+// returned.
+func StrikeFromDelta(targetDelta float64, in Inputs, conv FXDeltaConvention) (float64, error) {
+	probe := in
+	probe.K = in.S0
+	if err := probe.Validate(); err != nil {
+		return 0, err
+	}
+	if in.Model != "" && in.Model != BSMModel || len(in.Dividends) > 0 {
+		return 0, errors.New("strike from delta needs the BSM model without discrete dividends")
+	}
+	if in.T == 0 || in.Sigma == 0 {
+		return 0, errors.New("strike from delta needs positive expiry and volatility")
+	}
+	phi := 1.0
+	if in.OptType == Put {
+		phi = -1
+	}
+	if targetDelta*phi <= 0 {
+		return 0, fmt.Errorf("delta %g has the wrong sign for a %s", targetDelta, in.OptType)
+	}
+	sd := in.Sigma * math.Sqrt(in.T)
+	F := in.S0 * math.Exp((in.R-in.Q)*in.T)
+	discount := 1.0 // Foreign discount factor in spot deltas
+	if conv == SpotDelta || conv == SpotDeltaPA {
+		discount = math.Exp(-in.Q * in.T)
+	}
+
+	switch conv {
+	case SpotDelta, ForwardDelta:
+		n := targetDelta / discount * phi // N(phi d1)
+		if n >= 1 {
+			return 0, fmt.Errorf("|delta| %g is at or beyond its bound %g", math.Abs(targetDelta), discount)
+		}
+		d1 := phi * normInv(n)
+		return F * math.Exp(-d1*sd+0.5*sd*sd), nil
+	case SpotDeltaPA, ForwardDeltaPA:
+	default:
+		return 0, fmt.Errorf("unknown delta convention %q", conv)
+	}
+
+	// Premium-adjusted delta in log-moneyness x = ln(K/F).
+	pa := func(x float64) float64 {
+		d2 := -x/sd - 0.5*sd
+		return discount * phi * math.Exp(x) * normCDF(phi*d2)
+	}
+	f := func(x float64) float64 { return pa(x) - targetDelta }
+	if phi < 0 {
+		// Monotone from 0 (K -> 0) to -infinity: widen until bracketed.
+		lo, hi := -20*sd, sd
+		for f(hi) > 0 {
+			hi += 2 * sd
+		}
+		return solveLogStrike(f, F, lo, hi)
+	}
+	// Call: the delta peaks at xMax; the market strike is above it, below
+	// the unadjusted forward-delta strike where pa < target.
+	if targetDelta >= discount {
+		return 0, fmt.Errorf("|delta| %g is at or beyond its bound %g", targetDelta, discount)
+	}
+	hi := -normInv(targetDelta/discount)*sd + 0.5*sd*sd
+	peak, err := numerics.GoldenSection(func(x float64) float64 { return -pa(x) }, -20*sd, hi, numerics.Options{})
+	if err != nil {
+		return 0, fmt.Errorf("premium-adjusted delta peak: %w", err)
+	}
+	if targetDelta > -peak.F {
+		return 0, fmt.Errorf("premium-adjusted call delta %g exceeds its maximum %g", targetDelta, -peak.F)
+	}
+	return solveLogStrike(f, F, peak.X, hi)
+}
+
+// solveLogStrike finds the log-moneyness root of f in [lo, hi] and returns
+// the strike.
+func solveLogStrike(f func(float64) float64, F, lo, hi float64) (float64, error) {
+	res, err := numerics.Brent(f, lo, hi, numerics.Options{})
+	if err != nil {
+		return 0, fmt.Errorf("strike from delta: %w", err)
+	}
+	return F * math.Exp(res.X), nil
+}
@@ -0,0 +1,370 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// SurfaceFitOptions configures FitChain. Zero values take the defaults in
+// parentheses.
+type SurfaceFitOptions struct {
+	Model           SmileModel // (SVI)
+	SABRBeta        float64    // (1)
+	R               float64    // Discount rate for parity forwards and IVs
+	MinQuotes       int        // Per expiry (5)
+	MinT            float64    // Shortest expiry kept (one day)
+	RejectArbitrage bool       // Fail rather than report violations
+	Filters         QuoteFilters
+	SpreadWeighted  bool    // Weight the smile fit by inverse bid/ask vol spread
+	MinVolSpread    float64 // Floor on the vol spread for the weights (0.005)
+}
+
+// DroppedQuote records a quote excluded from the fit.
+type DroppedQuote struct {
+	Quote  ChainQuote
+	Reason string
+}
+
+// SliceFit is the fitted smile for one expiry.
+type SliceFit struct {
+	T, Forward float64
+	Fit        SmileFit
+	Quotes     int
+}
+
+// SurfaceFitResult is the outcome of FitChain.
+type SurfaceFitResult struct {
+	AsOf      time.Time
+	Surface   *VolSurface
+	Slices    []SliceFit
+	Dropped   []DroppedQuote
+	Arbitrage []ArbitrageViolation
+}
+
+// FitChain turns a chain snapshot into a VolSurface: it cleans the quotes
+// with opt.Filters, implies each expiry's forward from put-call parity,
+// computes out-of-the-money bid/mid/ask IVs, fits a smile to each expiry's
+// mids and checks the result for static arbitrage.
+func FitChain(snap *ChainSnapshot, opt SurfaceFitOptions) (*SurfaceFitResult, error) {
+	return FitChainContext(context.Background(), snap, opt)
+}
+
+// FitChainContext is FitChain with a context for cancellation between
+// expiries and for logging: each slice's fit at debug level and the
+// outcome with drop and arbitrage counts.
+func FitChainContext(ctx context.Context, snap *ChainSnapshot, opt SurfaceFitOptions) (*SurfaceFitResult, error) {
+	var quotes int
+	if snap != nil {
+		quotes = len(snap.Quotes)
+	}
+	ctx, sp := StartSpan(ctx, "fit_chain", slog.Int("quotes", quotes), slog.String("model", string(opt.Model)), fingerprintAttr(snap))
+	res, err := fitChain(ctx, snap, opt)
+	attrs := []slog.Attr{}
+	if res != nil {
+		attrs = append(attrs, slog.Int("slices", len(res.Slices)), slog.Int("dropped", len(res.Dropped)),
+			slog.Int("arbitrage", len(res.Arbitrage)))
+	}
+	sp.End(err, attrs...)
+	return res, err
+}
+
+func fitChain(ctx context.Context, snap *ChainSnapshot, opt SurfaceFitOptions) (*SurfaceFitResult, error) {
+	if snap == nil || len(snap.Quotes) == 0 {
+		return nil, errors.New("empty chain")
+	}
+	if opt.Model == "" {
+		opt.Model = SVIModel
+	}
+	if opt.SABRBeta == 0 {
+		opt.SABRBeta = 1
+	}
+	if opt.MinQuotes <= 0 {
+		opt.MinQuotes = 5
+	}
+	if opt.MinT <= 0 {
+		opt.MinT = 1 / calendarDaysPerYear
+	}
+	if opt.MinVolSpread <= 0 {
+		opt.MinVolSpread = 0.005
+	}
+
+	res := &SurfaceFitResult{AsOf: snap.AsOf}
+	drop := func(q ChainQuote, reason string) { res.Dropped = append(res.Dropped, DroppedQuote{q, reason}) }
+
+	filtered := FilterQuotes(snap, opt.R, opt.Filters)
+	res.Dropped = filtered.Dropped
+	byExpiry := map[float64][]ChainQuote{}
+	for _, q := range filtered.Kept {
+		if q.T < opt.MinT {
+			drop(q, "expiry too short")
+		} else {
+			byExpiry[q.T] = append(byExpiry[q.T], q)
+		}
+	}
+	expiries := make([]float64, 0, len(byExpiry))
+	for T := range byExpiry {
+		expiries = append(expiries, T)
+	}
+	sort.Float64s(expiries)
+
+	var slices []VolSlice
+	for _, T := range expiries {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		quotes := byExpiry[T]
+		df := math.Exp(-opt.R * T)
+		F, ok := parityForward(quotes, df)
+		if !ok {
+			if snap.Spot <= 0 {
+				for _, q := range quotes {
+					drop(q, "no put-call pair or spot to set the forward")
+				}
+				continue
+			}
+			F = snap.Spot / df
+		}
+		strikes, ivs, used := otmVols(quotes, F, df, drop)
+		if len(strikes) < opt.MinQuotes {
+			for _, q := range used {
+				drop(q, "too few quotes in expiry")
+			}
+			continue
+		}
+		vols := make([]float64, len(ivs))
+		bids := make([]float64, len(ivs))
+		asks := make([]float64, len(ivs))
+		for j, iv := range ivs {
+			bids[j], vols[j], asks[j] = iv.Bid, iv.Mid, iv.Ask
+		}
+		var weights []float64
+		if opt.SpreadWeighted {
+			weights, _ = SpreadWeights(bids, asks, opt.MinVolSpread)
+			// Scale to at most one so FitSVI's arbitrage penalty keeps its bite.
+			peak := 0.0
+			for _, w := range weights {
+				peak = math.Max(peak, w)
+			}
+			for j := range weights {
+				weights[j] /= peak
+			}
+		}
+		var fit SmileFit
+		var err error
+		if opt.Model == SABRModel {
+			fit, err = FitSABR(T, F, opt.SABRBeta, strikes, vols, weights)
+		} else {
+			fit, err = FitSVI(T, F, strikes, vols, weights)
+		}
+		if err != nil {
+			for _, q := range used {
+				drop(q, "smile fit failed: "+err.Error())
+			}
+			continue
+		}
+		logAttrs(ctx, slog.LevelDebug, "fit_chain slice", slog.Float64("t", T), slog.Float64("forward", F),
+			slog.Int("quotes", len(strikes)), slog.Float64("rmse", fit.RMSE), slog.Int("iterations", fit.Result.Iterations),
+			slog.String("reason", fit.Result.Reason))
+		res.Slices = append(res.Slices, SliceFit{T: T, Forward: F, Fit: fit, Quotes: len(strikes)})
+		slices = append(slices, VolSlice{T: T, Forward: F, Strikes: strikes, Vols: vols, BidVols: bids, AskVols: asks, Smile: fit.Smile})
+	}
+	if len(slices) == 0 {
+		return res, errors.New("no expiry had enough usable quotes")
+	}
+
+	spot := snap.Spot
+	if spot <= 0 {
+		spot = slices[0].Forward * math.Exp(-opt.R*slices[0].T)
+	}
+	surf, err := NewVolSurface(spot, opt.R, 0, slices)
+	if err != nil {
+		return res, err
+	}
+	res.Surface = surf
+	res.Arbitrage = surf.CheckArbitrage()
+	if opt.RejectArbitrage && len(res.Arbitrage) > 0 {
+		return res, fmt.Errorf("fitted surface has %d static-arbitrage violations", len(res.Arbitrage))
+	}
+	return res, nil
+}
+
+// parityForward implies the forward from the three call/put pairs closest to
+// the money, F = K + (C - P)/DF, taking the median.
+func parityForward(quotes []ChainQuote, df float64) (float64, bool) {
+	calls, puts := map[float64]float64{}, map[float64]float64{}
+	for _, q := range quotes {
+		if q.Bid <= 0 {
+			continue
+		}
+		if q.OptType == Call {
+			calls[q.Strike] = q.Mid()
+		} else {
+			puts[q.Strike] = q.Mid()
+		}
+	}
+	type pair struct{ diff, fwd float64 }
+	var pairs []pair
+	for K, c := range calls {
+		if p, ok := puts[K]; ok {
+			pairs = append(pairs, pair{math.Abs(c - p), K + (c-p)/df})
+		}
+	}
+	if len(pairs) == 0 {
+		return 0, false
+	}
+	sort.Slice(pairs, func(a, b int) bool { return pairs[a].diff < pairs[b].diff })
+	if len(pairs) > 3 {
+		pairs = pairs[:3]
+	}
+	fwds := make([]float64, len(pairs))
+	for i, p := range pairs {
+		fwds[i] = p.fwd
+	}
+	sort.Float64s(fwds)
+	return fwds[len(fwds)/2], true
+}
+
+// otmVols returns the bid, mid and ask IVs of the out-of-the-money option
+// at each strike (calls at or above the forward, puts below), sorted by
+// strike.
+func otmVols(quotes []ChainQuote, F, df float64, drop func(ChainQuote, string)) (strikes []float64, vols []IVQuote, used []ChainQuote) {
+	var otm []ChainQuote
+	for _, q := range quotes {
+		if (q.OptType == Call) == (q.Strike >= F) {
+			otm = append(otm, q)
+		}
+	}
+	sort.Slice(otm, func(a, b int) bool { return otm[a].Strike < otm[b].Strike })
+	for _, q := range otm {
+		if len(strikes) > 0 && q.Strike == strikes[len(strikes)-1] {
+			drop(q, "duplicate strike")
+			continue
+		}
+		vol, err := q.ImpliedVols(F, df)
+		if err != nil || vol.Mid <= 0 {
+			drop(q, "no implied vol for mid or ask")
+			continue
+		}
+		strikes, vols, used = append(strikes, q.Strike), append(vols, vol), append(used, q)
+	}
+	return strikes, vols, used
+}
+
+// ArbitrageViolation is a static-arbitrage breach found on a surface.
+// Butterfly: call prices not decreasing and convex in strike. Calendar:
+// total variance decreasing in expiry at fixed moneyness.
+type ArbitrageViolation struct {
+	Kind   string // "butterfly" or "calendar"
+	T, K   float64
+	Amount float64 // Size of the breach (price or total variance)
+}
+
+// CheckArbitrage scans each slice over its quoted strike range for
+// butterfly arbitrage, and consecutive slices for calendar arbitrage.
+func (v *VolSurface) CheckArbitrage() []ArbitrageViolation {
+	const points = 60
+	var out []ArbitrageViolation
+	for i, s := range v.Slices {
+		F := v.Forward(i)
+		lo, hi := s.strikeRange(F)
+		tol := 1e-8 * F
+		ks := make([]float64, points)
+		cs := make([]float64, points)
+		for j := range ks {
+			ks[j] = lo * math.Pow(hi/lo, float64(j)/float64(points-1))
+			cs[j] = blackFormula(F, ks[j], s.Vol(ks[j])*math.Sqrt(s.T), true)
+		}
+		for j := 1; j < points-1; j++ {
+			if d := cs[j] - cs[j-1]; d > tol {
+				out = append(out, ArbitrageViolation{"butterfly", s.T, ks[j], d})
+				continue
+			}
+			left := (cs[j] - cs[j-1]) / (ks[j] - ks[j-1])
+			right := (cs[j+1] - cs[j]) / (ks[j+1] - ks[j])
+			if d := left - right; d*(ks[j+1]-ks[j-1]) > tol {
+				out = append(out, ArbitrageViolation{"butterfly", s.T, ks[j], d * (ks[j+1] - ks[j-1])})
+			}
+		}
+	}
+	for i := 1; i < len(v.Slices); i++ {
+		a, b := v.Slices[i-1], v.Slices[i]
+		fa, fb := v.Forward(i-1), v.Forward(i)
+		loA, hiA := a.strikeRange(fa)
+		loB, hiB := b.strikeRange(fb)
+		kLo := math.Max(math.Log(loA/fa), math.Log(loB/fb))
+		kHi := math.Min(math.Log(hiA/fa), math.Log(hiB/fb))
+		for j := 0; j < points && kHi > kLo; j++ {
+			k := kLo + (kHi-kLo)*float64(j)/float64(points-1)
+			va, vb := a.Vol(fa*math.Exp(k)), b.Vol(fb*math.Exp(k))
+			if d := va*va*a.T - vb*vb*b.T; d > 1e-10 {
+				out = append(out, ArbitrageViolation{"calendar", b.T, fb * math.Exp(k), d})
+			}
+		}
+	}
+	return out
+}
+
+// strikeRange is the quoted strike range, or three standard deviations
+// either side of the forward for a slice with no quotes.
+func (s VolSlice) strikeRange(F float64) (float64, float64) {
+	if len(s.Strikes) > 1 {
+		return s.Strikes[0], s.Strikes[len(s.Strikes)-1]
+	}
+	sd := s.Vol(F) * math.Sqrt(s.T)
+	return F * math.Exp(-3*sd), F * math.Exp(3*sd)
+}
+
+// Record converts the fitted surface for storage, with each slice's fit
+// RMSE.
+func (r *SurfaceFitResult) Record(meta SurfaceMetadata) (SurfaceRecord, error) {
+	if r.Surface == nil {
+		return SurfaceRecord{}, errors.New("no fitted surface")
+	}
+	if meta.AsOf.IsZero() {
+		meta.AsOf = r.AsOf
+	}
+	if meta.Method == "" && len(r.Slices) > 0 {
+		meta.Method = string(r.Slices[0].Fit.Model)
+	}
+	rec, err := NewSurfaceRecord(r.Surface, meta)
+	if err != nil {
+		return SurfaceRecord{}, err
+	}
+	for i, s := range r.Slices {
+		rec.Slices[i].RMSE = s.Fit.RMSE
+	}
+	return rec, nil
+}
+
+// WriteJSON writes the fitted surface as indented JSON.
+func (r *SurfaceFitResult) WriteJSON(w io.Writer) error {
+	rec, err := r.Record(SurfaceMetadata{})
+	if err != nil {
+		return err
+	}
+	return rec.WriteJSON(w)
+}
+
+// FitChainFile reads a chain snapshot file, fits it and saves the surface
+// to outPath in the format its extension selects (see SaveSurface).
+func FitChainFile(inPath, outPath string, asOf time.Time, opt SurfaceFitOptions) (*SurfaceFitResult, error) {
+	snap, err := LoadChainSnapshot(inPath, asOf)
+	if err != nil {
+		return nil, err
+	}
+	res, err := FitChainContext(WithTraceID(context.Background(), NewTraceID()), snap, opt)
+	if err != nil {
+		return res, err
+	}
+	rec, err := res.Record(SurfaceMetadata{})
+	if err != nil {
+		return res, err
+	}
+	return res, rec.Save(outPath)
+}
@@ -0,0 +1,366 @@
+package bsm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SurfaceFormat is the version of the stored surface layout, bumped on any
+// incompatible change. Readers reject other versions.
+const SurfaceFormat = 1
+
+// surfaceMagic starts every binary surface.
+var surfaceMagic = [4]byte{'V', 'S', 'R', 'F'}
+
+// SurfaceMetadata describes where a stored surface came from.
+type SurfaceMetadata struct {
+	AsOf       time.Time `json:"as_of"`
+	Underlying string    `json:"underlying,omitempty"`
+	Method     string    `json:"method"`            // Fitting method, e.g. "svi"
+	Version    string    `json:"version,omitempty"` // Producer's version label
+}
+
+// SurfaceRecord is the stored form of a VolSurface.
+type SurfaceRecord struct {
+	Format int `json:"format"`
+	SurfaceMetadata
+	Spot   float64       `json:"spot"`
+	R      float64       `json:"r"`
+	Q      float64       `json:"q"`
+	Slices []SliceRecord `json:"slices"`
+}
+
+// SliceRecord is one expiry: its quotes and, for a fitted slice, the smile
+// model and parameters. A SABR smile quoting normal vols has the parameter
+// "normal" set to 1.
+type SliceRecord struct {
+	T       float64            `json:"t"`
+	Forward float64            `json:"forward"`
+	Model   SmileModel         `json:"model,omitempty"`
+	Params  map[string]float64 `json:"params,omitempty"`
+	RMSE    float64            `json:"rmse,omitempty"`
+	Strikes []float64          `json:"strikes"`
+	Vols    []float64          `json:"vols"`
+	BidVols []float64          `json:"bid_vols,omitempty"`
+	AskVols []float64          `json:"ask_vols,omitempty"`
+}
+
+// NewSurfaceRecord converts a surface for storage. Only SVI and SABR
+// smiles can be stored.
+func NewSurfaceRecord(v *VolSurface, meta SurfaceMetadata) (SurfaceRecord, error) {
+	rec := SurfaceRecord{Format: SurfaceFormat, SurfaceMetadata: meta, Spot: v.Spot, R: v.R, Q: v.Q}
+	for _, s := range v.Slices {
+		sr := SliceRecord{T: s.T, Forward: s.Forward, Strikes: s.Strikes, Vols: s.Vols, BidVols: s.BidVols, AskVols: s.AskVols}
+		switch sm := s.Smile.(type) {
+		case nil:
+		case SVISmile:
+			sr.Model = SVIModel
+			sr.Params = map[string]float64{"a": sm.A, "b": sm.B, "rho": sm.Rho, "m": sm.M, "sigma": sm.Sigma}
+		case SABRSmile:
+			sr.Model = SABRModel
+			sr.Params = map[string]float64{"alpha": sm.Alpha, "beta": sm.Beta, "rho": sm.Rho, "nu": sm.Nu}
+			if sm.Normal {
+				sr.Params["normal"] = 1
+			}
+		default:
+			return SurfaceRecord{}, fmt.Errorf("slice %g: cannot store smile of type %T", s.T, s.Smile)
+		}
+		rec.Slices = append(rec.Slices, sr)
+	}
+	return rec, nil
+}
+
+// Surface rebuilds the VolSurface.
+func (r SurfaceRecord) Surface() (*VolSurface, error) {
+	if r.Format != SurfaceFormat {
+		return nil, fmt.Errorf("surface format %d not supported (want %d)", r.Format, SurfaceFormat)
+	}
+	slices := make([]VolSlice, len(r.Slices))
+	for i, sr := range r.Slices {
+		smile, err := sr.smile()
+		if err != nil {
+			return nil, err
+		}
+		slices[i] = VolSlice{T: sr.T, Forward: sr.Forward, Strikes: sr.Strikes, Vols: sr.Vols, BidVols: sr.BidVols, AskVols: sr.AskVols, Smile: smile}
+	}
+	return NewVolSurface(r.Spot, r.R, r.Q, slices)
+}
+
+func (sr SliceRecord) smile() (Smile, error) {
+	var names []string
+	switch sr.Model {
+	case "":
+		return nil, nil
+	case SVIModel:
+		names = []string{"a", "b", "rho", "m", "sigma"}
+	case SABRModel:
+		names = []string{"alpha", "beta", "rho", "nu"}
+	default:
+		return nil, fmt.Errorf("slice %g: unknown smile model %q", sr.T, sr.Model)
+	}
+	x := make([]float64, len(names))
+	for j, n := range names {
+		v, ok := sr.Params[n]
+		if !ok {
+			return nil, fmt.Errorf("slice %g: %s smile missing parameter %q", sr.T, sr.Model, n)
+		}
+		x[j] = v
+	}
+	if sr.Model == SVIModel {
+		return SVISmile{T: sr.T, Forward: sr.Forward, A: x[0], B: x[1], Rho: x[2], M: x[3], Sigma: x[4]}, nil
+	}
+	return SABRSmile{T: sr.T, Forward: sr.Forward, Alpha: x[0], Beta: x[1], Rho: x[2], Nu: x[3], Normal: sr.Params["normal"] != 0}, nil
+}
+
+// WriteJSON writes the record as indented JSON.
+func (r SurfaceRecord) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadSurfaceRecordJSON reads a record written by WriteJSON.
+func ReadSurfaceRecordJSON(r io.Reader) (SurfaceRecord, error) {
+	var rec SurfaceRecord
+	if err := json.NewDecoder(r).Decode(&rec); err != nil {
+		return SurfaceRecord{}, err
+	}
+	return rec, nil
+}
+
+// WriteBinary writes the record in a compact little-endian layout:
+//
+//	"VSRF" format:u16 as_of:i64(unix ns, 0 if unset) underlying method version:str
+//	spot r q:f64 slices:u32
+//	per slice: t forward rmse:f64 model:str params:u8 {name:str value:f64}
+//	           strikes:u32 has_bid_ask:u8 strikes vols [bid_vols ask_vols]:f64...
+//
+// Strings are a u16 length then UTF-8 bytes.
+func (r SurfaceRecord) WriteBinary(w io.Writer) error {
+	bw := &binWriter{w: bufio.NewWriter(w)}
+	bw.write(surfaceMagic)
+	bw.write(uint16(r.Format))
+	var nanos int64
+	if !r.AsOf.IsZero() {
+		nanos = r.AsOf.UnixNano()
+	}
+	bw.write(nanos)
+	bw.str(r.Underlying)
+	bw.str(r.Method)
+	bw.str(r.Version)
+	bw.write([]float64{r.Spot, r.R, r.Q})
+	bw.write(uint32(len(r.Slices)))
+	for _, s := range r.Slices {
+		bw.write([]float64{s.T, s.Forward, s.RMSE})
+		bw.str(string(s.Model))
+		names := make([]string, 0, len(s.Params))
+		for n := range s.Params {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		bw.write(uint8(len(names)))
+		for _, n := range names {
+			bw.str(n)
+			bw.write(s.Params[n])
+		}
+		if len(s.Vols) != len(s.Strikes) {
+			return fmt.Errorf("slice %g: strikes and vols differ in length", s.T)
+		}
+		bw.write(uint32(len(s.Strikes)))
+		hasBidAsk := s.BidVols != nil
+		if hasBidAsk && (len(s.BidVols) != len(s.Strikes) || len(s.AskVols) != len(s.Strikes)) {
+			return fmt.Errorf("slice %g: bid and ask vols must match its strikes", s.T)
+		}
+		bw.write(hasBidAsk)
+		bw.write(s.Strikes)
+		bw.write(s.Vols)
+		if hasBidAsk {
+			bw.write(s.BidVols)
+			bw.write(s.AskVols)
+		}
+	}
+	if bw.err != nil {
+		return bw.err
+	}
+	return bw.w.Flush()
+}
+
+// ReadSurfaceRecordBinary reads a record written by WriteBinary.
+func ReadSurfaceRecordBinary(r io.Reader) (SurfaceRecord, error) {
+	br := &binReader{r: bufio.NewReader(r)}
+	var magic [4]byte
+	var format uint16
+	br.read(&magic)
+	br.read(&format)
+	if br.err != nil {
+		return SurfaceRecord{}, br.err
+	}
+	if magic != surfaceMagic {
+		return SurfaceRecord{}, errors.New("not a binary vol surface")
+	}
+	if format != SurfaceFormat {
+		return SurfaceRecord{}, fmt.Errorf("surface format %d not supported (want %d)", format, SurfaceFormat)
+	}
+	rec := SurfaceRecord{Format: int(format)}
+	var nanos int64
+	br.read(&nanos)
+	if nanos != 0 {
+		rec.AsOf = time.Unix(0, nanos).UTC()
+	}
+	rec.Underlying, rec.Method, rec.Version = br.str(), br.str(), br.str()
+	br.read(&rec.Spot)
+	br.read(&rec.R)
+	br.read(&rec.Q)
+	var nSlices uint32
+	br.read(&nSlices)
+	for i := uint32(0); i < nSlices && br.err == nil; i++ {
+		var s SliceRecord
+		br.read(&s.T)
+		br.read(&s.Forward)
+		br.read(&s.RMSE)
+		s.Model = SmileModel(br.str())
+		var nParams uint8
+		br.read(&nParams)
+		if nParams > 0 {
+			s.Params = map[string]float64{}
+		}
+		for j := uint8(0); j < nParams && br.err == nil; j++ {
+			name := br.str()
+			var v float64
+			br.read(&v)
+			s.Params[name] = v
+		}
+		var n uint32
+		var hasBidAsk bool
+		br.read(&n)
+		br.read(&hasBidAsk)
+		if n > 1<<24 {
+			return SurfaceRecord{}, fmt.Errorf("slice %g: implausible strike count %d", s.T, n)
+		}
+		s.Strikes, s.Vols = br.floats(n), br.floats(n)
+		if hasBidAsk {
+			s.BidVols, s.AskVols = br.floats(n), br.floats(n)
+		}
+		rec.Slices = append(rec.Slices, s)
+	}
+	if br.err != nil {
+		return SurfaceRecord{}, fmt.Errorf("reading binary surface: %w", br.err)
+	}
+	return rec, nil
+}
+
+// binWriter and binReader keep the first error so a record can be written
+// or read field by field and checked once.
+type binWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (b *binWriter) write(v any) {
+	if b.err == nil {
+		b.err = binary.Write(b.w, binary.LittleEndian, v)
+	}
+}
+
+func (b *binWriter) str(s string) {
+	if len(s) > math.MaxUint16 && b.err == nil {
+		b.err = fmt.Errorf("string of %d bytes too long", len(s))
+	}
+	b.write(uint16(len(s)))
+	if b.err == nil {
+		_, b.err = b.w.WriteString(s)
+	}
+}
+
+type binReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (b *binReader) read(v any) {
+	if b.err == nil {
+		b.err = binary.Read(b.r, binary.LittleEndian, v)
+	}
+}
+
+func (b *binReader) str() string {
+	var n uint16
+	b.read(&n)
+	if b.err != nil {
+		return ""
+	}
+	buf := make([]byte, n)
+	_, b.err = io.ReadFull(b.r, buf)
+	return string(buf)
+}
+
+func (b *binReader) floats(n uint32) []float64 {
+	out := make([]float64, n)
+	b.read(out)
+	return out
+}
+
+// SaveSurface writes v to path: JSON for ".json", the binary layout for
+// ".vsb".
+func SaveSurface(path string, v *VolSurface, meta SurfaceMetadata) error {
+	rec, err := NewSurfaceRecord(v, meta)
+	if err != nil {
+		return err
+	}
+	return rec.Save(path)
+}
+
+// Save writes the record to path, choosing the format by extension as
+// SaveSurface does.
+func (r SurfaceRecord) Save(path string) error {
+	write := r.WriteBinary
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		write = r.WriteJSON
+	case ".vsb":
+	default:
+		return fmt.Errorf("unknown surface file type %q", filepath.Ext(path))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadSurface reads a surface saved by SaveSurface.
+func LoadSurface(path string) (*VolSurface, SurfaceMetadata, error) {
+	read := ReadSurfaceRecordBinary
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		read = ReadSurfaceRecordJSON
+	case ".vsb":
+	default:
+		return nil, SurfaceMetadata{}, fmt.Errorf("unknown surface file type %q", filepath.Ext(path))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, SurfaceMetadata{}, err
+	}
+	defer f.Close()
+	rec, err := read(f)
+	if err != nil {
+		return nil, SurfaceMetadata{}, err
+	}
+	v, err := rec.Surface()
+	return v, rec.SurfaceMetadata, err
+}
@@ -0,0 +1,46 @@
+package bsm
+
+import (
+	"bytes"
+	"testing"
+)
+
+// A normal-vol SABR smile survives both storage formats.
+func TestSurfaceRecordNormalSABR(t *testing.T) {
+	smile := SABRSmile{T: 1, Forward: 0.03, Alpha: 0.01, Beta: 0, Rho: -0.2, Nu: 0.4, Normal: true}
+	v, err := NewVolSurface(0.03, 0, 0, []VolSlice{{
+		T: 1, Forward: 0.03, Strikes: []float64{0.02, 0.03, 0.04},
+		Vols: []float64{smile.Vol(0.02), smile.Vol(0.03), smile.Vol(0.04)}, Smile: smile,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := NewSurfaceRecord(v, SurfaceMetadata{Method: "sabr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var js, bin bytes.Buffer
+	if err := rec.WriteJSON(&js); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.WriteBinary(&bin); err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ReadSurfaceRecordJSON(&js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromBinary, err := ReadSurfaceRecordBinary(&bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, r := range map[string]SurfaceRecord{"json": fromJSON, "binary": fromBinary} {
+		got, err := r.Surface()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s, ok := got.Slices[0].Smile.(SABRSmile); !ok || s != smile {
+			t.Errorf("%s: smile %+v, want %+v", name, got.Slices[0].Smile, smile)
+		}
+	}
+}
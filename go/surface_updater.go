@@ -0,0 +1,229 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// SurfaceUpdaterOptions configures a SurfaceUpdater. Zero values take the
+// defaults in parentheses.
+type SurfaceUpdaterOptions struct {
+	HalfLife     time.Duration // Half-life of a quote's weight (5 minutes)
+	MinWeight    float64       // Floor on decayed weights (1e-3)
+	MinVolSpread float64       // Floor on the vol spread for the weights (0.005)
+	Threshold    float64       // Smallest vol move that raises a change event (0.0005)
+	MaxExpiryGap float64       // Largest gap in years between a tick's T and its slice's (half a day)
+	LM           LMOptions     // For the warm-started refits (20 iterations)
+}
+
+// SurfaceChange reports how one slice moved in an update.
+type SurfaceChange struct {
+	Time         time.Time
+	T            float64
+	Ticks        int
+	ATMVolBefore float64
+	ATMVolAfter  float64
+	MaxVolChange float64 // Largest absolute vol change at the slice's strikes
+	RMSE         float64 // Unweighted fit error against the current quotes
+}
+
+type updaterPoint struct {
+	vol IVQuote
+	at  time.Time
+}
+
+// SurfaceUpdater keeps a fitted surface current from streaming quote
+// ticks. Each tick replaces the stored quote at its strike; the touched
+// slices are refitted from their previous smile parameters with every
+// stored quote weighted by its inverse vol spread and an exponential decay
+// in its age, so fresh quotes dominate and stale ones fade instead of
+// being dropped.
+type SurfaceUpdater struct {
+	opt       SurfaceUpdaterOptions
+	surf      *VolSurface
+	points    []map[float64]updaterPoint // Per slice, by strike
+	listeners []func(SurfaceChange)
+}
+
+// NewSurfaceUpdater starts from surf, a surface of fitted SVI or SABR
+// slices such as FitChain produces, whose quotes are taken as observed at
+// asOf.
+func NewSurfaceUpdater(surf *VolSurface, asOf time.Time, opt SurfaceUpdaterOptions) (*SurfaceUpdater, error) {
+	if surf == nil || len(surf.Slices) == 0 {
+		return nil, errors.New("empty surface")
+	}
+	if opt.HalfLife <= 0 {
+		opt.HalfLife = 5 * time.Minute
+	}
+	if opt.MinWeight <= 0 {
+		opt.MinWeight = 1e-3
+	}
+	if opt.MinVolSpread <= 0 {
+		opt.MinVolSpread = 0.005
+	}
+	if opt.Threshold <= 0 {
+		opt.Threshold = 0.0005
+	}
+	if opt.MaxExpiryGap <= 0 {
+		opt.MaxExpiryGap = 0.5 / calendarDaysPerYear
+	}
+	if opt.LM.MaxIterations <= 0 {
+		opt.LM.MaxIterations = 20
+	}
+	surf = &VolSurface{Spot: surf.Spot, R: surf.R, Q: surf.Q, Slices: append([]VolSlice(nil), surf.Slices...)}
+	u := &SurfaceUpdater{opt: opt, surf: surf}
+	for i, s := range surf.Slices {
+		switch s.Smile.(type) {
+		case SVISmile, SABRSmile:
+		default:
+			return nil, errors.New("every slice needs a fitted SVI or SABR smile")
+		}
+		pts := map[float64]updaterPoint{}
+		for j, K := range s.Strikes {
+			iv := IVQuote{s.Vols[j], s.Vols[j], s.Vols[j]}
+			if s.BidVols != nil {
+				iv.Bid, iv.Ask = s.BidVols[j], s.AskVols[j]
+			}
+			pts[K] = updaterPoint{iv, asOf}
+		}
+		u.points = append(u.points, pts)
+		if s.Forward == 0 {
+			surf.Slices[i].Forward = surf.Forward(i)
+		}
+	}
+	return u, nil
+}
+
+// Surface returns the current surface. Updates build a new surface rather
+// than modifying this one, so it can be read while updates continue.
+func (u *SurfaceUpdater) Surface() *VolSurface { return u.surf }
+
+// Subscribe registers fn to receive every change event.
+func (u *SurfaceUpdater) Subscribe(fn func(SurfaceChange)) {
+	u.listeners = append(u.listeners, fn)
+}
+
+// Update applies ticks observed up to now and returns a change event for
+// each slice whose vols moved by at least the threshold. A tick with an
+// underlying price moves the spot and every forward in proportion, with
+// the smiles held in moneyness. Ticks that match no slice, are in the
+// money or have no implied vol are ignored.
+func (u *SurfaceUpdater) Update(ticks []ChainQuote, now time.Time) ([]SurfaceChange, error) {
+	old := u.surf
+	next := &VolSurface{Spot: old.Spot, R: old.R, Q: old.Q, Slices: append([]VolSlice(nil), old.Slices...)}
+	for _, q := range ticks {
+		if q.Underlying > 0 {
+			scale := q.Underlying / next.Spot
+			next.Spot = q.Underlying
+			for i := range next.Slices {
+				next.Slices[i].Forward *= scale
+				next.Slices[i].Smile = smileWithForward(next.Slices[i].Smile, next.Slices[i].Forward)
+			}
+		}
+	}
+
+	counts := map[int]int{}
+	for _, q := range ticks {
+		i := u.matchSlice(next, q.T)
+		if i < 0 {
+			continue
+		}
+		s := next.Slices[i]
+		if (q.OptType == Call) != (q.Strike >= s.Forward) {
+			continue
+		}
+		iv, err := q.ImpliedVols(s.Forward, math.Exp(-next.R*s.T))
+		if err != nil || iv.Mid <= 0 {
+			continue
+		}
+		at := q.Timestamp
+		if at.IsZero() {
+			at = now
+		}
+		u.points[i][q.Strike] = updaterPoint{iv, at}
+		counts[i]++
+	}
+
+	var events []SurfaceChange
+	for i := range next.Slices {
+		if counts[i] == 0 {
+			continue
+		}
+		ev, err := u.refit(next, i, now)
+		if err != nil {
+			return nil, err
+		}
+		ev.Ticks = counts[i]
+		ev.ATMVolBefore = old.Slices[i].Vol(old.Forward(i))
+		if ev.MaxVolChange >= u.opt.Threshold {
+			events = append(events, ev)
+		}
+	}
+	u.surf = next
+	for _, ev := range events {
+		for _, fn := range u.listeners {
+			fn(ev)
+		}
+	}
+	return events, nil
+}
+
+// matchSlice returns the slice whose expiry is nearest T, or -1 if none is
+// within MaxExpiryGap.
+func (u *SurfaceUpdater) matchSlice(v *VolSurface, T float64) int {
+	best, gap := -1, u.opt.MaxExpiryGap
+	for i, s := range v.Slices {
+		if d := math.Abs(s.T - T); d <= gap {
+			best, gap = i, d
+		}
+	}
+	return best
+}
+
+// refit rebuilds slice i of v from the stored quotes, warm-starting from
+// its current smile.
+func (u *SurfaceUpdater) refit(v *VolSurface, i int, now time.Time) (SurfaceChange, error) {
+	s := v.Slices[i]
+	pts := u.points[i]
+	strikes := make([]float64, 0, len(pts))
+	for K := range pts {
+		strikes = append(strikes, K)
+	}
+	sort.Float64s(strikes)
+	n := len(strikes)
+	vols, bids, asks, weights := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	halfLife := u.opt.HalfLife.Seconds()
+	for j, K := range strikes {
+		p := pts[K]
+		vols[j], bids[j], asks[j] = p.vol.Mid, p.vol.Bid, p.vol.Ask
+		age := math.Max(now.Sub(p.at).Seconds(), 0)
+		decay := math.Pow(0.5, age/halfLife)
+		weights[j] = math.Max(decay*u.opt.MinVolSpread/math.Max(p.vol.Spread(), u.opt.MinVolSpread), u.opt.MinWeight)
+	}
+	fit, err := RefitSmile(s.Smile, s.Forward, strikes, vols, weights, u.opt.LM)
+	if err != nil {
+		return SurfaceChange{}, err
+	}
+	ev := SurfaceChange{Time: now, T: s.T, ATMVolAfter: fit.Smile.Vol(s.Forward), RMSE: fit.RMSE}
+	for _, K := range strikes {
+		ev.MaxVolChange = math.Max(ev.MaxVolChange, math.Abs(fit.Smile.Vol(K)-s.Smile.Vol(K)))
+	}
+	v.Slices[i] = VolSlice{T: s.T, Forward: s.Forward, Strikes: strikes, Vols: vols, BidVols: bids, AskVols: asks, Smile: fit.Smile}
+	return ev, nil
+}
+
+// smileWithForward moves a parametric smile to a new forward, keeping its
+// shape in moneyness.
+func smileWithForward(sm Smile, F float64) Smile {
+	switch s := sm.(type) {
+	case SVISmile:
+		s.Forward = F
+		return s
+	case SABRSmile:
+		s.Forward = F
+		return s
+	}
+	return sm
+}
@@ -0,0 +1,43 @@
+package bsm
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// Rates options reject what Price rejects, with the same errors.
+func TestRateOptionsValidate(t *testing.T) {
+	swaption := SwaptionInputs{Forward: 0.03, K: 0.03, T: 1, Annuity: 4.5, Vol: 0.2, Type: Payer}
+	caplet := CapletInputs{Period: CapletPeriod{Fixing: 0.5, Accrual: 0.25, Forward: 0.03, Discount: 0.98, Vol: 0.0080},
+		K: 0.03, Quoting: NormalVol, Type: Cap}
+	for _, c := range []struct {
+		name     string
+		swaption func(*SwaptionInputs)
+		caplet   func(*CapletInputs)
+		basis    int
+		want     error
+	}{
+		{"expired", func(in *SwaptionInputs) { in.T = -0.1 }, func(in *CapletInputs) { in.Period.Fixing = -0.1 }, 365, ErrExpired},
+		{"negative vol", func(in *SwaptionInputs) { in.Vol = -0.2 }, func(in *CapletInputs) { in.Period.Vol = -0.01 }, 365, ErrNegativeVol},
+		{"NaN strike", func(in *SwaptionInputs) { in.K = math.NaN() }, func(in *CapletInputs) { in.K = math.NaN() }, 365, ErrNonFinite},
+		{"zero theta basis", func(*SwaptionInputs) {}, func(*CapletInputs) {}, 0, ErrThetaBasis},
+	} {
+		s, cp := swaption, caplet
+		c.swaption(&s)
+		c.caplet(&cp)
+		if _, err := PriceSwaption(s, c.basis); !errors.Is(err, c.want) {
+			t.Errorf("swaption, %s: got %v, want %v", c.name, err, c.want)
+		}
+		if _, err := PriceCaplet(cp, c.basis); !errors.Is(err, c.want) {
+			t.Errorf("caplet, %s: got %v, want %v", c.name, err, c.want)
+		}
+		cf := CapFloorInputs{Periods: []CapletPeriod{caplet.Period, cp.Period}, K: cp.K, Quoting: NormalVol, Type: Cap}
+		if _, err := PriceCapFloor(cf, c.basis); !errors.Is(err, c.want) {
+			t.Errorf("cap, %s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+	if _, err := PriceSwaption(swaption, 365); err != nil {
+		t.Error(err)
+	}
+}
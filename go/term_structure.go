@@ -0,0 +1,114 @@
+package bsm
+
+import (
+	"fmt"
+	"math"
+)
+
+// Validate checks that the curve has matching, finite, strictly increasing
+// non-negative tenors and finite rates.
+func (c ZeroCurve) Validate() error {
+	return validateTermStructure("zero curve", c.Tenors, c.Rates, false)
+}
+
+// DiscountFactor is exp(-Rate(T) T).
+func (c ZeroCurve) DiscountFactor(T float64) float64 {
+	return math.Exp(-c.Rate(T) * T)
+}
+
+// VolCurve is a term structure of forward vols, piecewise constant in
+// time: Vols[i] applies from Tenors[i-1] (today for i = 0) to Tenors[i],
+// and the last vol beyond the last tenor.
+type VolCurve struct {
+	Tenors []float64 // Sorted
+	Vols   []float64
+}
+
+// Validate checks that the curve has matching, strictly increasing
+// non-negative tenors and finite non-negative vols.
+func (c VolCurve) Validate() error {
+	return validateTermStructure("vol curve", c.Tenors, c.Vols, true)
+}
+
+// RMSVol is the root-mean-square vol to T, sqrt(integral of sigma^2 dt / T):
+// the flat vol with the same total variance. It is the first vol at T = 0.
+func (c VolCurve) RMSVol(T float64) float64 {
+	if len(c.Vols) == 0 {
+		return 0
+	}
+	if T <= 0 {
+		return c.Vols[0]
+	}
+	variance, prev := 0.0, 0.0
+	for i, t := range c.Tenors {
+		if t >= T {
+			return math.Sqrt((variance + c.Vols[i]*c.Vols[i]*(T-prev)) / T)
+		}
+		variance += c.Vols[i] * c.Vols[i] * (t - prev)
+		prev = t
+	}
+	last := c.Vols[len(c.Vols)-1]
+	return math.Sqrt((variance + last*last*(T-prev)) / T)
+}
+
+func validateTermStructure(name string, tenors, values []float64, nonNegative bool) error {
+	if len(tenors) == 0 || len(tenors) != len(values) {
+		return fmt.Errorf("%s needs matching, non-empty tenors and values", name)
+	}
+	prev := math.Inf(-1)
+	for i, t := range tenors {
+		v := values[i]
+		if math.IsNaN(t) || math.IsInf(t, 0) || math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%s point %d is not finite", name, i)
+		}
+		if t < 0 || t <= prev {
+			return fmt.Errorf("%s tenors must be non-negative and increasing at index %d (%g)", name, i, t)
+		}
+		if nonNegative && v < 0 {
+			return fmt.Errorf("%s value at %g is negative", name, t)
+		}
+		prev = t
+	}
+	return nil
+}
+
+// CurveInputs prices off term structures: the zero rate to expiry from
+// RateCurve replaces R, and the RMS vol to expiry from VolCurve replaces
+// Sigma. A nil curve leaves the flat value in Inputs.
+type CurveInputs struct {
+	Inputs
+	RateCurve *ZeroCurve
+	VolCurve  *VolCurve
+}
+
+// Resolve returns the equivalent flat Inputs.
+func (c CurveInputs) Resolve() (Inputs, error) {
+	in := c.Inputs
+	if c.RateCurve != nil {
+		if err := c.RateCurve.Validate(); err != nil {
+			return Inputs{}, err
+		}
+		in.R = c.RateCurve.Rate(in.T)
+	}
+	if c.VolCurve != nil {
+		if err := c.VolCurve.Validate(); err != nil {
+			return Inputs{}, err
+		}
+		in.Sigma = c.VolCurve.RMSVol(in.T)
+	}
+	return in, nil
+}
+
+// PriceCurves prices like Price on the resolved flat inputs, so the
+// discount factor is the curve's and the total variance the vol curve's.
+// Rho is to a parallel shift of the zero curve. Vega is per unit of RMS
+// vol; a parallel shift of the forward vols moves the RMS vol by the mean
+// forward vol over the RMS vol per unit. Theta holds R and Sigma fixed,
+// not the curves.
+func PriceCurves(in CurveInputs, opts ...Option) (Outputs, error) {
+	inputs, err := in.Resolve()
+	if err != nil {
+		return Outputs{}, err
+	}
+	return Price(inputs, opts...)
+}
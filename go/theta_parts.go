@@ -0,0 +1,36 @@
+package bsm
+
+import "math"
+
+// ThetaParts splits theta per year into what passing time does through
+// each of the rate, the dividend yield and the vol. With constant
+// parameters the price depends on time only through rT, qT and sigma^2 T,
+// so theta = -(r Rho + q Phi + sigma Vega / 2) / T. Under BSM that is
+// Interest = -r K e^(-rT) N(d2) for a call (the strike's financing; a put
+// earns it), Dividend = q S e^(-qT) N(d1) (the yield a call forgoes) and
+// Volatility = -S e^(-qT) n(d1) sigma / (2 sqrt(T)), the pure decay of time
+// value. Options on forwards carry only the premium: Interest = r Price.
+// Residual is theta less the parts, zero in closed form but not on a tree
+// or with discrete dividends, whose dates do not scale with T.
+type ThetaParts struct {
+	Interest   float64
+	Dividend   float64
+	Volatility float64
+	Residual   float64
+}
+
+// ThetaParts splits o.ThetaPerYear for the contract in that o prices.
+func (o Outputs) ThetaParts(in Inputs) ThetaParts {
+	T := math.Max(in.T, 1e-6) // The kernel's limit
+	p := ThetaParts{
+		Interest:   -in.R * o.RhoPer1 / T,
+		Dividend:   -in.Q * o.PhiPer1 / T,
+		Volatility: -math.Max(in.Sigma, 1e-8) * o.VegaPerVol / (2 * T),
+	}
+	p.Residual = o.ThetaPerYear - p.Interest - p.Dividend - p.Volatility
+	return p
+}
+
+func (p ThetaParts) scale(f float64) ThetaParts {
+	return ThetaParts{p.Interest * f, p.Dividend * f, p.Volatility * f, p.Residual * f}
+}
@@ -0,0 +1,230 @@
+package bsm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// TreeMethod selects the lattice for PriceTree.
+type TreeMethod string
+
+const (
+	Binomial  TreeMethod = "binomial"  // Cox-Ross-Rubinstein
+	Trinomial TreeMethod = "trinomial" // Boyle, with stretch sqrt(3)
+)
+
+// TreeOptions configures PriceTree.
+type TreeOptions struct {
+	Method     TreeMethod // Binomial (default) or Trinomial
+	Steps      int        // Time steps (200)
+	Richardson bool       // Extrapolate as 2 V(Steps) - V(Steps/2)
+}
+
+// PriceTree prices an option on a recombining tree, with early exercise
+// when inputs.Exercise is American. Discrete dividends follow the escrowed
+// model: the tree carries the spot less the dividends' present value, which
+// is added back at each node when testing exercise. Delta and gamma come from the nodes
+// after the first steps and theta from the node that returns to the spot;
+// vega, rho and phi are central bumps of the tree. Second-order Greeks
+// beyond gamma are left zero.
+func PriceTree(inputs Inputs, opt TreeOptions, thetaBasis int) (Outputs, error) {
+	return PriceTreeContext(context.Background(), inputs, opt, thetaBasis)
+}
+
+// PriceTreeContext is PriceTree with a context: when ctx is done it stops
+// rolling back and returns ctx's error. A tree gives no partial result.
+func PriceTreeContext(ctx context.Context, inputs Inputs, opt TreeOptions, thetaBasis int) (Outputs, error) {
+	opt, err := opt.resolve()
+	if err != nil {
+		return Outputs{}, err
+	}
+	if thetaBasis <= 0 {
+		return Outputs{}, fmt.Errorf("%w, got %d", ErrThetaBasis, thetaBasis)
+	}
+	if err := inputs.Validate(); err != nil {
+		return Outputs{}, err
+	}
+	if inputs.T == 0 || inputs.Sigma == 0 {
+		return Outputs{}, errors.New("tree needs positive expiry and volatility")
+	}
+	out, err := treeGreeks(ctx, inputs, opt.Method, opt.Steps, thetaBasis)
+	if err != nil || !opt.Richardson {
+		return out, err
+	}
+	half, err := treeGreeks(ctx, inputs, opt.Method, opt.Steps/2, thetaBasis)
+	if err != nil {
+		return Outputs{}, err
+	}
+	return out.scale(2).add(half.scale(-1)), nil
+}
+
+// resolve fills in the defaults and checks the options.
+func (opt TreeOptions) resolve() (TreeOptions, error) {
+	if opt.Method == "" {
+		opt.Method = Binomial
+	}
+	if opt.Steps == 0 {
+		opt.Steps = 200
+	}
+	if opt.Method != Binomial && opt.Method != Trinomial {
+		return opt, fmt.Errorf("unknown tree method %q", opt.Method)
+	}
+	if opt.Steps < 2 || (opt.Richardson && opt.Steps < 4) {
+		return opt, errors.New("too few tree steps")
+	}
+	return opt, nil
+}
+
+// treePrice is PriceTree's price alone, for resolved options and validated
+// inputs.
+func treePrice(in Inputs, opt TreeOptions) (float64, error) {
+	ctx := context.Background()
+	price, _, _, _, err := treeValue(ctx, in, opt.Method, opt.Steps)
+	if err != nil || !opt.Richardson {
+		return price, err
+	}
+	half, _, _, _, err := treeValue(ctx, in, opt.Method, opt.Steps/2)
+	return 2*price - half, err
+}
+
+// treeGreeks prices on one tree and bumps it for vega, rho and phi.
+func treeGreeks(ctx context.Context, in Inputs, method TreeMethod, steps, thetaBasis int) (Outputs, error) {
+	price, delta, gamma, theta, err := treeValue(ctx, in, method, steps)
+	if err != nil {
+		return Outputs{}, err
+	}
+	bump := func(set func(*Inputs, float64), h float64) (float64, error) {
+		up, down := in, in
+		set(&up, h)
+		set(&down, -h)
+		pu, _, _, _, err := treeValue(ctx, up, method, steps)
+		if err != nil {
+			return 0, err
+		}
+		pd, _, _, _, err := treeValue(ctx, down, method, steps)
+		return (pu - pd) / (2 * h), err
+	}
+	vega, err := bump(func(x *Inputs, h float64) { x.Sigma += h }, 1e-4)
+	if err != nil {
+		return Outputs{}, err
+	}
+	rho, err := bump(func(x *Inputs, h float64) { x.R += h }, 1e-4)
+	if err != nil {
+		return Outputs{}, err
+	}
+	phi, err := bump(func(x *Inputs, h float64) { x.Q += h }, 1e-4)
+	if err != nil {
+		return Outputs{}, err
+	}
+	return Outputs{
+		Price:        price,
+		Delta:        delta,
+		Gamma:        gamma,
+		VegaPerVol:   vega,
+		VegaPerVolPt: vega * 0.01,
+		ThetaPerYear: theta,
+		ThetaPerDay:  theta / float64(thetaBasis),
+		RhoPer1:      rho,
+		RhoPerBp:     rho / 10000.0,
+		PhiPer1:      phi,
+		PhiPerBp:     phi / 10000.0,
+	}, nil
+}
+
+// treeCheckEvery is how many time steps treeValue rolls back between checks
+// of its context.
+const treeCheckEvery = 64
+
+// treeValue rolls the tree back to the root, keeping the early layers for
+// the spot and time Greeks.
+func treeValue(ctx context.Context, in Inputs, method TreeMethod, steps int) (price, delta, gamma, theta float64, err error) {
+	dt := in.T / float64(steps)
+	disc := math.Exp(-in.R * dt)
+	// With discrete dividends the tree models the spot less the present
+	// value of the dividends still to come; nodes add it back to exercise.
+	pv := func(t float64) float64 { return dividendPV(in, t) }
+	s0 := in.S0 - pv(0)
+	payoff := func(s float64) float64 {
+		if in.OptType == Call {
+			return math.Max(s-in.K, 0)
+		}
+		return math.Max(in.K-s, 0)
+	}
+	american := in.Exercise == American
+
+	if method == Binomial {
+		u := math.Exp(in.Sigma * math.Sqrt(dt))
+		d := 1 / u
+		p := (math.Exp((in.R-in.Q)*dt) - d) / (u - d)
+		if !(p > 0 && p < 1) {
+			return 0, 0, 0, 0, errors.New("binomial probabilities outside (0, 1); use more steps")
+		}
+		v := make([]float64, steps+1)
+		for j := range v {
+			v[j] = payoff(s0 * math.Pow(u, float64(2*j-steps)))
+		}
+		var layers [3][]float64
+		for i := steps - 1; i >= 0; i-- {
+			if i%treeCheckEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return 0, 0, 0, 0, err
+				}
+			}
+			shift := pv(float64(i) * dt)
+			for j := 0; j <= i; j++ {
+				v[j] = disc * (p*v[j+1] + (1-p)*v[j])
+				if american {
+					v[j] = math.Max(v[j], payoff(s0*math.Pow(u, float64(2*j-i))+shift))
+				}
+			}
+			if i <= 2 {
+				layers[i] = append([]float64(nil), v[:i+1]...)
+			}
+		}
+		s := s0
+		su, sd, suu, sdd := s*u, s*d, s*u*u, s*d*d
+		delta = (layers[1][1] - layers[1][0]) / (su - sd)
+		gamma = ((layers[2][2]-layers[2][1])/(suu-s) - (layers[2][1]-layers[2][0])/(s-sdd)) / (0.5 * (suu - sdd))
+		// The middle node holds the tree's spot, not the stock price, fixed.
+		theta = (layers[2][1]-layers[0][0])/(2*dt) - delta*(pv(2*dt)-pv(0))/(2*dt)
+		return layers[0][0], delta, gamma, theta, nil
+	}
+
+	dx := in.Sigma * math.Sqrt(3*dt)
+	nu := in.R - in.Q - 0.5*in.Sigma*in.Sigma
+	drift := nu * math.Sqrt(dt/(12*in.Sigma*in.Sigma))
+	pu, pm, pd := 1.0/6+drift, 2.0/3, 1.0/6-drift
+	if pu <= 0 || pd <= 0 {
+		return 0, 0, 0, 0, errors.New("trinomial probabilities outside (0, 1); use more steps")
+	}
+	v := make([]float64, 2*steps+1)
+	for j := range v {
+		v[j] = payoff(s0 * math.Exp(float64(j-steps)*dx))
+	}
+	var layers [2][]float64
+	for i := steps - 1; i >= 0; i-- {
+		if i%treeCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return 0, 0, 0, 0, err
+			}
+		}
+		shift := pv(float64(i) * dt)
+		for j := 0; j <= 2*i; j++ {
+			v[j] = disc * (pu*v[j+2] + pm*v[j+1] + pd*v[j])
+			if american {
+				v[j] = math.Max(v[j], payoff(s0*math.Exp(float64(j-i)*dx)+shift))
+			}
+		}
+		if i <= 1 {
+			layers[i] = append([]float64(nil), v[:2*i+1]...)
+		}
+	}
+	s, su, sd := s0, s0*math.Exp(dx), s0*math.Exp(-dx)
+	l := layers[1]
+	delta = (l[2] - l[0]) / (su - sd)
+	gamma = ((l[2]-l[1])/(su-s) - (l[1]-l[0])/(s-sd)) / (0.5 * (su - sd))
+	theta = (l[1]-layers[0][0])/dt - delta*(pv(dt)-pv(0))/dt
+	return layers[0][0], delta, gamma, theta, nil
+}
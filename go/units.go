@@ -0,0 +1,139 @@
+package bsm
+
+import (
+	"fmt"
+)
+
+// VegaUnit is the vol move vega is quoted for.
+type VegaUnit string
+
+const (
+	VegaPerPoint VegaUnit = "point" // Per 1 vol point (0.01)
+	VegaPerUnit  VegaUnit = "unit"  // Per 1.00 of vol
+)
+
+// ThetaDays is the day count theta is quoted per day on.
+type ThetaDays string
+
+const (
+	CalendarDays ThetaDays = "calendar" // 365 a year
+	TradingDays  ThetaDays = "trading"  // 252 a year
+	ActualDays   ThetaDays = "actual"   // 365.25 a year, averaging leap years as ACT/365.25
+)
+
+// RateUnit is the rate move rho and phi are quoted for.
+type RateUnit string
+
+const (
+	RatePerBp      RateUnit = "bp"      // Per 0.0001
+	RatePerPercent RateUnit = "percent" // Per 0.01
+	RatePerUnit    RateUnit = "unit"    // Per 1.00
+)
+
+// DeltaUnit is how delta, and with it gamma, is quoted.
+type DeltaUnit string
+
+const (
+	// DeltaShares is units of underlying per unit of option, gamma the
+	// change per 1.00 of spot.
+	DeltaShares DeltaUnit = "shares"
+	// DeltaPercent is DeltaShares times 100, gamma likewise.
+	DeltaPercent DeltaUnit = "percent"
+	// DeltaCash is the currency value of the delta hedge, delta times
+	// spot, with gamma its change for a 1% spot move, gamma S^2 / 100.
+	DeltaCash DeltaUnit = "cash"
+)
+
+// GreekUnits chooses the conventions Greeks are reported in. Zero values
+// take the defaults in parentheses.
+type GreekUnits struct {
+	Vega       VegaUnit  // (VegaPerPoint)
+	Theta      ThetaDays // Theta per day on this count (CalendarDays)
+	Rho        RateUnit  // For rho and phi (RatePerPercent)
+	Delta      DeltaUnit // (DeltaShares)
+	Multiplier float64   // Units of underlying per contract, scaling every value to one contract (1)
+}
+
+// UnitGreeks are the price and first-order Greeks with gamma in the
+// conventions of a GreekUnits.
+type UnitGreeks struct {
+	Units GreekUnits // With the defaults filled in
+	Price float64
+	Delta float64
+	Gamma float64
+	Vega  float64
+	Theta float64 // Per day
+	Rho   float64
+	Phi   float64
+}
+
+// resolve fills the defaults and rejects unknown units.
+func (u GreekUnits) resolve() (GreekUnits, error) {
+	if u.Vega == "" {
+		u.Vega = VegaPerPoint
+	}
+	if u.Theta == "" {
+		u.Theta = CalendarDays
+	}
+	if u.Rho == "" {
+		u.Rho = RatePerPercent
+	}
+	if u.Delta == "" {
+		u.Delta = DeltaShares
+	}
+	if u.Multiplier == 0 {
+		u.Multiplier = 1
+	}
+	switch {
+	case u.Vega != VegaPerPoint && u.Vega != VegaPerUnit:
+		return u, fmt.Errorf("unknown vega unit %q", u.Vega)
+	case u.Theta != CalendarDays && u.Theta != TradingDays && u.Theta != ActualDays:
+		return u, fmt.Errorf("unknown theta day count %q", u.Theta)
+	case u.Rho != RatePerBp && u.Rho != RatePerPercent && u.Rho != RatePerUnit:
+		return u, fmt.Errorf("unknown rate unit %q", u.Rho)
+	case u.Delta != DeltaShares && u.Delta != DeltaPercent && u.Delta != DeltaCash:
+		return u, fmt.Errorf("unknown delta unit %q", u.Delta)
+	case !(u.Multiplier > 0):
+		return u, fmt.Errorf("multiplier must be positive, got %g", u.Multiplier)
+	}
+	return u, nil
+}
+
+// Convert restates o, priced at the given spot (the forward under Black76
+// and Bachelier), in the units u. It reads the per-1.00 and per-year
+// fields, so it does not depend on the theta basis o was priced with.
+func (u GreekUnits) Convert(o Outputs, spot float64) (UnitGreeks, error) {
+	u, err := u.resolve()
+	if err != nil {
+		return UnitGreeks{}, err
+	}
+	g := UnitGreeks{Units: u, Price: o.Price, Delta: o.Delta, Gamma: o.Gamma}
+	switch u.Delta {
+	case DeltaPercent:
+		g.Delta, g.Gamma = 100*o.Delta, 100*o.Gamma
+	case DeltaCash:
+		g.Delta, g.Gamma = o.Delta*spot, o.Gamma*spot*spot/100
+	}
+	g.Vega = o.VegaPerVol
+	if u.Vega == VegaPerPoint {
+		g.Vega *= 0.01
+	}
+	days := map[ThetaDays]float64{CalendarDays: 365, TradingDays: 252, ActualDays: 365.25}[u.Theta]
+	g.Theta = o.ThetaPerYear / days
+	rate := map[RateUnit]float64{RatePerBp: 1e-4, RatePerPercent: 0.01, RatePerUnit: 1}[u.Rho]
+	g.Rho, g.Phi = o.RhoPer1*rate, o.PhiPer1*rate
+
+	m := u.Multiplier
+	g.Price, g.Delta, g.Gamma, g.Vega, g.Theta, g.Rho, g.Phi =
+		g.Price*m, g.Delta*m, g.Gamma*m, g.Vega*m, g.Theta*m, g.Rho*m, g.Phi*m
+	return g, nil
+}
+
+// PriceInUnits prices like Price and reports the Greeks in the units u.
+func PriceInUnits(inputs Inputs, u GreekUnits, opts ...Option) (UnitGreeks, error) {
+	out, err := Price(inputs, opts...)
+	if err != nil {
+		return UnitGreeks{}, err
+	}
+	return u.Convert(out, inputs.S0)
+}
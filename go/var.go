@@ -0,0 +1,273 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// RiskFactorKind is what a VaR risk factor moves.
+type RiskFactorKind string
+
+const (
+	SpotFactor RiskFactorKind = "spot" // Relative return of the underlying's spot
+	VolFactor  RiskFactorKind = "vol"  // Absolute change in the underlying's vol
+)
+
+// RiskFactor is one market variable in a VaR covariance matrix.
+type RiskFactor struct {
+	Underlying string
+	Kind       RiskFactorKind
+}
+
+// VaRMethod selects how the quadratic P&L's distribution is turned into
+// VaR and expected shortfall.
+type VaRMethod string
+
+const (
+	// CornishFisherVaR adjusts the normal quantile for the exact skew and
+	// kurtosis of the delta-gamma P&L.
+	CornishFisherVaR VaRMethod = "cornish-fisher"
+	// MonteCarloVaR samples the factors from the covariance and evaluates
+	// the quadratic P&L on each draw.
+	MonteCarloVaR VaRMethod = "monte-carlo"
+)
+
+// VaROptions configures Portfolio.ParametricVaR. Zero values take the
+// defaults in parentheses.
+type VaROptions struct {
+	Factors    []RiskFactor
+	Covariance [][]float64 // Of the factor moves over the VaR horizon
+	Confidence float64     // (0.99)
+	Method     VaRMethod   // (CornishFisherVaR)
+	Paths      int         // Monte Carlo draws (100000)
+	Seed       uint64
+}
+
+// VaRResult holds VaR and expected shortfall as positive losses in the
+// base currency, with the moments of the delta-gamma P&L and the factor
+// sensitivities it was built from.
+type VaRResult struct {
+	VaR, ES  float64
+	Mean     float64
+	StdDev   float64
+	Skewness float64
+	ExKurt   float64 // Excess kurtosis
+	// Delta[i] is the P&L per unit move of factor i and Gamma[i][j] the
+	// second derivative, so P&L = Delta.x + x.Gamma.x / 2.
+	Delta []float64
+	Gamma [][]float64
+}
+
+// ParametricVaR computes delta-gamma-vega VaR and expected shortfall. Each
+// position's Greeks from Price, converted to the base currency, load on
+// its underlying's factors: delta S and gamma S^2 on the spot return,
+// vega and volga on the vol change, and vanna S across the two. An
+// underlying without a vol factor contributes delta-gamma only; every
+// position needs a spot factor. Theta over the horizon is not included.
+func (p *Portfolio) ParametricVaR(fx FXRates, opt VaROptions) (VaRResult, error) {
+	n := len(opt.Factors)
+	if n == 0 {
+		return VaRResult{}, errors.New("VaR needs at least one risk factor")
+	}
+	if len(opt.Covariance) != n {
+		return VaRResult{}, fmt.Errorf("covariance has %d rows for %d factors", len(opt.Covariance), n)
+	}
+	for i, row := range opt.Covariance {
+		if len(row) != n {
+			return VaRResult{}, fmt.Errorf("covariance row %d has %d entries for %d factors", i, len(row), n)
+		}
+		for j := range row {
+			if math.Abs(row[j]-opt.Covariance[j][i]) > 1e-12*math.Max(1, math.Abs(row[j])) {
+				return VaRResult{}, errors.New("covariance is not symmetric")
+			}
+		}
+	}
+	if opt.Confidence == 0 {
+		opt.Confidence = 0.99
+	}
+	if opt.Confidence <= 0 || opt.Confidence >= 1 {
+		return VaRResult{}, fmt.Errorf("confidence %g is not between 0 and 1", opt.Confidence)
+	}
+	if p.BaseCurrency == "" {
+		return VaRResult{}, errors.New("portfolio has no base currency")
+	}
+	if fx.Base != p.BaseCurrency {
+		return VaRResult{}, fmt.Errorf("FX table is based in %s, portfolio reports in %s", fx.Base, p.BaseCurrency)
+	}
+	spot, vol := map[string]int{}, map[string]int{}
+	for i, f := range opt.Factors {
+		switch f.Kind {
+		case SpotFactor:
+			spot[f.Underlying] = i
+		case VolFactor:
+			vol[f.Underlying] = i
+		default:
+			return VaRResult{}, fmt.Errorf("unknown risk factor kind %q", f.Kind)
+		}
+	}
+	basis := p.ThetaBasis
+	if basis == 0 {
+		basis = 365
+	}
+
+	res := VaRResult{Delta: make([]float64, n), Gamma: make([][]float64, n)}
+	for i := range res.Gamma {
+		res.Gamma[i] = make([]float64, n)
+	}
+	for _, pos := range p.Positions {
+		if err := pos.checkSide(); err != nil {
+			return VaRResult{}, err
+		}
+		s, ok := spot[pos.Underlying]
+		if !ok {
+			return VaRResult{}, fmt.Errorf("position %s: no spot factor for underlying %q", pos.ID, pos.Underlying)
+		}
+		rate, err := fx.Rate(pos.Currency)
+		if err != nil {
+			return VaRResult{}, fmt.Errorf("position %s: %w", pos.ID, err)
+		}
+		out, err := Price(pos.Inputs, WithThetaBasis(basis))
+		if err != nil {
+			return VaRResult{}, fmt.Errorf("position %s: %w", pos.ID, err)
+		}
+		g, S := out.scale(pos.units()*rate), pos.Inputs.S0
+		res.Delta[s] += g.Delta * S
+		res.Gamma[s][s] += g.Gamma * S * S
+		if v, ok := vol[pos.Underlying]; ok {
+			res.Delta[v] += g.VegaPerVol
+			res.Gamma[v][v] += g.Volga
+			res.Gamma[s][v] += g.Vanna * S
+			res.Gamma[v][s] += g.Vanna * S
+		}
+	}
+
+	// Cumulants of Q = d.x + x.G.x/2 for x ~ N(0, C), with M = G C.
+	C, d := opt.Covariance, res.Delta
+	M := matMul(res.Gamma, C)
+	Cd := matVec(C, d)
+	M2 := matMul(M, M)
+	k1 := 0.5 * trace(M)
+	k2 := dot(d, Cd) + 0.5*trace(M2)
+	k3 := 3*dot(Cd, matVec(res.Gamma, Cd)) + trace(matMul(M2, M))
+	k4 := 12*dot(Cd, matVec(matMul(res.Gamma, matMul(C, res.Gamma)), Cd)) + 3*trace(matMul(M2, M2))
+	res.Mean, res.StdDev = k1, math.Sqrt(math.Max(k2, 0))
+	if res.StdDev > 0 {
+		res.Skewness = k3 / (k2 * res.StdDev)
+		res.ExKurt = k4 / (k2 * k2)
+	}
+
+	alpha := 1 - opt.Confidence
+	switch opt.Method {
+	case "", CornishFisherVaR:
+		quantile := func(p float64) float64 {
+			z := normInv(p)
+			s, k := res.Skewness, res.ExKurt
+			w := z + (z*z-1)*s/6 + (z*z*z-3*z)*k/24 - (2*z*z*z-5*z)*s*s/36
+			return res.Mean + res.StdDev*w
+		}
+		res.VaR = -quantile(alpha)
+		// ES averages the quantile over the tail, by the midpoint rule. The
+		// expansion stops being monotone when the skew is large, so each
+		// point is capped by those nearer the VaR.
+		const steps = 1000
+		tail, q := 0.0, -res.VaR
+		for i := steps - 1; i >= 0; i-- {
+			q = math.Min(q, quantile(alpha*(float64(i)+0.5)/steps))
+			tail += q
+		}
+		res.ES = -tail / steps
+	case MonteCarloVaR:
+		if opt.Paths == 0 {
+			opt.Paths = 100000
+		}
+		if opt.Paths < 2 {
+			return VaRResult{}, errors.New("need at least two paths")
+		}
+		L, err := cholesky(C)
+		if err != nil {
+			return VaRResult{}, err
+		}
+		rng := NewDefaultRNG(opt.Seed)
+		z, x := make([]float64, n), make([]float64, n)
+		pnl := make([]float64, opt.Paths)
+		for p := range pnl {
+			for i := range z {
+				z[i] = rng.NormFloat64()
+			}
+			for i := range x {
+				x[i] = dot(L[i][:i+1], z[:i+1])
+			}
+			pnl[p] = dot(d, x) + 0.5*dot(x, matVec(res.Gamma, x))
+		}
+		sort.Float64s(pnl)
+		cut := max(int(math.Floor(alpha*float64(len(pnl)))), 1)
+		res.VaR = -pnl[cut-1]
+		res.ES = -mean(pnl[:cut])
+	default:
+		return VaRResult{}, fmt.Errorf("unknown VaR method %q", opt.Method)
+	}
+	return res, nil
+}
+
+// cholesky returns the lower-triangular L with L L' = A. A positive
+// semi-definite A is accepted: a zero pivot leaves its column zero.
+func cholesky(A [][]float64) ([][]float64, error) {
+	n := len(A)
+	L := make([][]float64, n)
+	for i := range L {
+		L[i] = make([]float64, n)
+	}
+	for j := 0; j < n; j++ {
+		d := A[j][j] - dot(L[j][:j], L[j][:j])
+		if d < -1e-12*math.Max(1, A[j][j]) {
+			return nil, errors.New("covariance is not positive semi-definite")
+		}
+		if d <= 0 {
+			continue
+		}
+		L[j][j] = math.Sqrt(d)
+		for i := j + 1; i < n; i++ {
+			L[i][j] = (A[i][j] - dot(L[i][:j], L[j][:j])) / L[j][j]
+		}
+	}
+	return L, nil
+}
+
+func matMul(A, B [][]float64) [][]float64 {
+	out := make([][]float64, len(A))
+	for i := range A {
+		out[i] = make([]float64, len(B[0]))
+		for k, a := range A[i] {
+			for j, b := range B[k] {
+				out[i][j] += a * b
+			}
+		}
+	}
+	return out
+}
+
+func matVec(A [][]float64, x []float64) []float64 {
+	out := make([]float64, len(A))
+	for i, row := range A {
+		out[i] = dot(row, x)
+	}
+	return out
+}
+
+func trace(A [][]float64) float64 {
+	t := 0.0
+	for i := range A {
+		t += A[i][i]
+	}
+	return t
+}
+
+func dot(a, b []float64) float64 {
+	s := 0.0
+	for i := range a {
+		s += a[i] * b[i]
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// TwoClockInputs separates the two clocks a single T conflates: Inputs.T
+// is carry time, over which rates and the dividend yield accrue and the
+// payoff is discounted, and VolT is variance time, over which Sigma
+// diffuses. Variance time runs slower over weekends and holidays and
+// faster on event days, so short-dated options price off the trading days
+// left, not the calendar days.
+type TwoClockInputs struct {
+	Inputs
+	VolT float64 // Variance time (years); T if zero
+	// VolDecay is how fast variance time passes now, in variance years per
+	// carry year, for theta, charm and color (VolT/T if zero: evenly).
+	VolDecay float64
+}
+
+// Resolve returns the Inputs on the carry clock with Sigma rescaled to
+// carry the variance of VolT: sigma sqrt(VolT/T).
+func (x TwoClockInputs) Resolve() (Inputs, error) {
+	if err := x.check(); err != nil {
+		return Inputs{}, err
+	}
+	in := x.Inputs
+	if in.T > 0 && x.VolT > 0 {
+		in.Sigma *= math.Sqrt(x.VolT / in.T)
+	}
+	return in, nil
+}
+
+func (x TwoClockInputs) check() error {
+	switch {
+	case x.VolT < 0 || math.IsNaN(x.VolT) || math.IsInf(x.VolT, 0):
+		return fmt.Errorf("variance time must be finite and non-negative, got %g", x.VolT)
+	case x.VolDecay < 0 || math.IsNaN(x.VolDecay) || math.IsInf(x.VolDecay, 0):
+		return fmt.Errorf("variance decay must be finite and non-negative, got %g", x.VolDecay)
+	case x.Exercise == American:
+		return errors.New("two-clock pricing supports European options only")
+	}
+	return nil
+}
+
+// PriceTwoClock prices like Price on the two clocks. The Greeks are
+// sensitivities to Inputs.Sigma, not to the rescaled vol, and theta,
+// charm and color let variance time pass at VolDecay while carry time
+// passes at one year a year.
+func PriceTwoClock(x TwoClockInputs, opts ...Option) (Outputs, error) {
+	in, err := x.Resolve()
+	if err != nil {
+		return Outputs{}, err
+	}
+	out, err := Price(in, opts...)
+	if err != nil || in.T == 0 || in.Sigma == 0 {
+		return out, err
+	}
+	o := resolveOptions(opts)
+	volT := x.VolT
+	if volT == 0 {
+		volT = in.T
+	}
+	// Price's time Greeks let variance time pass at volT/T. With V(T, VolT)
+	// and dV/dVolT = vega sigmaEff / (2 VolT) at the rescaled vol, the
+	// difference to passing at VolDecay is dV/dVolT (volT/T - VolDecay),
+	// and likewise for delta through vanna and gamma through zomma.
+	if x.VolDecay > 0 {
+		excess := (volT/in.T - x.VolDecay) * in.Sigma / (2 * volT)
+		out.ThetaPerYear += out.VegaPerVol * excess
+		out.CharmPerYear += out.Vanna * excess
+		out.ColorPerYear += out.Zomma * excess
+	}
+	k := math.Sqrt(volT / in.T) // dSigmaEff/dSigma
+	out.VegaPerVol *= k
+	out.Vanna *= k
+	out.Zomma *= k
+	out.Volga *= k * k
+	return out.withUnits(o.thetaBasis), nil
+}
+
+// VarianceCalendar weights calendar days by the variance they carry, in
+// ordinary trading days: one for a weekday, WeekendWeight for weekends and
+// holidays, and an event's Weight on its day. Zero fields take the
+// defaults in parentheses.
+type VarianceCalendar struct {
+	Location      *time.Location  // Where days begin and end (UTC)
+	WeekendWeight float64         // Variance of a weekend day or holiday (0)
+	Holidays      []time.Time     // Full-day closures (only the date part is used)
+	Events        []VarianceEvent // Days with unusual variance, such as earnings
+	DaysPerYear   float64         // Trading days of variance in a year (252)
+}
+
+// VarianceEvent is a day whose variance is Weight ordinary trading days,
+// e.g. 4 for an earnings release that moves the stock twice as much as a
+// normal day.
+type VarianceEvent struct {
+	Date   time.Time // Only the date part is used
+	Weight float64
+}
+
+// VarianceTime returns the variance time from from to to in years: each
+// day's weight, prorated over the part of the day inside the interval,
+// summed and divided by DaysPerYear.
+func (c VarianceCalendar) VarianceTime(from, to time.Time) float64 {
+	if !to.After(from) {
+		return 0
+	}
+	loc := c.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	weights := make(map[civilDate]float64, len(c.Holidays)+len(c.Events))
+	for _, h := range c.Holidays {
+		weights[dateOf(h)] = c.WeekendWeight
+	}
+	for _, e := range c.Events {
+		weights[dateOf(e.Date)] = e.Weight
+	}
+	days := 0.0
+	for t, end := from.In(loc), to.In(loc); t.Before(end); {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		next := start.AddDate(0, 0, 1)
+		w, ok := weights[dateOf(start)]
+		if !ok {
+			w = c.WeekendWeight
+			if isWeekday(start) {
+				w = 1
+			}
+		}
+		stop := next
+		if end.Before(stop) {
+			stop = end
+		}
+		days += w * float64(stop.Sub(t)) / float64(next.Sub(start)) // Days are 23 or 25 hours at DST changes
+		t = stop
+	}
+	daysPerYear := c.DaysPerYear
+	if daysPerYear <= 0 {
+		daysPerYear = tradingDaysPerYear
+	}
+	return days / daysPerYear
+}
+
+// TwoClock returns in on both clocks from valuation to expiry: carry time
+// is ACT/365 to the second and variance time comes from the calendar.
+// VolDecay is the variance of the 24 hours after valuation, so theta is
+// the decay of the day ahead: little on a Friday evening, a lot on the eve
+// of an event.
+func (c VarianceCalendar) TwoClock(in Inputs, valuation, expiry time.Time) TwoClockInputs {
+	in.T = 0
+	if expiry.After(valuation) {
+		in.T = expiry.Sub(valuation).Seconds() / (calendarDaysPerYear * secondsPerDay)
+	}
+	x := TwoClockInputs{Inputs: in}
+	if in.T > 0 {
+		day := valuation.Add(24 * time.Hour)
+		if day.After(expiry) {
+			day = expiry
+		}
+		// Zero would mean the defaults, so no variance is a very little.
+		x.VolT = math.Max(c.VarianceTime(valuation, expiry), 1e-12)
+		x.VolDecay = c.VarianceTime(valuation, day) / day.Sub(valuation).Hours() * 24 * calendarDaysPerYear
+		x.VolDecay = math.Max(x.VolDecay, 1e-12)
+	}
+	return x
+}
@@ -0,0 +1,327 @@
+package bsm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Smile gives implied volatility by strike at one expiry, e.g. a fitted
+// parametric smile.
+type Smile interface {
+	Vol(K float64) float64
+}
+
+// VolSlice holds the quoted implied vols at one expiry. If Smile is set it
+// is used for Vol; otherwise the quotes are interpolated linearly in strike
+// and held flat beyond the wings. A zero Forward means the surface's
+// spot forward. BidVols and AskVols, if set, are the quotes' bid and ask
+// implied vols (Vols being the mids).
+type VolSlice struct {
+	T       float64
+	Forward float64
+	Strikes []float64
+	Vols    []float64
+	BidVols []float64
+	AskVols []float64
+	Smile   Smile
+}
+
+// Vol returns the slice's implied vol at strike K.
+func (s VolSlice) Vol(K float64) float64 {
+	if s.Smile != nil {
+		return s.Smile.Vol(K)
+	}
+	n := len(s.Strikes)
+	j := sort.SearchFloat64s(s.Strikes, K)
+	switch {
+	case j == 0:
+		return s.Vols[0]
+	case j == n:
+		return s.Vols[n-1]
+	}
+	w := (K - s.Strikes[j-1]) / (s.Strikes[j] - s.Strikes[j-1])
+	return (1-w)*s.Vols[j-1] + w*s.Vols[j]
+}
+
+// VolSurface is a set of expiry slices on one underlying.
+type VolSurface struct {
+	Spot, R, Q float64
+	Slices     []VolSlice // Sorted by expiry
+}
+
+// NewVolSurface validates and sorts the slices.
+func NewVolSurface(spot, r, q float64, slices []VolSlice) (*VolSurface, error) {
+	if spot <= 0 {
+		return nil, errors.New("spot must be positive")
+	}
+	if len(slices) == 0 {
+		return nil, errors.New("surface needs at least one slice")
+	}
+	out := append([]VolSlice(nil), slices...)
+	sort.Slice(out, func(a, b int) bool { return out[a].T < out[b].T })
+	for i, s := range out {
+		if s.T <= 0 || (i > 0 && s.T == out[i-1].T) {
+			return nil, fmt.Errorf("slice expiries must be positive and distinct (got %g)", s.T)
+		}
+		if len(s.Strikes) != len(s.Vols) || (len(s.Strikes) == 0 && s.Smile == nil) {
+			return nil, fmt.Errorf("slice %g needs matching strikes and vols or a smile", s.T)
+		}
+		if (s.BidVols != nil || s.AskVols != nil) && (len(s.BidVols) != len(s.Strikes) || len(s.AskVols) != len(s.Strikes)) {
+			return nil, fmt.Errorf("slice %g bid and ask vols must match its strikes", s.T)
+		}
+		if !sort.Float64sAreSorted(s.Strikes) {
+			return nil, fmt.Errorf("slice %g strikes must be sorted", s.T)
+		}
+	}
+	return &VolSurface{Spot: spot, R: r, Q: q, Slices: out}, nil
+}
+
+// Forward returns the forward for slice i.
+func (v *VolSurface) Forward(i int) float64 {
+	if f := v.Slices[i].Forward; f > 0 {
+		return f
+	}
+	return v.Spot * math.Exp((v.R-v.Q)*v.Slices[i].T)
+}
+
+// Vol returns the implied vol at (K, T). Between slices total variance is
+// interpolated linearly in T at fixed forward moneyness K/F; before the
+// first slice its vol is held, and beyond the last total variance grows
+// linearly.
+func (v *VolSurface) Vol(K, T float64) float64 {
+	sl := v.Slices
+	j := sort.Search(len(sl), func(i int) bool { return sl[i].T >= T })
+	switch {
+	case j == 0:
+		m := K / v.forwardAt(T)
+		return sl[0].Vol(m * v.Forward(0))
+	case j == len(sl):
+		j = len(sl) - 1
+		m := K / v.forwardAt(T)
+		return sl[j].Vol(m * v.Forward(j))
+	}
+	m := K / v.forwardAt(T)
+	t0, t1 := sl[j-1].T, sl[j].T
+	v0, v1 := sl[j-1].Vol(m*v.Forward(j-1)), sl[j].Vol(m*v.Forward(j))
+	w := (T - t0) / (t1 - t0)
+	tv := (1-w)*v0*v0*t0 + w*v1*v1*t1
+	return math.Sqrt(tv / T)
+}
+
+// forwardAt returns the forward for expiry T: log-linear in T through the
+// spot at T = 0 and each slice's forward, so the surface reprices its
+// slices at their own forwards (such as those a chain fit implies), and
+// continuing the last slices' carry beyond them. Without slice forwards it
+// is the spot forward at R - Q.
+func (v *VolSurface) forwardAt(T float64) float64 {
+	sl := v.Slices
+	j := sort.Search(len(sl), func(i int) bool { return sl[i].T >= T })
+	if j == len(sl) {
+		j = len(sl) - 1
+	}
+	t0, f0 := 0.0, v.Spot
+	if j > 0 {
+		t0, f0 = sl[j-1].T, v.Forward(j-1)
+	}
+	carry := math.Log(v.Forward(j)/f0) / (sl[j].T - t0)
+	return f0 * math.Exp(carry*(T-t0))
+}
+
+// SurfaceQuote is one quoted point of a surface.
+type SurfaceQuote struct {
+	Slice     int
+	T, K, Vol float64
+	Forward   float64
+}
+
+// Quotes lists every quoted point, slice by slice.
+func (v *VolSurface) Quotes() []SurfaceQuote {
+	var out []SurfaceQuote
+	for i, s := range v.Slices {
+		for j, k := range s.Strikes {
+			out = append(out, SurfaceQuote{Slice: i, T: s.T, K: k, Vol: s.Vols[j], Forward: v.Forward(i)})
+		}
+	}
+	return out
+}
+
+// sliceQuotes are one slice's quotes in the form calibrations fit:
+// out-of-the-money prices, weighted by 1/vega so price errors approximate
+// implied-vol errors.
+type sliceQuotes struct {
+	T, F, df float64
+	strikes  []float64
+	vols     []float64
+	isCall   []bool
+	prices   []float64
+	weights  []float64
+}
+
+func (v *VolSurface) otmQuotes(i int, minVega float64) sliceQuotes {
+	s := v.Slices[i]
+	q := sliceQuotes{T: s.T, F: v.Forward(i), df: math.Exp(-v.R * s.T), strikes: s.Strikes, vols: s.Vols}
+	for j, K := range s.Strikes {
+		call := K >= q.F
+		sd := s.Vols[j] * math.Sqrt(s.T)
+		vega := q.df * q.F * normPDF(math.Log(q.F/K)/sd+0.5*sd) * math.Sqrt(s.T)
+		q.isCall = append(q.isCall, call)
+		q.prices = append(q.prices, q.df*blackFormula(q.F, K, sd, call))
+		q.weights = append(q.weights, 1/math.Max(vega, minVega))
+	}
+	return q
+}
+
+// SmileFitPoint compares a calibrated model with one quote.
+type SmileFitPoint struct {
+	T, K      float64
+	MarketVol float64
+	ModelVol  float64
+	VolError  float64 // Model minus market
+}
+
+// compare converts model prices for the quotes to implied vols and returns
+// the per-quote errors with their RMSE and largest absolute value.
+func (q sliceQuotes) compare(model []float64) (pts []SmileFitPoint, rmse, maxAbs float64) {
+	sumSq := 0.0
+	for j, K := range q.strikes {
+		vol, err := blackImpliedVol(model[j]/q.df, q.F, K, q.T, q.isCall[j])
+		if err != nil {
+			vol = math.NaN()
+		}
+		pt := SmileFitPoint{T: q.T, K: K, MarketVol: q.vols[j], ModelVol: vol, VolError: vol - q.vols[j]}
+		pts = append(pts, pt)
+		sumSq += pt.VolError * pt.VolError
+		maxAbs = math.Max(maxAbs, math.Abs(pt.VolError))
+	}
+	if len(pts) > 0 {
+		rmse = math.Sqrt(sumSq / float64(len(pts)))
+	}
+	return pts, rmse, maxAbs
+}
+
+// VolQuote is one implied vol quoted at expiry T and strike K.
+type VolQuote struct {
+	T, K, Vol float64
+}
+
+// SurfaceFromQuotes builds a surface from (expiry, strike, vol) quotes on
+// spot forwards. Each expiry with at least five quotes is fitted with raw
+// SVI; one with fewer is interpolated linearly in strike. The surface is
+// returned with any static-arbitrage violations of the fitted slices (see
+// CheckArbitrage), which the caller may treat as fatal.
+func SurfaceFromQuotes(spot, r, q float64, quotes []VolQuote) (*VolSurface, []ArbitrageViolation, error) {
+	byExpiry := map[float64][]VolQuote{}
+	for _, vq := range quotes {
+		if math.IsNaN(vq.T) || math.IsNaN(vq.K) || math.IsNaN(vq.Vol) || vq.K <= 0 || vq.Vol <= 0 {
+			return nil, nil, fmt.Errorf("quote %+v needs a positive strike and vol", vq)
+		}
+		byExpiry[vq.T] = append(byExpiry[vq.T], vq)
+	}
+	slices := make([]VolSlice, 0, len(byExpiry))
+	for T, qs := range byExpiry {
+		sort.Slice(qs, func(a, b int) bool { return qs[a].K < qs[b].K })
+		s := VolSlice{T: T, Strikes: make([]float64, len(qs)), Vols: make([]float64, len(qs))}
+		for j, vq := range qs {
+			if j > 0 && vq.K == qs[j-1].K {
+				return nil, nil, fmt.Errorf("strike %g is quoted twice at expiry %g", vq.K, T)
+			}
+			s.Strikes[j], s.Vols[j] = vq.K, vq.Vol
+		}
+		if len(qs) >= 5 && T > 0 {
+			fit, err := FitSVI(T, spot*math.Exp((r-q)*T), s.Strikes, s.Vols, nil)
+			if err != nil {
+				return nil, nil, fmt.Errorf("expiry %g: %w", T, err)
+			}
+			s.Smile = fit.Smile
+		}
+		slices = append(slices, s)
+	}
+	surf, err := NewVolSurface(spot, r, q, slices)
+	if err != nil {
+		return nil, nil, err
+	}
+	return surf, surf.CheckArbitrage(), nil
+}
+
+// SmileDynamics is how a vol surface is assumed to move with the spot.
+type SmileDynamics string
+
+const (
+	// StickyStrike holds each strike's vol as the spot moves, so the
+	// delta is the BSM one at the surface vol.
+	StickyStrike SmileDynamics = "sticky-strike"
+	// StickyDelta holds each moneyness K/S's vol, so the smile moves with
+	// the spot and delta picks up vega times dVol/dSpot = -(K/S) dVol/dK,
+	// through the skew.
+	StickyDelta SmileDynamics = "sticky-delta"
+)
+
+// SurfaceInputs prices off a vol surface: Sigma is replaced by the
+// surface's vol at the contract's strike and expiry. Zero values take the
+// defaults in parentheses.
+type SurfaceInputs struct {
+	Inputs
+	Surface  *VolSurface
+	Dynamics SmileDynamics // For Delta and Gamma (StickyStrike)
+}
+
+// Resolve returns the Inputs with Sigma looked up on the surface.
+func (s SurfaceInputs) Resolve() (Inputs, error) {
+	if s.Surface == nil || len(s.Surface.Slices) == 0 {
+		return Inputs{}, errors.New("surface inputs need a surface")
+	}
+	in := s.Inputs
+	in.Sigma = s.Surface.Vol(in.K, in.T)
+	return in, nil
+}
+
+// PriceSurface prices like Price at the surface vol. Delta and gamma move
+// the spot under in.Dynamics; under StickyDelta they are central
+// differences of the price with the smile carried along, and Lambda
+// follows. The other Greeks are sticky strike: vega is to a parallel
+// shift of the surface, and theta holds Sigma fixed rather than rolling
+// down the term structure.
+func PriceSurface(in SurfaceInputs, opts ...Option) (Outputs, error) {
+	inputs, err := in.Resolve()
+	if err != nil {
+		return Outputs{}, err
+	}
+	out, err := Price(inputs, opts...)
+	if err != nil {
+		return Outputs{}, err
+	}
+	switch in.Dynamics {
+	case "", StickyStrike:
+		return out, nil
+	case StickyDelta:
+	default:
+		return Outputs{}, fmt.Errorf("unknown smile dynamics %q", in.Dynamics)
+	}
+
+	// Resolve reads the surface at K for spot S0, so at spot s the vol for
+	// K is the one read at the strike of the same moneyness, K S0 / s.
+	priceAt := func(s float64) (float64, error) {
+		x := inputs
+		x.S0 = s
+		x.Sigma = in.Surface.Vol(in.K*in.S0/s, in.T)
+		o, err := Price(x, opts...)
+		return o.Price, err
+	}
+	h := 1e-3 * in.S0
+	up, err := priceAt(in.S0 + h)
+	if err != nil {
+		return Outputs{}, err
+	}
+	down, err := priceAt(in.S0 - h)
+	if err != nil {
+		return Outputs{}, err
+	}
+	out.Delta = (up - down) / (2 * h)
+	out.Gamma = (up - 2*out.Price + down) / (h * h)
+	if out.Price > 0 {
+		out.Lambda = out.Delta * in.S0 / out.Price
+	}
+	return out, nil
+}
@@ -0,0 +1,70 @@
+package bsm
+
+import (
+	"math"
+	"testing"
+)
+
+// A surface whose slices carry their own forwards, as FitChain's do,
+// must read each slice's vols back at its own forward.
+func TestVolSurfaceSliceForwards(t *testing.T) {
+	strikes, vols := []float64{90, 95, 100}, []float64{0.27, 0.25, 0.23}
+	v, err := NewVolSurface(100, 0, 0, []VolSlice{
+		{T: 0.5, Forward: 95, Strikes: strikes, Vols: vols},
+		{T: 1, Forward: 92, Strikes: strikes, Vols: vols},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, T := range []float64{0.5, 1} {
+		for i, K := range strikes {
+			if got := v.Vol(K, T); math.Abs(got-vols[i]) > 1e-12 {
+				t.Errorf("Vol(%g, %g) = %g, want %g", K, T, got, vols[i])
+			}
+		}
+	}
+	if got, want := v.forwardAt(0.75), math.Sqrt(95*92); math.Abs(got-want) > 1e-12 {
+		t.Errorf("forward between slices = %g, want %g", got, want)
+	}
+}
+
+// Surface Greeks price on the slice forward, as Black-76 on that forward.
+func TestVolSurfaceGreeksOnForward(t *testing.T) {
+	v, err := NewVolSurface(100, 0.03, 0, []VolSlice{
+		{T: 0.5, Forward: 95, Strikes: []float64{90, 95, 100}, Vols: []float64{0.27, 0.25, 0.23}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := v.Greeks(95, 0.5, Call, 365)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Price(Inputs{S0: 95, K: 95, T: 0.5, Sigma: 0.25, R: 0.03, OptType: Call, Model: Black76Model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(g.Mid.Price-want.Price) > 1e-10 {
+		t.Errorf("price %g, want %g on the forward", g.Mid.Price, want.Price)
+	}
+}
+
+func TestVolSurfaceGreekBandsOnForward(t *testing.T) {
+	v, err := NewVolSurface(100, 0.03, 0, []VolSlice{
+		{T: 0.5, Forward: 95, Strikes: []float64{90, 95, 100}, Vols: []float64{0.27, 0.25, 0.23}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := v.Greeks(100, 0.5, Put, 365)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := v.GreekBands(100, 0.5, Put, SpotQuote{}, 365)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Price.Mid != g.Mid.Price || b.Delta.Mid != g.Mid.Delta {
+		t.Errorf("bands mid (%g, %g), Greeks (%g, %g)", b.Price.Mid, b.Delta.Mid, g.Mid.Price, g.Mid.Delta)
+	}
+}
@@ -18,7 +18,7 @@ g++ -std=c++11 -o bsm_greeks bsm_greeks.cpp && ./bsm_greeks
 # Go
 run_section "Go"
 cd "$ROOT_DIR/go"
-go run bsm_greeks.go
+go run ./cmd/bsm
 
 # Python
 run_section "Python"